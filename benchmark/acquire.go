@@ -0,0 +1,31 @@
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+)
+
+// semaphoreの取得に失敗したとき（コンテキストのキャンセル時）の方針
+type AcquirePolicy string
+
+const (
+	// タスクを破棄して次のタスクに進み、破棄した数を記録する（従来の動作）
+	AcquireCount AcquirePolicy = "count"
+	// キャンセルされないコンテキストで取得をやり直し、タスクを破棄しない
+	AcquireRetry AcquirePolicy = "retry"
+	// 残りのタスクをすべて破棄してディスパッチを中止し、エラーを返す
+	AcquireAbort AcquirePolicy = "abort"
+)
+
+var acquirePolicies = []AcquirePolicy{AcquireCount, AcquireRetry, AcquireAbort}
+
+func (p AcquirePolicy) validate() error {
+	names := make([]string, 0, len(acquirePolicies))
+	for _, known := range acquirePolicies {
+		if p == known {
+			return nil
+		}
+		names = append(names, string(known))
+	}
+	return fmt.Errorf("unknown acquire policy %q (available: %s)", p, strings.Join(names, ", "))
+}
@@ -0,0 +1,58 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// ディスパッチの途中でコンテキストをキャンセルしたときの、方針ごとの動作を確認する
+func TestChannelWithLimitedParallelismCancelMidDispatch(t *testing.T) {
+	const n = 200
+	const cancelAfter = 20
+
+	cases := []struct {
+		policy      AcquirePolicy
+		wantErr     bool
+		wantDropped bool
+	}{
+		{AcquireCount, false, true},
+		{AcquireRetry, false, false},
+		{AcquireAbort, true, true},
+	}
+	for _, c := range cases {
+		t.Run(string(c.policy), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var processed atomic.Int64
+			env := BatchEnv(n)
+			env.Ctx = ctx
+			env.Stats = &RunStats{}
+			env.Process = func(task Task) error {
+				if processed.Add(1) == cancelAfter {
+					cancel()
+				}
+				return processTask(task)
+			}
+
+			err := ChannelWithLimitedParallelism(env, 2, ChannelOptions{Policy: c.policy})
+			if c.wantErr != (err != nil) {
+				t.Fatalf("err = %v, wantErr %v", err, c.wantErr)
+			}
+			if err != nil && !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want context.Canceled", err)
+			}
+
+			dropped := env.Stats.Dropped.Load()
+			if c.wantDropped != (dropped > 0) {
+				t.Errorf("dropped = %d, wantDropped %v", dropped, c.wantDropped)
+			}
+			// 全てのタスクは処理されるか、破棄として数えられる
+			if got := processed.Load() + dropped; got != n {
+				t.Errorf("processed(%d) + dropped(%d) = %d, want %d", processed.Load(), dropped, got, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// context.AfterFuncのシナリオの戦略一覧。
+// 同じワーカープールで、ルートのコンテキストのキャンセル時に走る後始末をcontext.AfterFuncで登録する単位を変え、
+// タスクごとに登録と解除を繰り返す場合と、ワーカーごとに1回だけ登録する場合の処理時間と、
+// ルートのキャンセルから後始末を含めて全てのワーカーが終了するまでの時間を比べる。
+// 登録と解除のオーバーヘッドが表れるよう、処理時間を待機しないタスクを使う
+func afterFuncStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	topology := func(note string) Topology {
+		return Topology{
+			Stages: []Stage{
+				producerStage,
+				{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100), Note: note},
+			},
+			Completion: "ルートのキャンセル + sync.WaitGroup",
+		}
+	}
+	list := []Strategy{
+		{
+			Name:     "afterfunc-none",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ 後始末を登録せずルートのコンテキストのキャンセルを待つ", numWorkers),
+			Func:     "AfterFuncPool",
+			Limit:    numWorkers,
+			Topology: topology("ルートのコンテキストのキャンセルを待つ"),
+			Run: func(env Env) error {
+				return AfterFuncPool(env, numWorkers, hookNone)
+			},
+		},
+		{
+			Name:     "afterfunc-per-task",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ タスクごとにcontext.AfterFuncを登録し、処理後に解除", numWorkers),
+			Func:     "AfterFuncPool",
+			Limit:    numWorkers,
+			Topology: topology("タスクごとに後始末を登録して解除し、ルートのコンテキストのキャンセルを待つ"),
+			Run: func(env Env) error {
+				return AfterFuncPool(env, numWorkers, hookPerTask)
+			},
+		},
+		{
+			Name:     "afterfunc-per-worker",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ ワーカーごとに1回だけcontext.AfterFuncを登録", numWorkers),
+			Func:     "AfterFuncPool",
+			Limit:    numWorkers,
+			Topology: topology("ワーカーごとの後始末がチャネルを閉じるのを待つ"),
+			Run: func(env Env) error {
+				return AfterFuncPool(env, numWorkers, hookPerWorker)
+			},
+		},
+	}
+	for i, s := range list {
+		run := s.Run
+		list[i].Run = func(env Env) error {
+			env.Source = &instantSource{src: env.Source}
+			return run(env)
+		}
+	}
+	return list
+}
+
+// context.AfterFuncで後始末を登録する単位
+type hookScope int
+
+const (
+	// 後始末を登録しない
+	hookNone hookScope = iota
+	// タスクごとに登録し、処理を終えたら解除する
+	hookPerTask
+	// ワーカーごとに1回だけ登録し、ルートのキャンセルまで解除しない
+	hookPerWorker
+)
+
+// numWorkers個のワーカーでタスクを処理し、処理を終えたワーカーはルートのコンテキストのキャンセルを待つ。
+// scopeの単位でcontext.AfterFuncに後始末を登録し、全てのタスクの処理が終わったらルートをキャンセルして、
+// 全てのワーカーが終了するまでの時間を記録する。戻る前に、走り始めた後始末の終了も待つ
+func AfterFuncPool(env Env, numWorkers int, scope hookScope) error {
+	root, cancelRoot := context.WithCancel(env.context())
+	defer cancelRoot()
+
+	tasks := make(chan Task, 100)
+	var processed, exited, hooks sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	for i := 0; i < numWorkers; i++ {
+		quit := root.Done()
+		if scope == hookPerWorker {
+			// ルートのキャンセル時に、後始末がワーカーの終了を知らせるチャネルを閉じる
+			ch := make(chan struct{})
+			hooks.Add(1)
+			context.AfterFunc(root, func() {
+				defer hooks.Done()
+				close(ch)
+			})
+			quit = ch
+		}
+		processed.Add(1)
+		exited.Add(1)
+		go func() {
+			defer exited.Done()
+			for task := range tasks {
+				select {
+				case <-quit:
+					// キャンセル後は送信側を止めないよう残りを読み捨てる
+					env.drop(1)
+					continue
+				default:
+				}
+				var stop func() bool
+				if scope == hookPerTask {
+					// タスクの途中でキャンセルされた場合の後始末（ここでは終了を数えるだけ）
+					hooks.Add(1)
+					stop = context.AfterFunc(root, hooks.Done)
+				}
+				err := env.Process(task)
+				if stop != nil && stop() {
+					// 後始末が走る前に解除できた
+					hooks.Done()
+				}
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancelRoot()
+					})
+				}
+			}
+			processed.Done()
+			<-quit
+		}()
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks <- task
+	}
+	close(tasks)
+	processed.Wait()
+	teardown(env, cancelRoot, &exited)
+	hooks.Wait()
+	return firstErr
+}
@@ -0,0 +1,44 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 全てのタスクを処理し、キャンセルの伝播時間と待機中のメモリ使用量を記録することを確認する
+func TestAfterFuncStrategies(t *testing.T) {
+	const n = 2000
+
+	for _, s := range afterFuncStrategies(Params{Workers: 100}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var completed atomic.Int64
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			env.Stats.Idle.enabled = true
+			env.Process = func(task Task) error {
+				defer completed.Add(1)
+				return processTask(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := completed.Load(); got != n {
+				t.Errorf("processed %d tasks, want %d", got, n)
+			}
+			if env.Stats.Teardown.Load() <= 0 {
+				t.Error("cancellation propagation time was not recorded")
+			}
+			if !env.Stats.Idle.recorded || env.Stats.Idle.mem.RSS == 0 {
+				t.Error("idle memory was not recorded")
+			}
+
+			var processed atomic.Int64
+			env = BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package benchmark
+
+import (
+	"fmt"
+	"sync"
+)
+
+// 結果の集約のシナリオの戦略一覧。
+// いずれもワーカープールでタスクを処理し、全てのワーカーの結果を1つのスライスに集める仕組みだけが異なる
+func aggregateStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	pool := func(completion string) Topology {
+		return Topology{
+			Stages: []Stage{
+				producerStage,
+				{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100)},
+			},
+			Completion: completion,
+		}
+	}
+	discard := func(aggregate func(Env, int) ([]taskResult, error)) func(Env) error {
+		return func(env Env) error {
+			_, err := aggregate(env, numWorkers)
+			return err
+		}
+	}
+	return []Strategy{
+		{
+			Name:     "aggregate-mutex",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ 各ワーカーがミューテックスで保護したスライスに追加", numWorkers),
+			Func:     "MutexAppend",
+			Limit:    numWorkers,
+			Topology: pool("sync.WaitGroup（結果はsync.Mutexで保護したスライスに追加）"),
+			Run:      discard(MutexAppend),
+		},
+		{
+			Name:     "aggregate-writer",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ 結果チャネルを受信する単一の書き込みgoroutineが追加", numWorkers),
+			Func:     "SingleWriterAppend",
+			Limit:    numWorkers,
+			Topology: pool("結果チャネル（バッファ100）を単一の書き込みgoroutineが受信し、ワーカーの終了後に閉じる"),
+			Run:      discard(SingleWriterAppend),
+		},
+		{
+			Name:     "aggregate-per-worker",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ ワーカーごとのバッファに追加し、最後に連結", numWorkers),
+			Func:     "PerWorkerBuffers",
+			Limit:    numWorkers,
+			Topology: pool("sync.WaitGroup（ワーカーごとのスライスを終了後に連結）"),
+			Run:      discard(PerWorkerBuffers),
+		},
+	}
+}
+
+// 供給元のタスクをワーカーに送るチャネルを作り、読み出しを別のgoroutineで始める
+func feedTasks(src Source) <-chan Task {
+	tasks := make(chan Task, 100)
+	go func() {
+		defer close(tasks)
+		for {
+			task, ok := src.Next()
+			if !ok {
+				return
+			}
+			tasks <- task
+		}
+	}()
+	return tasks
+}
+
+// 結果のうち最初のエラーを返す
+func firstResultError(results []taskResult) error {
+	for _, r := range results {
+		if r.err != nil {
+			return r.err
+		}
+	}
+	return nil
+}
+
+// 各ワーカーが、ミューテックスで保護した共有のスライスに結果を追加する
+func MutexAppend(env Env, numWorkers int) ([]taskResult, error) {
+	tasks := feedTasks(env.Source)
+	var mu sync.Mutex
+	var results []taskResult
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				r := taskResult{id: task.ID, err: env.Process(task)}
+				mu.Lock()
+				results = append(results, r)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return results, firstResultError(results)
+}
+
+// 各ワーカーが結果チャネルに送信し、単一の書き込みgoroutine（呼び出し元）だけがスライスに追加する
+func SingleWriterAppend(env Env, numWorkers int) ([]taskResult, error) {
+	tasks := feedTasks(env.Source)
+	out := make(chan taskResult, 100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				out <- taskResult{id: task.ID, err: env.Process(task)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	var results []taskResult
+	for r := range out {
+		results = append(results, r)
+	}
+	return results, firstResultError(results)
+}
+
+// 各ワーカーが自分専用のスライスに結果を追加し、全てのワーカーの終了後に1つのスライスに連結する。
+// 追加の間は同期が要らない
+func PerWorkerBuffers(env Env, numWorkers int) ([]taskResult, error) {
+	tasks := feedTasks(env.Source)
+	buffers := make([][]taskResult, numWorkers)
+
+	var wg sync.WaitGroup
+	for i := range buffers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				buffers[i] = append(buffers[i], taskResult{id: task.ID, err: env.Process(task)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for _, b := range buffers {
+		total += len(b)
+	}
+	results := make([]taskResult, 0, total)
+	for _, b := range buffers {
+		results = append(results, b...)
+	}
+	return results, firstResultError(results)
+}
@@ -0,0 +1,43 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 全てのタスクの結果を1件ずつ集め、エラーが返されることを確認する
+func TestAggregateStrategies(t *testing.T) {
+	const n = 2000
+
+	funcs := map[string]func(Env, int) ([]taskResult, error){
+		"MutexAppend":        MutexAppend,
+		"SingleWriterAppend": SingleWriterAppend,
+		"PerWorkerBuffers":   PerWorkerBuffers,
+	}
+	for name, aggregate := range funcs {
+		t.Run(name, func(t *testing.T) {
+			results, err := aggregate(BatchEnv(n), 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != n {
+				t.Fatalf("got %d results, want %d", len(results), n)
+			}
+			seen := make([]bool, n)
+			for _, r := range results {
+				if seen[r.id] {
+					t.Fatalf("result for task %d collected twice", r.id)
+				}
+				seen[r.id] = true
+			}
+
+			var processed atomic.Int64
+			env := BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if _, err := aggregate(env, 100); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package benchmark
+
+import (
+	"runtime"
+	"strconv"
+)
+
+// メモリアロケーションの累計（runtime.MemStatsのMallocsとTotalAlloc）
+type allocStats struct {
+	Mallocs uint64
+	Bytes   uint64
+}
+
+// 現在までのアロケーションの累計を読み取る
+func readAllocs() allocStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return allocStats{Mallocs: m.Mallocs, Bytes: m.TotalAlloc}
+}
+
+// 2時点間のアロケーションの差分を求める
+func (a allocStats) sub(b allocStats) allocStats {
+	return allocStats{Mallocs: a.Mallocs - b.Mallocs, Bytes: a.Bytes - b.Bytes}
+}
+
+func (a allocStats) add(b allocStats) allocStats {
+	return allocStats{Mallocs: a.Mallocs + b.Mallocs, Bytes: a.Bytes + b.Bytes}
+}
+
+// タスクあたりの割り当て回数が予算（上限）以内かを結果に記録する。負の予算は判定しない。
+// 割り当て回数は、testing.AllocsPerRunと同様に実行の前後のMallocsの差分を実行回数（ここではタスク数）で割って求める
+func applyAllocBudget(res *Result, budget float64) {
+	if budget < 0 {
+		return
+	}
+	res.Params["alloc_budget"] = strconv.FormatFloat(budget, 'g', -1, 64)
+	res.Metrics[metricAllocBudgetMet] = boolMetric(res.Metrics[metricAllocsPerTask] <= budget)
+}
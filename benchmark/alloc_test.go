@@ -0,0 +1,60 @@
+package benchmark
+
+import (
+	"testing"
+)
+
+func TestApplyAllocBudget(t *testing.T) {
+	cases := []struct {
+		budget, allocs float64
+		want           bool
+	}{
+		{0, 0, true},
+		{0, 0.5, false},
+		{2, 2, true},
+		{2, 2.01, false},
+	}
+	for _, c := range cases {
+		res := Result{Params: map[string]string{}, Metrics: map[string]float64{metricAllocsPerTask: c.allocs}}
+		applyAllocBudget(&res, c.budget)
+		if got := res.flag(metricAllocBudgetMet); got != c.want {
+			t.Errorf("budget %v, allocs %v: met = %v, want %v", c.budget, c.allocs, got, c.want)
+		}
+	}
+
+	res := Result{Params: map[string]string{}, Metrics: map[string]float64{}}
+	applyAllocBudget(&res, -1)
+	if _, ok := res.Metrics[metricAllocBudgetMet]; ok {
+		t.Error("negative budget: want no verdict")
+	}
+}
+
+// データを持たず処理時間を待機しないタスクで、ディスパッチの戦略ごとのタスクあたりの割り当て回数が
+// 予算以内に収まることをtesting.AllocsPerRunで確認する（割り当てが増える変更を検出するため）
+func TestDispatchAllocsPerTask(t *testing.T) {
+	const n = 2000
+	budgets := map[string]float64{
+		"chan-unlimited":   3,
+		"direct-unlimited": 3,
+		"chan-limited":     6,
+		"direct-limited":   5,
+		"sharded":          5,
+	}
+	for _, s := range strategies(defaultParams()) {
+		budget, ok := budgets[s.Name]
+		if !ok {
+			t.Errorf("%s: no allocation budget", s.Name)
+			continue
+		}
+		allocs := testing.AllocsPerRun(3, func() {
+			env := batchEnv(n, newDataGen(DataNone, 0))
+			env.Source = &instantSource{src: env.Source}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+		}) / n
+		if allocs > budget {
+			t.Errorf("%s: %.2f allocs/task, want <= %v", s.Name, allocs, budget)
+		}
+	}
+}
@@ -1,10 +1,10 @@
 package benchmark
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log"
-	"runtime"
 	"sync"
 	"time"
 
@@ -19,64 +19,113 @@ const numTasks = 100000
 type Task struct {
 	ID   int
 	Data string
+	// タスクを送り出す予定だった時刻（レート指定の供給元のみ設定）
+	Scheduled time.Time
+	// タスクが供給元から実際に送り出された時刻（レイテンシ計測時のみ設定）
+	Sent time.Time
+	// 処理時間を待機する関数（nilの場合はtime.Sleep）。スリープシナリオで待機の仕組みを差し替える
+	sleep func(time.Duration)
+	// 処理時間（0の場合はtaskDurationのモデル）。設定ファイルで処理時間の分布を指定した場合に設定する
+	duration time.Duration
 }
 
 // タスクを処理する関数（タスクIDによって処理時間を変えることができる）
 func processTask(task Task) error {
-	// シミュレートされた処理時間
-	// タスクのIDによって処理時間を可変にする（より現実的なワークロード）
+	d := task.duration
+	if d == 0 {
+		d = taskDuration(task.ID)
+	}
+	if task.sleep != nil {
+		task.sleep(d)
+		return nil
+	}
+	time.Sleep(d)
+	return nil
+}
+
+// シミュレートされた処理時間
+// タスクのIDによって処理時間を可変にする（より現実的なワークロード）
+func taskDuration(id int) time.Duration {
 	processingTime := 10 * time.Microsecond
-	if task.ID%10 == 0 {
+	if id%10 == 0 {
 		// 10個に1つは少し重いタスク
 		processingTime = 50 * time.Microsecond
 	}
-	if task.ID%100 == 0 {
+	if id%100 == 0 {
 		// 100個に1つはさらに重いタスク
 		processingTime = 200 * time.Microsecond
 	}
+	return processingTime
+}
 
-	time.Sleep(processingTime)
-	return nil
+// チャネルを使用した実装の構成。ゼロ値の項目は既定値を使う
+type ChannelOptions struct {
+	// タスクのチャネルのバッファの大きさ（0の場合はdefaultTaskBuffer）
+	Buffer int
+	// 同じタスクのチャネルから競合して受信するディスパッチャーの数（0の場合は1つ）
+	Dispatchers int
+	// semaphoreの取得に失敗したときの方針（制限付き並列処理のみ。空の場合はAcquireCount）
+	Policy AcquirePolicy
 }
 
-// チャネルを使用した実装：1つのgoroutineを事前に起動
-func ChannelWithUnlimitedParallelism() error {
-	tasks := make(chan Task, 100)
+// ゼロ値の項目を既定値に置き換える
+func (o ChannelOptions) withDefaults() ChannelOptions {
+	o.Buffer = cmp.Or(o.Buffer, defaultTaskBuffer)
+	o.Dispatchers = cmp.Or(o.Dispatchers, 1)
+	o.Policy = cmp.Or(o.Policy, AcquireCount)
+	return o
+}
+
+// チャネルを使用した実装：ディスパッチャーのgoroutine（既定は1つ）を事前に起動
+func ChannelWithUnlimitedParallelism(env Env, opts ChannelOptions) error {
+	opts = opts.withDefaults()
+	tasks := make(chan Task, opts.Buffer)
 	done := make(chan struct{})
 
 	// errgroupを作成
-	eg, ctx := errgroup.WithContext(context.Background())
+	eg, ctx := errgroup.WithContext(env.context())
 
-	// ワーカーgoroutineを一つ起動
-	go func() {
-		defer close(done)
-		for task := range tasks {
-			task := task // ループ変数をキャプチャ
-			// errgroup.Goを使用してタスク処理を実行
-			eg.Go(func() error {
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				default:
-					if err := processTask(task); err != nil {
-						log.Printf("Error processing task %d: %v", task.ID, err)
+	// ディスパッチャーgoroutineを起動
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Dispatchers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				task := task // ループ変数をキャプチャ
+				start := env.dispatchStart()
+				// errgroup.Goを使用してタスク処理を実行
+				eg.Go(func() error {
+					select {
+					case <-ctx.Done():
+						env.drop(1)
+						return ctx.Err()
+					default:
+						if err := env.Process(task); err != nil {
+							log.Printf("Error processing task %d: %v", task.ID, err)
+						}
+						return nil
 					}
-					return nil
-				}
-			})
-		}
+				})
+				env.dispatched(start)
+			}
+		}()
+	}
 
-		// すべてのタスク処理が完了するのを待つ
+	go func() {
+		defer close(done)
+		// すべてのディスパッチャーが受信を終えてから、すべてのタスク処理が完了するのを待つ
+		wg.Wait()
 		if err := eg.Wait(); err != nil {
 			log.Printf("Error in worker: %v", err)
 		}
 	}()
 
 	// タスクをチャネルに送信
-	for i := 0; i < numTasks; i++ {
-		task := Task{
-			ID:   i,
-			Data: fmt.Sprintf("Task data %d", i),
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
 		}
 		tasks <- task
 	}
@@ -84,30 +133,30 @@ func ChannelWithUnlimitedParallelism() error {
 	// タスクの送信が終了したらチャネルを閉じる
 	close(tasks)
 
-	// ワーカーの終了を待つ
+	// ディスパッチャーの終了を待つ
 	<-done
 	return nil
 }
 
 // goroutineをループ内で起動する実装
-func DirectGoroutineWithUnlimitedParallelism() error {
+func DirectGoroutineWithUnlimitedParallelism(env Env) error {
 	// errgroupでgoroutineの実行を管理
-	eg, ctx := errgroup.WithContext(context.Background())
+	eg, ctx := errgroup.WithContext(env.context())
 
 	// タスクごとにgoroutineを起動
-	for i := 0; i < numTasks; i++ {
-		i := i // ループ変数をキャプチャ
-		task := Task{
-			ID:   i,
-			Data: fmt.Sprintf("Task data %d", i),
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
 		}
 
 		eg.Go(func() error {
 			select {
 			case <-ctx.Done():
+				env.drop(1)
 				return ctx.Err()
 			default:
-				return processTask(task)
+				return env.Process(task)
 			}
 		})
 	}
@@ -116,56 +165,94 @@ func DirectGoroutineWithUnlimitedParallelism() error {
 	return eg.Wait()
 }
 
-// 複数のワーカーを使用するチャネル実装（比較用）
-func ChannelWithLimitedParallelism(numWorkers int) error {
-	tasks := make(chan Task, 100)
+// 複数のワーカーを使用するチャネル実装（比較用）。
+// semaphoreは全てのディスパッチャーで共有するため、同時実行数はディスパッチャーの数に関わらずnumWorkersまで
+func ChannelWithLimitedParallelism(env Env, numWorkers int, opts ChannelOptions) error {
+	opts = opts.withDefaults()
+	policy := opts.Policy
+	tasks := make(chan Task, opts.Buffer)
 	done := make(chan struct{})
 
 	// errgroupを作成
-	eg, ctx := errgroup.WithContext(context.Background())
+	eg, ctx := errgroup.WithContext(env.context())
 
 	// semaphoreを作成して並列度を制限
 	sem := semaphore.NewWeighted(int64(numWorkers))
 
-	// ディスパッチャーgoroutineを一つ起動
-	go func() {
-		defer close(done)
-		for task := range tasks {
-			task := task // ループ変数をキャプチャ
+	// ディスパッチを中止した場合のエラー（最初に中止したディスパッチャーのもの）
+	var abortOnce sync.Once
+	var abortErr error
 
-			// semaphoreの空きを待つ
-			if err := sem.Acquire(ctx, 1); err != nil {
-				log.Printf("Failed to acquire semaphore: %v", err)
-				continue
-			}
-
-			// errgroup.Goを使用してタスク処理を実行（semaphoreで制限）
-			eg.Go(func() error {
-				defer sem.Release(1) // 処理完了時にsemaphoreを解放
+	// ディスパッチャーgoroutineを起動
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Dispatchers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				task := task // ループ変数をキャプチャ
+
+				// semaphoreの空きを待つ
+				if err := sem.Acquire(ctx, 1); err != nil {
+					switch policy {
+					case AcquireRetry:
+						// キャンセルされないコンテキストで取得をやり直し、タスクを落とさない
+						if err := sem.Acquire(context.WithoutCancel(ctx), 1); err != nil {
+							env.drop(1)
+							continue
+						}
+					case AcquireAbort:
+						// 残りのタスクを破棄してディスパッチを中止する（送信側を止めないようチャネルは読み切る）
+						abortOnce.Do(func() { abortErr = fmt.Errorf("dispatch aborted: %w", err) })
+						env.drop(1)
+						for range tasks {
+							env.drop(1)
+						}
+						return
+					default:
+						log.Printf("Failed to acquire semaphore: %v", err)
+						env.drop(1)
+						continue
+					}
+				}
 
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				default:
-					if err := processTask(task); err != nil {
+				// errgroup.Goを使用してタスク処理を実行（semaphoreで制限）
+				start := env.dispatchStart()
+				eg.Go(func() error {
+					defer sem.Release(1) // 処理完了時にsemaphoreを解放
+
+					select {
+					case <-ctx.Done():
+						if policy != AcquireRetry {
+							env.drop(1)
+							return ctx.Err()
+						}
+					default:
+					}
+					if err := env.Process(task); err != nil {
 						log.Printf("Error processing task %d: %v", task.ID, err)
 					}
 					return nil
-				}
-			})
-		}
+				})
+				env.dispatched(start)
+			}
+		}()
+	}
 
-		// すべてのタスク処理が完了するのを待つ
+	go func() {
+		defer close(done)
+		// すべてのディスパッチャーが受信を終えてから、すべてのタスク処理が完了するのを待つ
+		wg.Wait()
 		if err := eg.Wait(); err != nil {
 			log.Printf("Error in worker: %v", err)
 		}
 	}()
 
 	// タスクをチャネルに送信
-	for i := 0; i < numTasks; i++ {
-		task := Task{
-			ID:   i,
-			Data: fmt.Sprintf("Task data %d", i),
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
 		}
 		tasks <- task
 	}
@@ -175,11 +262,11 @@ func ChannelWithLimitedParallelism(numWorkers int) error {
 
 	// ディスパッチャーの終了を待つ
 	<-done
-	return nil
+	return abortErr
 }
 
 // semaphoreを使用してgoroutineの同時実行数を制限する実装
-func DirectGoroutineWithLimitedParallelism(maxConcurrency int64) error {
+func DirectGoroutineWithLimitedParallelism(env Env, maxConcurrency int64) error {
 	// コンテキストを作成
 	ctx := context.Background()
 
@@ -190,11 +277,10 @@ func DirectGoroutineWithLimitedParallelism(maxConcurrency int64) error {
 	var wg sync.WaitGroup
 
 	// タスクごとにgoroutineを起動（semaphoreで同時実行数を制限）
-	for i := 0; i < numTasks; i++ {
-		i := i // ループ変数をキャプチャ
-		task := Task{
-			ID:   i,
-			Data: fmt.Sprintf("Task data %d", i),
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
 		}
 
 		// semaphoreの空きを待つ
@@ -207,7 +293,7 @@ func DirectGoroutineWithLimitedParallelism(maxConcurrency int64) error {
 			defer sem.Release(1)
 			defer wg.Done()
 
-			if err := processTask(task); err != nil {
+			if err := env.Process(task); err != nil {
 				log.Printf("Error processing task %d: %v", task.ID, err)
 			}
 		}()
@@ -217,42 +303,3 @@ func DirectGoroutineWithLimitedParallelism(maxConcurrency int64) error {
 	wg.Wait()
 	return nil
 }
-
-// ベンチマークを実行する関数
-func Run() error {
-	fmt.Printf("CPUs: %d\n", runtime.NumCPU())
-	fmt.Printf("処理タスク数: %d\n\n", numTasks)
-
-	fmt.Println("1. チャネル + 単一ディスパッチャー + 無制限の並列処理（errgroup.Go）")
-	start := time.Now()
-	if err := ChannelWithUnlimitedParallelism(); err != nil {
-		return err
-	}
-	fmt.Printf("処理時間: %v\n\n", time.Since(start))
-
-	fmt.Println("2. 直接goroutine起動 + 無制限の並列処理（errgroup.Go）")
-	start = time.Now()
-	if err := DirectGoroutineWithUnlimitedParallelism(); err != nil {
-		return err
-	}
-	fmt.Printf("処理時間: %v\n\n", time.Since(start))
-
-	// 比較のために複数ワーカーのチャネル実装も実行
-	numWorkers := runtime.NumCPU()
-	fmt.Printf("3. チャネル + 単一ディスパッチャー + 制限付き並列処理（errgroup.Go + semaphore、%d同時実行）\n", numWorkers)
-	start = time.Now()
-	if err := ChannelWithLimitedParallelism(numWorkers); err != nil {
-		return err
-	}
-	fmt.Printf("処理時間: %v\n\n", time.Since(start))
-
-	// 4つ目のアプローチ：semaphoreを使用した実装
-	fmt.Printf("4. 直接goroutine起動 + 制限付き並列処理（semaphore、%d同時実行）\n", numWorkers)
-	start = time.Now()
-	if err := DirectGoroutineWithLimitedParallelism(int64(numWorkers)); err != nil {
-		return err
-	}
-	fmt.Printf("処理時間: %v\n\n", time.Since(start))
-
-	return nil
-}
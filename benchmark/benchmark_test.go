@@ -8,7 +8,7 @@ import (
 // チャネル + 単一ディスパッチャー + 無制限の並列処理
 func BenchmarkChannelWithUnlimitedParallelism(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		if err := ChannelWithUnlimitedParallelism(); err != nil {
+		if err := ChannelWithUnlimitedParallelism(BatchEnv(numTasks), ChannelOptions{}); err != nil {
 			b.Fatal(err)
 		}
 	}
@@ -17,7 +17,7 @@ func BenchmarkChannelWithUnlimitedParallelism(b *testing.B) {
 // 直接goroutine起動 + 無制限の並列処理
 func BenchmarkDirectGoroutineWithUnlimitedParallelism(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		if err := DirectGoroutineWithUnlimitedParallelism(); err != nil {
+		if err := DirectGoroutineWithUnlimitedParallelism(BatchEnv(numTasks)); err != nil {
 			b.Fatal(err)
 		}
 	}
@@ -29,7 +29,7 @@ func BenchmarkChannelWithLimitedParallelism(b *testing.B) {
 
 	b.Run("4Workers", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			if err := ChannelWithLimitedParallelism(numWorkers); err != nil {
+			if err := ChannelWithLimitedParallelism(BatchEnv(numTasks), numWorkers, ChannelOptions{}); err != nil {
 				b.Fatal(err)
 			}
 		}
@@ -43,7 +43,7 @@ func BenchmarkChannelWithLimitedParallelismVaryingWorkers(b *testing.B) {
 	for _, count := range workerCounts {
 		b.Run(string("Workers"+string(rune(count+'0'))), func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
-				if err := ChannelWithLimitedParallelism(count); err != nil {
+				if err := ChannelWithLimitedParallelism(BatchEnv(numTasks), count, ChannelOptions{}); err != nil {
 					b.Fatal(err)
 				}
 			}
@@ -57,7 +57,7 @@ func BenchmarkDirectGoroutineWithLimitedParallelism(b *testing.B) {
 
 	b.Run("DefaultConcurrency", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			if err := DirectGoroutineWithLimitedParallelism(numWorkers); err != nil {
+			if err := DirectGoroutineWithLimitedParallelism(BatchEnv(numTasks), numWorkers); err != nil {
 				b.Fatal(err)
 			}
 		}
@@ -71,7 +71,160 @@ func BenchmarkDirectGoroutineWithVaryingConcurrency(b *testing.B) {
 	for _, count := range concurrencyCounts {
 		b.Run(string("Concurrency"+string(rune(count+'0'))), func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
-				if err := DirectGoroutineWithLimitedParallelism(count); err != nil {
+				if err := DirectGoroutineWithLimitedParallelism(BatchEnv(numTasks), count); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// 複数種類のタスクの振り分け方法の比較
+func BenchmarkTaskKinds(b *testing.B) {
+	for _, s := range taskKindStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// フェーズ分割処理のバリア方式の比較
+func BenchmarkPhases(b *testing.B) {
+	for _, s := range phaseStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// errgroupのエラー伝播の修正前後の比較
+func BenchmarkErrgroup(b *testing.B) {
+	for _, s := range errgroupStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// 重み付きsemaphoreの一律の重みとタスクの重さに応じた重みの比較
+func BenchmarkWeighted(b *testing.B) {
+	for _, s := range weightedStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// 2段階のディスパッチ（シャードごとのチャネル + シャード内で直接goroutine起動）
+func BenchmarkTwoLevelDispatch(b *testing.B) {
+	p := defaultParams()
+	for i := 0; i < b.N; i++ {
+		if err := TwoLevelDispatch(BatchEnv(numTasks), p.Shards, p.ShardLimit); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 重複タスクの排除方法の比較
+func BenchmarkDedup(b *testing.B) {
+	for _, s := range dedupStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// 処理時間の待機の仕組みの比較
+func BenchmarkSleep(b *testing.B) {
+	for _, s := range sleepStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// 開始予定時刻のあるタスクの予約実行の方法の比較
+func BenchmarkScheduled(b *testing.B) {
+	for _, s := range scheduledStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// 高い競合下でのセマフォの取得と解放の比較
+func BenchmarkSemaphore(b *testing.B) {
+	for _, s := range semaphoreStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// 直接goroutine起動の完了待ちの仕組みの比較
+func BenchmarkCompletion(b *testing.B) {
+	for _, s := range completionStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// ワーカープールの各タスクの完了通知の方法の比較
+func BenchmarkNotify(b *testing.B) {
+	for _, s := range notifyStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// ワーカープールの停止の順序の比較
+func BenchmarkShutdown(b *testing.B) {
+	for _, s := range shutdownStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
 					b.Fatal(err)
 				}
 			}
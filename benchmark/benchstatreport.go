@@ -0,0 +1,69 @@
+package benchmark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 結果をgo test -benchの出力と同じ形式（BenchmarkName  N  ns/op）で書き出す。
+// golang.org/x/perf/cmd/benchstatにそのまま渡し、コミット間の差を統計的に比べられるようにする。
+// 1タスクを1操作とし、繰り返しごとに1行（N はタスク数、ns/op は1タスクあたりの処理時間）を書くため、
+// benchstatは繰り返し回数をサンプル数として扱う。繰り返しごとの処理時間がない結果（負荷ランプなど）は書き出さない
+type benchstatReporter struct {
+	w      io.WriteCloser
+	buf    *bufio.Writer
+	header bool
+}
+
+func newBenchstatReporter(target string, stdout io.Writer) (Reporter, error) {
+	w, err := createOutput(target, stdout)
+	if err != nil {
+		return nil, err
+	}
+	return &benchstatReporter{w: w, buf: bufio.NewWriter(w)}, nil
+}
+
+func (b *benchstatReporter) Report(r Result) error {
+	tasks := r.Metrics[metricTasks]
+	if len(r.Samples) == 0 || tasks <= 0 {
+		return nil
+	}
+	if !b.header {
+		// benchstatは「key: value」の行を設定として読み、結果の表に表示する
+		fmt.Fprintf(b.buf, "goos: %s\ngoarch: %s\n", r.Environment.GOOS, r.Environment.GOARCH)
+		if r.Provenance.Module != "" {
+			fmt.Fprintf(b.buf, "pkg: %s\n", r.Provenance.Module)
+		}
+		if r.Provenance.Commit != "" {
+			fmt.Fprintf(b.buf, "commit: %s\n", r.Provenance.Commit)
+		}
+		b.header = true
+	}
+	name := benchstatName(r)
+	for _, ns := range r.Samples {
+		fmt.Fprintf(b.buf, "%s\t%d\t%.2f ns/op\n", name, int64(tasks), ns/tasks)
+	}
+	return nil
+}
+
+func (b *benchstatReporter) Close() error {
+	err := b.buf.Flush()
+	if closeErr := b.w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// go test -benchと同じ形式のベンチマーク名（例: BenchmarkDispatch/chan-unlimited-8）。
+// シナリオを関数名、戦略をサブベンチマーク名にし、末尾にGOMAXPROCSを付ける
+func benchstatName(r Result) string {
+	sc, size := utf8.DecodeRuneInString(r.Scenario)
+	scenario := string(unicode.ToUpper(sc)) + r.Scenario[size:]
+	// 名前の空白はgo test -benchと同じく_に置き換える（空白はフィールドの区切りになるため）
+	name := strings.Join(strings.Fields("Benchmark"+scenario+"/"+r.Strategy), "_")
+	return fmt.Sprintf("%s-%d", name, r.Environment.GOMAXPROCS)
+}
@@ -0,0 +1,137 @@
+package benchmark
+
+import (
+	"bufio"
+	"bytes"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+)
+
+// goroutineプロファイルを取得する間隔。取得中は全goroutineのスタックを走査するため、
+// 10万goroutineの実行でも計測を乱しすぎない程度に間隔を空ける
+const blockedSampleInterval = 50 * time.Millisecond
+
+// ブロックの分類（メトリクス名のblocked_<分類>_pctに使う）
+var blockedKinds = []string{"chan_send", "chan_receive", "select", "semacquire", "sleep", "other"}
+
+// goroutineの状態（goroutineプロファイルの[...]の部分）をブロックの分類に振り分ける。
+// 実行中または実行待ちの場合は空を返す
+func classifyBlocked(state string) string {
+	switch {
+	case state == "running" || state == "runnable":
+		return ""
+	case strings.HasPrefix(state, "chan send"):
+		return "chan_send"
+	case strings.HasPrefix(state, "chan receive"):
+		return "chan_receive"
+	case strings.HasPrefix(state, "select"):
+		return "select"
+	case state == "semacquire" || strings.HasPrefix(state, "sync."):
+		// semaphore.Weightedの待ちはselectとなり、WaitGroupやMutexの待ちはここに入る
+		return "semacquire"
+	case state == "sleep":
+		return "sleep"
+	}
+	return "other"
+}
+
+// goroutineプロファイル（debug=2の形式）から状態ごとのgoroutine数を数える
+func countGoroutineStates(profile []byte, counts map[string]int) (total int) {
+	sc := bufio.NewScanner(bytes.NewReader(profile))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "goroutine ") {
+			continue
+		}
+		_, state, ok := strings.Cut(line, "[")
+		if !ok {
+			continue
+		}
+		state, _, _ = strings.Cut(state, "]")
+		// 「chan receive, 2 minutes」のような待ち時間の表記を除く
+		state, _, _ = strings.Cut(state, ",")
+		total++
+		if kind := classifyBlocked(state); kind != "" {
+			counts[kind]++
+		}
+	}
+	return total
+}
+
+// 実行中に定期的にgoroutineプロファイルを取得し、goroutineがどの同期プリミティブで
+// ブロックしていたかの割合を戦略ごとに集計する
+type blockedHook struct {
+	mu      sync.Mutex
+	counts  map[string]map[string]int
+	totals  map[string]int
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newBlockedHook(Config) (Hook, error) {
+	return &blockedHook{counts: map[string]map[string]int{}, totals: map[string]int{}}, nil
+}
+
+func (h *blockedHook) BeforeRun(s Strategy) {
+	h.done = make(chan struct{})
+	h.stopped = make(chan struct{})
+	go h.sample(s.Name, h.done, h.stopped)
+}
+
+func (h *blockedHook) sample(name string, done, stopped chan struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(blockedSampleInterval)
+	defer ticker.Stop()
+	var buf bytes.Buffer
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		buf.Reset()
+		if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+			continue
+		}
+		counts := map[string]int{}
+		total := countGoroutineStates(buf.Bytes(), counts)
+
+		h.mu.Lock()
+		if h.counts[name] == nil {
+			h.counts[name] = map[string]int{}
+		}
+		for kind, n := range counts {
+			h.counts[name][kind] += n
+		}
+		h.totals[name] += total
+		h.mu.Unlock()
+	}
+}
+
+func (h *blockedHook) WrapTask(_ Strategy, next func(Task) error) func(Task) error {
+	return next
+}
+
+func (h *blockedHook) AfterRun(Strategy, time.Duration, error) {
+	close(h.done)
+	<-h.stopped
+}
+
+func (h *blockedHook) Collect(res *Result) {
+	h.mu.Lock()
+	counts, total := h.counts[res.Strategy], h.totals[res.Strategy]
+	delete(h.counts, res.Strategy)
+	delete(h.totals, res.Strategy)
+	h.mu.Unlock()
+	if total == 0 {
+		return
+	}
+
+	res.Metrics["goroutine_samples"] = float64(total)
+	for _, kind := range blockedKinds {
+		res.Metrics["blocked_"+kind+"_pct"] = float64(counts[kind]) / float64(total) * 100
+	}
+}
@@ -0,0 +1,56 @@
+package benchmark
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountGoroutineStates(t *testing.T) {
+	profile := []byte(`goroutine 1 [running]:
+main.main()
+
+goroutine 7 [chan receive, 2 minutes]:
+main.worker()
+
+goroutine 8 [chan send]:
+goroutine 9 [select]:
+goroutine 10 [sync.WaitGroup.Wait]:
+goroutine 11 [semacquire]:
+goroutine 12 [sleep]:
+goroutine 13 [IO wait]:
+goroutine 14 [runnable]:
+`)
+	counts := map[string]int{}
+	if total := countGoroutineStates(profile, counts); total != 9 {
+		t.Errorf("total = %d, want 9", total)
+	}
+	want := map[string]int{"chan_receive": 1, "chan_send": 1, "select": 1, "semacquire": 2, "sleep": 1, "other": 1}
+	for kind, n := range want {
+		if counts[kind] != n {
+			t.Errorf("%s = %d, want %d", kind, counts[kind], n)
+		}
+	}
+}
+
+// チャネルの受信で止まっているgoroutineが集計されることを確認する
+func TestBlockedHook(t *testing.T) {
+	h, err := newBlockedHook(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Strategy{Name: "blocked-recv"}
+	h.BeforeRun(s)
+	ch := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		go func() { <-ch }()
+	}
+	time.Sleep(3 * blockedSampleInterval)
+	close(ch)
+	h.AfterRun(s, 0, nil)
+
+	res := Result{Strategy: s.Name, Metrics: map[string]float64{}}
+	h.Collect(&res)
+	if res.Metrics["goroutine_samples"] == 0 || res.Metrics["blocked_chan_receive_pct"] == 0 {
+		t.Errorf("unexpected metrics: %v", res.Metrics)
+	}
+}
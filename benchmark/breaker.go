@@ -0,0 +1,262 @@
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// サーキットブレーカーのシナリオで1回の実行で処理するタスク数と、タスクの到着間隔
+	breakerTasks = 10000
+	breakerPace  = 50 * time.Microsecond
+	// タスクを処理するワーカー数
+	breakerWorkers = 64
+	// 処理先が障害を起こす期間（供給開始からの時間）と、障害中の呼び出しがタイムアウトするまでの時間
+	outageFrom    = 100 * time.Millisecond
+	outageUntil   = 250 * time.Millisecond
+	outageTimeout = 5 * time.Millisecond
+	// 連続してこの回数失敗したらブレーカーを開き、この時間が経ったら試しに1つ通す
+	breakerThreshold = 5
+	breakerCooldown  = 20 * time.Millisecond
+)
+
+var (
+	// 障害中の処理先の呼び出しがタイムアウトした
+	errDownstreamTimeout = errors.New("downstream timed out")
+	// ブレーカーが開いているため処理先を呼び出さなかった
+	errCircuitOpen = errors.New("circuit breaker is open")
+)
+
+// 処理先の呼び出しの記録
+type downstreamStats struct {
+	// タイムアウトした呼び出しの数と、それに費やした時間の合計（ナノ秒）
+	Failures, Wasted atomic.Int64
+	// ブレーカーが開いていたため呼び出さなかったタスクの数
+	Rejected atomic.Int64
+	// 障害の終了後に到着したタスクが初めて成功するまでの時間（ナノ秒、0は未記録）
+	Recovery atomic.Int64
+}
+
+// 全ての回の処理先の呼び出しの記録の合計
+type downstreamTotals struct {
+	failures, wasted, rejected, recovery int64
+	recovered                            int
+}
+
+func (t *downstreamTotals) add(s *downstreamStats) {
+	t.failures += s.Failures.Load()
+	t.wasted += s.Wasted.Load()
+	t.rejected += s.Rejected.Load()
+	if d := s.Recovery.Load(); d > 0 {
+		t.recovery += d
+		t.recovered++
+	}
+}
+
+// 処理先を呼び出した戦略の場合のみ、1回あたりの値をメトリクスに記録する
+func (t downstreamTotals) setMetrics(res *Result, reps int) {
+	if t.failures == 0 && t.rejected == 0 && t.recovered == 0 {
+		return
+	}
+	res.Metrics[metricOutageFailures] = float64(t.failures) / float64(reps)
+	res.Metrics[metricWastedWork] = float64(t.wasted) / float64(reps)
+	res.Metrics[metricBreakerRejected] = float64(t.rejected) / float64(reps)
+	if t.recovered > 0 {
+		res.Metrics[metricRecovery] = float64(t.recovery) / float64(t.recovered)
+	}
+}
+
+// 一定の期間だけ障害を起こす処理先。障害中の呼び出しは、タイムアウトするまで待たされてから失敗する
+type flakyDownstream struct {
+	start  time.Time
+	stats  *downstreamStats
+	ending time.Time
+}
+
+func newFlakyDownstream(stats *downstreamStats) *flakyDownstream {
+	start := time.Now()
+	return &flakyDownstream{start: start, stats: stats, ending: start.Add(outageUntil)}
+}
+
+// 処理先を呼び出す
+func (d *flakyDownstream) call(process func(Task) error, task Task) error {
+	now := time.Now()
+	if elapsed := now.Sub(d.start); elapsed >= outageFrom && elapsed < outageUntil {
+		time.Sleep(outageTimeout)
+		if d.stats != nil {
+			d.stats.Failures.Add(1)
+			d.stats.Wasted.Add(int64(time.Since(now)))
+		}
+		return &TaskError{Kind: ErrorTimeout, Err: fmt.Errorf("task %d: %w", task.ID, errDownstreamTimeout)}
+	}
+	if err := process(task); err != nil {
+		return err
+	}
+	if d.stats != nil && !task.Scheduled.Before(d.ending) {
+		d.stats.Recovery.CompareAndSwap(0, int64(time.Since(d.ending)))
+	}
+	return nil
+}
+
+// サーキットブレーカーの状態
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// 連続した失敗で開き、一定時間後に1つだけ試しに通して、成功すれば閉じるサーキットブレーカー
+type circuitBreaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	threshold int
+	cooldown  time.Duration
+}
+
+// 呼び出してよいかを返す。開いてから一定時間が経っていれば、試しの1つだけを通す
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// 試しの呼び出しの結果を待つ
+		return false
+	}
+	return true
+}
+
+// 呼び出しの結果を記録する
+func (b *circuitBreaker) record(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ok {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// サーキットブレーカーのシナリオの戦略一覧。
+// 一定間隔で到着するタスクをワーカープールで処理する途中で処理先が障害を起こし、呼び出しがタイムアウトするようになる。
+// 処理先を毎回呼び出す方法と、サーキットブレーカーで障害中の呼び出しを止める方法で、
+// 無駄になった処理時間と障害の終了から回復するまでの時間を比べる
+func breakerStrategies(p Params) []Strategy {
+	topology := func(completion string) Topology {
+		return Topology{
+			Stages: []Stage{
+				{Role: RoleProducer, Goroutines: 1, Note: fmt.Sprintf("%v間隔で読み出す", breakerPace)},
+				{Role: RoleWorker, Goroutines: breakerWorkers, From: chanFrom("Task", 100)},
+			},
+			Completion: completion,
+		}
+	}
+	list := []Strategy{
+		{
+			Name:     "breaker-none",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ 障害中も処理先を毎回呼び出す", breakerWorkers),
+			Func:     "DownstreamPool",
+			Limit:    breakerWorkers,
+			Topology: topology("sync.WaitGroup（処理先のエラーは数えるのみ）"),
+			Run: func(env Env) error {
+				return DownstreamPool(env, breakerWorkers, false)
+			},
+		},
+		{
+			Name: "breaker",
+			Title: fmt.Sprintf("ワーカープール（%dワーカー）+ サーキットブレーカー（%d回連続の失敗で開き、%v後に試しに1つ通す）",
+				breakerWorkers, breakerThreshold, breakerCooldown),
+			Func:     "DownstreamPool",
+			Limit:    breakerWorkers,
+			Topology: topology("sync.WaitGroup（処理先のエラーと開いたブレーカーで止めた呼び出しは数えるのみ）"),
+			Run: func(env Env) error {
+				return DownstreamPool(env, breakerWorkers, true)
+			},
+		},
+	}
+	for i, s := range list {
+		run := s.Run
+		list[i].Pace = breakerPace
+		list[i].Tasks = breakerTasks
+		list[i].Run = func(env Env) error {
+			env.Source = newTickerSource(env.Source, breakerPace)
+			return run(withJitter(env))
+		}
+	}
+	return list
+}
+
+// 障害を起こす処理先をワーカープールから呼び出す。breakerがtrueの場合は、サーキットブレーカーを通して呼び出す。
+// 処理先のタイムアウトとブレーカーで止めた呼び出しは数えるのみで、それ以外のエラーを返す
+func DownstreamPool(env Env, numWorkers int, breaker bool) error {
+	var stats *downstreamStats
+	if env.Stats != nil {
+		stats = &env.Stats.Downstream
+	}
+	down := newFlakyDownstream(stats)
+	cb := &circuitBreaker{threshold: breakerThreshold, cooldown: breakerCooldown}
+	process := env.Process
+	call := func(task Task) error {
+		if !breaker {
+			return down.call(process, task)
+		}
+		if !cb.allow() {
+			if stats != nil {
+				stats.Rejected.Add(1)
+			}
+			return errCircuitOpen
+		}
+		err := down.call(process, task)
+		cb.record(!errors.Is(err, errDownstreamTimeout))
+		return err
+	}
+
+	tasks := make(chan Task, 100)
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				err := call(task)
+				if err == nil || errors.Is(err, errDownstreamTimeout) || errors.Is(err, errCircuitOpen) {
+					continue
+				}
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks <- task
+	}
+	close(tasks)
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,96 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 連続した失敗で開き、待ち時間の後に試しの1つだけを通し、その結果で閉じるか開き直すことを確認する
+func TestCircuitBreaker(t *testing.T) {
+	b := &circuitBreaker{threshold: 3, cooldown: 10 * time.Millisecond}
+	for i := 0; i < 3; i++ {
+		if !b.allow() {
+			t.Fatalf("call %d rejected before reaching the threshold", i)
+		}
+		b.record(false)
+	}
+	if b.allow() {
+		t.Fatal("call allowed while open")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("probe rejected after cooldown")
+	}
+	if b.allow() {
+		t.Fatal("second call allowed while probing")
+	}
+	b.record(false)
+	if b.allow() {
+		t.Fatal("call allowed after failed probe")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("probe rejected after cooldown")
+	}
+	b.record(true)
+	for i := 0; i < 3; i++ {
+		if !b.allow() {
+			t.Fatalf("call %d rejected after successful probe", i)
+		}
+	}
+}
+
+// 処理先の障害がない場合は全てのタスクを処理し、それ以外のエラーは返すことを確認する
+func TestDownstreamPool(t *testing.T) {
+	const n = 2000
+
+	for _, breaker := range []bool{false, true} {
+		var completed atomic.Int64
+		env := BatchEnv(n)
+		env.Process = func(task Task) error {
+			defer completed.Add(1)
+			return processTask(task)
+		}
+		// 障害の期間より前に終わる
+		if err := DownstreamPool(env, 100, breaker); err != nil {
+			t.Fatal(err)
+		}
+		if got := completed.Load(); got != n {
+			t.Errorf("breaker=%v: returned after %d tasks completed, want %d", breaker, got, n)
+		}
+
+		var processed atomic.Int64
+		env = BatchEnv(n)
+		env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+		if err := DownstreamPool(env, 100, breaker); !errors.Is(err, errInjectedFailure) {
+			t.Errorf("breaker=%v: got %v, want injected failure", breaker, err)
+		}
+	}
+}
+
+// 障害中の呼び出しがタイムアウトして数えられ、障害の終了後に到着したタスクの成功で回復を記録することを確認する
+func TestFlakyDownstream(t *testing.T) {
+	var stats downstreamStats
+	d := newFlakyDownstream(&stats)
+	d.start = time.Now().Add(-outageFrom)
+	d.ending = d.start.Add(outageUntil)
+	if err := d.call(processTask, Task{ID: 1}); !errors.Is(err, errDownstreamTimeout) || classifyError(err) != ErrorTimeout {
+		t.Fatalf("got %v, want downstream timeout", err)
+	}
+	if stats.Failures.Load() != 1 || stats.Wasted.Load() < int64(outageTimeout) {
+		t.Errorf("failures = %d, wasted = %v", stats.Failures.Load(), time.Duration(stats.Wasted.Load()))
+	}
+
+	d.start = time.Now().Add(-outageUntil)
+	d.ending = d.start.Add(outageUntil)
+	if err := d.call(processTask, Task{ID: 1, Scheduled: d.ending}); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Recovery.Load() <= 0 {
+		t.Error("recovery was not recorded")
+	}
+}
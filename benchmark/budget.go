@@ -0,0 +1,68 @@
+package benchmark
+
+import (
+	"fmt"
+	"time"
+)
+
+// 実行全体の時間の上限（-max-duration）。残りの時間を残りのシナリオに均等に割り振り、
+// 共有のCIマシンでも大きな組み合わせを時間内に収められるようにする。
+// 実行中の戦略は中断せず、戦略やスイープのタスク数の区切りで残りの時間を確認する。nilは上限なし
+type runBudget struct {
+	deadline time.Time
+	// 実行中のシナリオに割り当てた時間の終わり
+	scenarioEnd time.Time
+	now         func() time.Time
+}
+
+func newRunBudget(max time.Duration) *runBudget {
+	if max <= 0 {
+		return nil
+	}
+	return &runBudget{deadline: time.Now().Add(max), now: time.Now}
+}
+
+// 残りの時間をremaining個のシナリオで均等に分け、次のシナリオの割り当てを決める
+func (b *runBudget) startScenario(remaining int) time.Duration {
+	if b == nil {
+		return 0
+	}
+	slice := b.deadline.Sub(b.now()) / time.Duration(max(remaining, 1))
+	b.scenarioEnd = b.now().Add(slice)
+	return slice
+}
+
+// 実行全体の上限に達したか
+func (b *runBudget) exhausted() bool {
+	return b != nil && !b.now().Before(b.deadline)
+}
+
+// 見積もった時間が、実行中のシナリオの割り当ての残りに収まるか
+func (b *runBudget) fits(estimate time.Duration) bool {
+	return b == nil || !b.now().Add(estimate).After(b.scenarioEnd)
+}
+
+// 時間の上限のために実行しなかった戦略の結果
+func (r *runner) skippedResult(s Strategy, reason string) Result {
+	res := r.newResult(r.mode(), s)
+	res.AbortReason = reason
+	res.Metrics[metricAborted] = 1
+	res.Metrics[metricSkipped] = 1
+	return res
+}
+
+// 実行全体の上限に達したため、戦略を実行せずに記録する
+func (r *runner) skipStrategy(s Strategy) error {
+	return r.report(r.skippedResult(s, fmt.Sprintf("実行時間の上限（%v）に達したため実行しませんでした", r.cfg.MaxDuration)))
+}
+
+// 実行全体の上限に達したため、シナリオの全ての戦略を実行せずに記録する
+func (r *runner) skipScenario(sc Scenario) error {
+	r.out.scenarioOverBudget(sc)
+	for _, s := range r.cfg.strategies(sc) {
+		if err := r.skipStrategy(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
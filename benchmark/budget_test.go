@@ -0,0 +1,78 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// 残りの時間を残りのシナリオで均等に分け、上限に達したかを判定することを確認する
+func TestRunBudget(t *testing.T) {
+	if newRunBudget(0) != nil {
+		t.Fatal("zero max duration should mean no budget")
+	}
+	var unlimited *runBudget
+	if unlimited.exhausted() || !unlimited.fits(time.Hour) {
+		t.Error("nil budget should never be exhausted")
+	}
+
+	now := time.Unix(0, 0)
+	b := &runBudget{deadline: now.Add(60 * time.Second), now: func() time.Time { return now }}
+	if slice := b.startScenario(3); slice != 20*time.Second {
+		t.Errorf("slice = %v, want 20s", slice)
+	}
+	if !b.fits(20*time.Second) || b.fits(21*time.Second) {
+		t.Error("fits should compare against the scenario slice")
+	}
+	now = now.Add(50 * time.Second)
+	if b.exhausted() {
+		t.Error("exhausted before the deadline")
+	}
+	if slice := b.startScenario(2); slice != 5*time.Second {
+		t.Errorf("slice = %v, want the remaining 10s split in two", slice)
+	}
+	now = now.Add(10 * time.Second)
+	if !b.exhausted() {
+		t.Error("not exhausted at the deadline")
+	}
+}
+
+// シナリオに割り当てた時間に収まらないスイープのタスク数を、実行せずに記録することを確認する
+func TestRunSweepTruncated(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxDuration = time.Hour
+	cfg.Sweep = SweepConfig{Enabled: true, Tasks: []int{10, 20, 1000000}}
+	rep := &collectReporter{}
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}, reporters: []Reporter{rep}}
+	now := time.Now()
+	r.budget = &runBudget{deadline: now.Add(time.Second), now: time.Now}
+	r.budget.startScenario(1)
+
+	var sizes []int
+	s := Strategy{Name: "slow", Run: func(env Env) error {
+		n := 0
+		for {
+			if _, ok := env.Source.Next(); !ok {
+				break
+			}
+			n++
+		}
+		sizes = append(sizes, n)
+		time.Sleep(time.Duration(n) * time.Millisecond)
+		return nil
+	}}
+	if err := r.runSweep([]Strategy{s}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sizes) != 2 {
+		t.Errorf("ran with %v tasks, want the last size skipped", sizes)
+	}
+	if len(rep.results) != 3 {
+		t.Fatalf("got %d results, want 3", len(rep.results))
+	}
+	last := rep.results[2]
+	if !last.flag(metricSkipped) || last.Params["tasks"] != "1000000" {
+		t.Errorf("last result = %+v, want skipped 1000000 tasks", last)
+	}
+}
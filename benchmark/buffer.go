@@ -0,0 +1,109 @@
+package benchmark
+
+import (
+	"fmt"
+	"time"
+)
+
+// 既定のタスクのチャネルのバッファ
+const defaultTaskBuffer = 100
+
+// チャネルのバッファのシナリオの戦略一覧。
+// 同じディスパッチの構成で、タスクのチャネルのバッファを既定の小さなもの（100）と
+// タスク数と同じもの（送信側が待たされない）で比べ、送信側の背圧と受信側のディスパッチのコストを切り分ける
+func bufferStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	// バッファの大きさの表示。0はタスク数に合わせる
+	label := func(buffer int) (name, title string) {
+		if buffer == 0 {
+			return "all", "=タスク数"
+		}
+		return fmt.Sprint(buffer), fmt.Sprint(buffer)
+	}
+	from := func(title string) string {
+		return fmt.Sprintf("chan Task（バッファ%s）", title)
+	}
+	dispatcher := func(buffer int) Strategy {
+		name, title := label(buffer)
+		return Strategy{
+			Name:  "buffer-dispatcher-" + name,
+			Title: fmt.Sprintf("チャネル（バッファ%s）+ 単一ディスパッチャー + 制限付き並列処理（errgroup.Go + semaphore、%d同時実行）", title, numWorkers),
+			Func:  "ChannelWithLimitedParallelism",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleDispatcher, Goroutines: 1, From: from(title)},
+					{Role: RoleWorker, From: "errgroup.Go"},
+				},
+				Limiter:    fmt.Sprintf("semaphore.Weighted(%d)（ディスパッチャーが取得）", numWorkers),
+				Completion: "errgroup.Wait（エラーはログに出力するのみ）",
+			},
+			Run: timeSubmit(func(env Env) error {
+				return ChannelWithLimitedParallelism(env, numWorkers, ChannelOptions{Buffer: taskBuffer(env, buffer), Policy: p.AcquirePolicy})
+			}),
+		}
+	}
+	pool := func(buffer int) Strategy {
+		name, title := label(buffer)
+		return Strategy{
+			Name:  "buffer-pool-" + name,
+			Title: fmt.Sprintf("ワーカープール（%dワーカー）+ 全ワーカーが受信する共有チャネル（バッファ%s）", numWorkers, title),
+			Func:  "SharedChannelPool",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: numWorkers, From: from(title)},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: timeSubmit(func(env Env) error {
+				return SharedChannelPool(env, numWorkers, taskBuffer(env, buffer))
+			}),
+		}
+	}
+	return []Strategy{
+		dispatcher(defaultTaskBuffer),
+		dispatcher(0),
+		pool(defaultTaskBuffer),
+		pool(0),
+	}
+}
+
+// タスクのチャネルのバッファの大きさ。0の場合は供給するタスク数（不明ならnumTasks）に合わせる
+func taskBuffer(env Env, buffer int) int {
+	switch {
+	case buffer > 0:
+		return buffer
+	case env.Tasks > 0:
+		return env.Tasks
+	}
+	return numTasks
+}
+
+// 開始から供給元を読み切るまでの時間を記録するよう戦略をラップする。
+// 送信側は前のタスクをチャネルに送ってから次を読み出すため、読み切った時点で全てのタスクの送信を終えている
+func timeSubmit(run func(Env) error) func(Env) error {
+	return func(env Env) error {
+		env.Source = &submitTimer{src: env.Source, env: env, start: time.Now()}
+		return run(env)
+	}
+}
+
+// 供給元を読み切った時点で、開始からの時間を記録する供給元
+type submitTimer struct {
+	src   Source
+	env   Env
+	start time.Time
+	done  bool
+}
+
+func (s *submitTimer) Next() (Task, bool) {
+	task, ok := s.src.Next()
+	if !ok && !s.done {
+		s.done = true
+		s.env.submitted(time.Since(s.start))
+	}
+	return task, ok
+}
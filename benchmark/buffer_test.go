@@ -0,0 +1,181 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+// バッファの大きさに関わらず全てのタスクを処理し、供給元を読み切るまでの時間を記録することを確認する
+func TestBufferStrategies(t *testing.T) {
+	const n = 2000
+
+	for _, s := range bufferStrategies(Params{Workers: 100}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var completed atomic.Int64
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			env.Process = func(task Task) error {
+				defer completed.Add(1)
+				return processTask(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := completed.Load(); got != n {
+				t.Errorf("returned after %d tasks completed, want %d", got, n)
+			}
+			if env.Stats.Submit.Load() <= 0 {
+				t.Error("submit time was not recorded")
+			}
+		})
+	}
+}
+
+func TestTaskBuffer(t *testing.T) {
+	cases := []struct {
+		tasks, buffer, want int
+	}{
+		{tasks: 2000, buffer: 100, want: 100},
+		{tasks: 2000, buffer: 0, want: 2000},
+		{tasks: 0, buffer: 0, want: numTasks},
+	}
+	for _, c := range cases {
+		if got := taskBuffer(Env{Tasks: c.tasks}, c.buffer); got != c.want {
+			t.Errorf("taskBuffer(tasks=%d, buffer=%d) = %d, want %d", c.tasks, c.buffer, got, c.want)
+		}
+	}
+}
+
+// -workersと-bufferの指定を戦略のパラメータに反映し、未指定の場合は既定値を使うことを確認する
+func TestConfigParams(t *testing.T) {
+	cfg := DefaultConfig()
+	if p := cfg.params(); p.Workers != runtime.NumCPU() || p.Buffer != defaultTaskBuffer {
+		t.Errorf("default params: workers=%d buffer=%d", p.Workers, p.Buffer)
+	}
+	cfg.Workers, cfg.Buffer = 7, 3
+	if p := cfg.params(); p.Workers != 7 || p.Buffer != 3 {
+		t.Errorf("params: workers=%d buffer=%d, want 7 and 3", p.Workers, p.Buffer)
+	}
+	cfg.Dispatchers = 4
+	if p := cfg.params(); p.Dispatchers != 4 {
+		t.Errorf("params: dispatchers=%d, want 4", p.Dispatchers)
+	}
+	cfg.Buffer = -1
+	if err := cfg.validate(); err == nil {
+		t.Error("negative buffer: want error")
+	}
+}
+
+// 複数のディスパッチャーで全てのタスクを処理し、制限付きの戦略では同時実行数がsemaphoreの上限を超えないことを確認する
+func TestDispatchers(t *testing.T) {
+	const n = 2000
+	const dispatchers = 4
+	const limit = 8
+
+	strategies := map[string]func(Env) error{
+		"unlimited": func(env Env) error {
+			return ChannelWithUnlimitedParallelism(env, ChannelOptions{Dispatchers: dispatchers})
+		},
+		"limited": func(env Env) error {
+			return ChannelWithLimitedParallelism(env, limit, ChannelOptions{Dispatchers: dispatchers})
+		},
+	}
+	for name, run := range strategies {
+		t.Run(name, func(t *testing.T) {
+			var completed, inFlight, peak atomic.Int64
+			env := BatchEnv(n)
+			env.Process = func(task Task) error {
+				defer completed.Add(1)
+				cur := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					p := peak.Load()
+					if cur <= p || peak.CompareAndSwap(p, cur) {
+						break
+					}
+				}
+				return processTask(task)
+			}
+			if err := run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := completed.Load(); got != n {
+				t.Errorf("returned after %d tasks completed, want %d", got, n)
+			}
+			if name == "limited" && peak.Load() > limit {
+				t.Errorf("peak concurrency %d exceeds limit %d", peak.Load(), limit)
+			}
+		})
+	}
+
+	// 中止する方針では、全てのディスパッチャーが残りのタスクを破棄し、最初のエラーを返す
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var processed atomic.Int64
+	env := BatchEnv(n)
+	env.Ctx = ctx
+	env.Stats = &RunStats{}
+	env.Process = func(task Task) error {
+		if processed.Add(1) == 20 {
+			cancel()
+		}
+		return processTask(task)
+	}
+	err := ChannelWithLimitedParallelism(env, 2, ChannelOptions{Dispatchers: dispatchers, Policy: AcquireAbort})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if got := processed.Load() + env.Stats.Dropped.Load(); got != n {
+		t.Errorf("processed(%d) + dropped(%d) = %d, want %d", processed.Load(), env.Stats.Dropped.Load(), got, n)
+	}
+}
+
+// チャネルの戦略で、ディスパッチャーがタスクの受け渡しに使った時間を全てのタスクについて記録することを確認する
+func TestDispatchTime(t *testing.T) {
+	const n = 2000
+
+	strategies := map[string]func(Env) error{
+		"unlimited": func(env Env) error { return ChannelWithUnlimitedParallelism(env, ChannelOptions{}) },
+		"limited":   func(env Env) error { return ChannelWithLimitedParallelism(env, 100, ChannelOptions{}) },
+	}
+	for name, run := range strategies {
+		t.Run(name, func(t *testing.T) {
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			env.Stats.Dispatch.enabled = true
+			if err := run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := env.Stats.Dispatch.tasks.Load(); got != n {
+				t.Errorf("dispatched %d tasks, want %d", got, n)
+			}
+			if env.Stats.Dispatch.busy.Load() <= 0 {
+				t.Error("dispatch time was not recorded")
+			}
+
+			env = BatchEnv(n)
+			env.Stats = &RunStats{}
+			if err := run(env); err != nil {
+				t.Fatal(err)
+			}
+			if env.Stats.Dispatch.tasks.Load() != 0 {
+				t.Error("dispatch time was recorded without being enabled")
+			}
+		})
+	}
+}
+
+// チャネルの実装の構成で、ゼロ値の項目だけを既定値に置き換えることを確認する
+func TestChannelOptionsDefaults(t *testing.T) {
+	want := ChannelOptions{Buffer: defaultTaskBuffer, Dispatchers: 1, Policy: AcquireCount}
+	if got := (ChannelOptions{}).withDefaults(); got != want {
+		t.Errorf("defaults = %+v, want %+v", got, want)
+	}
+	opts := ChannelOptions{Buffer: 1, Dispatchers: 4, Policy: AcquireAbort}
+	if got := opts.withDefaults(); got != opts {
+		t.Errorf("withDefaults(%+v) = %+v", opts, got)
+	}
+}
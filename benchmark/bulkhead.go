@@ -0,0 +1,205 @@
+package benchmark
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// バルクヘッドのシナリオで1回の実行で処理するタスク数と、タスクの到着間隔
+	bulkheadTasks = 20000
+	bulkheadPace  = 25 * time.Microsecond
+	// 全体のワーカー数（分ける場合は軽いタスクと重いタスクで半分ずつ）
+	bulkheadWorkers = 64
+	// 重いタスクの処理時間に加える時間
+	bulkheadHeavy = 5 * time.Millisecond
+	// 重いタスクが急増する期間（タスクIDの範囲）。期間中は2つに1つが、それ以外は100個に1つが重い
+	spikeFrom  = 6000
+	spikeUntil = 10000
+)
+
+// バルクヘッドのシナリオで重いタスクか
+func isHeavy(task Task) bool {
+	if task.ID >= spikeFrom && task.ID < spikeUntil {
+		return task.ID%2 == 0
+	}
+	return task.ID%100 == 50
+}
+
+// 予定時刻を一定間隔で決めてタスクを供給する。予定時刻より早くは送り出さず、遅れた場合も予定時刻は動かさない
+type pacedSource struct {
+	src      Source
+	interval time.Duration
+	start    time.Time
+	n        int
+}
+
+func (s *pacedSource) Next() (Task, bool) {
+	if s.start.IsZero() {
+		s.start = time.Now()
+	}
+	at := s.start.Add(time.Duration(s.n) * s.interval)
+	if d := time.Until(at); d > 0 {
+		time.Sleep(d)
+	}
+	task, ok := s.src.Next()
+	if !ok {
+		return Task{}, false
+	}
+	s.n++
+	task.Scheduled = at
+	return task, true
+}
+
+// バルクヘッドのシナリオの戦略一覧。
+// 軽いタスクと重いタスクが混ざって到着し、途中で重いタスクが急増する。
+// 全てのタスクを1つのプールで処理する方法と、軽いタスクと重いタスクを別々のプール（バルクヘッド）で処理する方法で、
+// 軽いタスクの到着から処理開始までの遅れを比べる
+func bulkheadStrategies(p Params) []Strategy {
+	half := bulkheadWorkers / 2
+	split := func(reject bool) Strategy {
+		name, title, send := "bulkhead-split", "送信側は空くまで待つ", "タスクの重さでチャネルを選んで送信"
+		if reject {
+			name, title, send = "bulkhead-reject", "満杯のプールのタスクは破棄", "タスクの重さでチャネルを選んで送信し、満杯なら破棄"
+		}
+		return Strategy{
+			Name:  name,
+			Title: fmt.Sprintf("軽いタスクと重いタスクを別々のワーカープール（%dワーカーずつ）で処理、%s", half, title),
+			Func:  "BulkheadPools",
+			Limit: bulkheadWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					{Role: RoleProducer, Goroutines: 1, Note: send},
+					{Role: RoleWorker, Goroutines: bulkheadWorkers, From: chanFrom("Task", 100), Note: fmt.Sprintf("軽いタスクと重いタスクのプールに%dずつ", half)},
+				},
+				Completion: "sync.WaitGroup（プールごと）",
+			},
+			Run: func(env Env) error {
+				return BulkheadPools(env, half, half, reject)
+			},
+		}
+	}
+	list := []Strategy{
+		{
+			Name:  "bulkhead-shared",
+			Title: fmt.Sprintf("全てのタスクを1つのワーカープール（%dワーカー）で処理", bulkheadWorkers),
+			Func:  "BulkheadPools",
+			Limit: bulkheadWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: bulkheadWorkers, From: chanFrom("Task", 100)},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return SharedPool(env, bulkheadWorkers)
+			},
+		},
+		split(false),
+		split(true),
+	}
+	for i, s := range list {
+		run := s.Run
+		list[i].Pace = bulkheadPace
+		list[i].Tasks = bulkheadTasks
+		stages := append([]Stage(nil), s.Topology.Stages...)
+		note := fmt.Sprintf("%v間隔で到着、ID %d〜%dは重いタスクが急増", bulkheadPace, spikeFrom, spikeUntil-1)
+		if stages[0].Note != "" {
+			note += "。" + stages[0].Note
+		}
+		stages[0].Note = note
+		list[i].Topology.Stages = stages
+		list[i].Run = func(env Env) error {
+			env.Source = &pacedSource{src: env.Source, interval: bulkheadPace}
+			return run(withHeavyTasks(env))
+		}
+	}
+	return list
+}
+
+// 重いタスクの処理時間を延ばし、軽いタスクの到着から処理開始までの遅れを記録するよう処理関数をラップする
+func withHeavyTasks(env Env) Env {
+	process := env.Process
+	env.Process = func(task Task) error {
+		if isHeavy(task) {
+			time.Sleep(bulkheadHeavy)
+		} else {
+			env.jitter(time.Since(task.Scheduled))
+		}
+		return process(task)
+	}
+	return env
+}
+
+// チャネルから受信したタスクを処理するワーカーを起動し、最初のエラーを記録する
+func runPool(env Env, tasks <-chan Task, numWorkers int, wg *sync.WaitGroup, record func(error)) {
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				if err := env.Process(task); err != nil {
+					record(err)
+				}
+			}
+		}()
+	}
+}
+
+// 全てのタスクを1つのワーカープールで処理する
+func SharedPool(env Env, numWorkers int) error {
+	return BulkheadPools(env, numWorkers, 0, false)
+}
+
+// 軽いタスクと重いタスクを別々のワーカープールで処理する。重いタスクのワーカー数が0の場合は、
+// 全てのタスクを軽いタスクのプールで処理する。
+// 送信側は1つのため、rejectがfalseの場合は満杯のプールへの送信を待つ間、もう一方のプールのタスクも送り出せない。
+// rejectがtrueの場合は満杯のプールのタスクを破棄し、もう一方のプールへの供給を止めない
+func BulkheadPools(env Env, lightWorkers, heavyWorkers int, reject bool) error {
+	var mu sync.Mutex
+	var firstErr error
+	record := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	light := make(chan Task, 100)
+	runPool(env, light, lightWorkers, &wg, record)
+	heavy := light
+	if heavyWorkers > 0 {
+		heavy = make(chan Task, 100)
+		runPool(env, heavy, heavyWorkers, &wg, record)
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		pool := light
+		if isHeavy(task) {
+			pool = heavy
+		}
+		if !reject {
+			pool <- task
+			continue
+		}
+		select {
+		case pool <- task:
+		default:
+			env.drop(1)
+		}
+	}
+	close(light)
+	if heavyWorkers > 0 {
+		close(heavy)
+	}
+	wg.Wait()
+	return firstErr
+}
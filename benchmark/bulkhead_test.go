@@ -0,0 +1,86 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// プールを分けるかどうかに関わらず全てのタスクを処理し、エラーが返されることを確認する
+func TestBulkheadPools(t *testing.T) {
+	const n = 2000
+
+	for _, heavyWorkers := range []int{0, 50} {
+		var completed atomic.Int64
+		env := BatchEnv(n)
+		env.Process = func(task Task) error {
+			defer completed.Add(1)
+			return processTask(task)
+		}
+		if err := BulkheadPools(env, 50, heavyWorkers, false); err != nil {
+			t.Fatal(err)
+		}
+		if got := completed.Load(); got != n {
+			t.Errorf("heavyWorkers=%d: returned after %d tasks completed, want %d", heavyWorkers, got, n)
+		}
+
+		var processed atomic.Int64
+		env = BatchEnv(n)
+		env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+		if err := BulkheadPools(env, 50, heavyWorkers, false); !errors.Is(err, errInjectedFailure) {
+			t.Errorf("heavyWorkers=%d: got %v, want injected failure", heavyWorkers, err)
+		}
+	}
+}
+
+// 満杯のプールのタスクを破棄し、処理したタスクと破棄したタスクが全体と一致することを確認する
+func TestBulkheadPoolsReject(t *testing.T) {
+	const n = 2000
+
+	var completed atomic.Int64
+	env := BatchEnv(n)
+	env.Stats = &RunStats{}
+	env.Process = func(task Task) error {
+		defer completed.Add(1)
+		if isHeavy(task) {
+			time.Sleep(time.Millisecond)
+		}
+		return processTask(task)
+	}
+	if err := BulkheadPools(env, 10, 1, true); err != nil {
+		t.Fatal(err)
+	}
+	dropped := env.Stats.Dropped.Load()
+	if dropped == 0 {
+		t.Error("no tasks were rejected")
+	}
+	if got := completed.Load() + dropped; got != n {
+		t.Errorf("completed %d + dropped %d = %d, want %d", completed.Load(), dropped, got, n)
+	}
+}
+
+// 予定時刻を一定間隔で決め、予定時刻より早く送り出さないことを確認する
+func TestPacedSource(t *testing.T) {
+	const interval = time.Millisecond
+	src := &pacedSource{src: BatchEnv(5).Source, interval: interval}
+	var first time.Time
+	for i := 0; i < 5; i++ {
+		task, ok := src.Next()
+		if !ok {
+			t.Fatalf("source ended after %d tasks", i)
+		}
+		if i == 0 {
+			first = task.Scheduled
+		}
+		if got := task.Scheduled.Sub(first); got != time.Duration(i)*interval {
+			t.Errorf("task %d scheduled %v after the first, want %v", i, got, time.Duration(i)*interval)
+		}
+		if time.Now().Before(task.Scheduled) {
+			t.Errorf("task %d delivered before its scheduled time", i)
+		}
+	}
+	if _, ok := src.Next(); ok {
+		t.Error("source did not end")
+	}
+}
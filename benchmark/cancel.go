@@ -0,0 +1,237 @@
+package benchmark
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// 途中キャンセルのシナリオのタスク数
+	cancelTasks = 2000
+	// 1タスクの作業の単位数と、1単位の計算の反復回数（1単位は数マイクロ秒）
+	cancelTaskUnits      = 100
+	cancelUnitIterations = 2000
+)
+
+// 途中キャンセルのシナリオで比べる、ctxを確認する間隔（作業の単位数）のデフォルト
+var defaultCheckpointUnits = []int{1, 10, 100}
+
+// 途中キャンセルのシナリオの戦略一覧。
+// dispatchシナリオの各戦略で、数マイクロ秒の計算を100単位続ける長いタスクを処理し、全体の半分の作業を
+// 実行した時点（あるタスクの作業の途中）でキャンセルする。タスクは指定した単位数ごとにctxを確認し、キャンセルに気付いたら残りの作業をやめる。
+// キャンセルの後に実行してしまった単位数（無駄な作業）を、ctxを確認する間隔ごとに比べる。
+// キャンセルはタスクの作業にだけ伝え、戦略のディスパッチには伝えない（供給元はキャンセル後にタスクを渡さない）。
+// 途中のタスクまで処理が進まないため、厳格モードのエラーの伝播の確認は行わない
+func cancelStrategies(p Params) []Strategy {
+	units := p.CheckpointUnits
+	if len(units) == 0 {
+		units = defaultCheckpointUnits
+	}
+	var list []Strategy
+	for _, every := range units {
+		for _, s := range strategies(p) {
+			run := s.Run
+			s.Name = fmt.Sprintf("cancel-%d-%s", every, s.Name)
+			s.Title += fmt.Sprintf(" + %d単位ごとにctxを確認するタスク（%d単位）", every, cancelTaskUnits)
+			s.Tasks = cancelTasks
+			s.Params = map[string]string{"checkpoint_units": itoa(every)}
+			s.SkipErrorPropagation = true
+			s.Run = func(env Env) error {
+				env, c := withCancellation(env, every)
+				err := run(env)
+				c.stopped()
+				return err
+			}
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// 途中でキャンセルする1回の実行の状態
+type cancellation struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	every  int
+	stats  *cancelStats
+	// 実行した作業の単位数と、キャンセルする単位数
+	done      atomic.Int64
+	threshold int64
+	// 供給元のタスク数（0は不明）と、供給元から渡したタスク数
+	tasks    int
+	supplied atomic.Int64
+	// キャンセルした時刻（UnixNano、0は未キャンセル）。無駄な作業を数えるための計測用で、タスクの作業は参照しない
+	at atomic.Int64
+}
+
+// 途中キャンセルの記録
+type cancelStats struct {
+	// キャンセルの後に実行した作業の単位数
+	WastedUnits atomic.Int64
+	// 作業の途中でキャンセルに気付いてやめたタスクの数
+	Abandoned atomic.Int64
+	// キャンセルしたため供給元から渡さなかったタスクの数（供給元のタスク数が分かる場合のみ記録）
+	Unsupplied atomic.Int64
+	// キャンセルしてから戦略が戻るまでの時間（ナノ秒）
+	Stop atomic.Int64
+}
+
+// 全ての回の途中キャンセルの記録の合計
+type cancelTotals struct {
+	wasted, abandoned, unsupplied, stop int64
+	cancelled                           int
+}
+
+func (t *cancelTotals) add(s *cancelStats) {
+	if d := s.Stop.Load(); d > 0 {
+		t.wasted += s.WastedUnits.Load()
+		t.abandoned += s.Abandoned.Load()
+		t.unsupplied += s.Unsupplied.Load()
+		t.stop += d
+		t.cancelled++
+	}
+}
+
+// 途中でキャンセルした戦略の場合のみ、1回あたりの値をメトリクスに記録する。
+// 作業を最後まで行ったタスクは、供給元のタスクから渡さなかったものと作業をやめたものを除いた数
+func (t cancelTotals) setMetrics(res *Result) {
+	if t.cancelled == 0 {
+		return
+	}
+	n := float64(t.cancelled)
+	res.Metrics[metricCancelWasted] = float64(t.wasted) / n
+	res.Metrics[metricCancelAbandoned] = float64(t.abandoned) / n
+	res.Metrics[metricCancelStop] = float64(t.stop) / n
+	res.Metrics[metricProcessedTasks] = res.Metrics[metricTasks] - float64(t.unsupplied+t.abandoned)/n
+}
+
+// 全体の半分の作業を実行した時点でキャンセルし、処理関数をevery単位ごとにctxを確認する長いタスクに置き換える。
+// キャンセルする単位数はタスクの作業の中ほどに合わせ、キャンセルしたタスク自身も次の確認までは作業を続けるようにする
+func withCancellation(env Env, every int) (Env, *cancellation) {
+	ctx, cancel := context.WithCancel(env.context())
+	c := &cancellation{ctx: ctx, cancel: cancel, every: every, stats: &cancelStats{}, tasks: env.Tasks}
+	if env.Stats != nil {
+		c.stats = &env.Stats.Cancel
+	}
+	n := env.Tasks
+	if n <= 0 {
+		n = cancelTasks
+	}
+	c.threshold = int64(n/2*cancelTaskUnits + cancelTaskUnits/2)
+	env.Source = &midRunCancelSource{src: env.Source, c: c}
+	process := env.Process
+	env.Process = func(task Task) error {
+		if err := c.work(task.ID); err != nil {
+			// 戦略にはキャンセルを伝えないため、作業をやめたタスクは中断として数えるだけにする
+			return nil
+		}
+		return process(task)
+	}
+	return env, c
+}
+
+// キャンセルする
+func (c *cancellation) fire() {
+	c.at.Store(time.Now().UnixNano())
+	c.cancel()
+}
+
+// 戦略が戻った時刻から、キャンセルしてから停止するまでの時間と、渡さなかったタスクの数を記録する
+func (c *cancellation) stopped() {
+	if at := c.at.Load(); at != 0 {
+		c.stats.Stop.Store(time.Now().UnixNano() - at)
+		if c.tasks > 0 {
+			c.stats.Unsupplied.Store(int64(c.tasks) - c.supplied.Load())
+		}
+	}
+	c.cancel()
+}
+
+// 長いタスクの作業。every単位ごと（最初の単位の前を含む）にctxを確認し、キャンセルされていれば残りの作業をやめる
+func (c *cancellation) work(id int) error {
+	x := uint64(id) | 1
+	for u := 0; u < cancelTaskUnits; u++ {
+		if u%c.every == 0 {
+			if err := c.ctx.Err(); err != nil {
+				c.stats.Abandoned.Add(1)
+				return err
+			}
+		}
+		for i := 0; i < cancelUnitIterations; i++ {
+			x ^= x << 13
+			x ^= x >> 7
+			x ^= x << 17
+		}
+		if c.at.Load() != 0 {
+			c.stats.WastedUnits.Add(1)
+		} else if c.done.Add(1) == c.threshold {
+			c.fire()
+		}
+	}
+	cpuSink.Add(x)
+	return nil
+}
+
+// キャンセルした後はタスクを渡さない供給元
+type midRunCancelSource struct {
+	src Source
+	c   *cancellation
+}
+
+func (s *midRunCancelSource) Next() (Task, bool) {
+	if s.c.at.Load() != 0 {
+		return Task{}, false
+	}
+	task, ok := s.src.Next()
+	if ok {
+		s.c.supplied.Add(1)
+	}
+	return task, ok
+}
+
+// ctxを確認する間隔ごとの無駄な作業とキャンセルから停止までの時間を実装ごとにwに並べる
+func printCheckpointGranularity(w io.Writer, results []Result) {
+	type entry struct {
+		every        int
+		wasted, stop float64
+	}
+	var order []string
+	groups := map[string][]entry{}
+	for _, r := range results {
+		u, ok := r.Params["checkpoint_units"]
+		if !ok {
+			continue
+		}
+		if _, ok := r.Metrics[metricCancelWasted]; !ok {
+			continue
+		}
+		every, _ := strconv.Atoi(u)
+		impl := strings.TrimPrefix(r.Strategy, "cancel-"+u+"-")
+		if _, ok := groups[impl]; !ok {
+			order = append(order, impl)
+		}
+		groups[impl] = append(groups[impl], entry{every, r.Metrics[metricCancelWasted], r.Metrics[metricCancelStop]})
+	}
+	if len(order) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "ctxを確認する間隔（単位数）ごとのキャンセル後の無駄な作業（単位数）/ キャンセルから停止までの時間")
+	for _, impl := range order {
+		entries := groups[impl]
+		slices.SortFunc(entries, func(a, b entry) int { return cmp.Compare(a.every, b.every) })
+		var cols []string
+		for _, e := range entries {
+			cols = append(cols, fmt.Sprintf("%d単位ごと %.0f / %v", e.every, e.wasted, time.Duration(e.stop).Round(time.Microsecond)))
+		}
+		fmt.Fprintf(w, "  %-16s %s\n", impl, strings.Join(cols, "  "))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,127 @@
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// ctxを確認する間隔ごとに戦略を作り、途中キャンセルの記録をメトリクスに加えることを確認する
+func TestRunCancel(t *testing.T) {
+	p := defaultParams()
+	p.CheckpointUnits = []int{1, cancelTaskUnits}
+	list := map[string]Strategy{}
+	for _, s := range cancelStrategies(p) {
+		list[s.Name] = s
+	}
+	if n := len(list); n != 2*len(strategies(p)) {
+		t.Fatalf("got %d strategies, want %d", n, 2*len(strategies(p)))
+	}
+
+	cfg := DefaultConfig()
+	cfg.Repetitions = 1
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}}
+	run := func(name string) Result {
+		s, ok := list[name]
+		if !ok {
+			t.Fatalf("strategy %s not found", name)
+		}
+		res, err := r.runRepeated(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := res.Metrics[metricCancelWasted]; !ok {
+			t.Fatalf("%s: no wasted work metric: %v", name, res.Metrics)
+		}
+		if res.Metrics[metricCancelStop] <= 0 {
+			t.Errorf("%s: stop = %v, want positive", name, res.Metrics[metricCancelStop])
+		}
+		return res
+	}
+
+	// 単位ごとに確認する場合、キャンセルの時点で実行中のタスクはそれぞれ最大1単位しか続けない
+	fine := run("cancel-1-direct-limited")
+	if w, limit := fine.Metrics[metricCancelWasted], float64(list["cancel-1-direct-limited"].Limit); w > limit {
+		t.Errorf("wasted = %v units with per-unit checkpoints, want at most %v", w, limit)
+	}
+	if fine.Params["checkpoint_units"] != "1" {
+		t.Errorf("params = %v", fine.Params)
+	}
+	// 1タスクあたりの処理時間は、キャンセルまでに処理したタスクで計算する
+	processed := fine.Metrics[metricProcessedTasks]
+	if processed <= 0 || processed >= cancelTasks {
+		t.Errorf("processed = %v, want between 0 and %d", processed, cancelTasks)
+	}
+	fine.normalize()
+	if got, want := fine.Metrics[metricNsPerTask], fine.Metrics[metricWall]/processed; got != want {
+		t.Errorf("ns_per_task = %v, want %v", got, want)
+	}
+	run("cancel-100-direct-limited")
+
+	// 厳格モードでは、キャンセルで渡さなかったタスクと作業をやめたタスクを違反として数えない
+	r.cfg.Strict = true
+	for _, name := range []string{"cancel-1-direct-unlimited", "cancel-100-chan-limited"} {
+		if res := run(name); res.Metrics[metricStrictViolations] != 0 {
+			t.Errorf("%s: strict violations %q", name, res.Notes)
+		}
+	}
+}
+
+// 順に処理すると、キャンセルしたタスクは次にctxを確認するまで作業を続け、その後に始めたタスクは
+// 最初の単位の前に作業をやめることを確認する
+func TestCancellationCheckpoints(t *testing.T) {
+	// 10タスクの場合、6番目のタスクの50単位目でキャンセルする
+	for every, wantWasted := range map[int]int64{1: 0, 10: 0, 30: 10, 100: cancelTaskUnits / 2} {
+		env, c := withCancellation(batchEnv(10, sprintfData), every)
+		var processed int
+		for {
+			task, ok := env.Source.Next()
+			if !ok {
+				break
+			}
+			env.Process(task)
+			processed++
+		}
+		if processed != 6 {
+			t.Fatalf("every %d: source yielded %d tasks, want 6", every, processed)
+		}
+		// 供給元がキャンセルした後に処理するタスク
+		env.Process(Task{ID: 9})
+		c.stopped()
+		if got := c.stats.WastedUnits.Load(); got != wantWasted {
+			t.Errorf("every %d: wasted = %d, want %d", every, got, wantWasted)
+		}
+		wantAbandoned := int64(2)
+		if every == cancelTaskUnits {
+			// 最初の単位の前にしか確認しないため、キャンセルしたタスク自身は最後まで作業を続ける
+			wantAbandoned = 1
+		}
+		if got := c.stats.Abandoned.Load(); got != wantAbandoned {
+			t.Errorf("every %d: abandoned = %d, want %d", every, got, wantAbandoned)
+		}
+		if c.stats.Stop.Load() <= 0 {
+			t.Errorf("every %d: stop time was not recorded", every)
+		}
+		if got := c.stats.Unsupplied.Load(); got != 4 {
+			t.Errorf("every %d: unsupplied = %d, want 4", every, got)
+		}
+	}
+}
+
+// 間隔ごとの無駄な作業を、実装ごとに間隔の昇順で渡されたwに並べることを確認する
+func TestPrintCheckpointGranularity(t *testing.T) {
+	result := func(every string, wasted float64) Result {
+		return Result{
+			Strategy: "cancel-" + every + "-direct-limited",
+			Params:   map[string]string{"checkpoint_units": every},
+			Metrics:  map[string]float64{metricCancelWasted: wasted, metricCancelStop: 2000},
+		}
+	}
+	var buf bytes.Buffer
+	printCheckpointGranularity(&buf, []Result{result("10", 30), result("1", 4), testResult("a", 100)})
+	want := "  direct-limited   1単位ごと 4 / 2µs  10単位ごと 30 / 2µs\n"
+	if out := buf.String(); !strings.Contains(out, want) {
+		t.Errorf("output = %q, want it to contain %q", out, want)
+	}
+}
@@ -0,0 +1,56 @@
+//go:build cgo && cgowork
+
+package benchmark
+
+/*
+#include <time.h>
+
+// 指定したマイクロ秒だけスレッドをブロックする（システムコールを伴うC言語のライブラリ呼び出しを模す）
+static void cgo_sleep(long us) {
+	struct timespec ts = { us / 1000000, (us % 1000000) * 1000 };
+	nanosleep(&ts, NULL);
+}
+*/
+import "C"
+
+import "time"
+
+func init() {
+	RegisterScenario(Scenario{
+		Name:       "cgo",
+		Title:      "cgo呼び出しでブロックするタスク（チャネル + ディスパッチャー vs 直接goroutine起動）",
+		Strategies: cgoStrategies,
+	})
+}
+
+// cgoシナリオの戦略一覧。dispatchシナリオの各戦略で、処理時間の待機をC言語の呼び出しに置き換える。
+// cgoの呼び出し中はOSスレッドを占有するため、同時に待機するタスクの数だけスレッドが必要になり、
+// 並列度を制限しない戦略ほどスレッドの生成とスケジューリングのコストが大きくなる
+func cgoStrategies(p Params) []Strategy {
+	list := strategies(p)
+	for i, s := range list {
+		run := s.Run
+		list[i].Name = "cgo-" + s.Name
+		list[i].Title = s.Title + "（cgo呼び出しで待機）"
+		list[i].Run = func(env Env) error {
+			env.Source = cgoSource{env.Source}
+			return run(env)
+		}
+	}
+	return list
+}
+
+// 供給元のタスクの待機をcgoの呼び出しに置き換える
+type cgoSource struct {
+	Source
+}
+
+func (s cgoSource) Next() (Task, bool) {
+	task, ok := s.Source.Next()
+	task.sleep = cgoSleep
+	return task, ok
+}
+
+func cgoSleep(d time.Duration) {
+	C.cgo_sleep(C.long(d / time.Microsecond))
+}
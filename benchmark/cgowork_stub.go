@@ -0,0 +1,8 @@
+//go:build !cgo || !cgowork
+
+package benchmark
+
+// cgoシナリオはCコンパイラを必要とするため、-tags cgoworkを付けてビルドした場合のみ有効にする
+func init() {
+	registerUnavailableScenario("cgo", "cgowork")
+}
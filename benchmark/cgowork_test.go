@@ -0,0 +1,47 @@
+//go:build cgo && cgowork
+
+package benchmark
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+// 全ての戦略がcgo呼び出しで待機しながら全てのタスクを処理することを確認する
+func TestCgoStrategies(t *testing.T) {
+	const n = 1000
+
+	p := defaultParams()
+	p.Workers = 100
+	for _, s := range cgoStrategies(p) {
+		t.Run(s.Name, func(t *testing.T) {
+			var processed atomic.Int64
+			env := BatchEnv(n)
+			env.Process = func(task Task) error {
+				if task.sleep == nil {
+					t.Error("task is not waited by cgo call")
+				}
+				processed.Add(1)
+				return processTask(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := processed.Load(); got != n {
+				t.Errorf("processed %d tasks, want %d", got, n)
+			}
+		})
+	}
+}
+
+func BenchmarkCgo(b *testing.B) {
+	for _, s := range cgoStrategies(defaultParams()) {
+		b.Run(s.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.Run(BatchEnv(numTasks)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package benchmark
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CPUクォータの周期（マイクロ秒）。Kubernetesのデフォルトと同じ100ms
+const cgroupCPUPeriod = 100000
+
+// CPUクォータを設定したcgroup。子プロセスを追加してクォータの下で実行する
+type cpuCgroup struct {
+	dir string
+	v2  bool
+}
+
+// 自プロセスのcgroupの下にCPUクォータを設定したcgroupを作成する。
+// cgroup v1（cpu.cfs_quota_us）とv2（cpu.max）のどちらにも対応し、作成にはcgroupへの書き込み権限が必要
+func newCPUCgroup(quota float64) (*cpuCgroup, error) {
+	base, v2, err := selfCPUCgroupDir()
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("go-speed-chan-vs-goroutine-%d-%s", os.Getpid(), strconv.FormatFloat(quota, 'f', -1, 64))
+	c := &cpuCgroup{dir: filepath.Join(base, name), v2: v2}
+	if v2 {
+		// 子のcgroupでcpuコントローラーを使えるようにする（既に有効な場合や委譲されていない場合は失敗してよい）
+		os.WriteFile(filepath.Join(base, "cgroup.subtree_control"), []byte("+cpu"), 0)
+	}
+	if err := os.Mkdir(c.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cgroup: %w", err)
+	}
+
+	limit := int(quota * cgroupCPUPeriod)
+	if v2 {
+		err = c.write("cpu.max", fmt.Sprintf("%d %d", limit, cgroupCPUPeriod))
+	} else {
+		err = errors.Join(
+			c.write("cpu.cfs_period_us", strconv.Itoa(cgroupCPUPeriod)),
+			c.write("cpu.cfs_quota_us", strconv.Itoa(limit)),
+		)
+	}
+	if err != nil {
+		os.Remove(c.dir)
+		return nil, fmt.Errorf("set CPU quota: %w", err)
+	}
+	return c, nil
+}
+
+func (c *cpuCgroup) write(file, value string) error {
+	return os.WriteFile(filepath.Join(c.dir, file), []byte(value), 0)
+}
+
+// プロセスをcgroupに移す
+func (c *cpuCgroup) add(pid int) error {
+	if err := c.write("cgroup.procs", strconv.Itoa(pid)); err != nil {
+		return fmt.Errorf("move process to cgroup: %w", err)
+	}
+	return nil
+}
+
+// cgroupを削除する。中のプロセスが全て終了している必要がある
+func (c *cpuCgroup) remove() error {
+	return os.Remove(c.dir)
+}
+
+// 自プロセスのcgroupで、CPUクォータによって停止させられた周期の数と時間を読み取る
+func readCPUThrottling() (cpuThrottling, bool) {
+	dir, v2, err := selfCPUCgroupDir()
+	if err != nil {
+		return cpuThrottling{}, false
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return cpuThrottling{}, false
+	}
+	return parseCPUStat(string(b), v2), true
+}
+
+// cpu.statから停止させられた周期の数と時間を取り出す（v1はナノ秒、v2はマイクロ秒で記録される）
+func parseCPUStat(stat string, v2 bool) cpuThrottling {
+	var t cpuThrottling
+	sc := bufio.NewScanner(strings.NewReader(stat))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(sc.Text(), " ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch {
+		case key == "nr_throttled":
+			t.Periods = n
+		case key == "throttled_time" && !v2:
+			t.Nanos = n
+		case key == "throttled_usec" && v2:
+			t.Nanos = n * 1000
+		}
+	}
+	return t
+}
+
+// 自プロセスが属するcpuコントローラーのcgroupのディレクトリを求める
+func selfCPUCgroupDir() (dir string, v2 bool, err error) {
+	mountinfo, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return "", false, err
+	}
+	cgroups, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", false, err
+	}
+	return parseCPUCgroupDir(string(mountinfo), string(cgroups))
+}
+
+// /proc/self/mountinfoと/proc/self/cgroupから、cpuコントローラーのcgroupのディレクトリを求める。
+// cgroup v1のcpuコントローラーのマウントがあればv1を、なければv2（統合階層）を使う
+func parseCPUCgroupDir(mountinfo, cgroups string) (string, bool, error) {
+	// /proc/self/cgroupの各行は「階層ID:コントローラー:パス」
+	var v1Path, v2Path string
+	hasV1, hasV2 := false, false
+	for _, line := range strings.Split(strings.TrimSpace(cgroups), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			v2Path, hasV2 = parts[2], true
+			continue
+		}
+		for _, ctrl := range strings.Split(parts[1], ",") {
+			if ctrl == "cpu" {
+				v1Path, hasV1 = parts[2], true
+			}
+		}
+	}
+
+	// mountinfoの各行は「ID 親ID デバイス ルート マウントポイント オプション ... - 種類 ソース スーパーブロックのオプション」
+	var v2Mount, v2Root string
+	for _, line := range strings.Split(mountinfo, "\n") {
+		pre, post, ok := strings.Cut(line, " - ")
+		if !ok {
+			continue
+		}
+		fields, sfields := strings.Fields(pre), strings.Fields(post)
+		if len(fields) < 5 || len(sfields) < 3 {
+			continue
+		}
+		root, mount := fields[3], fields[4]
+		switch sfields[0] {
+		case "cgroup":
+			if !hasV1 {
+				continue
+			}
+			for _, opt := range strings.Split(sfields[2], ",") {
+				if opt == "cpu" {
+					return cgroupPath(mount, root, v1Path), false, nil
+				}
+			}
+		case "cgroup2":
+			v2Mount, v2Root = mount, root
+		}
+	}
+	if hasV2 && v2Mount != "" {
+		return cgroupPath(v2Mount, v2Root, v2Path), true, nil
+	}
+	return "", false, errors.New("cgroup with the cpu controller is not mounted")
+}
+
+// マウントポイントからcgroupのディレクトリを求める。
+// コンテナ内ではマウントのルートがcgroupのパスの途中になるため、その部分を取り除く
+func cgroupPath(mount, root, path string) string {
+	if root != "/" {
+		path = strings.TrimPrefix(path, root)
+	}
+	return filepath.Join(mount, path)
+}
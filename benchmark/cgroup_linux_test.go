@@ -0,0 +1,64 @@
+package benchmark
+
+import "testing"
+
+func TestParseCPUCgroupDir(t *testing.T) {
+	cases := []struct {
+		name       string
+		mountinfo  string
+		cgroups    string
+		wantDir    string
+		wantV2     bool
+		wantErrors bool
+	}{
+		{
+			name: "v1 with cpu,cpuacct",
+			mountinfo: "30 25 0:26 / /sys/fs/cgroup/cpu,cpuacct rw,nosuid - cgroup cgroup rw,cpu,cpuacct\n" +
+				"31 25 0:27 / /sys/fs/cgroup/memory rw,nosuid - cgroup cgroup rw,memory\n",
+			cgroups: "4:memory:/user.slice\n3:cpu,cpuacct:/user.slice/session-1.scope\n0::/user.slice\n",
+			wantDir: "/sys/fs/cgroup/cpu,cpuacct/user.slice/session-1.scope",
+		},
+		{
+			name:      "v2",
+			mountinfo: "28 22 0:25 / /sys/fs/cgroup rw,nosuid - cgroup2 cgroup2 rw,nsdelegate\n",
+			cgroups:   "0::/system.slice/bench.service\n",
+			wantDir:   "/sys/fs/cgroup/system.slice/bench.service",
+			wantV2:    true,
+		},
+		{
+			// コンテナ内ではマウントのルートが自身のcgroupになる
+			name:      "v2 in container",
+			mountinfo: "700 690 0:25 /kubepods/pod1/ctr /sys/fs/cgroup ro,nosuid - cgroup2 cgroup2 rw\n",
+			cgroups:   "0::/kubepods/pod1/ctr\n",
+			wantDir:   "/sys/fs/cgroup",
+			wantV2:    true,
+		},
+		{
+			name:       "no cgroup",
+			mountinfo:  "22 1 8:1 / / rw - ext4 /dev/sda1 rw\n",
+			cgroups:    "",
+			wantErrors: true,
+		},
+	}
+	for _, c := range cases {
+		dir, v2, err := parseCPUCgroupDir(c.mountinfo, c.cgroups)
+		if (err != nil) != c.wantErrors {
+			t.Errorf("%s: err = %v", c.name, err)
+			continue
+		}
+		if dir != c.wantDir || v2 != c.wantV2 {
+			t.Errorf("%s: got %q (v2 %v), want %q (v2 %v)", c.name, dir, v2, c.wantDir, c.wantV2)
+		}
+	}
+}
+
+func TestParseCPUStat(t *testing.T) {
+	v1 := parseCPUStat("nr_periods 120\nnr_throttled 30\nthrottled_time 4500000\n", false)
+	if v1 != (cpuThrottling{Periods: 30, Nanos: 4500000}) {
+		t.Errorf("v1: got %+v", v1)
+	}
+	v2 := parseCPUStat("usage_usec 100\nnr_periods 120\nnr_throttled 30\nthrottled_usec 4500\n", true)
+	if v2 != (cpuThrottling{Periods: 30, Nanos: 4500000}) {
+		t.Errorf("v2: got %+v", v2)
+	}
+}
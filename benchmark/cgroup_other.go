@@ -0,0 +1,20 @@
+//go:build !linux
+
+package benchmark
+
+import "errors"
+
+// CPUクォータはLinuxのcgroupでのみ設定できる
+type cpuCgroup struct{}
+
+func newCPUCgroup(quota float64) (*cpuCgroup, error) {
+	return nil, errors.New("CPU quotas require Linux cgroups")
+}
+
+func (c *cpuCgroup) add(pid int) error { return nil }
+
+func (c *cpuCgroup) remove() error { return nil }
+
+func readCPUThrottling() (cpuThrottling, bool) {
+	return cpuThrottling{}, false
+}
@@ -0,0 +1,114 @@
+package benchmark
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// 実行中にスケジューラーを乱す外乱の設定。
+// バックグラウンドの負荷やGCが割り込んだときに、各設計のレイテンシがどれだけ崩れるかを比べるために使う
+type ChaosConfig struct {
+	// 外乱を起こすか
+	Enabled bool
+	// GCの実行やCPUを占有するgoroutineの起動を起こす平均間隔（間隔は指数分布でばらつかせる）
+	Interval time.Duration
+	// 各タスクの処理の前にruntime.Goschedを呼ぶ確率
+	YieldProbability float64
+	// CPUを占有するgoroutineが回り続ける時間
+	HogDuration time.Duration
+}
+
+func (c ChaosConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("chaos interval must be positive, got %v", c.Interval)
+	}
+	if c.YieldProbability < 0 || c.YieldProbability > 1 {
+		return fmt.Errorf("chaos yield probability must be between 0 and 1, got %v", c.YieldProbability)
+	}
+	if c.HogDuration <= 0 {
+		return fmt.Errorf("chaos hog duration must be positive, got %v", c.HogDuration)
+	}
+	return nil
+}
+
+// 結果のパラメータに記録する設定の説明
+func (c ChaosConfig) label() string {
+	return fmt.Sprintf("interval=%v,yield=%g,hog=%v", c.Interval, c.YieldProbability, c.HogDuration)
+}
+
+// 戦略の実行中に外乱を起こす。各タスクの処理の前に確率的にruntime.Goschedを呼び、
+// 別のgoroutineからランダムな間隔でruntime.GCの実行とCPUを占有するgoroutineの起動を繰り返す。
+// 外乱は戦略の実行時間に含まれ、実行が終わると止まる
+func withChaos(s Strategy, cfg ChaosConfig) Strategy {
+	if !cfg.Enabled {
+		return s
+	}
+	run := s.Run
+	s.Run = func(env Env) error {
+		if cfg.YieldProbability > 0 {
+			process := env.Process
+			env.Process = func(task Task) error {
+				if rand.Float64() < cfg.YieldProbability {
+					runtime.Gosched()
+				}
+				return process(task)
+			}
+		}
+
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			disturb(cfg, done, &wg)
+		}()
+
+		err := run(env)
+		close(done)
+		// 次の実行に外乱が残らないよう、CPUを占有するgoroutineの終了も待つ
+		wg.Wait()
+		return err
+	}
+	return s
+}
+
+// doneが閉じられるまで、ランダムな間隔でGCの実行かCPUを占有するgoroutineの起動を起こす
+func disturb(cfg ChaosConfig, done <-chan struct{}, wg *sync.WaitGroup) {
+	timer := time.NewTimer(chaosWait(cfg.Interval))
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+		}
+		if rand.IntN(2) == 0 {
+			runtime.GC()
+		} else {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				hog(cfg.HogDuration)
+			}()
+		}
+		timer.Reset(chaosWait(cfg.Interval))
+	}
+}
+
+// 次の外乱までの待ち時間（平均がintervalの指数分布）
+func chaosWait(interval time.Duration) time.Duration {
+	return time.Duration(rand.ExpFloat64() * float64(interval))
+}
+
+// 指定した時間、CPUを占有して回り続ける
+func hog(d time.Duration) {
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+	}
+}
@@ -0,0 +1,68 @@
+package benchmark
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 外乱を起こしても全てのタスクが処理され、実行中にGCが起き、
+// 実行の終了とともに外乱のgoroutineが全て止まることを確認する
+func TestWithChaos(t *testing.T) {
+	const n = 50
+	cfg := ChaosConfig{Enabled: true, Interval: time.Millisecond, YieldProbability: 1, HogDuration: time.Millisecond}
+
+	// 外乱が何度も起きるよう、タスクを1つずつ処理して実行を外乱の間隔より十分長くする
+	s := Strategy{Name: "sequential", Run: func(env Env) error {
+		for {
+			task, ok := env.Source.Next()
+			if !ok {
+				return nil
+			}
+			if err := env.Process(task); err != nil {
+				return err
+			}
+		}
+	}}
+	base := runtime.NumGoroutine()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	gcBefore := stats.NumGC
+
+	var processed atomic.Int64
+	env := BatchEnv(n)
+	env.Process = func(task Task) error {
+		processed.Add(1)
+		time.Sleep(time.Millisecond)
+		return nil
+	}
+	if err := withChaos(s, cfg).Run(env); err != nil {
+		t.Fatal(err)
+	}
+	if got := processed.Load(); got != n {
+		t.Errorf("processed %d tasks, want %d", got, n)
+	}
+	runtime.ReadMemStats(&stats)
+	if stats.NumGC == gcBefore {
+		t.Error("no GC was triggered during the run")
+	}
+	waitGoroutines(t, base)
+}
+
+func TestChaosConfigValidate(t *testing.T) {
+	valid := DefaultConfig().Chaos
+	valid.Enabled = true
+	if err := valid.validate(); err != nil {
+		t.Error(err)
+	}
+	for _, c := range []ChaosConfig{
+		{Enabled: true, Interval: 0, HogDuration: time.Millisecond},
+		{Enabled: true, Interval: time.Millisecond, YieldProbability: 1.5, HogDuration: time.Millisecond},
+		{Enabled: true, Interval: time.Millisecond, HogDuration: 0},
+	} {
+		if err := c.validate(); err == nil {
+			t.Errorf("%+v: want error", c)
+		}
+	}
+}
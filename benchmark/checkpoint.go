@@ -0,0 +1,154 @@
+package benchmark
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// 複数シナリオの実行の進捗。シナリオが完了するたびにファイルに保存し、
+// 中断した実行を完了済みのシナリオを飛ばして再開できるようにする
+type checkpoint struct {
+	path string
+	// 結果に影響する設定。異なる設定で作られたチェックポイントからは再開しない
+	Settings checkpointSettings `json:"settings"`
+	// チェックポイントを作ったベンチマークのコードのコミット。異なるコードで作られたチェックポイントからは再開しない
+	Commit string `json:"commit,omitempty"`
+	// 完了済みのシナリオ名
+	Completed []string `json:"completed"`
+	// 完了済みのシナリオの結果
+	Results []Result `json:"results"`
+}
+
+// チェックポイントの互換性の判定に使う設定
+type checkpointSettings struct {
+	SchemaVersion int
+	Scenario      string
+	Repetitions   int
+	Tasks         int
+	Interleave    []string
+	Ramp          RampConfig
+	Sweep         SweepConfig
+	Chaos         ChaosConfig
+	FailAt        int
+	FailKind      ErrorKind
+	AcquirePolicy AcquirePolicy
+	TaskData      string
+	AllocBudget   float64
+	Hooks         []string
+	Workers       int                `json:",omitempty"`
+	Buffer        int                `json:",omitempty"`
+	Dispatchers   int                `json:",omitempty"`
+	Checkpoints   []int              `json:",omitempty"`
+	GCOff         bool               `json:",omitempty"`
+	Strict        bool               `json:",omitempty"`
+	OTelSample    int                `json:",omitempty"`
+	Strategies    []string           `json:",omitempty"`
+	Processing    *ProcessingProfile `json:",omitempty"`
+}
+
+// チェックポイントを読み込む。ファイルがない場合は空のチェックポイントを返す
+func loadCheckpoint(cfg Config) (*checkpoint, error) {
+	cp := &checkpoint{
+		path:     cfg.Checkpoint,
+		Settings: cfg.settings(),
+		Commit:   captureProvenance(cfg).Commit,
+	}
+	if cfg.Checkpoint == "" {
+		return cp, nil
+	}
+
+	b, err := os.ReadFile(cfg.Checkpoint)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved checkpoint
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", cfg.Checkpoint, err)
+	}
+	if !saved.Settings.equal(cp.Settings) {
+		return nil, fmt.Errorf("checkpoint %s was created with different settings (remove it to start over)", cfg.Checkpoint)
+	}
+	if saved.Commit != "" && cp.Commit != "" && saved.Commit != cp.Commit {
+		return nil, fmt.Errorf("checkpoint %s was created by different benchmark code (commit %s, remove it to start over)", cfg.Checkpoint, saved.Commit)
+	}
+	saved.path = cfg.Checkpoint
+	return &saved, nil
+}
+
+// 結果に影響する設定
+func (c Config) settings() checkpointSettings {
+	return checkpointSettings{
+		SchemaVersion: ResultSchemaVersion,
+		Scenario:      c.Scenario,
+		Repetitions:   c.Repetitions,
+		Tasks:         c.Tasks,
+		Interleave:    c.Interleave,
+		Ramp:          c.Ramp,
+		Sweep:         c.Sweep,
+		Chaos:         c.Chaos,
+		FailAt:        c.FailAt,
+		FailKind:      c.FailKind,
+		AcquirePolicy: c.AcquirePolicy,
+		TaskData:      c.TaskData.label(c.TaskDataSize),
+		AllocBudget:   c.AllocBudget,
+		Hooks:         c.Hooks,
+		Workers:       c.Workers,
+		Buffer:        c.Buffer,
+		Dispatchers:   c.Dispatchers,
+		Checkpoints:   c.CheckpointUnits,
+		GCOff:         c.GCOff,
+		Strict:        c.Strict,
+		OTelSample:    c.otelSample(),
+		Strategies:    c.Strategies,
+		Processing:    c.Processing,
+	}
+}
+
+func (s checkpointSettings) equal(other checkpointSettings) bool {
+	a, errA := json.Marshal(s)
+	b, errB := json.Marshal(other)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// シナリオが完了済みかを返す
+func (cp *checkpoint) done(scenario string) bool {
+	return slices.Contains(cp.Completed, scenario)
+}
+
+// シナリオの完了と結果を記録して保存する
+func (cp *checkpoint) complete(scenario string, results []Result) error {
+	cp.Completed = append(cp.Completed, scenario)
+	cp.Results = append(cp.Results, results...)
+	if cp.path == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	// 書き込み中に中断されてもチェックポイントが壊れないよう、一時ファイルを置き換える
+	tmp := cp.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, cp.path)
+}
+
+// 全てのシナリオが完了したらチェックポイントを削除する
+func (cp *checkpoint) remove() error {
+	if cp.path == "" {
+		return nil
+	}
+	if err := os.Remove(cp.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package benchmark
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 保存したチェックポイントから完了済みのシナリオと結果を復元できることを確認する
+func TestCheckpointResume(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Scenario = allScenarios
+	cfg.Checkpoint = filepath.Join(t.TempDir(), "checkpoint.json")
+
+	cp, err := loadCheckpoint(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.complete(defaultScenario, []Result{testResult("a", 100)}); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := loadCheckpoint(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.done(defaultScenario) || resumed.done("phases") {
+		t.Errorf("unexpected completed scenarios: %v", resumed.Completed)
+	}
+	if len(resumed.Results) != 1 || resumed.Results[0].Metrics[metricWall] != 100 {
+		t.Errorf("unexpected results: %+v", resumed.Results)
+	}
+
+	// 設定が異なる場合は再開しない
+	changed := cfg
+	changed.Repetitions = 5
+	if _, err := loadCheckpoint(changed); err == nil {
+		t.Error("expected error for checkpoint with different settings")
+	}
+
+	if err := resumed.remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cfg.Checkpoint); !os.IsNotExist(err) {
+		t.Errorf("checkpoint not removed: %v", err)
+	}
+}
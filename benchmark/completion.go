@@ -0,0 +1,116 @@
+package benchmark
+
+import (
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// 完了待ちのシナリオの戦略一覧。
+// いずれもタスクごとにgoroutineを起動する直接起動の実装で、全goroutineの完了を待つ仕組みだけが異なる
+func completionStrategies(Params) []Strategy {
+	return []Strategy{
+		{
+			Name:  "completion-waitgroup",
+			Title: "直接goroutine起動 + sync.WaitGroupで完了待ち",
+			Func:  "CompletionWaitGroup",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: CompletionWaitGroup,
+		},
+		{
+			Name:  "completion-errgroup",
+			Title: "直接goroutine起動 + errgroupで完了待ち",
+			Func:  "CompletionErrgroup",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "errgroup.Go"},
+				},
+				Completion: "errgroup.Wait",
+			},
+			Run: CompletionErrgroup,
+		},
+		{
+			Name:  "completion-done-chan",
+			Title: "直接goroutine起動 + 完了チャネルで起動数だけ受信して完了待ち",
+			Func:  "CompletionDoneChannel",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文"},
+				},
+				Completion: "完了チャネル（バッファは全タスク分）を起動数だけ受信",
+			},
+			Run: CompletionDoneChannel,
+		},
+	}
+}
+
+// WaitGroupで完了を待ち、最初のエラーをsync.Onceで記録する
+func CompletionWaitGroup(env Env) error {
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := env.Process(task); err != nil {
+				errOnce.Do(func() { firstErr = err })
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// errgroupで完了を待ち、最初のエラーを受け取る。
+// 他の実装と条件を揃えるため、エラー時のキャンセルは使わない
+func CompletionErrgroup(env Env) error {
+	var eg errgroup.Group
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		eg.Go(func() error {
+			return env.Process(task)
+		})
+	}
+	return eg.Wait()
+}
+
+// 各goroutineが完了チャネルに結果を送信し、起動した数だけ受信して完了を待つ。
+// 起動が終わるまで受信しないため、バッファは全タスク分確保する（超えた分は受信されるまで送信で待つ）
+func CompletionDoneChannel(env Env) error {
+	done := make(chan error, numTasks)
+	started := 0
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		started++
+		go func() {
+			done <- env.Process(task)
+		}()
+	}
+
+	var firstErr error
+	for i := 0; i < started; i++ {
+		if err := <-done; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
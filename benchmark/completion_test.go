@@ -0,0 +1,36 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 全てのタスクの完了を待ってから戻り、エラーが返されることを確認する
+func TestCompletionStrategies(t *testing.T) {
+	const n = 2000
+
+	for _, s := range completionStrategies(Params{}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var completed atomic.Int64
+			env := BatchEnv(n)
+			env.Process = func(task Task) error {
+				defer completed.Add(1)
+				return processTask(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := completed.Load(); got != n {
+				t.Errorf("returned after %d tasks completed, want %d", got, n)
+			}
+
+			var processed atomic.Int64
+			env = BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package benchmark
+
+import (
+	"sync"
+)
+
+// 共有マップのワークロードのキー数
+const sharedMapKeys = 1024
+
+// 共有マップのワークロードで、書き込みを行うタスクの間隔（4タスクに1つが書き込む）
+const sharedMapWriteEvery = 4
+
+// シャーディングしたマップのシャード数
+const sharedMapShards = 32
+
+// 複数のgoroutineから読み書きされる共有マップ
+type sharedMap interface {
+	load(key int) (int, bool)
+	store(key, value int)
+	// 所有者のgoroutineなど、マップが使う資源を解放する
+	close()
+}
+
+// 共有マップの実装の種類
+type sharedMapKind struct {
+	name  string
+	title string
+	new   func() sharedMap
+}
+
+var sharedMapKinds = []sharedMapKind{
+	{name: "syncmap", title: "sync.Map", new: func() sharedMap { return &syncMap{} }},
+	{name: "shardmap", title: "ミューテックスで保護した32シャードのマップ", new: newShardedMap},
+	{name: "ownermap", title: "チャネルで要求を受け取る所有者のgoroutineのマップ", new: newOwnedMap},
+}
+
+// 共有マップのシナリオの戦略一覧。dispatchシナリオの各戦略で、処理時間の待機の代わりに
+// 共有マップの読み書き（キー1024個、4タスクに1つが書き込み）を行い、マップの実装とディスパッチの組み合わせを比べる。
+// 「メモリを共有して通信するのではなく、通信してメモリを共有する」の典型的な比較
+func concurrentMapStrategies(p Params) []Strategy {
+	var list []Strategy
+	for _, kind := range sharedMapKinds {
+		for _, s := range strategies(p) {
+			run := s.Run
+			s.Name = kind.name + "-" + s.Name
+			s.Title = s.Title + "（" + kind.title + "の読み書き）"
+			s.Run = func(env Env) error {
+				m := kind.new()
+				defer m.close()
+				env.Source = &instantSource{src: env.Source}
+				env.Process = withSharedMap(env.Process, m)
+				return run(env)
+			}
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// 各タスクの処理の前に共有マップのキーを読み、一部のタスクでは書き込むよう処理関数をラップする
+func withSharedMap(process func(Task) error, m sharedMap) func(Task) error {
+	return func(task Task) error {
+		key := task.ID % sharedMapKeys
+		v, _ := m.load(key)
+		if task.ID%sharedMapWriteEvery == 0 {
+			m.store(key, v+1)
+		}
+		return process(task)
+	}
+}
+
+// sync.Mapによる共有マップ
+type syncMap struct {
+	m sync.Map
+}
+
+func (s *syncMap) load(key int) (int, bool) {
+	v, ok := s.m.Load(key)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+func (s *syncMap) store(key, value int) {
+	s.m.Store(key, value)
+}
+
+func (s *syncMap) close() {}
+
+// キーごとにシャードを分け、シャードごとの読み書きロックで保護した共有マップ
+type shardedMap struct {
+	shards [sharedMapShards]struct {
+		mu sync.RWMutex
+		m  map[int]int
+	}
+}
+
+func newShardedMap() sharedMap {
+	s := &shardedMap{}
+	for i := range s.shards {
+		s.shards[i].m = map[int]int{}
+	}
+	return s
+}
+
+func (s *shardedMap) load(key int) (int, bool) {
+	shard := &s.shards[key%sharedMapShards]
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	v, ok := shard.m[key]
+	return v, ok
+}
+
+func (s *shardedMap) store(key, value int) {
+	shard := &s.shards[key%sharedMapShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.m[key] = value
+}
+
+func (s *shardedMap) close() {}
+
+// 所有者のgoroutineへの読み書きの要求。読み込みの結果はreplyに返す（書き込みは応答を待たない）
+type mapRequest struct {
+	key   int
+	value int
+	write bool
+	reply chan mapReply
+}
+
+type mapReply struct {
+	value int
+	ok    bool
+}
+
+// 1つの所有者のgoroutineだけがマップに触れ、他のgoroutineはチャネルで要求を送る共有マップ
+type ownedMap struct {
+	requests chan mapRequest
+	done     chan struct{}
+	// 読み込みの応答を受け取るチャネルを使い回す
+	replies sync.Pool
+}
+
+func newOwnedMap() sharedMap {
+	o := &ownedMap{
+		requests: make(chan mapRequest, 100),
+		done:     make(chan struct{}),
+		replies:  sync.Pool{New: func() any { return make(chan mapReply, 1) }},
+	}
+	go func() {
+		defer close(o.done)
+		m := map[int]int{}
+		for req := range o.requests {
+			if req.write {
+				m[req.key] = req.value
+				continue
+			}
+			v, ok := m[req.key]
+			req.reply <- mapReply{value: v, ok: ok}
+		}
+	}()
+	return o
+}
+
+func (o *ownedMap) load(key int) (int, bool) {
+	reply := o.replies.Get().(chan mapReply)
+	o.requests <- mapRequest{key: key, reply: reply}
+	r := <-reply
+	o.replies.Put(reply)
+	return r.value, r.ok
+}
+
+func (o *ownedMap) store(key, value int) {
+	o.requests <- mapRequest{key: key, value: value, write: true}
+}
+
+// 要求のチャネルを閉じ、所有者のgoroutineの終了を待つ
+func (o *ownedMap) close() {
+	close(o.requests)
+	<-o.done
+}
@@ -0,0 +1,60 @@
+package benchmark
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// 各実装が並行した読み書きで最後に書いた値を返すことを確認する
+func TestSharedMaps(t *testing.T) {
+	for _, kind := range sharedMapKinds {
+		t.Run(kind.name, func(t *testing.T) {
+			m := kind.new()
+			defer m.close()
+			if _, ok := m.load(1); ok {
+				t.Fatal("empty map returned a value")
+			}
+
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for key := g * 100; key < (g+1)*100; key++ {
+						m.store(key, key*2)
+						if v, ok := m.load(key); !ok || v != key*2 {
+							t.Errorf("load(%d) = %d, %v, want %d", key, v, ok, key*2)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+// マップの実装とディスパッチの全ての組み合わせで全てのタスクを処理することを確認する
+func TestConcurrentMapStrategies(t *testing.T) {
+	const n = 2000
+
+	p := defaultParams()
+	p.Workers = 100
+	for _, s := range concurrentMapStrategies(p) {
+		t.Run(s.Name, func(t *testing.T) {
+			var completed atomic.Int64
+			env := BatchEnv(n)
+			env.Process = func(Task) error {
+				completed.Add(1)
+				return nil
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := completed.Load(); got != n {
+				t.Errorf("processed %d tasks, want %d", got, n)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package benchmark
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ベンチマークの実行設定
+type Config struct {
+	// 適用したプリセットの名前。プリセットを使わない場合は空
+	Preset string
+	// 読み込んだ設定ファイル。設定ファイルを使わない場合は空
+	ConfigFile string
+	// 実行するシナリオ名。カンマ区切りで複数指定でき、allは全てのシナリオを表す
+	Scenario string
+	// 実行する戦略名。空の場合はシナリオの全ての戦略を実行する
+	Strategies []string
+	// 各戦略を繰り返し実行する回数
+	Repetitions int
+	// 1回の実行で処理するタスク数。0の場合は戦略ごとの既定値（通常はnumTasks）
+	Tasks int
+	// 並列度を制限する戦略の同時実行数（ワーカー数）。0の場合はCPU数
+	Workers int
+	// dispatchシナリオのチャネルを使う戦略のタスクのチャネルのバッファの大きさ。0の場合は既定値（100）
+	Buffer int
+	// dispatchシナリオのチャネルを使う戦略で、同じタスクのチャネルから競合して受信するディスパッチャーの数。0の場合は1つ
+	Dispatchers int
+	// 途中キャンセルのシナリオで比べる、タスクがctxを確認する間隔（作業の単位数）。空の場合は既定値（1、10、100）
+	CheckpointUnits []int
+	// タスクの処理時間の分布。nilの場合は既定のモデル（10µs、10個に1つは50µs、100個に1つは200µs）
+	Processing *ProcessingProfile
+	// 交互実行（ABAB…）で比較する2つの戦略名。空の場合は通常の実行
+	Interleave []string
+	// 各実行の間に挟むクールダウン時間
+	Cooldown time.Duration
+	// 処理時間の単調な増加を検出した場合にクールダウンを自動延長するか
+	ExtendCooldown bool
+	// 実行全体の時間の上限。残りの時間をシナリオに割り振り、超えたシナリオは実行せずに記録する（0は上限なし）
+	MaxDuration time.Duration
+	// 投入レートを段階的に上げる負荷ランプの設定
+	Ramp RampConfig
+	// タスク数を対数スケールで増やすスイープの設定
+	Sweep SweepConfig
+	// 実行中にスケジューラーを乱す外乱の設定
+	Chaos ChaosConfig
+	// CPUクォータ（CPU数）ごとに、cgroupでクォータを設定した子プロセスで全体を実行する（Linuxのみ）
+	CPUQuotas []float64
+	// CPUクォータごとに、GOMAXPROCSをCPU数にした場合とクォータに合わせた場合を比べるか
+	QuotaGOMAXPROCS bool
+	// 子プロセスとして実行中のCPUクォータ（CPUQuotasの実行で親プロセスが設定する）。0はクォータなし
+	CPUQuota float64
+	// 戦略ごとのCPUプロファイルを書き出すディレクトリ。空の場合はプロファイルを取らない
+	ProfileDir string
+	// CPUプロファイルからフレームグラフのSVGを生成するか
+	Flamegraph bool
+	// 戦略ごとのブロックとミューテックスの競合のプロファイルを書き出すか
+	ContentionProfile bool
+	// 実行中にpprofのHTTPサーバーを起動するアドレス（ループバックアドレスのみ）。空の場合は起動しない
+	PprofAddr string
+	// ログの出力先（stderr: 標準エラー出力、ring: 計測中はリングバッファに書き、計測の外で書き出す）
+	LogSink string
+	// LogSinkがringの場合に保持する行数
+	LogRingSize int
+	// 各タスクの処理の終了をログに出力するか（デバッグ用。計測を歪めないようLogSinkをringにして使う）
+	DebugLog bool
+	// 実行中の戦略のカウンタをexpvarで公開するか（PprofAddrのサーバーの/debug/varsで取得できる）
+	LiveCounters bool
+	// 実行中の戦略のカウンタとgoroutine数をPrometheusの形式（/metrics）で公開するアドレス。空の場合は公開しない
+	ListenAddr string
+	// このIDのタスクでエラーを発生させる（負の場合は発生させない）。
+	// 戦略がエラーを正しく返すかを確認するために使う
+	FailAt int
+	// FailAtのタスクで発生させるエラーの分類
+	FailKind ErrorKind
+	// 厳格モード。各実行で全てのタスクを処理したか、エラーを返したかを確かめ、
+	// タスクを破棄したりエラーを握りつぶしたりした戦略があれば実行全体を失敗させる
+	Strict bool
+	// semaphoreの取得に失敗したときの方針（チャネル + 制限付き並列処理）
+	AcquirePolicy AcquirePolicy
+	// タスクのデータの生成方法と、DataBytesの場合のバイト数
+	TaskData     TaskData
+	TaskDataSize int
+	// 供給元から読み出されてから処理を終えるまでのタスク（チャネルに積まれたものと処理中のもの）が
+	// 保持するバイト数のピークを計測するか
+	InflightBytes bool
+	// 全てのタスクを処理し終えてから、プールを残したままその終了を始めるまでの待機中のメモリ使用量を計測するか
+	IdleMemory bool
+	// チャネルの戦略で、ディスパッチャーのgoroutineがタスクの受け渡しに使った時間を計測するか
+	DispatchTime bool
+	// 供給元から読み出されてから処理を終えるまでの時間をタスクごとに記録し、分位数を求めるか
+	TaskLatency bool
+	// 計測とは別にもう1回実行し、goroutine数のピーク時と完了後のgoroutineを生成元ごとに数えるか
+	GoroutineProfile bool
+	// 各戦略をGCありで実行した後に、GCを止めて（GOGC=off）もう一度実行し、両方の結果を並べるか
+	GCOff bool
+	// GCを止めて実行する場合のヒープの上限（MB）。これに近づいた場合だけGCが動く（GOMEMLIMITと同じ）
+	GCOffLimitMB uint64
+	// 各戦略を計装なしで実行した後に、OpenTelemetryのスパンを送る計装をしてもう一度実行する場合の、
+	// スパンの送信先（「file=ファイル」「otlp」「otlp=エンドポイント」）。空の場合は計装しない
+	OTel string
+	// 計装して実行する場合に、タスクのスパンを送る間隔（IDがこの倍数のタスクだけ送る）
+	OTelSample int
+	// タスクあたりの割り当て回数の予算。各戦略が予算以内に収まるかを判定して表示する（負の場合は判定しない）
+	AllocBudget float64
+	// 結果の出力先（「種類」または「種類=出力先」）。複数指定すると全てに同じ結果を出力する
+	Reports []string
+	// 標準出力の形式（FormatText: 人が読む形式、FormatJSON: 全ての結果のJSON配列だけを書き、
+	// コンソールや出力先を省略した出力先などそれ以外の表示は標準エラー出力に書く）
+	Format string
+	// 実行の最後に全ての結果を送る送信先。認証のヘッダーを含むため、子プロセスに渡す設定には書き出さない
+	Publish PublishConfig `json:"-"`
+	// 実行の最後に結果のファイルとプロファイルをアップロードするオブジェクトストレージ。子プロセスではアップロードしない
+	Upload UploadConfig `json:"-"`
+	// 実行の最後に要約を送るWebhook。子プロセスでは送らない
+	Webhook WebhookConfig `json:"-"`
+	// 全ての戦略の実行に差し込むフックの名前
+	Hooks []string
+	// 実行せずに実行計画と所要時間の見積もりを表示する
+	DryRun bool
+	// 進捗を保存するチェックポイントファイル。空の場合は保存しない。
+	// ファイルが既にあれば、完了済みのシナリオを飛ばして続きから実行する
+	Checkpoint string
+	// 実行中のリソース使用量の上限。超えた場合はシナリオの実行を中止する
+	Limits ResourceLimits
+}
+
+// デフォルトの実行設定
+func DefaultConfig() Config {
+	return Config{
+		Scenario:      defaultScenario,
+		Repetitions:   1,
+		FailAt:        -1,
+		FailKind:      ErrorFatal,
+		LogSink:       LogSinkStderr,
+		Format:        FormatText,
+		LogRingSize:   10000,
+		AcquirePolicy: AcquireCount,
+		TaskData:      DataSprintf,
+		TaskDataSize:  64,
+		AllocBudget:   -1,
+		GCOffLimitMB:  defaultGCOffLimitMB,
+		OTelSample:    defaultOTelSample,
+		Reports:       []string{"console"},
+		Ramp: RampConfig{
+			Loop:         LoopOpen,
+			StartRate:    1000,
+			StartClients: 1,
+			Factor:       2,
+			Steps:        8,
+			StepDuration: time.Second,
+		},
+		Sweep: SweepConfig{Tasks: defaultSweepTasks},
+		Chaos: ChaosConfig{
+			Interval:         5 * time.Millisecond,
+			YieldProbability: 0.01,
+			HogDuration:      2 * time.Millisecond,
+		},
+	}
+}
+
+// 戦略の生成に使うパラメータ
+func (c Config) params() Params {
+	p := defaultParams()
+	p.AcquirePolicy = c.AcquirePolicy
+	if c.Workers > 0 {
+		p.Workers = c.Workers
+	}
+	if c.Buffer > 0 {
+		p.Buffer = c.Buffer
+	}
+	if c.Dispatchers > 0 {
+		p.Dispatchers = c.Dispatchers
+	}
+	if len(c.CheckpointUnits) > 0 {
+		p.CheckpointUnits = c.CheckpointUnits
+	}
+	return p
+}
+
+// 表示に使うタスク数（戦略ごとに既定値が異なる場合は代表としてnumTasks）
+func (c Config) tasks() int {
+	if c.Tasks > 0 {
+		return c.Tasks
+	}
+	return numTasks
+}
+
+// シナリオの戦略一覧を作成し、実行する戦略の絞り込みとタスク数の指定があれば適用する
+func (c Config) strategies(sc Scenario) []Strategy {
+	list := sc.Strategies(c.params())
+	if len(c.Strategies) > 0 {
+		list = slices.DeleteFunc(list, func(s Strategy) bool {
+			return !slices.Contains(c.Strategies, s.Name)
+		})
+	}
+	if c.Tasks > 0 {
+		for i := range list {
+			list[i].Tasks = c.Tasks
+		}
+	}
+	return list
+}
+
+// タスクのデータの生成関数を作成する
+func (c Config) dataGen() dataGen {
+	return newDataGen(c.TaskData, c.TaskDataSize)
+}
+
+// 設定値の妥当性を検証する
+func (c Config) validate() error {
+	list, err := resolveScenarios(c.Scenario)
+	if err != nil {
+		return err
+	}
+	if err := c.validateStrategies(list); err != nil {
+		return err
+	}
+	if c.Processing != nil {
+		if err := c.Processing.validate(); err != nil {
+			return err
+		}
+	}
+	if err := c.Limits.validate(); err != nil {
+		return err
+	}
+	if err := c.Publish.validate(); err != nil {
+		return err
+	}
+	if err := c.Upload.validate(); err != nil {
+		return err
+	}
+	if err := c.Webhook.validate(); err != nil {
+		return err
+	}
+	if err := c.AcquirePolicy.validate(); err != nil {
+		return err
+	}
+	if _, err := ParseErrorKind(string(c.FailKind)); err != nil {
+		return err
+	}
+	if c.MaxDuration < 0 {
+		return fmt.Errorf("max duration must not be negative, got %v", c.MaxDuration)
+	}
+	switch c.LogSink {
+	case LogSinkStderr:
+	case LogSinkRing:
+		if c.LogRingSize <= 0 {
+			return fmt.Errorf("log ring size must be positive, got %d", c.LogRingSize)
+		}
+	default:
+		return fmt.Errorf("unknown log sink %q (available: %s, %s)", c.LogSink, LogSinkStderr, LogSinkRing)
+	}
+	if err := c.TaskData.validate(); err != nil {
+		return err
+	}
+	if c.TaskData == DataBytes && c.TaskDataSize < 1 {
+		return fmt.Errorf("task data size must be at least 1, got %d", c.TaskDataSize)
+	}
+	for _, name := range c.Hooks {
+		if _, ok := hookKinds[name]; !ok {
+			return fmt.Errorf("unknown hook %q (available: %s)", name, strings.Join(HookNames(), ", "))
+		}
+	}
+	for _, spec := range c.Reports {
+		if _, _, err := parseReportSpec(spec); err != nil {
+			return err
+		}
+	}
+	switch c.Format {
+	case FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("unknown format %q (available: %s, %s)", c.Format, FormatText, FormatJSON)
+	}
+	if c.Tasks < 0 {
+		return fmt.Errorf("tasks must not be negative, got %d", c.Tasks)
+	}
+	if c.Workers < 0 {
+		return fmt.Errorf("workers must not be negative, got %d", c.Workers)
+	}
+	if c.Buffer < 0 {
+		return fmt.Errorf("buffer must not be negative, got %d", c.Buffer)
+	}
+	if c.Dispatchers < 0 {
+		return fmt.Errorf("dispatchers must not be negative, got %d", c.Dispatchers)
+	}
+	if c.Repetitions < 1 {
+		return fmt.Errorf("repetitions must be at least 1, got %d", c.Repetitions)
+	}
+	if len(c.Interleave) != 0 && len(c.Interleave) != 2 {
+		return fmt.Errorf("interleave requires exactly 2 strategies, got %d", len(c.Interleave))
+	}
+	if c.Cooldown < 0 {
+		return fmt.Errorf("cooldown must not be negative, got %v", c.Cooldown)
+	}
+	if c.Flamegraph && c.ProfileDir == "" {
+		return fmt.Errorf("flamegraph requires a profile directory")
+	}
+	if c.ContentionProfile && c.ProfileDir == "" {
+		return fmt.Errorf("contention profile requires a profile directory")
+	}
+	if err := validatePprofAddr(c.PprofAddr); err != nil {
+		return err
+	}
+	if c.LiveCounters && c.PprofAddr == "" {
+		return fmt.Errorf("expvar requires a pprof address")
+	}
+	for _, n := range c.CheckpointUnits {
+		if n < 1 || n > cancelTaskUnits {
+			return fmt.Errorf("checkpoint units must be between 1 and %d, got %d", cancelTaskUnits, n)
+		}
+	}
+	if c.Strict && c.Ramp.Enabled {
+		return fmt.Errorf("strict cannot be combined with ramp")
+	}
+	if c.GCOff && (c.Ramp.Enabled || c.Sweep.Enabled || len(c.Interleave) != 0) {
+		return fmt.Errorf("gc-off cannot be combined with ramp, sweep or interleave")
+	}
+	if c.GCOff && c.GCOffLimitMB == 0 {
+		return fmt.Errorf("gc-off requires a memory limit")
+	}
+	if c.OTel != "" && (c.Ramp.Enabled || c.Sweep.Enabled || len(c.Interleave) != 0) {
+		return fmt.Errorf("otel cannot be combined with ramp, sweep or interleave")
+	}
+	if c.OTel != "" && c.OTelSample < 1 {
+		return fmt.Errorf("otel sample interval must be positive")
+	}
+	if c.Sweep.Enabled && (c.Ramp.Enabled || len(c.Interleave) != 0) {
+		return fmt.Errorf("sweep cannot be combined with ramp or interleave")
+	}
+	if err := c.Sweep.validate(); err != nil {
+		return err
+	}
+	if err := c.Chaos.validate(); err != nil {
+		return err
+	}
+	for _, q := range c.CPUQuotas {
+		if q <= 0 {
+			return fmt.Errorf("CPU quota must be positive, got %v", q)
+		}
+	}
+	if c.QuotaGOMAXPROCS && len(c.CPUQuotas) == 0 && c.CPUQuota == 0 {
+		return fmt.Errorf("quota GOMAXPROCS comparison requires CPU quotas")
+	}
+	if len(c.CPUQuotas) > 0 && c.Checkpoint != "" {
+		return fmt.Errorf("CPU quotas cannot be combined with a checkpoint")
+	}
+	return c.Ramp.validate()
+}
+
+// 結果に影響するスパンの間隔。計装しない場合は0
+func (c Config) otelSample() int {
+	if c.OTel == "" {
+		return 0
+	}
+	return c.OTelSample
+}
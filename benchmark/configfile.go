@@ -0,0 +1,194 @@
+package benchmark
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// タスクの処理時間の分布。全てのタスクの処理時間をBaseとし、Tiersの区分に当てはまるタスクは
+// その区分の処理時間にする（複数の区分に当てはまる場合は後の区分を優先する）
+type ProcessingProfile struct {
+	Base  time.Duration
+	Tiers []ProcessingTier
+}
+
+// タスクIDがEveryで割り切れるタスクの処理時間をDurationにする区分
+type ProcessingTier struct {
+	Every    int
+	Duration time.Duration
+}
+
+// タスクIDに対応する処理時間
+func (p ProcessingProfile) duration(id int) time.Duration {
+	d := p.Base
+	for _, t := range p.Tiers {
+		if id%t.Every == 0 {
+			d = t.Duration
+		}
+	}
+	return d
+}
+
+func (p ProcessingProfile) validate() error {
+	if p.Base <= 0 {
+		return fmt.Errorf("processing base must be positive, got %v", p.Base)
+	}
+	for _, t := range p.Tiers {
+		if t.Every < 1 {
+			return fmt.Errorf("processing tier every must be at least 1, got %d", t.Every)
+		}
+		if t.Duration <= 0 {
+			return fmt.Errorf("processing tier duration must be positive, got %v", t.Duration)
+		}
+	}
+	return nil
+}
+
+// 結果のパラメータに記録する表示（例: 10µs,50µs/10,200µs/100）
+func (p ProcessingProfile) label() string {
+	parts := []string{p.Base.String()}
+	for _, t := range p.Tiers {
+		parts = append(parts, fmt.Sprintf("%v/%d", t.Duration, t.Every))
+	}
+	return strings.Join(parts, ",")
+}
+
+// 処理時間の分布に従って、供給するタスクに処理時間を設定する供給元
+type profiledSource struct {
+	src     Source
+	profile ProcessingProfile
+}
+
+func (s *profiledSource) Next() (Task, bool) {
+	task, ok := s.src.Next()
+	task.duration = s.profile.duration(task.ID)
+	return task, ok
+}
+
+// 処理時間の分布の指定があれば、供給するタスクに処理時間を設定する
+func (c Config) withProcessing(env Env) Env {
+	if c.Processing != nil {
+		env.Source = &profiledSource{src: env.Source, profile: *c.Processing}
+	}
+	return env
+}
+
+// 設定ファイル（YAML）の形式。指定した項目だけをデフォルトまたはプリセットの設定に上書きする
+type configFile struct {
+	Preset      string          `yaml:"preset"`
+	Scenarios   []string        `yaml:"scenarios"`
+	Strategies  []string        `yaml:"strategies"`
+	Reps        *int            `yaml:"reps"`
+	Tasks       *int            `yaml:"tasks"`
+	Workers     *int            `yaml:"workers"`
+	Buffer      *int            `yaml:"buffer"`
+	Dispatchers *int            `yaml:"dispatchers"`
+	Cooldown    *time.Duration  `yaml:"cooldown"`
+	TaskData    *TaskData       `yaml:"task_data"`
+	Processing  *processingFile `yaml:"processing"`
+	Reports     []string        `yaml:"reports"`
+	Hooks       []string        `yaml:"hooks"`
+	MaxDuration *time.Duration  `yaml:"max_duration"`
+}
+
+// 設定ファイルの処理時間の分布
+type processingFile struct {
+	Base  time.Duration `yaml:"base"`
+	Tiers []struct {
+		Every    int           `yaml:"every"`
+		Duration time.Duration `yaml:"duration"`
+	} `yaml:"tiers"`
+}
+
+// 設定ファイル（YAML）を読み込み、ファイルに書かれた項目を設定に適用する。
+// 実験の定義（シナリオ、実行する戦略、タスク数、ワーカー数、処理時間の分布など）をファイルで管理し、
+// 同じ条件で再現できるようにする。ファイルにpresetがあればプリセットを先に適用し、ファイルの項目で上書きする。
+// 知らない項目はエラーにする（項目名の誤りで設定が無視されたまま計測しないようにする）
+func LoadConfigFile(cfg *Config, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var f configFile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if f.Preset != "" {
+		if err := ApplyPreset(cfg, f.Preset); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if len(f.Scenarios) > 0 {
+		cfg.Scenario = strings.Join(f.Scenarios, ",")
+	}
+	if len(f.Strategies) > 0 {
+		cfg.Strategies = f.Strategies
+	}
+	setIf(&cfg.Repetitions, f.Reps)
+	setIf(&cfg.Tasks, f.Tasks)
+	setIf(&cfg.Workers, f.Workers)
+	setIf(&cfg.Buffer, f.Buffer)
+	setIf(&cfg.Dispatchers, f.Dispatchers)
+	setIf(&cfg.Cooldown, f.Cooldown)
+	setIf(&cfg.TaskData, f.TaskData)
+	setIf(&cfg.MaxDuration, f.MaxDuration)
+	if p := f.Processing; p != nil {
+		profile := &ProcessingProfile{Base: p.Base}
+		for _, t := range p.Tiers {
+			profile.Tiers = append(profile.Tiers, ProcessingTier{Every: t.Every, Duration: t.Duration})
+		}
+		cfg.Processing = profile
+	}
+	if len(f.Reports) > 0 {
+		cfg.Reports = f.Reports
+	}
+	if len(f.Hooks) > 0 {
+		cfg.Hooks = f.Hooks
+	}
+	cfg.ConfigFile = path
+	return nil
+}
+
+// ファイルに値が書かれていれば設定に適用する
+func setIf[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// 実行するシナリオ一覧。実行する戦略を絞り込む場合は、該当する戦略がないシナリオを除く
+func (c Config) scenarios() ([]Scenario, error) {
+	list, err := resolveScenarios(c.Scenario)
+	if err != nil || len(c.Strategies) == 0 {
+		return list, err
+	}
+	return slices.DeleteFunc(list, func(sc Scenario) bool {
+		return len(c.strategies(sc)) == 0
+	}), nil
+}
+
+// 実行する戦略を絞り込む場合に、指定した名前が選択したシナリオのいずれかの戦略にあるかを検証する
+func (c Config) validateStrategies(list []Scenario) error {
+	var names []string
+	for _, sc := range list {
+		for _, s := range sc.Strategies(c.params()) {
+			names = append(names, s.Name)
+		}
+	}
+	for _, name := range c.Strategies {
+		if !slices.Contains(names, name) {
+			return fmt.Errorf("unknown strategy %q in the selected scenarios", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package benchmark
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "experiment.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// ファイルに書いた項目だけを設定に適用し、書いていない項目は元の設定のまま残すことを確認する
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `
+preset: quick
+scenarios: [dispatch, semaphore]
+strategies: [chan-limited, sem-chan]
+workers: 8
+cooldown: 500ms
+processing:
+  base: 20us
+  tiers:
+    - every: 10
+      duration: 1ms
+`)
+	cfg := DefaultConfig()
+	if err := LoadConfigFile(&cfg, path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Preset != "quick" || cfg.Tasks != 10000 {
+		t.Errorf("preset was not applied: preset=%q tasks=%d", cfg.Preset, cfg.Tasks)
+	}
+	if cfg.Scenario != "dispatch,semaphore" || cfg.Workers != 8 || cfg.Cooldown != 500*time.Millisecond {
+		t.Errorf("got scenario=%q workers=%d cooldown=%v", cfg.Scenario, cfg.Workers, cfg.Cooldown)
+	}
+	if cfg.Buffer != 0 || cfg.ConfigFile != path {
+		t.Errorf("got buffer=%d config=%q", cfg.Buffer, cfg.ConfigFile)
+	}
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Processing.label(); got != "20µs,1ms/10" {
+		t.Errorf("processing = %q", got)
+	}
+
+	list, err := cfg.scenarios()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sc := range list {
+		if got := cfg.strategies(sc); len(got) != 1 {
+			t.Errorf("%s: %d strategies selected, want 1", sc.Name, len(got))
+		}
+	}
+}
+
+// 知らない項目と、選択したシナリオにない戦略をエラーにすることを確認する
+func TestLoadConfigFileErrors(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := LoadConfigFile(&cfg, writeConfigFile(t, "worker: 8\n")); err == nil {
+		t.Error("unknown field: want error")
+	}
+	cfg = DefaultConfig()
+	if err := LoadConfigFile(&cfg, writeConfigFile(t, "strategies: [sem-chan]\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("strategy outside the selected scenarios: want error")
+	}
+}
+
+// 処理時間の分布を指定すると、供給するタスクの処理時間を後の区分を優先して設定することを確認する
+func TestProcessingProfile(t *testing.T) {
+	p := ProcessingProfile{Base: time.Microsecond, Tiers: []ProcessingTier{{Every: 2, Duration: 2 * time.Microsecond}, {Every: 4, Duration: 4 * time.Microsecond}}}
+	cfg := DefaultConfig()
+	cfg.Processing = &p
+	env := cfg.withProcessing(BatchEnv(5))
+	want := []time.Duration{4 * time.Microsecond, time.Microsecond, 2 * time.Microsecond, time.Microsecond, 4 * time.Microsecond}
+	for i, w := range want {
+		task, _ := env.Source.Next()
+		if task.duration != w {
+			t.Errorf("task %d: duration %v, want %v", i, task.duration, w)
+		}
+	}
+}
@@ -0,0 +1,628 @@
+package benchmark
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 結果を人が読む形式で表示する。
+// 見出しなどの進捗表示はnilのときは何もしないため、出力先にコンソールがなくても呼び出せる
+type console struct {
+	// 表示先（標準出力。標準出力に結果のJSON配列を書く場合は標準エラー出力）
+	w io.Writer
+	// 交互実行で、Bの結果と並べて表示するために保持するAの結果
+	pending *Result
+	// 表にまとめて表示するために保持するランプのステップ
+	steps []Result
+	// 表にまとめて表示するために保持するスイープの結果
+	sweep []Result
+	// 最後にピークメモリを比較するために保持する繰り返し実行の結果
+	batch []Result
+}
+
+// 実行環境とシナリオを表示する
+func (c *console) header(r *runner, list []Scenario) {
+	if c == nil {
+		return
+	}
+	fmt.Fprintf(c.w, "CPUs: %d\n", r.env.NumCPU)
+	// 負荷ランプは時間で区切るため、タスク数は投入レートによって決まる
+	if r.cfg.Ramp.Enabled {
+		fmt.Fprintln(c.w)
+		return
+	}
+	if r.cfg.Sweep.Enabled {
+		fmt.Fprintf(c.w, "処理タスク数: %s（スイープ）\n\n", joinInts(r.cfg.Sweep.Tasks))
+		return
+	}
+	fmt.Fprintf(c.w, "処理タスク数: %s\n\n", scenarioTasks(r.cfg, list))
+}
+
+// 実行するシナリオの戦略が処理するタスク数。タスク数を決めているシナリオがあるため戦略ごとに求め、
+// シナリオによって異なる場合はシナリオ名を付けて並べる
+func scenarioTasks(cfg Config, list []Scenario) string {
+	var parts []string
+	var all []int
+	for _, sc := range list {
+		var counts []int
+		for _, s := range cfg.strategies(sc) {
+			if n := s.tasks(); !slices.Contains(counts, n) {
+				counts = append(counts, n)
+			}
+		}
+		if len(counts) == 0 {
+			continue
+		}
+		slices.Sort(counts)
+		parts = append(parts, sc.Name+" "+joinInts(counts))
+		for _, n := range counts {
+			if !slices.Contains(all, n) {
+				all = append(all, n)
+			}
+		}
+	}
+	switch len(all) {
+	case 0:
+		return itoa(cfg.tasks())
+	case 1:
+		return itoa(all[0])
+	}
+	return strings.Join(parts, "、")
+}
+
+// pprofのHTTPサーバーのURLを表示する
+func (c *console) pprofServer(s *pprofServer) {
+	if c == nil || s == nil {
+		return
+	}
+	fmt.Fprintf(c.w, "pprof: %s（goroutineのダンプ: %sgoroutine?debug=2）\n", s.url(), s.url())
+	fmt.Fprintf(c.w, "expvar: %s\n\n", s.varsURL())
+}
+
+// Prometheusの形式でカウンタを公開するURLを表示する
+func (c *console) metricsServer(s *metricsServer) {
+	if c == nil || s == nil {
+		return
+	}
+	fmt.Fprintf(c.w, "Prometheus: %s\n\n", s.url())
+}
+
+// シナリオの見出しを表示する（デフォルトのシナリオのみを実行する場合は表示しない）
+func (c *console) scenarioStart(sc Scenario, multiple bool) {
+	if c == nil {
+		return
+	}
+	c.flush()
+	if multiple || sc.Name != defaultScenario {
+		fmt.Fprintf(c.w, "シナリオ: %s\n\n", sc.Title)
+	}
+}
+
+// チェックポイントから再開したことを表示する
+func (c *console) resumed(cp *checkpoint) {
+	if c == nil {
+		return
+	}
+	fmt.Fprintf(c.w, "チェックポイント %s から再開（完了済み: %s）\n\n", cp.path, strings.Join(cp.Completed, ", "))
+}
+
+// 完了済みのため飛ばしたシナリオを表示する
+func (c *console) scenarioSkipped(sc Scenario) {
+	if c == nil {
+		return
+	}
+	fmt.Fprintf(c.w, "シナリオ: %s（完了済みのためスキップ）\n\n", sc.Title)
+}
+
+// 実行全体の時間の上限に達したため、シナリオを実行しないことを表示する
+func (c *console) scenarioOverBudget(sc Scenario) {
+	if c == nil {
+		return
+	}
+	fmt.Fprintf(c.w, "シナリオ: %s（実行時間の上限に達したためスキップ）\n\n", sc.Title)
+}
+
+// 中断されたことを表示する。保持している結果はこの後のCloseで表示する
+func (c *console) interrupted() {
+	if c == nil {
+		return
+	}
+	c.pending = nil
+	fmt.Fprintf(c.w, "\n中断されました。完了した戦略の結果のみを出力します\n\n")
+}
+
+// 戦略の見出しを表示する
+func (c *console) strategyStart(i int, s Strategy) {
+	if c == nil {
+		return
+	}
+	c.flush()
+	fmt.Fprintf(c.w, "%d. %s\n", i+1, s.Title)
+}
+
+// タスク数のスイープの条件と表の見方を表示する
+func (c *console) sweepStart(cfg SweepConfig) {
+	if c == nil {
+		return
+	}
+	fmt.Fprintf(c.w, "タスク数のスイープ（%s）\n", joinInts(cfg.Tasks))
+	fmt.Fprintf(c.w, "指数: 前のタスク数からの処理時間の伸び（1で線形、%.1fを超えると超線形の劣化とみなす）\n", superlinearExponent)
+	fmt.Fprintln(c.w)
+}
+
+// 交互実行の見出しを表示する
+func (c *console) interleaveStart(rounds int) {
+	if c == nil {
+		return
+	}
+	fmt.Fprintf(c.w, "交互実行（ラウンドごとにAB、BAの順を入れ替え、%dラウンド）\n", rounds)
+}
+
+// 負荷ランプの条件と表の見方を表示する
+func (c *console) rampStart(cfg RampConfig) {
+	if c == nil {
+		return
+	}
+	if cfg.Loop == LoopClosed {
+		fmt.Fprintf(c.w, "負荷ランプ（閉ループ、開始 %d クライアント、倍率 %.1f、最大 %d ステップ、各 %v）\n",
+			cfg.StartClients, cfg.Factor, cfg.Steps, cfg.StepDuration)
+	} else {
+		fmt.Fprintf(c.w, "負荷ランプ（開ループ、開始 %.0f タスク/秒、倍率 %.1f、最大 %d ステップ、各 %v）\n",
+			cfg.StartRate, cfg.Factor, cfg.Steps, cfg.StepDuration)
+	}
+	fmt.Fprintln(c.w, "p50/p90/p99: 予定送信時刻からのレイテンシ（供給側のブロックによる遅れを含む）")
+	fmt.Fprintln(c.w, "p99(実送信): 実際の送信時刻からのレイテンシ（Coordinated Omissionの影響を受ける）")
+	fmt.Fprintln(c.w, "待ち: 予定送信時刻から処理開始までの時間（キューイング）、処理: 処理開始から完了までの時間")
+	fmt.Fprintln(c.w)
+}
+
+// 結果を表示する
+func (c *console) Report(r Result) error {
+	if r.flag(metricSkipped) {
+		fmt.Fprintf(c.w, "%s: %s\n\n", r.Strategy, r.AbortReason)
+		return nil
+	}
+	if r.AbortReason != "" {
+		c.flush()
+		c.pending = nil
+		fmt.Fprintf(c.w, "%s: リソースの上限を超えたため中止しました（%s）。このシナリオの残りの戦略はスキップします\n\n", r.Strategy, r.AbortReason)
+		return nil
+	}
+	switch r.Mode {
+	case modeRamp:
+		c.steps = append(c.steps, r)
+	case modeSweep:
+		c.sweep = append(c.sweep, r)
+	case modeInterleave:
+		if r.Params["role"] == "A" {
+			c.pending = &r
+			return nil
+		}
+		if c.pending != nil {
+			printInterleaved(c.w, *c.pending, r)
+			c.pending = nil
+		}
+	default:
+		printBatch(c.w, r)
+		c.batch = append(c.batch, r)
+	}
+	return nil
+}
+
+// 保持している結果を表示する
+func (c *console) Close() error {
+	c.flush()
+	printPeakMemory(c.w, c.batch)
+	printAllocBudget(c.w, c.batch)
+	printOversubscription(c.w, c.batch)
+	printCheckpointGranularity(c.w, c.batch)
+	return nil
+}
+
+func (c *console) flush() {
+	if len(c.steps) > 0 {
+		printRamp(c.w, c.steps)
+		c.steps = nil
+	}
+	if len(c.sweep) > 0 {
+		printSweep(c.w, c.sweep)
+		c.sweep = nil
+	}
+}
+
+// 繰り返し実行の結果を表示する
+func printBatch(w io.Writer, r Result) {
+	for _, note := range r.Notes {
+		fmt.Fprintf(w, "  %s\n", note)
+	}
+	if len(r.Samples) > 1 {
+		for i, d := range r.Samples {
+			fmt.Fprintf(w, "  %d回目: %v\n", i+1, time.Duration(d))
+		}
+		fmt.Fprintf(w, "処理時間（中央値）: %v%s\n", r.duration(metricWall), slowdownNote(r.flag(metricSlowdownSuspected)))
+		if warm := r.Metrics[metricWarmWall]; warm > 0 {
+			fmt.Fprintf(w, "初回（コールド）: %v、2回目以降（ウォーム）の中央値: %v（初回は%.2f倍）\n",
+				r.duration(metricColdWall), r.duration(metricWarmWall), r.Metrics[metricColdWall]/warm)
+		}
+	} else {
+		fmt.Fprintf(w, "処理時間: %v\n", r.duration(metricWall))
+	}
+	if nsPerTask, throughput, ok := r.perTask(); ok {
+		note := ""
+		if processed, ok := r.Metrics[metricProcessedTasks]; ok {
+			note = fmt.Sprintf("、処理した%.0f / %.0fタスクで計算", processed, r.Metrics[metricTasks])
+		}
+		fmt.Fprintf(w, "1タスクあたり: %s（%.0f タスク/秒%s）\n", shortDuration(nsPerTask), throughput, note)
+	}
+	// go test -benchmemのallocs/op、B/opに相当
+	fmt.Fprintf(w, "アロケーション: %.2f allocs/タスク, %.1f B/タスク\n",
+		r.Metrics[metricAllocsPerTask], r.Metrics[metricBytesPerTask])
+	if budget, ok := r.Params["alloc_budget"]; ok {
+		verdict := "達成"
+		if !r.flag(metricAllocBudgetMet) {
+			verdict = "超過"
+		}
+		fmt.Fprintf(w, "割り当て予算（%s allocs/タスク以内）: %s\n", budget, verdict)
+	}
+	if cycles, ok := r.Metrics[metricGCCycles]; ok {
+		fmt.Fprintf(w, "計測中のGC: %.1f回（1回あたり）\n", cycles)
+	}
+	if on, ok := r.Metrics[metricGCOnWall]; ok {
+		wall := r.Metrics[metricWall]
+		fmt.Fprintf(w, "GCあり %v / GCなし %v（GCによる増分 %.1f%%）\n",
+			time.Duration(on), time.Duration(wall), (on-wall)/wall*100)
+		if r.Metrics[metricGCCycles] > 0 {
+			fmt.Fprintf(w, "  GCなしの実行中にヒープが上限（%s MB）に近づいてGCが動いたため、GCの影響を除き切れていません\n", r.Params["gc_memory_limit_mb"])
+		}
+	}
+	if off, ok := r.Metrics[metricOTelOffWall]; ok {
+		wall := r.Metrics[metricWall]
+		fmt.Fprintf(w, "計装なし %v / 計装あり %v（計装による増分 %.1f%%、タスク%s個に1つのスパン）\n",
+			time.Duration(off), time.Duration(wall), (wall-off)/off*100, r.Params["otel_sample"])
+	}
+	if created, ok := r.Metrics[metricGoroutinesCreated]; ok {
+		fmt.Fprintf(w, "起動したgoroutine: %.0f（1回あたり）\n", created)
+	}
+	if rss, ok := r.Metrics[metricPeakRSS]; ok {
+		fmt.Fprintf(w, "ピークメモリ: %.1f MB（RSS）、ヒープ %.1f MB、スタック %.1f MB\n",
+			rss, r.Metrics[metricPeakHeap], r.Metrics[metricPeakStack])
+	}
+	if rss, ok := r.Metrics[metricIdleRSS]; ok {
+		fmt.Fprintf(w, "待機中のメモリ: %.1f MB（RSS）、ヒープ %.1f MB、スタック %.1f MB（処理後、プールの終了前、1回あたりの平均）\n",
+			rss, r.Metrics[metricIdleHeap], r.Metrics[metricIdleStack])
+	}
+	if busy, ok := r.Metrics[metricDispatchBusy]; ok {
+		fmt.Fprintf(w, "ディスパッチャーの処理時間: %v（処理時間の%.1f%%、1タスクあたり%v、受信とセマフォの待ちを除く）\n",
+			time.Duration(busy), r.Metrics[metricDispatchShare]*100, time.Duration(busy/r.Metrics[metricTasks]))
+	}
+	if dropped := r.Metrics[metricDroppedTasks]; dropped > 0 {
+		fmt.Fprintf(w, "破棄されたタスク: %.0f（1回あたり）\n", dropped)
+	}
+	if dedup, ok := r.Metrics[metricDeduplicatedTasks]; ok {
+		fmt.Fprintf(w, "重複排除されたタスク: %.0f / %.0f（1回あたり）\n", dedup, r.Metrics[metricTasks])
+	}
+	if timedOut, ok := r.Metrics[metricTimedOutTasks]; ok {
+		fmt.Fprintf(w, "タイムアウトしたタスク: %.0f（1回あたり）\n", timedOut)
+	}
+	if _, ok := r.Metrics[metricSleepOvershoot]; ok {
+		fmt.Fprintf(w, "待機の超過時間: %v（1タスクあたりの平均）\n", r.duration(metricSleepOvershoot))
+	}
+	if throughput, ok := r.Metrics[metricAcquireThroughput]; ok {
+		fmt.Fprintf(w, "セマフォの取得と解放: %.0f 回/秒\n", throughput)
+	}
+	if _, ok := r.Metrics[ErrorFatal.metric()]; ok {
+		counts := make([]string, len(errorKinds))
+		for i, k := range errorKinds {
+			counts[i] = fmt.Sprintf("%s %.0f", k, r.Metrics[k.metric()])
+		}
+		fmt.Fprintf(w, "タスクのエラー: %s（1回あたり）\n", strings.Join(counts, "、"))
+	}
+	if _, ok := r.Metrics[metricTeardown]; ok {
+		fmt.Fprintf(w, "キャンセルの伝播: %v（ルートのキャンセルから全てのgoroutineの終了まで、1回あたり）\n", r.duration(metricTeardown).Round(time.Microsecond))
+	}
+	if _, ok := r.Metrics[metricSubmit]; ok {
+		fmt.Fprintf(w, "投入の完了: %v（開始から供給元を読み切るまで、1回あたり）\n", r.duration(metricSubmit).Round(time.Microsecond))
+	}
+	if _, ok := r.Metrics[metricRampUp]; ok {
+		fmt.Fprintf(w, "立ち上がりの遅れ: %v（最初の%d個のタスクの予定時刻から処理開始までの最大、1回あたり）\n", r.duration(metricRampUp).Round(time.Microsecond), spawnWorkers)
+	}
+	if wasted, ok := r.Metrics[metricCancelWasted]; ok {
+		fmt.Fprintf(w, "キャンセル後の無駄な作業: %.0f単位（作業の途中でやめたタスク %.0f個、キャンセルから停止まで %v、1回あたり）\n",
+			wasted, r.Metrics[metricCancelAbandoned], r.duration(metricCancelStop).Round(time.Microsecond))
+	}
+	if failures, ok := r.Metrics[metricOutageFailures]; ok {
+		fmt.Fprintf(w, "処理先の障害: タイムアウト %.0f 件（%vを浪費）、ブレーカーで止めた呼び出し %.0f 件（1回あたり）\n",
+			failures, r.duration(metricWastedWork).Round(time.Microsecond), r.Metrics[metricBreakerRejected])
+		if _, ok := r.Metrics[metricRecovery]; ok {
+			fmt.Fprintf(w, "障害からの回復: %v（障害の終了後に到着したタスクが初めて成功するまで）\n", r.duration(metricRecovery).Round(time.Microsecond))
+		}
+	}
+	if mean, ok := r.Metrics[metricWorkerUtilMean]; ok {
+		fmt.Fprintf(w, "ワーカーの稼働率: 最小 %.0f%%、平均 %.0f%%、最大 %.0f%%", r.Metrics[metricWorkerUtilMin]*100, mean*100, r.Metrics[metricWorkerUtilMax]*100)
+		if hit, ok := r.Metrics[metricCacheHitRatio]; ok {
+			fmt.Fprintf(w, "（状態を保持していた割合 %.1f%%）", hit*100)
+		}
+		fmt.Fprintln(w)
+	}
+	if peak, ok := r.Metrics[metricInflightPeak]; ok {
+		fmt.Fprintf(w, "処理中のタスクのバイト数のピーク: %.1f KB（チャネルに積まれたものを含む、全ての回の最大）\n", peak/1024)
+	}
+	if periods, ok := r.Metrics[metricThrottledPeriods]; ok {
+		fmt.Fprintf(w, "CPUクォータによる停止: %.0f 周期、%v（1回あたり）\n", periods, r.duration(metricThrottledNs))
+	}
+	if _, ok := r.Metrics[metricJitterP99]; ok {
+		fmt.Fprintf(w, "予定時刻から処理開始までの遅れ: p50 %v, p99 %v, 最大 %v\n",
+			r.duration(metricJitterP50), r.duration(metricJitterP99), r.duration(metricJitterMax))
+	}
+	if _, ok := r.Metrics[metricRequestP99]; ok {
+		fmt.Fprintf(w, "要求ごとのレイテンシ: p50 %v, p99 %v, 最大 %v\n",
+			r.duration(metricRequestP50), r.duration(metricRequestP99), r.duration(metricRequestMax))
+	}
+	if _, ok := r.Metrics[metricServiceP99]; ok {
+		fmt.Fprintf(w, "タスクの処理開始から完了までの時間: p50 %v, p99 %v\n", r.duration(metricServiceP50), r.duration(metricServiceP99))
+	}
+	if _, ok := r.Metrics[metricTaskLatencyP99]; ok {
+		fmt.Fprintf(w, "タスクごとのレイテンシ（読み出しから完了まで）: p50 %v, p95 %v, p99 %v\n",
+			r.duration(metricTaskLatencyP50), r.duration(metricTaskLatencyP95), r.duration(metricTaskLatencyP99))
+	}
+	if len(r.GoroutineSites) > 0 {
+		printGoroutineSites(w, r.GoroutineSites)
+	}
+	for _, name := range extraMetrics(r) {
+		fmt.Fprintf(w, "%s: %s\n", name, formatMetric(name, r.Metrics[name]))
+	}
+	if path, ok := r.Artifacts["cpu_profile"]; ok {
+		fmt.Fprintf(w, "CPUプロファイル: %s\n", path)
+	}
+	if path, ok := r.Artifacts["block_profile"]; ok {
+		fmt.Fprintf(w, "ブロックプロファイル: %s\n", path)
+	}
+	if path, ok := r.Artifacts["mutex_profile"]; ok {
+		fmt.Fprintf(w, "ミューテックスプロファイル: %s\n", path)
+	}
+	if path, ok := r.Artifacts["flamegraph"]; ok {
+		fmt.Fprintf(w, "フレームグラフ: %s\n", path)
+	}
+	for i := 1; ; i++ {
+		path, ok := r.Artifacts["trace-"+strconv.Itoa(i)]
+		if !ok {
+			break
+		}
+		fmt.Fprintf(w, "トレース: %s\n", path)
+	}
+	fmt.Fprintln(w)
+}
+
+// 繰り返し実行の表示で専用の行を持つメトリクス
+var batchMetrics = map[string]bool{
+	ErrorTransient.metric(): true,
+	ErrorFatal.metric():     true,
+	ErrorCancelled.metric(): true,
+	ErrorTimeout.metric():   true,
+	metricWall:              true,
+	metricColdWall:          true,
+	metricWarmWall:          true,
+	metricTasks:             true,
+	metricNsPerTask:         true,
+	metricThroughput:        true,
+	metricAllocsPerTask:     true,
+	metricBytesPerTask:      true,
+	metricAllocBudgetMet:    true,
+	metricGoroutinesCreated: true,
+	metricDroppedTasks:      true,
+	metricDeduplicatedTasks: true,
+	metricTimedOutTasks:     true,
+	metricGCCycles:          true,
+	metricGCOnWall:          true,
+	metricOTelOffWall:       true,
+	metricSleepOvershoot:    true,
+	metricJitterP50:         true,
+	metricJitterP99:         true,
+	metricJitterMax:         true,
+	metricAcquireThroughput: true,
+	metricTeardown:          true,
+	metricSubmit:            true,
+	metricRampUp:            true,
+	metricCancelWasted:      true,
+	metricCancelAbandoned:   true,
+	metricCancelStop:        true,
+	metricProcessedTasks:    true,
+	metricStrictViolations:  true,
+	metricInflightPeak:      true,
+	metricIdleRSS:           true,
+	metricIdleHeap:          true,
+	metricIdleStack:         true,
+	metricDispatchBusy:      true,
+	metricDispatchShare:     true,
+	metricOutageFailures:    true,
+	metricWastedWork:        true,
+	metricBreakerRejected:   true,
+	metricRecovery:          true,
+	metricWorkerUtilMin:     true,
+	metricWorkerUtilMean:    true,
+	metricWorkerUtilMax:     true,
+	metricCacheHitRatio:     true,
+	metricRequestP50:        true,
+	metricRequestP99:        true,
+	metricRequestMax:        true,
+	metricServiceP50:        true,
+	metricServiceP99:        true,
+	metricTaskLatencyP50:    true,
+	metricTaskLatencyP95:    true,
+	metricTaskLatencyP99:    true,
+	metricSlowdownSuspected: true,
+	metricPeakRSS:           true,
+	metricPeakHeap:          true,
+	metricPeakStack:         true,
+	metricThrottledPeriods:  true,
+	metricThrottledNs:       true,
+}
+
+// フックなどが追加したメトリクスの名前を整列して返す
+func extraMetrics(r Result) []string {
+	var names []string
+	for _, name := range metricNames([]Result{r}) {
+		if !batchMetrics[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// 戦略ごとの処理時間とピークRSSを並べて表示する。
+// 処理時間が近くてもメモリ使用量が大きく異なる場合があるため、両方を比較できるようにする
+func printPeakMemory(w io.Writer, results []Result) {
+	if len(results) < 2 {
+		return
+	}
+	var maxRSS float64
+	for _, r := range results {
+		maxRSS = max(maxRSS, r.Metrics[metricPeakRSS])
+	}
+	if maxRSS == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "処理時間とピークメモリ（RSS）の比較")
+	for _, r := range results {
+		rss := r.Metrics[metricPeakRSS]
+		mark := ""
+		if rss == maxRSS {
+			mark = " ← 最大"
+		}
+		fmt.Fprintf(w, "  %-26s %14v %9.1f MB %s%s\n",
+			r.Strategy, r.duration(metricWall), rss, strings.Repeat("#", int(rss/maxRSS*30)), mark)
+	}
+	fmt.Fprintln(w)
+}
+
+// 交互実行の結果を表示する
+func printInterleaved(w io.Writer, a, b Result) {
+	fmt.Fprintf(w, "A: %s\n", a.Title)
+	fmt.Fprintf(w, "B: %s\n\n", b.Title)
+
+	for i := range a.Samples {
+		fmt.Fprintf(w, "ラウンド%d: A=%v B=%v B/A=%.3f\n",
+			i+1, time.Duration(a.Samples[i]), time.Duration(b.Samples[i]), b.Samples[i]/a.Samples[i])
+	}
+
+	fmt.Fprintf(w, "\nA 中央値: %v%s\n", a.duration(metricWall), slowdownNote(a.flag(metricSlowdownSuspected)))
+	fmt.Fprintf(w, "B 中央値: %v%s\n", b.duration(metricWall), slowdownNote(b.flag(metricSlowdownSuspected)))
+	fmt.Fprintf(w, "B/A 比率の中央値: %.3f（Bが速かったラウンド: %.0f/%d）\n\n",
+		b.Metrics[metricRatioMedian], b.Metrics[metricFasterRounds], len(a.Samples))
+}
+
+// ランプ結果を表とp99のバーで表示する（負荷に対するレイテンシの立ち上がりを見るため）
+func printRamp(w io.Writer, steps []Result) {
+	var maxP99 time.Duration
+	for _, st := range steps {
+		if p99 := st.duration(metricLatencyP99); p99 > maxP99 {
+			maxP99 = p99
+		}
+	}
+
+	loadLabel := "投入レート"
+	if steps[0].Params["loop"] == LoopClosed {
+		loadLabel = "クライアント数"
+	}
+	fmt.Fprintf(w, "%12s %12s %12s %12s %14s %12s %12s %12s %12s\n",
+		loadLabel, "スループット", "p50", "p99", "p99(実送信)", "待ちp50", "待ちp99", "処理p50", "処理p99")
+	for _, st := range steps {
+		p99 := st.duration(metricLatencyP99)
+		bar := 0
+		if maxP99 > 0 {
+			bar = int(float64(p99) / float64(maxP99) * 30)
+		}
+		mark := ""
+		if st.flag(metricSaturated) {
+			mark = " 飽和"
+		}
+		fmt.Fprintf(w, "%12.0f %12.0f %12v %12v %14v %12v %12v %12v %12v %s%s\n",
+			st.Metrics[metricLoad], st.Metrics[metricThroughput],
+			st.duration(metricLatencyP50), p99, st.duration(metricActualLatencyP99),
+			st.duration(metricWaitP50), st.duration(metricWaitP99),
+			st.duration(metricServiceP50), st.duration(metricServiceP99),
+			strings.Repeat("#", bar), mark)
+	}
+	fmt.Fprintln(w)
+}
+
+// 割り当て予算を満たした戦略と満たさなかった戦略を分けて表示する
+func printAllocBudget(w io.Writer, results []Result) {
+	var budget string
+	var met, exceeded []string
+	for _, r := range results {
+		b, ok := r.Params["alloc_budget"]
+		if !ok {
+			continue
+		}
+		budget = b
+		entry := fmt.Sprintf("%s（%.2f allocs/タスク）", r.Strategy, r.Metrics[metricAllocsPerTask])
+		if r.flag(metricAllocBudgetMet) {
+			met = append(met, entry)
+		} else {
+			exceeded = append(exceeded, entry)
+		}
+	}
+	if budget == "" {
+		return
+	}
+
+	fmt.Fprintf(w, "割り当て予算（%s allocs/タスク以内）の判定\n", budget)
+	for _, g := range []struct {
+		label   string
+		entries []string
+	}{{"達成", met}, {"超過", exceeded}} {
+		if len(g.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "  %s: %s\n", g.label, strings.Join(g.entries, ", "))
+	}
+	fmt.Fprintln(w)
+}
+
+// スイープの結果をタスク数ごとの表で表示する
+func printSweep(w io.Writer, results []Result) {
+	fmt.Fprintf(w, "%10s %14s %12s %14s %12s %8s\n", "タスク数", "処理時間", "ns/タスク", "allocs/タスク", "ピークRSS", "指数")
+	for _, r := range results {
+		exp, mark := "-", ""
+		if e, ok := r.Metrics[metricScalingExponent]; ok {
+			exp = fmt.Sprintf("%.2f", e)
+		}
+		if r.flag(metricSuperlinear) {
+			mark = " 超線形"
+		}
+		fmt.Fprintf(w, "%10.0f %14v %12.0f %14.2f %9.1f MB %8s%s\n",
+			r.Metrics[metricTasks], r.duration(metricWall), r.Metrics[metricNsPerTask],
+			r.Metrics[metricAllocsPerTask], r.Metrics[metricPeakRSS], exp, mark)
+	}
+	fmt.Fprintln(w)
+}
+
+// 整数の列をカンマ区切りで表示する
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ", ")
+}
+
+// コンソールに表示するgoroutineの生成元の数
+const goroutineSitesShown = 5
+
+// goroutineの生成元ごとのピーク時と完了後の数を、ピーク時の多い順に表示する
+func printGoroutineSites(w io.Writer, sites []GoroutineSite) {
+	fmt.Fprintln(w, "goroutineの生成元（ピーク時 / 完了後、実行前からあったものを除く）:")
+	for i, g := range sites {
+		if i == goroutineSitesShown {
+			fmt.Fprintf(w, "  ほか%d件\n", len(sites)-i)
+			break
+		}
+		fmt.Fprintf(w, "  %7d / %-7d %s\n", g.Peak, g.After, g.Site)
+	}
+}
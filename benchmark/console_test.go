@@ -0,0 +1,35 @@
+package benchmark
+
+import "testing"
+
+// 見出しのタスク数に、シナリオが決めたタスク数と-tasksの指定を反映することを確認する
+func TestScenarioTasks(t *testing.T) {
+	byName := map[string]Scenario{}
+	for _, sc := range scenarios {
+		byName[sc.Name] = sc
+	}
+	cfg := DefaultConfig()
+	tests := []struct {
+		name  string
+		tasks int
+		list  []string
+		want  string
+	}{
+		{"default", 0, []string{"dispatch"}, "100000"},
+		{"own count", 0, []string{"cancel"}, "2000"},
+		{"mixed", 0, []string{"dispatch", "cancel"}, "dispatch 100000、cancel 2000"},
+		{"flag", 500, []string{"dispatch", "cancel"}, "500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Tasks = tt.tasks
+			var list []Scenario
+			for _, name := range tt.list {
+				list = append(list, byName[name])
+			}
+			if got := scenarioTasks(cfg, list); got != tt.want {
+				t.Errorf("scenarioTasks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
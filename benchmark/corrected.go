@@ -0,0 +1,187 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+// チャネルを使用した実装の修正版：1つのディスパッチャーgoroutineを事前に起動。
+// 元の実装はタスクのエラーをログに出力するだけでerrgroupに返さないため、
+// errgroupのコンテキストがキャンセルされず、エラー発生後も全タスクの処理が続く。
+// 修正版ではエラーを返し、キャンセル後はディスパッチとチャネルへの送信を止める
+func ChannelWithUnlimitedParallelismCorrected(env Env) error {
+	tasks := make(chan Task, 100)
+	done := make(chan error, 1)
+
+	// errgroupを作成
+	eg, ctx := errgroup.WithContext(context.Background())
+
+	// ディスパッチャーgoroutineを一つ起動
+	go func() {
+		defer func() { done <- eg.Wait() }()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case task, ok := <-tasks:
+				if !ok {
+					return
+				}
+				eg.Go(func() error {
+					// キャンセル後に起動されたタスクは処理しない
+					if err := ctx.Err(); err != nil {
+						return err
+					}
+					return env.Process(task)
+				})
+			}
+		}
+	}()
+
+	// タスクをチャネルに送信（キャンセルされたら送信をやめる）
+	sendTasks(ctx, env.Source, tasks)
+	close(tasks)
+
+	// ディスパッチャーとすべてのタスク処理の終了を待つ
+	return <-done
+}
+
+// 複数のワーカーを使用するチャネル実装の修正版。
+// エラーを返してerrgroupのコンテキストをキャンセルし、semaphoreの取得に失敗した場合は
+// タスクを黙って読み飛ばすのではなくディスパッチを中止する
+func ChannelWithLimitedParallelismCorrected(env Env, numWorkers int) error {
+	tasks := make(chan Task, 100)
+	done := make(chan error, 1)
+
+	// errgroupを作成
+	eg, ctx := errgroup.WithContext(context.Background())
+
+	// semaphoreを作成して並列度を制限
+	sem := semaphore.NewWeighted(int64(numWorkers))
+
+	// ディスパッチャーgoroutineを一つ起動
+	go func() {
+		defer func() { done <- eg.Wait() }()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case task, ok := <-tasks:
+				if !ok {
+					return
+				}
+
+				// semaphoreの空きを待つ（キャンセルされたらディスパッチを中止する）
+				if err := sem.Acquire(ctx, 1); err != nil {
+					return
+				}
+
+				eg.Go(func() error {
+					defer sem.Release(1)
+					if err := ctx.Err(); err != nil {
+						return err
+					}
+					return env.Process(task)
+				})
+			}
+		}
+	}()
+
+	// タスクをチャネルに送信（キャンセルされたら送信をやめる）
+	sendTasks(ctx, env.Source, tasks)
+	close(tasks)
+
+	// ディスパッチャーとすべてのタスク処理の終了を待つ
+	return <-done
+}
+
+// 供給元のタスクをチャネルに送信する。コンテキストがキャンセルされたら送信をやめる
+func sendTasks(ctx context.Context, src Source, tasks chan<- Task) {
+	for {
+		task, ok := src.Next()
+		if !ok {
+			return
+		}
+		select {
+		case tasks <- task:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// errgroupの使い方の修正前後を比較するシナリオの戦略一覧
+func errgroupStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	return []Strategy{
+		{
+			Name:  "chan-unlimited",
+			Title: "チャネル + 無制限の並列処理（現行: エラーをログに出力するのみ）",
+			Func:  "ChannelWithUnlimitedParallelism",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleDispatcher, Goroutines: 1, From: chanFrom("Task", 100)},
+					{Role: RoleWorker, From: "errgroup.Go"},
+				},
+				Completion: "errgroup.Wait（エラーはログに出力するのみ）",
+			},
+			Run: func(env Env) error {
+				return ChannelWithUnlimitedParallelism(env, ChannelOptions{})
+			},
+		},
+		{
+			Name:  "chan-unlimited-corrected",
+			Title: "チャネル + 無制限の並列処理（修正版: エラーを返し、キャンセル時に送信を停止）",
+			Func:  "ChannelWithUnlimitedParallelismCorrected",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleDispatcher, Goroutines: 1, From: chanFrom("Task", 100), Note: "キャンセルされたら送信とディスパッチを停止"},
+					{Role: RoleWorker, From: "errgroup.Go"},
+				},
+				Completion: "errgroup.Wait（エラーでキャンセル）",
+			},
+			Run: ChannelWithUnlimitedParallelismCorrected,
+		},
+		{
+			Name:  "chan-limited",
+			Title: fmt.Sprintf("チャネル + 制限付き並列処理（現行: エラーをログに出力するのみ、%d同時実行）", numWorkers),
+			Func:  "ChannelWithLimitedParallelism",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleDispatcher, Goroutines: 1, From: chanFrom("Task", 100)},
+					{Role: RoleWorker, From: "errgroup.Go"},
+				},
+				Limiter:    fmt.Sprintf("semaphore.Weighted(%d)（ディスパッチャーが取得）", numWorkers),
+				Completion: "errgroup.Wait（エラーはログに出力するのみ）",
+			},
+			Run: func(env Env) error {
+				return ChannelWithLimitedParallelism(env, numWorkers, ChannelOptions{})
+			},
+		},
+		{
+			Name:  "chan-limited-corrected",
+			Title: fmt.Sprintf("チャネル + 制限付き並列処理（修正版: エラーを返し、キャンセル時に送信を停止、%d同時実行）", numWorkers),
+			Func:  "ChannelWithLimitedParallelismCorrected",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleDispatcher, Goroutines: 1, From: chanFrom("Task", 100), Note: "キャンセルされたら送信とディスパッチを停止"},
+					{Role: RoleWorker, From: "errgroup.Go"},
+				},
+				Limiter:    fmt.Sprintf("semaphore.Weighted(%d)（ディスパッチャーが取得）", numWorkers),
+				Completion: "errgroup.Wait（エラーでキャンセル）",
+			},
+			Run: func(env Env) error {
+				return ChannelWithLimitedParallelismCorrected(env, numWorkers)
+			},
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package benchmark
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// 現行の実装はエラーを握りつぶし、修正版はエラーを返すことを確認する
+func TestCorrectedVariantsPropagateErrors(t *testing.T) {
+	const n = 1000
+	const failAt = 10
+
+	for _, s := range errgroupStrategies(Params{Workers: 4}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var processed atomic.Int64
+			env := BatchEnv(n)
+			env.Process = injectFailure(env.Process, failAt, ErrorFatal, &processed)
+
+			err := s.Run(env)
+			if !strings.HasSuffix(s.Name, "-corrected") {
+				if err != nil {
+					t.Fatalf("current implementation returned %v, want nil (errors are only logged)", err)
+				}
+				if got := processed.Load(); got != n {
+					t.Errorf("processed = %d, want %d (no cancellation)", got, n)
+				}
+				return
+			}
+			if !errors.Is(err, errInjectedFailure) {
+				t.Fatalf("corrected implementation returned %v, want injected failure", err)
+			}
+		})
+	}
+}
+
+// 並列度を制限した修正版は、エラー発生後に残りのタスクをディスパッチしない
+func TestChannelWithLimitedParallelismCorrectedStopsDispatch(t *testing.T) {
+	const n = 1000
+
+	var processed atomic.Int64
+	env := BatchEnv(n)
+	env.Process = injectFailure(env.Process, 0, ErrorFatal, &processed)
+
+	if err := ChannelWithLimitedParallelismCorrected(env, 2); !errors.Is(err, errInjectedFailure) {
+		t.Fatalf("got %v, want injected failure", err)
+	}
+	if got := processed.Load(); got >= n {
+		t.Errorf("processed = %d, want fewer than %d after cancellation", got, n)
+	}
+}
@@ -0,0 +1,134 @@
+package benchmark
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 結果をメトリクスごとに1行のCSV（縦持ち）で書き出す。
+// メトリクスの種類がモードによって異なるため、列を固定できる縦持ちにしている
+type csvReporter struct {
+	w   io.WriteCloser
+	csv *csv.Writer
+}
+
+var csvHeader = []string{"schema_version", "time", "mode", "scenario", "strategy", "params", "metric", "value", "commit", "config_hash"}
+
+func newCSVReporter(target string, stdout io.Writer) (Reporter, error) {
+	w, err := createOutput(target, stdout)
+	if err != nil {
+		return nil, err
+	}
+	c := &csvReporter{w: w, csv: csv.NewWriter(w)}
+	if err := c.csv.Write(csvHeader); err != nil {
+		w.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *csvReporter) Report(r Result) error {
+	names := make([]string, 0, len(r.Metrics))
+	for name := range r.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err := c.csv.Write([]string{
+			strconv.Itoa(r.SchemaVersion),
+			r.Time.Format(time.RFC3339Nano),
+			r.Mode,
+			r.Scenario,
+			r.Strategy,
+			formatParams(r.Params),
+			name,
+			strconv.FormatFloat(r.Metrics[name], 'f', -1, 64),
+			r.Provenance.Commit,
+			r.Provenance.ConfigHash,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *csvReporter) Close() error {
+	c.csv.Flush()
+	err := c.csv.Error()
+	if closeErr := c.w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// パラメータを「key=value;…」の形式で表す
+func formatParams(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + params[k]
+	}
+	return strings.Join(pairs, ";")
+}
+
+// 戦略ごとに1行の要約CSV（横持ち）で書き出す。
+// 複数のマシンの結果をスプレッドシートで集計できるよう、列を処理時間とスループットに固定し、ホストとCPU数を含める。
+// 処理時間の中央値を記録しない結果（負荷ランプなど）は書き出さない
+type csvSummaryReporter struct {
+	w   io.WriteCloser
+	csv *csv.Writer
+}
+
+var csvSummaryHeader = []string{"time", "hostname", "num_cpu", "scenario", "strategy", "workers", "tasks", "duration_ns", "throughput_per_sec", "ns_per_task"}
+
+func newCSVSummaryReporter(target string, stdout io.Writer) (Reporter, error) {
+	w, err := createOutput(target, stdout)
+	if err != nil {
+		return nil, err
+	}
+	c := &csvSummaryReporter{w: w, csv: csv.NewWriter(w)}
+	if err := c.csv.Write(csvSummaryHeader); err != nil {
+		w.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *csvSummaryReporter) Report(r Result) error {
+	nsPerTask, throughput, ok := r.perTask()
+	if !ok {
+		return nil
+	}
+	return c.csv.Write([]string{
+		r.Time.Format(time.RFC3339Nano),
+		r.Environment.Hostname,
+		strconv.Itoa(r.Environment.NumCPU),
+		r.Scenario,
+		r.Strategy,
+		r.Params["workers"],
+		strconv.FormatFloat(r.Metrics[metricTasks], 'f', -1, 64),
+		strconv.FormatFloat(r.Metrics[metricWall], 'f', -1, 64),
+		strconv.FormatFloat(throughput, 'f', 1, 64),
+		strconv.FormatFloat(nsPerTask, 'f', 1, 64),
+	})
+}
+
+func (c *csvSummaryReporter) Close() error {
+	c.csv.Flush()
+	err := c.csv.Error()
+	if closeErr := c.w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
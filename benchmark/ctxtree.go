@@ -0,0 +1,195 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// コンテキストの木のシナリオの戦略一覧。
+// 長く続くリクエストのように、処理を終えた各goroutineが自分のコンテキストのキャンセルを待ち続ける状況で、
+// 全タスクの処理後にルートのコンテキストをキャンセルし、全てのgoroutineが終了するまでの時間（キャンセルの伝播）と
+// 子のコンテキストが使うメモリを比べる
+func contextTreeStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	perTask := func(ctx string) Topology {
+		return Topology{
+			Stages: []Stage{
+				producerStage,
+				{Role: RoleWorker, From: "go文", Note: ctx},
+			},
+			Completion: "ルートのキャンセル + sync.WaitGroup",
+		}
+	}
+	return []Strategy{
+		{
+			Name:     "ctx-per-task",
+			Title:    "タスクごとのgoroutine + タスクごとにcontext.WithCancelで子のコンテキストを作成",
+			Func:     "ContextPerTask",
+			Topology: perTask("タスクごとの子のコンテキストのキャンセルを待つ"),
+			Run:      ContextPerTask,
+		},
+		{
+			Name:     "ctx-pooled",
+			Title:    fmt.Sprintf("タスクごとのgoroutine + %d個の子のコンテキストを使い回す", numWorkers),
+			Func:     "PooledContexts",
+			Topology: perTask(fmt.Sprintf("%d個の子のコンテキストのいずれかのキャンセルを待つ", numWorkers)),
+			Run: func(env Env) error {
+				return PooledContexts(env, numWorkers)
+			},
+		},
+		{
+			Name:     "ctx-shared",
+			Title:    "タスクごとのgoroutine + 子を作らずルートのコンテキストを共有",
+			Func:     "SharedContext",
+			Topology: perTask("ルートのコンテキストのキャンセルを待つ"),
+			Run:      SharedContext,
+		},
+		{
+			Name:  "ctx-per-worker",
+			Title: fmt.Sprintf("ワーカープール（%dワーカー）+ ワーカーごとにcontext.WithCancelで子のコンテキストを作成", numWorkers),
+			Func:  "ContextPerWorker",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100), Note: "ワーカーごとの子のコンテキストのキャンセルを待つ"},
+				},
+				Completion: "ルートのキャンセル + sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return ContextPerWorker(env, numWorkers)
+			},
+		},
+	}
+}
+
+// タスクを処理するコンテキストを返す関数。返したCancelFuncはタスクの終了時に呼ばれる
+type contextFactory func(id int) (context.Context, context.CancelFunc)
+
+// タスクごとにcontext.WithCancelで子のコンテキストを作り、ルートの下に全タスク分の子がぶら下がる
+func ContextPerTask(env Env) error {
+	return contextTree(env, func(root context.Context) contextFactory {
+		return func(int) (context.Context, context.CancelFunc) {
+			return context.WithCancel(root)
+		}
+	})
+}
+
+// 事前に作った一定数の子のコンテキストを、タスクIDに応じて複数のタスクで使い回す
+func PooledContexts(env Env, size int) error {
+	return contextTree(env, func(root context.Context) contextFactory {
+		pool := make([]context.Context, size)
+		// 子はルートと一緒にキャンセルされるため、タスクの終了時には個別にキャンセルしない
+		cancels := make([]context.CancelFunc, size)
+		for i := range pool {
+			pool[i], cancels[i] = context.WithCancel(root)
+		}
+		return func(id int) (context.Context, context.CancelFunc) {
+			return pool[id%size], func() {}
+		}
+	})
+}
+
+// 子のコンテキストを作らず、全てのタスクがルートのコンテキストを共有する
+func SharedContext(env Env) error {
+	return contextTree(env, func(root context.Context) contextFactory {
+		return func(int) (context.Context, context.CancelFunc) {
+			return root, func() {}
+		}
+	})
+}
+
+// タスクごとにgoroutineを起動し、ルートからnewFactoryで作った関数のコンテキストでタスクを処理した後、
+// そのキャンセルを待たせる。全てのタスクの処理が終わったらルートのコンテキストをキャンセルし、
+// 全てのgoroutineが終了するまでの時間を記録する
+func contextTree(env Env, newFactory func(root context.Context) contextFactory) error {
+	root, cancelRoot := context.WithCancel(env.context())
+	defer cancelRoot()
+	newCtx := newFactory(root)
+
+	var processed, exited sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		ctx, cancel := newCtx(task.ID)
+		processed.Add(1)
+		exited.Add(1)
+		go func() {
+			defer exited.Done()
+			defer cancel()
+			err := env.Process(task)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+			processed.Done()
+			<-ctx.Done()
+		}()
+	}
+
+	processed.Wait()
+	teardown(env, cancelRoot, &exited)
+	return firstErr
+}
+
+// ワーカーごとにcontext.WithCancelで子のコンテキストを作り、ワーカーはタスクを処理し終えたらそのキャンセルを待つ
+func ContextPerWorker(env Env, numWorkers int) error {
+	root, cancelRoot := context.WithCancel(env.context())
+	defer cancelRoot()
+
+	tasks := make(chan Task, 100)
+	var processed, exited sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	for i := 0; i < numWorkers; i++ {
+		ctx, cancel := context.WithCancel(root)
+		processed.Add(1)
+		exited.Add(1)
+		go func() {
+			defer exited.Done()
+			defer cancel()
+			for task := range tasks {
+				if err := env.Process(task); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+			processed.Done()
+			<-ctx.Done()
+		}()
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks <- task
+	}
+	close(tasks)
+	processed.Wait()
+	teardown(env, cancelRoot, &exited)
+	return firstErr
+}
+
+// ルートのコンテキストをキャンセルし、全てのgoroutineが終了するまでの時間を記録する。
+// キャンセルの前に、プールを残したまま待機している間のメモリ使用量を記録する
+func teardown(env Env, cancelRoot context.CancelFunc, exited *sync.WaitGroup) {
+	env.idle()
+	start := time.Now()
+	cancelRoot()
+	exited.Wait()
+	env.tornDown(time.Since(start))
+}
@@ -0,0 +1,177 @@
+package benchmark
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// 重複排除シナリオで同じキーを持つ連続したタスクの数。
+// 同じキーのタスクがまとまって届く状況（キャッシュのスタンピードなど）を模擬する
+const dedupGroupSize = 100
+
+// タスクの重複排除に使うキー
+func dedupKey(task Task) int {
+	return task.ID / dedupGroupSize
+}
+
+// キーに対する処理。どのタスクが代表して処理しても処理時間が同じになるよう、
+// キーをIDとするタスクとして処理する
+func processKey(env Env, key int) error {
+	return env.Process(Task{ID: key, Data: "key " + strconv.Itoa(key)})
+}
+
+// 重複排除シナリオの戦略一覧。
+// 同じキーのタスクが処理中であれば、新たに処理せずにその結果を共有する
+func dedupStrategies(Params) []Strategy {
+	return []Strategy{
+		{
+			Name:  "dedup-none",
+			Title: "重複排除なし（直接goroutine起動、全タスクを処理）",
+			Func:  "dedupNone",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: dedupNone,
+		},
+		{
+			Name:  "dedup-dispatcher",
+			Title: "ディスパッチャーで重複排除（チャネル + 処理中のキーのマップ）",
+			Func:  "dedupInDispatcher",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleDispatcher, Goroutines: 1, From: chanFrom("Task", 100), Note: "処理中のキーのマップで重複を排除"},
+					{Role: RoleWorker, From: "go文", Note: "処理中でないキーのタスクのみ起動"},
+				},
+				Completion: "完了チャネルを処理中のキーがなくなるまで受信",
+			},
+			Run: dedupInDispatcher,
+		},
+		{
+			Name:  "dedup-singleflight",
+			Title: "処理時に重複排除（直接goroutine起動 + singleflight）",
+			Func:  "dedupWithSingleflight",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文", Note: "singleflightで同じキーの処理を共有"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: dedupWithSingleflight,
+		},
+	}
+}
+
+// タスクごとにgoroutineを起動し、重複しているかにかかわらず全てのタスクのキーを処理する
+func dedupNone(env Env) error {
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := processKey(env, dedupKey(task)); err != nil {
+				errOnce.Do(func() { firstErr = err })
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// 単一のディスパッチャーが処理中のキーを管理し、同じキーのタスクが処理中であれば
+// goroutineを起動せずに合流させる。マップはディスパッチャーだけが触るためロックは不要
+func dedupInDispatcher(env Env) error {
+	type completion struct {
+		key int
+		err error
+	}
+
+	ctx, cancel := context.WithCancel(env.context())
+	defer cancel()
+	tasks := make(chan Task, 100)
+	go func() {
+		sendTasks(ctx, env.Source, tasks)
+		close(tasks)
+	}()
+
+	completions := make(chan completion)
+	// 処理中のキーと、その結果を待っているタスク数
+	inflight := map[int]int{}
+	var firstErr error
+	for tasks != nil || len(inflight) > 0 {
+		select {
+		case task, ok := <-tasks:
+			if !ok {
+				tasks = nil
+				continue
+			}
+			key := dedupKey(task)
+			if _, ok := inflight[key]; ok {
+				inflight[key]++
+				continue
+			}
+			inflight[key] = 0
+			go func() {
+				completions <- completion{key: key, err: processKey(env, key)}
+			}()
+		case c := <-completions:
+			// 合流したタスクも同じ結果（エラー）を受け取る
+			env.dedup(inflight[c.key])
+			delete(inflight, c.key)
+			if c.err != nil && firstErr == nil {
+				firstErr = c.err
+				cancel()
+			}
+		}
+	}
+	return firstErr
+}
+
+// タスクごとにgoroutineを起動し、処理時にsingleflightで同じキーの処理をまとめる
+func dedupWithSingleflight(env Env) error {
+	var g singleflight.Group
+	var wg sync.WaitGroup
+	var tasks, executed atomic.Int64
+	var errOnce sync.Once
+	var firstErr error
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks.Add(1)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := dedupKey(task)
+			_, err, _ := g.Do(strconv.Itoa(key), func() (any, error) {
+				executed.Add(1)
+				return nil, processKey(env, key)
+			})
+			if err != nil {
+				errOnce.Do(func() { firstErr = err })
+			}
+		}()
+	}
+
+	wg.Wait()
+	env.dedup(int(tasks.Load() - executed.Load()))
+	return firstErr
+}
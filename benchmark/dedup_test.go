@@ -0,0 +1,40 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 処理したキーと重複排除したタスクの合計が全タスク数に一致し、エラーが返されることを確認する
+func TestDedupStrategies(t *testing.T) {
+	const n = 2000
+
+	for _, s := range dedupStrategies(Params{}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var executed atomic.Int64
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			env.Process = func(task Task) error {
+				executed.Add(1)
+				return processTask(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := executed.Load() + env.Stats.Deduplicated.Load(); got != n {
+				t.Errorf("executed + deduplicated = %d, want %d", got, n)
+			}
+			if s.Name != "dedup-none" && executed.Load() >= n {
+				t.Errorf("no tasks were deduplicated")
+			}
+
+			var processed atomic.Int64
+			env = BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
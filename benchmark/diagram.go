@@ -0,0 +1,125 @@
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 段のノードに表示するラベルの行
+func (st Stage) labelLines() []string {
+	lines := []string{st.Role.label(), "goroutine " + formatGoroutines(st.Goroutines)}
+	if st.Note != "" {
+		lines = append(lines, "（"+st.Note+"）")
+	}
+	return lines
+}
+
+// 構成をMermaidのフローチャートとして出力する
+func (t Topology) mermaid() string {
+	esc := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+	node := func(lines []string) string {
+		for i, l := range lines {
+			lines[i] = esc.Replace(l)
+		}
+		return strings.Join(lines, "<br/>")
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, st := range t.Stages {
+		fmt.Fprintf(&b, "  s%d[\"%s\"]\n", i, node(st.labelLines()))
+		if i > 0 {
+			fmt.Fprintf(&b, "  s%d -->|\"%s\"| s%d\n", i-1, esc.Replace(st.From), i)
+		}
+	}
+	last := len(t.Stages) - 1
+	if t.Limiter != "" {
+		fmt.Fprintf(&b, "  limiter{{\"%s\"}} -.- s%d\n", node([]string{"同時実行数の制限", t.Limiter}), last)
+	}
+	if t.Completion != "" {
+		fmt.Fprintf(&b, "  s%d -.-> done([\"%s\"])\n", last, node([]string{"完了待ち", t.Completion}))
+	}
+	return b.String()
+}
+
+// 構成をGraphvizのDOT形式の有向グラフとして出力する
+func (t Topology) dot(name string) string {
+	esc := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	label := func(lines []string) string {
+		for i, l := range lines {
+			lines[i] = esc.Replace(l)
+		}
+		return strings.Join(lines, `\n`)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph \"%s\" {\n", esc.Replace(name))
+	b.WriteString("  rankdir=LR;\n  node [shape=box];\n")
+	for i, st := range t.Stages {
+		fmt.Fprintf(&b, "  s%d [label=\"%s\"];\n", i, label(st.labelLines()))
+		if i > 0 {
+			fmt.Fprintf(&b, "  s%d -> s%d [label=\"%s\"];\n", i-1, i, esc.Replace(st.From))
+		}
+	}
+	last := len(t.Stages) - 1
+	if t.Limiter != "" {
+		fmt.Fprintf(&b, "  limiter [label=\"%s\", shape=hexagon, style=dashed];\n", label([]string{"同時実行数の制限", t.Limiter}))
+		fmt.Fprintf(&b, "  limiter -> s%d [style=dashed, arrowhead=none];\n", last)
+	}
+	if t.Completion != "" {
+		fmt.Fprintf(&b, "  done [label=\"%s\", shape=oval];\n", label([]string{"完了待ち", t.Completion}))
+		fmt.Fprintf(&b, "  s%d -> done [style=dashed];\n", last)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// シナリオの全戦略の構成図を出力する
+func printDiagrams(list []Scenario, format string) error {
+	p := defaultParams()
+	first := true
+	for _, sc := range list {
+		for _, s := range sc.Strategies(p) {
+			if len(s.Topology.Stages) == 0 {
+				continue
+			}
+			if !first {
+				fmt.Println()
+			}
+			first = false
+			name := sc.Name + "/" + s.Name
+			if format == FormatDOT {
+				fmt.Printf("// %s: %s\n%s", name, s.Title, s.Topology.dot(name))
+			} else {
+				fmt.Printf("%%%% %s: %s\n%s", name, s.Title, s.Topology.mermaid())
+			}
+		}
+	}
+	return nil
+}
+
+// レポートに埋め込む構成図
+type diagram struct {
+	Scenario, Strategy, Title string
+	Mermaid                   string
+}
+
+// 結果に含まれる戦略の構成図を、最初に現れた順に重複なく集める
+func resultDiagrams(results []Result) []diagram {
+	seen := map[string]bool{}
+	var diagrams []diagram
+	for _, r := range results {
+		key := r.Scenario + "/" + r.Strategy
+		if r.Topology == nil || seen[key] {
+			continue
+		}
+		seen[key] = true
+		diagrams = append(diagrams, diagram{
+			Scenario: r.Scenario,
+			Strategy: r.Strategy,
+			Title:    r.Title,
+			Mermaid:  r.Topology.mermaid(),
+		})
+	}
+	return diagrams
+}
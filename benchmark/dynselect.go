@@ -0,0 +1,253 @@
+package benchmark
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// 動的なselectのシナリオで、タスクを受け取るチャネルの数（実行時にしか分からない想定）
+const dynamicSelectSources = 64
+
+// ファンインの各チャネルのバッファ
+const fanInBuffer = 16
+
+// 固定のselect文で一度に待てるチャネルの数
+const staticSelectWidth = 8
+
+// 動的なselectのシナリオの戦略一覧。
+// 供給元のタスクを多数のチャネルに順に送り、実行時に決まる数のチャネルから1つのgoroutineで受信する仕組みだけが異なる。
+// 処理時間の待機をなくし、受信の仕組み自体の速さを比べる
+func dynamicSelectStrategies(p Params) []Strategy {
+	n := dynamicSelectSources
+	sources := Stage{Role: RoleProducer, Goroutines: 1, Note: fmt.Sprintf("%d個のチャネルに順に送信", n)}
+	list := []Strategy{
+		{
+			Name:  "select-reflect",
+			Title: fmt.Sprintf("%d個のチャネルをreflect.Selectで受信", n),
+			Func:  "ReflectSelectFanIn",
+			Topology: Topology{
+				Stages: []Stage{
+					sources,
+					{Role: RoleWorker, Goroutines: 1, From: "reflect.Select", Note: "閉じたチャネルは選択肢から外す"},
+				},
+				Completion: "全てのチャネルが閉じるまで受信",
+			},
+			Run: func(env Env) error {
+				return ReflectSelectFanIn(env, n)
+			},
+		},
+		{
+			Name:  "select-static",
+			Title: fmt.Sprintf("%d個のチャネルを%d個ずつ固定のselect文で受信し、木構造で合流", n, staticSelectWidth),
+			Func:  "StaticSelectFanIn",
+			Topology: Topology{
+				Stages: []Stage{
+					sources,
+					{Role: RoleDispatcher, Goroutines: staticSelectGoroutines(n), From: chanFrom("Task", fanInBuffer), Note: fmt.Sprintf("%d個ずつ固定のselect文で受信して合流", staticSelectWidth)},
+					{Role: RoleWorker, Goroutines: 1, From: fmt.Sprintf("固定のselect文（%d個のcase）", staticSelectWidth)},
+				},
+				Completion: "全てのチャネルが閉じるまで受信",
+			},
+			Run: func(env Env) error {
+				return StaticSelectFanIn(env, n)
+			},
+		},
+		{
+			Name:  "select-merged",
+			Title: fmt.Sprintf("%d個のチャネルをそれぞれgoroutineで1つのチャネルに合流して受信", n),
+			Func:  "MergedChannelFanIn",
+			Topology: Topology{
+				Stages: []Stage{
+					sources,
+					{Role: RoleDispatcher, Goroutines: n, From: chanFrom("Task", fanInBuffer), Note: "チャネルごとに1つ"},
+					{Role: RoleWorker, Goroutines: 1, From: chanFrom("Task", fanInBuffer)},
+				},
+				Completion: "合流チャネルが閉じるまで受信（全ての転送の終了後に閉じる）",
+			},
+			Run: func(env Env) error {
+				return MergedChannelFanIn(env, n)
+			},
+		},
+	}
+	for i, s := range list {
+		run := s.Run
+		list[i].Run = func(env Env) error {
+			env.Source = &instantSource{src: env.Source}
+			return run(env)
+		}
+	}
+	return list
+}
+
+// 供給元のタスクをn個のチャネルに順に送信するgoroutineを起動する。全て送り終えたら各チャネルを閉じる
+func spreadSource(src Source, n int) []<-chan Task {
+	chans := make([]chan Task, n)
+	out := make([]<-chan Task, n)
+	for i := range chans {
+		chans[i] = make(chan Task, fanInBuffer)
+		out[i] = chans[i]
+	}
+	go func() {
+		for i := 0; ; i++ {
+			task, ok := src.Next()
+			if !ok {
+				break
+			}
+			chans[i%n] <- task
+		}
+		for _, c := range chans {
+			close(c)
+		}
+	}()
+	return out
+}
+
+// 受信したタスクを呼び出し元のgoroutineで処理する。
+// 送信側を止めないよう、エラーの後も全てのタスクを受信して処理し、最初のエラーを返す
+func consume(env Env, recv func() (Task, bool)) error {
+	var firstErr error
+	for {
+		task, ok := recv()
+		if !ok {
+			return firstErr
+		}
+		if err := env.Process(task); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+}
+
+// 実行時に決まる数のチャネルを、reflect.Selectの選択肢に並べて受信する。
+// 閉じたチャネルは選択肢から外し、全て閉じたら終了する
+func ReflectSelectFanIn(env Env, n int) error {
+	chans := spreadSource(env.Source, n)
+	cases := make([]reflect.SelectCase, n)
+	for i, c := range chans {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)}
+	}
+	return consume(env, func() (Task, bool) {
+		for len(cases) > 0 {
+			chosen, v, ok := reflect.Select(cases)
+			if ok {
+				return v.Interface().(Task), true
+			}
+			cases[chosen] = cases[len(cases)-1]
+			cases = cases[:len(cases)-1]
+		}
+		return Task{}, false
+	})
+}
+
+// 実行時に決まる数のチャネルを、staticSelectWidth個のcaseを持つ固定のselect文で受信する。
+// 1つのselect文に収まらない場合は、グループごとにgoroutineで合流したチャネルを更に同じように受信する
+// （コード生成で固定のselect文を作る方法を、幅を固定した木構造で再現する）
+func StaticSelectFanIn(env Env, n int) error {
+	g := newSelectGroup(mergeStatic(spreadSource(env.Source, n)))
+	return consume(env, g.recv)
+}
+
+// 固定のselect文の木の合流に使うgoroutine数
+func staticSelectGoroutines(n int) int {
+	total := 0
+	for n > staticSelectWidth {
+		n = (n + staticSelectWidth - 1) / staticSelectWidth
+		total += n
+	}
+	return total
+}
+
+// チャネルがstaticSelectWidth個以下になるまで、グループごとにgoroutineで1つのチャネルに合流する
+func mergeStatic(chans []<-chan Task) []<-chan Task {
+	for len(chans) > staticSelectWidth {
+		var next []<-chan Task
+		for i := 0; i < len(chans); i += staticSelectWidth {
+			g := newSelectGroup(chans[i:min(i+staticSelectWidth, len(chans))])
+			out := make(chan Task, fanInBuffer)
+			go func() {
+				defer close(out)
+				for {
+					task, ok := g.recv()
+					if !ok {
+						return
+					}
+					out <- task
+				}
+			}()
+			next = append(next, out)
+		}
+		chans = next
+	}
+	return chans
+}
+
+// 固定のselect文で受信するチャネルの組。使わない枠と閉じたチャネルはnilにして選ばれないようにする
+type selectGroup struct {
+	chans [staticSelectWidth]<-chan Task
+	open  int
+}
+
+func newSelectGroup(chans []<-chan Task) *selectGroup {
+	g := &selectGroup{open: len(chans)}
+	copy(g.chans[:], chans)
+	return g
+}
+
+// いずれかのチャネルからタスクを受信する。全てのチャネルが閉じたらfalseを返す
+func (g *selectGroup) recv() (Task, bool) {
+	c := &g.chans
+	for g.open > 0 {
+		var task Task
+		var ok bool
+		var i int
+		select {
+		case task, ok = <-c[0]:
+			i = 0
+		case task, ok = <-c[1]:
+			i = 1
+		case task, ok = <-c[2]:
+			i = 2
+		case task, ok = <-c[3]:
+			i = 3
+		case task, ok = <-c[4]:
+			i = 4
+		case task, ok = <-c[5]:
+			i = 5
+		case task, ok = <-c[6]:
+			i = 6
+		case task, ok = <-c[7]:
+			i = 7
+		}
+		if ok {
+			return task, true
+		}
+		c[i] = nil
+		g.open--
+	}
+	return Task{}, false
+}
+
+// 実行時に決まる数のチャネルを、それぞれgoroutineで1つのチャネルに転送して合流し、そのチャネルから受信する
+func MergedChannelFanIn(env Env, n int) error {
+	return consumeMerged(env, mergeChannels(spreadSource(env.Source, n)))
+}
+
+// チャネルごとにgoroutineを起動して1つのチャネルに転送する。全ての転送が終わったら合流したチャネルを閉じる
+func mergeChannels(chans []<-chan Task) <-chan Task {
+	merged := make(chan Task, fanInBuffer)
+	var wg sync.WaitGroup
+	for _, c := range chans {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range c {
+				merged <- task
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+	return merged
+}
@@ -0,0 +1,50 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 全てのチャネルから全てのタスクを1回ずつ受信し、エラーが返されることを確認する
+func TestDynamicSelectStrategies(t *testing.T) {
+	const n = 5000
+
+	for _, s := range dynamicSelectStrategies(defaultParams()) {
+		t.Run(s.Name, func(t *testing.T) {
+			seen := make([]bool, n)
+			env := BatchEnv(n)
+			env.Process = func(task Task) error {
+				if seen[task.ID] {
+					t.Errorf("task %d received twice", task.ID)
+				}
+				seen[task.ID] = true
+				return nil
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			for id, ok := range seen {
+				if !ok {
+					t.Fatalf("task %d was not received", id)
+				}
+			}
+
+			var processed atomic.Int64
+			env = BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
+
+// 固定のselect文の木が、幅を超える数のチャネルを段に分けて合流することを確認する
+func TestStaticSelectGoroutines(t *testing.T) {
+	for n, want := range map[int]int{1: 0, 8: 0, 9: 2, 64: 8, 65: 9 + 2, 512: 64 + 8} {
+		if got := staticSelectGoroutines(n); got != want {
+			t.Errorf("staticSelectGoroutines(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// タスクのエラーの分類。処理時間だけでなく、各戦略がどの種類のエラーをどれだけ返したかを比べるために使う
+type ErrorKind string
+
+const (
+	// 再試行すれば成功しうる一時的なエラー
+	ErrorTransient ErrorKind = "transient"
+	// 再試行しても成功しないエラー（分類されていないエラーもこれに含める）
+	ErrorFatal ErrorKind = "fatal"
+	// キャンセルによって処理を中断したエラー
+	ErrorCancelled ErrorKind = "cancelled"
+	// 期限を過ぎて処理を中断したエラー
+	ErrorTimeout ErrorKind = "timeout"
+)
+
+// 全てのエラーの分類（結果の表示順）
+var errorKinds = []ErrorKind{ErrorTransient, ErrorFatal, ErrorCancelled, ErrorTimeout}
+
+// 名前からエラーの分類を求める
+func ParseErrorKind(s string) (ErrorKind, error) {
+	for _, k := range errorKinds {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+	names := make([]string, len(errorKinds))
+	for i, k := range errorKinds {
+		names[i] = string(k)
+	}
+	return "", fmt.Errorf("unknown error kind %q (available: %s)", s, strings.Join(names, ", "))
+}
+
+// エラーの分類ごとの件数を記録するメトリクス名
+func (k ErrorKind) metric() string {
+	return "errors_" + string(k)
+}
+
+// 分類を付けたタスクのエラー。ワークロードが返し、ハーネスが分類ごとに数える
+type TaskError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *TaskError) Error() string {
+	return string(e.Kind) + ": " + e.Err.Error()
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// エラーを分類する。TaskErrorはその分類を、コンテキストのキャンセルと期限切れはそれぞれの分類を、
+// それ以外は致命的なエラーとして扱う
+func classifyError(err error) ErrorKind {
+	var te *TaskError
+	switch {
+	case errors.As(err, &te):
+		return te.Kind
+	case errors.Is(err, context.Canceled):
+		return ErrorCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorTimeout
+	}
+	return ErrorFatal
+}
+
+// エラーの分類ごとの件数（errorKindsの順）
+type errorCounts [4]atomic.Int64
+
+// エラーを分類して数える
+func (c *errorCounts) add(err error) {
+	kind := classifyError(err)
+	for i, k := range errorKinds {
+		if k == kind {
+			c[i].Add(1)
+			return
+		}
+	}
+}
+
+// 分類ごとの件数をtotalsに加える
+func (c *errorCounts) addTo(totals map[ErrorKind]int64) {
+	for i, k := range errorKinds {
+		totals[k] += c[i].Load()
+	}
+}
+
+// 処理関数が返したエラーを分類して数えるよう処理関数をラップする
+func countErrors(env Env) Env {
+	if env.Stats == nil {
+		return env
+	}
+	process := env.Process
+	env.Process = func(task Task) error {
+		err := process(task)
+		if err != nil {
+			env.Stats.Errors.add(err)
+		}
+		return err
+	}
+	return env
+}
@@ -0,0 +1,62 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want ErrorKind
+	}{
+		{err: &TaskError{Kind: ErrorTransient, Err: errors.New("busy")}, want: ErrorTransient},
+		{err: fmt.Errorf("wrapped: %w", &TaskError{Kind: ErrorTimeout, Err: errInjectedFailure}), want: ErrorTimeout},
+		{err: fmt.Errorf("task 1: %w", context.Canceled), want: ErrorCancelled},
+		{err: context.DeadlineExceeded, want: ErrorTimeout},
+		{err: errors.New("unknown"), want: ErrorFatal},
+	}
+	for _, c := range cases {
+		if got := classifyError(c.err); got != c.want {
+			t.Errorf("classifyError(%v) = %s, want %s", c.err, got, c.want)
+		}
+	}
+	if _, err := ParseErrorKind("flaky"); err == nil {
+		t.Error("ParseErrorKind accepted an unknown kind")
+	}
+}
+
+// 処理関数が返したエラーが分類ごとに数えられることを確認する
+func TestCountErrors(t *testing.T) {
+	const n = 100
+
+	var processed atomic.Int64
+	env := BatchEnv(n)
+	env.Stats = &RunStats{}
+	env.Process = injectFailure(func(task Task) error {
+		if task.ID%10 == 0 {
+			return context.Canceled
+		}
+		return nil
+	}, 5, ErrorTransient, &processed)
+	env = countErrors(env)
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		env.Process(task)
+	}
+
+	totals := map[ErrorKind]int64{}
+	env.Stats.Errors.addTo(totals)
+	want := map[ErrorKind]int64{ErrorTransient: 1, ErrorFatal: 0, ErrorCancelled: 10, ErrorTimeout: 0}
+	for k, w := range want {
+		if totals[k] != w {
+			t.Errorf("%s errors = %d, want %d", k, totals[k], w)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package benchmark
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// コンパイラの -m 出力の1行（例: ./benchmark.go:52:11: func literal escapes to heap）
+var escapeLinePattern = regexp.MustCompile(`^(.+\.go):(\d+):\d+: (.*)$`)
+
+// エスケープ解析の結果の1件
+type escapeDiag struct {
+	File    string
+	Line    int
+	Message string
+}
+
+// ソースファイル中の関数の範囲
+type funcRange struct {
+	Name       string
+	File       string
+	Start, End int
+}
+
+// コンパイラのエスケープ解析（-gcflags=-m）を実行し、
+// 戦略ごとにヒープへエスケープする値（タスク、クロージャなど）を集計して表示する
+func RunEscapeAnalysis() error {
+	pkgPath := reflect.TypeOf(Task{}).PkgPath()
+	dir, err := packageDir(pkgPath)
+	if err != nil {
+		return err
+	}
+
+	diags, err := escapeDiagnostics(dir)
+	if err != nil {
+		return err
+	}
+	funcs, err := parseFuncRanges(dir)
+	if err != nil {
+		return err
+	}
+
+	// 関数ごとに診断結果をまとめる
+	byFunc := map[string][]escapeDiag{}
+	for _, d := range diags {
+		if name := enclosingFunc(funcs, d); name != "" {
+			byFunc[name] = append(byFunc[name], d)
+		}
+	}
+
+	fmt.Printf("エスケープ解析（go build -gcflags=-m %s）\n\n", pkgPath)
+	i := 0
+	for _, sc := range scenarios {
+		for _, s := range sc.Strategies(defaultParams()) {
+			i++
+			printEscapes(i, s, byFunc[s.Func])
+		}
+	}
+	return nil
+}
+
+// 戦略の実装関数で見つかったエスケープを表示する
+func printEscapes(i int, s Strategy, found []escapeDiag) {
+	fmt.Printf("%d. %s（%s）\n", i, s.Title, s.Func)
+	fmt.Printf("ヒープへのエスケープ: %d件\n", len(found))
+	for _, d := range found {
+		fmt.Printf("  %s:%d: %s\n", filepath.Base(d.File), d.Line, d.Message)
+	}
+	fmt.Println()
+}
+
+// パッケージのソースディレクトリを求める
+func packageDir(pkgPath string) (string, error) {
+	out, err := exec.Command("go", "list", "-f", "{{.Dir}}", pkgPath).Output()
+	if err != nil {
+		return "", fmt.Errorf("go list %s: %w", pkgPath, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// パッケージを -gcflags=-m 付きでビルドし、ヒープへのエスケープに関する診断を取り出す
+func escapeDiagnostics(dir string) ([]escapeDiag, error) {
+	cmd := exec.Command("go", "build", "-gcflags=-m", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("go build -gcflags=-m: %w\n%s", err, out)
+	}
+
+	var diags []escapeDiag
+	seen := map[escapeDiag]bool{}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		m := escapeLinePattern.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		msg := m[3]
+		if !strings.Contains(msg, "escapes to heap") && !strings.HasPrefix(msg, "moved to heap") {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		file := m[1]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		d := escapeDiag{File: file, Line: line, Message: msg}
+		// インライン展開により同じ診断が複数回出力されることがある
+		if seen[d] {
+			continue
+		}
+		seen[d] = true
+		diags = append(diags, d)
+	}
+	sort.Slice(diags, func(i, j int) bool {
+		if diags[i].File != diags[j].File {
+			return diags[i].File < diags[j].File
+		}
+		return diags[i].Line < diags[j].Line
+	})
+	return diags, sc.Err()
+}
+
+// パッケージ内（テストを除く）のトップレベル関数の範囲を求める
+func parseFuncRanges(dir string) ([]funcRange, error) {
+	fset := token.NewFileSet()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []funcRange
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			ranges = append(ranges, funcRange{
+				Name:  fn.Name.Name,
+				File:  path,
+				Start: fset.Position(fn.Pos()).Line,
+				End:   fset.Position(fn.End()).Line,
+			})
+		}
+	}
+	return ranges, nil
+}
+
+// 診断が含まれる関数の名前を返す
+func enclosingFunc(funcs []funcRange, d escapeDiag) string {
+	for _, f := range funcs {
+		if f.File == d.File && f.Start <= d.Line && d.Line <= f.End {
+			return f.Name
+		}
+	}
+	return ""
+}
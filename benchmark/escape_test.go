@@ -0,0 +1,77 @@
+package benchmark
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 全ての戦略のFuncが、処理の本体を持つ関数を指していることを確認する。
+// 別の関数を呼び出すだけの関数を指していると、エスケープ解析がその戦略の診断を1件も数えない
+func TestStrategyFuncs(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	decls := map[string]*ast.FuncDecl{}
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+				decls[fn.Name.Name] = fn
+			}
+		}
+	}
+
+	for _, sc := range scenarios {
+		for _, s := range sc.Strategies(defaultParams()) {
+			if s.Func == "" {
+				continue
+			}
+			fn, ok := decls[s.Func]
+			if !ok {
+				t.Errorf("%s/%s: Func %s is not declared", sc.Name, s.Name, s.Func)
+				continue
+			}
+			if callee := forwardsTo(fn, decls); callee != "" {
+				t.Errorf("%s/%s: Func %s only forwards to %s", sc.Name, s.Name, s.Func, callee)
+			}
+		}
+	}
+}
+
+// 関数の本体がパッケージの別の関数に引数を渡して呼び出した結果を返すだけの場合、その関数名を返す
+func forwardsTo(fn *ast.FuncDecl, decls map[string]*ast.FuncDecl) string {
+	if fn.Body == nil || len(fn.Body.List) != 1 {
+		return ""
+	}
+	ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return ""
+	}
+	call, ok := ret.Results[0].(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	// 関数リテラルや別の呼び出しを渡す場合は、その関数に固有の処理がある
+	for _, arg := range call.Args {
+		switch arg.(type) {
+		case *ast.FuncLit, *ast.CallExpr:
+			return ""
+		}
+	}
+	if id, ok := call.Fun.(*ast.Ident); ok && decls[id.Name] != nil {
+		return id.Name
+	}
+	return ""
+}
@@ -0,0 +1,22 @@
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// 故障注入で発生させるエラー
+var errInjectedFailure = errors.New("injected failure")
+
+// 指定したIDのタスクで、指定した分類のエラーを返すよう処理関数をラップする。
+// 実際に処理されたタスク数をprocessedに数える
+func injectFailure(process func(Task) error, failAt int, kind ErrorKind, processed *atomic.Int64) func(Task) error {
+	return func(task Task) error {
+		processed.Add(1)
+		if task.ID == failAt {
+			return &TaskError{Kind: kind, Err: fmt.Errorf("task %d: %w", task.ID, errInjectedFailure)}
+		}
+		return process(task)
+	}
+}
@@ -0,0 +1,104 @@
+package benchmark
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ファンインのシナリオで比べるチャネル数
+var fanInSources = []int{4, 64, 1024}
+
+// ファンインのシナリオの戦略一覧。
+// 供給元のタスクをN個のチャネルに順に送り、1つのストリーム（チャネル）に合流してから処理する。
+// 合流の仕組みごとに、チャネル数が増えたときのスループットとgoroutineのコストを比べる。
+// 処理時間の待機はなくし、合流の仕組み自体の速さを比べる
+func fanInStrategies(p Params) []Strategy {
+	var list []Strategy
+	for _, n := range fanInSources {
+		sources := Stage{Role: RoleProducer, Goroutines: 1, Note: fmt.Sprintf("%d個のチャネルに順に送信", n)}
+		list = append(list,
+			Strategy{
+				Name:  fmt.Sprintf("fanin-goroutines-%d", n),
+				Title: fmt.Sprintf("%d個のチャネルをチャネルごとのgoroutineで合流チャネルに転送", n),
+				Func:  "GoroutinePerSourceMerge",
+				Topology: Topology{
+					Stages: []Stage{
+						sources,
+						{Role: RoleDispatcher, Goroutines: n, From: chanFrom("Task", fanInBuffer), Note: "チャネルごとに1つ"},
+						{Role: RoleWorker, Goroutines: 1, From: chanFrom("Task", fanInBuffer)},
+					},
+					Completion: "合流チャネルが閉じるまで受信（全ての転送の終了後に閉じる）",
+				},
+				Run: func(env Env) error {
+					return GoroutinePerSourceMerge(env, n)
+				},
+			},
+			Strategy{
+				Name:  fmt.Sprintf("fanin-select-%d", n),
+				Title: fmt.Sprintf("%d個のチャネルを単一のgoroutineのselectループ（reflect.Select）で合流チャネルに転送", n),
+				Func:  "SelectLoopMerge",
+				Topology: Topology{
+					Stages: []Stage{
+						sources,
+						{Role: RoleDispatcher, Goroutines: 1, From: chanFrom("Task", fanInBuffer), Note: "reflect.Selectで全てのチャネルから受信"},
+						{Role: RoleWorker, Goroutines: 1, From: chanFrom("Task", fanInBuffer)},
+					},
+					Completion: "合流チャネルが閉じるまで受信（全てのチャネルが閉じた後に閉じる）",
+				},
+				Run: func(env Env) error {
+					return SelectLoopMerge(env, n)
+				},
+			},
+		)
+	}
+	for i, s := range list {
+		run := s.Run
+		list[i].Run = func(env Env) error {
+			env.Source = &instantSource{src: env.Source}
+			return run(env)
+		}
+	}
+	return list
+}
+
+// N個のチャネルを、チャネルごとに起動したgoroutineで1つのチャネルに合流して処理する
+func GoroutinePerSourceMerge(env Env, n int) error {
+	return consumeMerged(env, mergeChannels(spreadSource(env.Source, n)))
+}
+
+// N個のチャネルを、単一のgoroutineがreflect.Selectで受信して1つのチャネルに合流して処理する。
+// チャネル数によらずgoroutineは1つで済むが、受信のたびに全てのチャネルを選択肢に並べる
+func SelectLoopMerge(env Env, n int) error {
+	return consumeMerged(env, selectMerge(spreadSource(env.Source, n)))
+}
+
+// 合流したチャネルから受信したタスクを処理する
+func consumeMerged(env Env, merged <-chan Task) error {
+	return consume(env, func() (Task, bool) {
+		task, ok := <-merged
+		return task, ok
+	})
+}
+
+// 単一のgoroutineでreflect.Selectを繰り返し、全てのチャネルのタスクを1つのチャネルに転送する。
+// 閉じたチャネルは選択肢から外し、全て閉じたら合流したチャネルを閉じる
+func selectMerge(chans []<-chan Task) <-chan Task {
+	merged := make(chan Task, fanInBuffer)
+	cases := make([]reflect.SelectCase, len(chans))
+	for i, c := range chans {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)}
+	}
+	go func() {
+		defer close(merged)
+		for len(cases) > 0 {
+			chosen, v, ok := reflect.Select(cases)
+			if !ok {
+				cases[chosen] = cases[len(cases)-1]
+				cases = cases[:len(cases)-1]
+				continue
+			}
+			merged <- v.Interface().(Task)
+		}
+	}()
+	return merged
+}
@@ -0,0 +1,41 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 合流したストリームで全てのタスクを1回ずつ受信し、エラーが返されることを確認する
+func TestFanInStrategies(t *testing.T) {
+	const n = 2000
+
+	for _, s := range fanInStrategies(defaultParams()) {
+		t.Run(s.Name, func(t *testing.T) {
+			seen := make([]bool, n)
+			env := BatchEnv(n)
+			env.Process = func(task Task) error {
+				if seen[task.ID] {
+					t.Errorf("task %d received twice", task.ID)
+				}
+				seen[task.ID] = true
+				return nil
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			for id, ok := range seen {
+				if !ok {
+					t.Fatalf("task %d was not received", id)
+				}
+			}
+
+			var processed atomic.Int64
+			env = BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
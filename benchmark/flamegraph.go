@@ -0,0 +1,160 @@
+package benchmark
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/google/pprof/profile"
+)
+
+const (
+	flameWidth       = 1200
+	flameFrameHeight = 16
+	flameCharWidth   = 7
+	// これより狭いフレームは描画しない
+	flameMinWidth = 0.1
+)
+
+// フレームグラフのノード（サンプルのスタックを呼び出し元から順に木構造にしたもの）
+type flameNode struct {
+	name     string
+	value    int64
+	children map[string]*flameNode
+}
+
+func newFlameNode(name string) *flameNode {
+	return &flameNode{name: name, children: map[string]*flameNode{}}
+}
+
+// プロファイルのサンプルをスタックごとに集約する。値は最後のサンプル種別（CPUプロファイルではナノ秒）を使う
+func collapseProfile(p *profile.Profile) *flameNode {
+	root := newFlameNode("all")
+	valueIdx := len(p.SampleType) - 1
+	for _, s := range p.Sample {
+		v := s.Value[valueIdx]
+		root.value += v
+
+		// Sample.Locationは末端（呼び出し先）から並んでいるため逆順にたどる。
+		// インライン展開された関数はLine内で呼び出し先から並んでいる
+		node := root
+		for i := len(s.Location) - 1; i >= 0; i-- {
+			lines := s.Location[i].Line
+			for j := len(lines) - 1; j >= 0; j-- {
+				name := "?"
+				if lines[j].Function != nil {
+					name = lines[j].Function.Name
+				}
+				child, ok := node.children[name]
+				if !ok {
+					child = newFlameNode(name)
+					node.children[name] = child
+				}
+				child.value += v
+				node = child
+			}
+		}
+	}
+	return root
+}
+
+// フレームグラフの最大の深さを求める
+func (n *flameNode) depth() int {
+	d := 0
+	for _, c := range n.children {
+		if cd := c.depth(); cd > d {
+			d = cd
+		}
+	}
+	return d + 1
+}
+
+// フレームグラフをSVGとして描画する
+func renderFlamegraph(w io.Writer, root *flameNode, title string) error {
+	bw := bufio.NewWriter(w)
+	height := (root.depth()+2)*flameFrameHeight + 10
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="12">`+"\n", flameWidth, height)
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="#f8f8f8"/>`+"\n")
+	fmt.Fprintf(bw, `<text x="%d" y="16" text-anchor="middle" font-size="14">%s</text>`+"\n", flameWidth/2, html.EscapeString(title))
+	if root.value > 0 {
+		renderFlameNode(bw, root, root.value, 0, float64(flameWidth), height-flameFrameHeight)
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// ノードとその子孫を描画する（呼び出し元を下に、呼び出し先を上に積み上げる）
+func renderFlameNode(w io.Writer, n *flameNode, total int64, x, width float64, y int) {
+	if width < flameMinWidth {
+		return
+	}
+
+	ratio := float64(n.value) / float64(total) * 100
+	name := html.EscapeString(n.name)
+	fmt.Fprintf(w, `<g><title>%s (%.2f%%)</title>`, name, ratio)
+	fmt.Fprintf(w, `<rect x="%.2f" y="%d" width="%.2f" height="%d" fill="%s" stroke="#fff" stroke-width="0.5"/>`,
+		x, y, width, flameFrameHeight-1, flameColor(n.name))
+	if maxChars := int(width / flameCharWidth); maxChars >= 3 {
+		label := n.name
+		if len(label) > maxChars {
+			label = label[:maxChars-2] + ".."
+		}
+		fmt.Fprintf(w, `<text x="%.2f" y="%d">%s</text>`, x+3, y+flameFrameHeight-4, html.EscapeString(label))
+	}
+	fmt.Fprintln(w, "</g>")
+
+	// 子ノードは名前順に並べる（フレームグラフの慣例に合わせ、出力を安定させるため）
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cx := x
+	for _, name := range names {
+		c := n.children[name]
+		cw := width * float64(c.value) / float64(n.value)
+		renderFlameNode(w, c, total, cx, cw, y-flameFrameHeight)
+		cx += cw
+	}
+}
+
+// 関数名から暖色系の色を決める（同じ関数は常に同じ色になる）
+func flameColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	v := h.Sum32()
+	r := 205 + v%50
+	g := (v >> 8) % 230
+	b := (v >> 16) % 55
+	return fmt.Sprintf("rgb(%d,%d,%d)", r, g, b)
+}
+
+// CPUプロファイルを読み込み、フレームグラフのSVGを書き出す
+func writeFlamegraph(profilePath, svgPath, title string) error {
+	f, err := os.Open(profilePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	p, err := profile.Parse(f)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", profilePath, err)
+	}
+
+	out, err := os.Create(svgPath)
+	if err != nil {
+		return err
+	}
+	if err := renderFlamegraph(out, collapseProfile(p), title); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,55 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+func TestCollapseProfile(t *testing.T) {
+	fnMain := &profile.Function{ID: 1, Name: "main.main"}
+	fnSend := &profile.Function{ID: 2, Name: "runtime.chansend"}
+	fnSleep := &profile.Function{ID: 3, Name: "time.Sleep"}
+	locMain := &profile.Location{ID: 1, Line: []profile.Line{{Function: fnMain}}}
+	locSend := &profile.Location{ID: 2, Line: []profile.Line{{Function: fnSend}}}
+	locSleep := &profile.Location{ID: 3, Line: []profile.Line{{Function: fnSleep}}}
+
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}},
+		Sample: []*profile.Sample{
+			{Location: []*profile.Location{locSend, locMain}, Value: []int64{1, 30}},
+			{Location: []*profile.Location{locSleep, locMain}, Value: []int64{1, 10}},
+			{Location: []*profile.Location{locSend, locMain}, Value: []int64{1, 20}},
+		},
+	}
+
+	root := collapseProfile(p)
+	if root.value != 60 {
+		t.Fatalf("root value = %d, want 60", root.value)
+	}
+	mainNode := root.children["main.main"]
+	if mainNode == nil || mainNode.value != 60 {
+		t.Fatalf("main.main node = %+v, want value 60", mainNode)
+	}
+	if got := mainNode.children["runtime.chansend"].value; got != 50 {
+		t.Errorf("runtime.chansend value = %d, want 50", got)
+	}
+	if got := mainNode.children["time.Sleep"].value; got != 10 {
+		t.Errorf("time.Sleep value = %d, want 10", got)
+	}
+	if got := root.depth(); got != 3 {
+		t.Errorf("depth = %d, want 3", got)
+	}
+
+	var sb strings.Builder
+	if err := renderFlamegraph(&sb, root, "chan-unlimited <cpu>"); err != nil {
+		t.Fatal(err)
+	}
+	svg := sb.String()
+	for _, want := range []string{"<svg", "runtime.chansend", "chan-unlimited &lt;cpu&gt;", "</svg>"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("svg does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package benchmark
+
+import (
+	"runtime/debug"
+	"runtime/metrics"
+)
+
+// GCを止めて実行する場合の、ヒープの上限のデフォルト（MB）
+const defaultGCOffLimitMB = 2048
+
+// 完了したGCの累計を表すランタイムのメトリクス
+const gcCyclesMetric = "/gc/cycles/total:gc-cycles"
+
+// これまでに完了したGCの回数を読み取る
+func readGCCycles() uint64 {
+	s := []metrics.Sample{{Name: gcCyclesMetric}}
+	metrics.Read(s)
+	if s[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return s[0].Value.Uint64()
+}
+
+// GCを止め（GOGC=offと同じ）、ヒープがlimitバイトに近づいた場合だけGCを動かす（GOMEMLIMITと同じ）。
+// 戻り値の関数で元の設定に戻す。各回の実行の前のdebug.FreeOSMemoryによるGCは止めないため、
+// 前の回に確保したメモリは次の回に持ち越さない
+func disableGC(limit int64) func() {
+	percent := debug.SetGCPercent(-1)
+	prevLimit := debug.SetMemoryLimit(limit)
+	return func() {
+		debug.SetMemoryLimit(prevLimit)
+		debug.SetGCPercent(percent)
+	}
+}
+
+// GCを止めて実行する戦略。プロファイルなどの成果物がGCありの実行のものを上書きしないよう、戦略名に-gc-offを付ける
+func gcOffStrategy(s Strategy) Strategy {
+	s.Name += "-gc-off"
+	s.Title += "（GCなし）"
+	return s
+}
+
+// GCを止めて戦略を繰り返し実行し、GCを動かした結果（on）と並べられるよう、GCありの処理時間を結果に加える
+func (r *runner) runGCOff(s Strategy, on Result) (Result, error) {
+	restore := disableGC(int64(r.cfg.GCOffLimitMB) << 20)
+	res, err := r.runProfiled(s)
+	restore()
+	if err != nil {
+		return Result{}, err
+	}
+	res.Params["gc"] = "off"
+	res.Params["gc_memory_limit_mb"] = itoa(int(r.cfg.GCOffLimitMB))
+	res.Metrics[metricGCOnWall] = on.Metrics[metricWall]
+	return res, nil
+}
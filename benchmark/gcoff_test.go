@@ -0,0 +1,67 @@
+package benchmark
+
+import (
+	"context"
+	"runtime/debug"
+	"testing"
+)
+
+// GCを止めている間はGCの目標が無効になり、元の設定に戻せることを確認する
+func TestDisableGC(t *testing.T) {
+	percent := debug.SetGCPercent(100)
+	defer debug.SetGCPercent(percent)
+
+	restore := disableGC(1 << 30)
+	if got := debug.SetGCPercent(-1); got != -1 {
+		t.Errorf("GC percent while disabled = %d, want -1", got)
+	}
+	if got := debug.SetMemoryLimit(-1); got != 1<<30 {
+		t.Errorf("memory limit while disabled = %d, want %d", got, 1<<30)
+	}
+	restore()
+	if got := debug.SetGCPercent(-1); got != 100 {
+		t.Errorf("GC percent after restore = %d, want 100", got)
+	}
+	debug.SetGCPercent(100)
+}
+
+// GCを止めて戦略を実行し、GCありの処理時間を結果に加え、実行後にGCを戻すことを確認する
+func TestRunGCOff(t *testing.T) {
+	var percent int
+	s := Strategy{Name: "probe", Tasks: 1, Run: func(env Env) error {
+		percent = debug.SetGCPercent(-1)
+		debug.SetGCPercent(percent)
+		return nil
+	}}
+	cfg := DefaultConfig()
+	cfg.GCOff = true
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}}
+	on, err := r.runRepeated(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if percent == -1 {
+		t.Fatal("GC was disabled for the GC-on run")
+	}
+	off, err := r.runGCOff(gcOffStrategy(s), on)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if percent != -1 {
+		t.Errorf("GC percent during the GC-off run = %d, want -1", percent)
+	}
+	if p := debug.SetGCPercent(-1); p == -1 {
+		t.Error("GC was not restored after the GC-off run")
+	} else {
+		debug.SetGCPercent(p)
+	}
+	if off.Strategy != "probe-gc-off" || off.Params["gc"] != "off" {
+		t.Errorf("unexpected result: %s %v", off.Strategy, off.Params)
+	}
+	if off.Metrics[metricGCOnWall] != on.Metrics[metricWall] {
+		t.Errorf("gc_on_wall_ns = %v, want %v", off.Metrics[metricGCOnWall], on.Metrics[metricWall])
+	}
+	if _, ok := off.Metrics[metricGCCycles]; !ok {
+		t.Error("GC cycles were not recorded")
+	}
+}
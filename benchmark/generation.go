@@ -0,0 +1,150 @@
+package benchmark
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// 重いタスクの生成で、タスクのデータをハッシュし直す回数
+const generationRounds = 32
+
+// タスクの生成のシナリオの戦略一覧。
+// 同じワーカープールで、全てのタスクを生成してから投入する方法と、生成を別のgoroutineで処理と並行して行う方法を、
+// 軽い生成（通常の供給元）と重い生成（タスクごとにハッシュを計算する）で比べる
+func generationStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	type cost struct {
+		name, title string
+		wrap        func(Source) Source
+	}
+	costs := []cost{
+		{name: "light", title: "軽い生成", wrap: func(src Source) Source { return src }},
+		{name: "heavy", title: fmt.Sprintf("重い生成（SHA-256を%d回）", generationRounds), wrap: func(src Source) Source { return &heavySource{src: src} }},
+	}
+	var list []Strategy
+	for _, c := range costs {
+		generate := func(run func(Env, int) error) func(Env) error {
+			return func(env Env) error {
+				env.Source = c.wrap(env.Source)
+				return run(env, numWorkers)
+			}
+		}
+		list = append(list,
+			Strategy{
+				Name:  "gen-upfront-" + c.name,
+				Title: fmt.Sprintf("%s: 全てのタスクを生成してから投入 + ワーカープール（%dワーカー）", c.title, numWorkers),
+				Func:  "GenerateThenSubmit",
+				Limit: numWorkers,
+				Topology: Topology{
+					Stages: []Stage{
+						{Role: RoleProducer, Goroutines: 1, Note: "全てのタスクをスライスに生成してから送信"},
+						{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100)},
+					},
+					Completion: "sync.WaitGroup",
+				},
+				Run: generate(GenerateThenSubmit),
+			},
+			Strategy{
+				Name:  "gen-producer-" + c.name,
+				Title: fmt.Sprintf("%s: 生成するgoroutineが処理と並行して投入 + ワーカープール（%dワーカー）", c.title, numWorkers),
+				Func:  "ProducerGoroutinePool",
+				Limit: numWorkers,
+				Topology: Topology{
+					Stages: []Stage{
+						{Role: RoleProducer, Goroutines: 1, Note: "呼び出し元とは別のgoroutineで生成しながら送信"},
+						{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100)},
+					},
+					Completion: "sync.WaitGroup",
+				},
+				Run: generate(ProducerGoroutinePool),
+			},
+		)
+	}
+	return list
+}
+
+// タスクの生成にCPUを使う供給元。タスクのデータのハッシュを繰り返し計算し、その値をデータにする
+type heavySource struct {
+	src Source
+}
+
+func (s *heavySource) Next() (Task, bool) {
+	task, ok := s.src.Next()
+	if !ok {
+		return task, false
+	}
+	sum := sha256.Sum256([]byte(task.Data))
+	for i := 1; i < generationRounds; i++ {
+		sum = sha256.Sum256(sum[:])
+	}
+	task.Data = hex.EncodeToString(sum[:8])
+	return task, true
+}
+
+// タスクのチャネルを受信して処理するワーカーを起動する。返す関数は全てのワーカーの終了を待ち、最初のエラーを返す
+func startWorkers(env Env, tasks <-chan Task, numWorkers int) (wait func() error) {
+	var mu sync.Mutex
+	var firstErr error
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				if err := env.Process(task); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	return func() error {
+		wg.Wait()
+		return firstErr
+	}
+}
+
+// 全てのタスクを生成してスライスに集めてから、ワーカープールに投入して終了を待つ。
+// 生成の間はワーカーが何も処理しない
+func GenerateThenSubmit(env Env, numWorkers int) error {
+	var all []Task
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		all = append(all, task)
+	}
+
+	tasks := make(chan Task, 100)
+	wait := startWorkers(env, tasks, numWorkers)
+	for _, task := range all {
+		tasks <- task
+	}
+	close(tasks)
+	return wait()
+}
+
+// 生成するgoroutineがタスクを1つずつ生成しながらワーカープールに送り、呼び出し元は終了を待つだけにする。
+// 生成と処理が重なるため、生成が重いほど全体の時間が短くなる
+func ProducerGoroutinePool(env Env, numWorkers int) error {
+	tasks := make(chan Task, 100)
+	wait := startWorkers(env, tasks, numWorkers)
+	go func() {
+		defer close(tasks)
+		for {
+			task, ok := env.Source.Next()
+			if !ok {
+				return
+			}
+			tasks <- task
+		}
+	}()
+	return wait()
+}
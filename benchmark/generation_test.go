@@ -0,0 +1,55 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 全てのタスクを処理してから戻り、エラーが返されることを確認する
+func TestGenerationStrategies(t *testing.T) {
+	const n = 2000
+
+	for _, s := range generationStrategies(Params{Workers: 100}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var completed atomic.Int64
+			env := BatchEnv(n)
+			env.Process = func(task Task) error {
+				defer completed.Add(1)
+				return processTask(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := completed.Load(); got != n {
+				t.Errorf("returned after %d tasks completed, want %d", got, n)
+			}
+
+			var processed atomic.Int64
+			env = BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
+
+// 重い生成でもタスクのIDを保ち、データをハッシュの値に置き換えることを確認する
+func TestHeavySource(t *testing.T) {
+	src := &heavySource{src: BatchEnv(3).Source}
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		task, ok := src.Next()
+		if !ok || task.ID != i {
+			t.Fatalf("got task %d (ok=%v), want %d", task.ID, ok, i)
+		}
+		if len(task.Data) != 16 || seen[task.Data] {
+			t.Errorf("unexpected data %q", task.Data)
+		}
+		seen[task.Data] = true
+	}
+	if _, ok := src.Next(); ok {
+		t.Error("source did not end")
+	}
+}
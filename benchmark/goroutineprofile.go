@@ -0,0 +1,130 @@
+package benchmark
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"strings"
+	"time"
+)
+
+// goroutineの生成元ごとの数
+type GoroutineSite struct {
+	// goroutineを生成した関数と、go文の位置（例: errgroup.(*Group).Go (errgroup.go:93)）
+	Site string `json:"site"`
+	// ピーク時と完了後の数（実行前からあったgoroutineを除く）
+	Peak  int `json:"peak"`
+	After int `json:"after"`
+}
+
+const (
+	// ピークを捉えるためにgoroutine数を確認する間隔
+	goroutinePollInterval = time.Millisecond
+	// 計測した実行でのgoroutine数のピークのうち、この割合に達した時点をピークとしてダンプする
+	goroutinePeakRatio = 0.9
+)
+
+// 戦略を計測とは別にもう1回実行し、実行前、goroutine数のピーク時、完了後のgoroutineのダンプを生成元ごとに数える。
+// 10万goroutineのダンプには1秒以上かかるため、ピーク時のダンプは1度だけ、goroutine数がpeak（計測した実行での
+// ピーク）の9割に達した時点で取る。生成元はダンプの「created by」の関数と位置で、実行前からあったものは差し引く
+func profileGoroutineSites(s Strategy, env Env, peak int) ([]GoroutineSite, error) {
+	before := goroutineSites()
+	threshold := max(int(float64(peak)*goroutinePeakRatio), len(before)+1)
+
+	done := make(chan struct{})
+	captured := make(chan map[string]int, 1)
+	go sampleGoroutinePeak(threshold, done, captured)
+	_, err := measure(s, env)
+	close(done)
+	atPeak := <-captured
+	after := goroutineSites()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var sites []GoroutineSite
+	for _, counts := range []map[string]int{atPeak, after} {
+		for site := range counts {
+			if seen[site] {
+				continue
+			}
+			seen[site] = true
+			g := GoroutineSite{
+				Site:  site,
+				Peak:  max(atPeak[site]-before[site], 0),
+				After: max(after[site]-before[site], 0),
+			}
+			if g.Peak > 0 || g.After > 0 {
+				sites = append(sites, g)
+			}
+		}
+	}
+	slices.SortFunc(sites, func(a, b GoroutineSite) int {
+		if c := cmp.Compare(b.Peak, a.Peak); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.After, a.After); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Site, b.Site)
+	})
+	return sites, nil
+}
+
+// goroutine数がthresholdに達したらダンプを1度だけ取り、生成元ごとの数を送る。
+// 達しないまま実行が終わった場合は空の集計を送る
+func sampleGoroutinePeak(threshold int, done <-chan struct{}, captured chan<- map[string]int) {
+	ticker := time.NewTicker(goroutinePollInterval)
+	defer ticker.Stop()
+	for {
+		if runtime.NumGoroutine() >= threshold {
+			captured <- goroutineSites()
+			return
+		}
+		select {
+		case <-done:
+			captured <- map[string]int{}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 全てのgoroutineのダンプを取り、生成元ごとに数える（ピークを捉えるgoroutine自身は除く）
+func goroutineSites() map[string]int {
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 2)
+	return parseGoroutineSites(buf.Bytes())
+}
+
+// pprofのgoroutineのダンプ（debug=2）を生成元ごとに数える。生成元のないgoroutine（mainなど）は「(なし)」にする
+func parseGoroutineSites(dump []byte) map[string]int {
+	counts := map[string]int{}
+	for _, block := range bytes.Split(dump, []byte("\n\n")) {
+		if !bytes.HasPrefix(block, []byte("goroutine ")) || bytes.Contains(block, []byte("sampleGoroutinePeak")) {
+			continue
+		}
+		site := "(なし)"
+		sc := bufio.NewScanner(bytes.NewReader(block))
+		for sc.Scan() {
+			fn, ok := strings.CutPrefix(sc.Text(), "created by ")
+			if !ok {
+				continue
+			}
+			fn, _, _ = strings.Cut(fn, " in goroutine ")
+			site = shortFuncName(fn)
+			if sc.Scan() {
+				pos, _, _ := strings.Cut(strings.TrimSpace(sc.Text()), " ")
+				site += " (" + filepath.Base(pos) + ")"
+			}
+			break
+		}
+		counts[site]++
+	}
+	return counts
+}
@@ -0,0 +1,68 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGoroutineSites(t *testing.T) {
+	dump := `goroutine 1 [running]:
+main.main()
+	/src/main.go:15 +0x45
+
+goroutine 7 [chan receive]:
+example.com/app.worker()
+	/src/app/worker.go:9 +0x17
+created by example.com/app.spawn in goroutine 1
+	/src/app/worker.go:12 +0x25
+
+goroutine 8 [chan receive]:
+example.com/app.worker()
+	/src/app/worker.go:9 +0x17
+created by example.com/app.spawn in goroutine 1
+	/src/app/worker.go:12 +0x25
+
+goroutine 9 [sleep]:
+example.com/bench.sampleGoroutinePeak()
+	/src/bench/goroutineprofile.go:90 +0x10
+created by example.com/bench.profileGoroutineSites in goroutine 1
+	/src/bench/goroutineprofile.go:40 +0x20
+`
+	got := parseGoroutineSites([]byte(dump))
+	want := map[string]int{"(なし)": 1, "app.spawn (worker.go:12)": 2}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for site, n := range want {
+		if got[site] != n {
+			t.Errorf("%s: got %d, want %d", site, got[site], n)
+		}
+	}
+}
+
+// ピーク時にディスパッチャーとタスクごとのgoroutineを数え、完了後には残らないことを確認する
+func TestProfileGoroutineSites(t *testing.T) {
+	s, err := findStrategy(strategies(Params{Workers: 100, Buffer: defaultTaskBuffer}), "chan-unlimited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sites, err := profileGoroutineSites(s, BatchEnv(2000), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dispatcher, tasks int
+	for _, g := range sites {
+		if g.After != 0 {
+			t.Errorf("%s: %d goroutines left after completion", g.Site, g.After)
+		}
+		switch {
+		case strings.HasPrefix(g.Site, "benchmark.ChannelWithUnlimitedParallelism"):
+			dispatcher += g.Peak
+		case strings.HasPrefix(g.Site, "errgroup.(*Group).Go"):
+			tasks += g.Peak
+		}
+	}
+	if dispatcher < 1 || tasks < 1 {
+		t.Errorf("sites at peak: %+v", sites)
+	}
+}
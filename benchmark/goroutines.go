@@ -0,0 +1,17 @@
+package benchmark
+
+import "runtime/metrics"
+
+// 起動されたgoroutineの累計を表すランタイムのメトリクス
+const goroutinesCreatedMetric = "/sched/goroutines-created:goroutines"
+
+// これまでに起動されたgoroutineの累計を読み取る。
+// メトリクスに対応していないランタイムではokにfalseを返す
+func readGoroutinesCreated() (n uint64, ok bool) {
+	s := []metrics.Sample{{Name: goroutinesCreatedMetric}}
+	metrics.Read(s)
+	if s[0].Value.Kind() != metrics.KindUint64 {
+		return 0, false
+	}
+	return s[0].Value.Uint64(), true
+}
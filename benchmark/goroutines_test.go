@@ -0,0 +1,25 @@
+package benchmark
+
+import (
+	"sync"
+	"testing"
+)
+
+// 起動したgoroutineの数だけ累計が増えることを確認する
+func TestReadGoroutinesCreated(t *testing.T) {
+	before, ok := readGoroutinesCreated()
+	if !ok {
+		t.Skip("runtime does not support " + goroutinesCreatedMetric)
+	}
+	const n = 100
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go wg.Done()
+	}
+	wg.Wait()
+	after, _ := readGoroutinesCreated()
+	if after-before < n {
+		t.Errorf("created %d goroutines, want at least %d", after-before, n)
+	}
+}
@@ -0,0 +1,256 @@
+package benchmark
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 推移を表す棒（小さい値ほど低い）
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// 蓄積した結果（jsonの出力先に書き出したファイル、またはsqliteの出力先のデータベース）を読み込み、
+// シナリオごとに各戦略のメトリクスの推移をGoのバージョンとコミットごとに表示する。
+// Goの更新で最速の戦略が入れ替わった箇所（ランタイムの変更でチャネルとgoroutineの優劣が変わった箇所）を強調する。
+// metricは小さいほど良いメトリクスとして最速を判定する。scenariosが空の場合は全てのシナリオを表示する
+func RunTrend(files []string, metric string, scenarios []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("trend requires at least 1 result file")
+	}
+	var all []Result
+	for _, path := range files {
+		results, err := loadHistory(path)
+		if err != nil {
+			return err
+		}
+		all = append(all, results...)
+	}
+	return writeTrend(os.Stdout, all, metric, scenarios)
+}
+
+// 拡張子が.dbや.sqliteのファイルはSQLiteのデータベースとして、それ以外は結果のJSONファイルとして読み込む
+func loadHistory(path string) ([]Result, error) {
+	switch filepath.Ext(path) {
+	case ".db", ".sqlite", ".sqlite3":
+		results, err := loadSQLiteResults(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return results, nil
+	}
+	return loadResults(path)
+}
+
+// 推移の1点。同じGoのバージョンとコミットで計測した結果をまとめる
+type historyPoint struct {
+	time      time.Time
+	goVersion string
+	commit    string
+	// 戦略ごとのメトリクスの値（同じ点に複数の結果がある場合は全て）
+	values map[string][]float64
+}
+
+// 戦略の値の平均
+func (p *historyPoint) value(strategy string) (float64, bool) {
+	vs := p.values[strategy]
+	if len(vs) == 0 {
+		return 0, false
+	}
+	sum := 0.0
+	for _, v := range vs {
+		sum += v
+	}
+	return sum / float64(len(vs)), true
+}
+
+// 最も値の小さい戦略
+func (p *historyPoint) winner(strategies []string) string {
+	best, bestValue := "", 0.0
+	for _, s := range strategies {
+		if v, ok := p.value(s); ok && (best == "" || v < bestValue) {
+			best, bestValue = s, v
+		}
+	}
+	return best
+}
+
+// コミットの表示（先頭12文字、未コミットの変更を含む場合は*を付ける）
+func commitLabel(p Provenance) string {
+	s := cmp.Or(p.Commit[:min(12, len(p.Commit))], p.Version, "不明")
+	if p.Modified {
+		s += "*"
+	}
+	return s
+}
+
+// シナリオごとの推移を書き出す
+func writeTrend(w io.Writer, results []Result, metric string, scenarios []string) error {
+	type history struct {
+		strategies []string
+		points     []*historyPoint
+	}
+	var order []string
+	histories := map[string]*history{}
+	for _, r := range results {
+		v, ok := r.Metrics[metric]
+		if r.Mode != modeBatch || r.AbortReason != "" || !ok {
+			continue
+		}
+		if len(scenarios) > 0 && !slices.Contains(scenarios, r.Scenario) {
+			continue
+		}
+		h := histories[r.Scenario]
+		if h == nil {
+			h = &history{}
+			histories[r.Scenario] = h
+			order = append(order, r.Scenario)
+		}
+		if !slices.Contains(h.strategies, r.Strategy) {
+			h.strategies = append(h.strategies, r.Strategy)
+		}
+		commit := commitLabel(r.Provenance)
+		i := slices.IndexFunc(h.points, func(p *historyPoint) bool {
+			return p.goVersion == r.Environment.GoVersion && p.commit == commit
+		})
+		if i < 0 {
+			h.points = append(h.points, &historyPoint{
+				time:      r.Time,
+				goVersion: r.Environment.GoVersion,
+				commit:    commit,
+				values:    map[string][]float64{},
+			})
+			i = len(h.points) - 1
+		}
+		p := h.points[i]
+		if r.Time.Before(p.time) {
+			p.time = r.Time
+		}
+		p.values[r.Strategy] = append(p.values[r.Strategy], v)
+	}
+	if len(order) == 0 {
+		return fmt.Errorf("no batch results with metric %q", metric)
+	}
+
+	for n, name := range order {
+		h := histories[name]
+		slices.SortStableFunc(h.points, func(a, b *historyPoint) int {
+			return a.time.Compare(b.time)
+		})
+		if n > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "シナリオ: %s（%s、小さいほど良い）\n", name, metric)
+
+		rows := [][]string{append(append([]string{"時刻", "Go", "コミット"}, h.strategies...), "最速")}
+		var flips int
+		for i, p := range h.points {
+			cols := []string{p.time.Local().Format("2006-01-02 15:04"), p.goVersion, p.commit}
+			for _, s := range h.strategies {
+				col := "-"
+				if v, ok := p.value(s); ok {
+					col = formatTrendValue(metric, v)
+				}
+				cols = append(cols, col)
+			}
+			winner := p.winner(h.strategies)
+			cols = append(cols, winner)
+			if i > 0 {
+				prev := h.points[i-1]
+				if prevWinner := prev.winner(h.strategies); prev.goVersion != p.goVersion && prevWinner != winner {
+					cols = append(cols, fmt.Sprintf("← Goの更新（%s → %s）で最速が%sから%sに変化", prev.goVersion, p.goVersion, prevWinner, winner))
+					flips++
+				}
+			}
+			rows = append(rows, cols)
+		}
+		writeTable(w, "", rows)
+
+		// 戦略ごとの推移（最初と最後の点の値の変化）
+		rows = nil
+		for _, s := range h.strategies {
+			var values []float64
+			for _, p := range h.points {
+				if v, ok := p.value(s); ok {
+					values = append(values, v)
+				}
+			}
+			first, last := values[0], values[len(values)-1]
+			change := ""
+			if first > 0 && len(values) > 1 {
+				change = fmt.Sprintf("（%+.1f%%）", (last/first-1)*100)
+			}
+			rows = append(rows, []string{s, sparkline(values), formatTrendValue(metric, first) + " → " + formatTrendValue(metric, last) + change})
+		}
+		writeTable(w, "  ", rows)
+		if flips > 0 {
+			fmt.Fprintf(w, "Goの更新で最速の戦略が入れ替わった箇所: %d\n", flips)
+		}
+	}
+	return nil
+}
+
+// 列をそろえた表を書き出す。全角の文字は2桁として数える
+func writeTable(w io.Writer, indent string, rows [][]string) {
+	var widths []int
+	for _, row := range rows {
+		for i, col := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], displayWidth(col))
+		}
+	}
+	for _, row := range rows {
+		var b strings.Builder
+		b.WriteString(indent)
+		for i, col := range row {
+			b.WriteString(col)
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-displayWidth(col)+2))
+			}
+		}
+		fmt.Fprintln(w, b.String())
+	}
+}
+
+// 端末での表示幅（東アジアの全角の文字を2桁とする簡易的な判定）
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case r >= 0x1100 && r <= 0x115F, r >= 0x2E80 && r <= 0xA4CF, r >= 0xAC00 && r <= 0xD7A3,
+			r >= 0xF900 && r <= 0xFAFF, r >= 0xFE30 && r <= 0xFE4F, r >= 0xFF00 && r <= 0xFF60, r >= 0xFFE0 && r <= 0xFFE6:
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// 値の推移を棒で表す
+func sparkline(values []float64) string {
+	lo, hi := slices.Min(values), slices.Max(values)
+	bars := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(sparkBars)-1))
+		}
+		bars[i] = sparkBars[level]
+	}
+	return string(bars)
+}
+
+// 推移の値を表示する。平均で端数が出るため、時間はマイクロ秒、それ以外は4桁に丸める
+func formatTrendValue(metric string, v float64) string {
+	if strings.HasSuffix(metric, "_ns") {
+		return time.Duration(v).Round(time.Microsecond).String()
+	}
+	return fmt.Sprintf("%.4g", v)
+}
@@ -0,0 +1,74 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func historyResult(strategy, goVersion, commit string, day int, wall float64) Result {
+	r := testResult(strategy, wall)
+	r.Environment.GoVersion = goVersion
+	r.Provenance.Commit = commit
+	r.Time = time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC)
+	return r
+}
+
+// Goのバージョンとコミットごとの推移を表示し、同じ点の結果は平均することを確認する
+func TestWriteTrend(t *testing.T) {
+	results := []Result{
+		historyResult("chan", "go1.22.0", "aaa", 1, 2e6),
+		historyResult("direct", "go1.22.0", "aaa", 1, 3e6),
+		historyResult("chan", "go1.22.0", "bbb", 2, 2e6),
+		historyResult("chan", "go1.22.0", "bbb", 2, 4e6),
+		historyResult("direct", "go1.22.0", "bbb", 2, 2.5e6),
+		historyResult("chan", "go1.23.0", "bbb", 3, 4e6),
+		historyResult("direct", "go1.23.0", "bbb", 3, 1e6),
+	}
+	var b strings.Builder
+	if err := writeTrend(&b, results, metricWall, nil); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"シナリオ: dispatch（wall_ns、小さいほど良い）",
+		"時刻              Go        コミット  chan  direct  最速",
+		"go1.22.0  bbb       3ms   2.5ms   direct",
+		"  chan    ▁▄█  2ms → 4ms（+100.0%）",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("trend does not contain %q:\n%s", want, got)
+		}
+	}
+	// コミットの変更で入れ替わった箇所や、Goを更新しても最速が変わらなかった箇所は強調しない
+	if strings.Contains(got, "←") {
+		t.Errorf("highlighted a point where the Go update did not change the winner:\n%s", got)
+	}
+}
+
+// Goの更新で最速の戦略が入れ替わった箇所を強調することを確認する
+func TestWriteTrendRuntimeFlip(t *testing.T) {
+	results := []Result{
+		historyResult("chan", "go1.22.0", "aaa", 1, 2e6),
+		historyResult("direct", "go1.22.0", "aaa", 1, 3e6),
+		historyResult("chan", "go1.23.0", "aaa", 2, 3e6),
+		historyResult("direct", "go1.23.0", "aaa", 2, 1e6),
+	}
+	var b strings.Builder
+	if err := writeTrend(&b, results, metricWall, []string{defaultScenario}); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"direct  ← Goの更新（go1.22.0 → go1.23.0）で最速がchanからdirectに変化",
+		"Goの更新で最速の戦略が入れ替わった箇所: 1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("trend does not contain %q:\n%s", want, got)
+		}
+	}
+
+	if err := writeTrend(&b, results, metricWall, []string{"other"}); err == nil {
+		t.Error("expected error when no results match")
+	}
+}
@@ -0,0 +1,269 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/trace"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 戦略の実行に差し込む処理（計測、トレース、独自のカウンタなど）。
+// 全ての戦略を同じようにラップするため、計測用のコードを各実装に書かずに済み、
+// 追加したメトリクスは全ての戦略に自動的に適用される。
+// 交互実行では2つの戦略の実行が入れ替わるため、集計は戦略名ごとに保持する
+type Hook interface {
+	// 1回の実行の前に呼ばれる
+	BeforeRun(s Strategy)
+	// タスクの処理をラップする。複数の戦略のgoroutineから並行して呼ばれる
+	WrapTask(s Strategy, next func(Task) error) func(Task) error
+	// 1回の実行の後に呼ばれる
+	AfterRun(s Strategy, d time.Duration, err error)
+	// 結果を出力する前に呼ばれ、集計したメトリクスを結果に追加する。
+	// 追加した後はその戦略の集計をリセットする
+	Collect(res *Result)
+}
+
+// フックの種類ごとのコンストラクタ
+var hookKinds = map[string]func(cfg Config) (Hook, error){
+	"timing":  func(Config) (Hook, error) { return &timingHook{}, nil },
+	"counter": func(Config) (Hook, error) { return &counterHook{}, nil },
+	"trace":   newTraceHook,
+	"blocked": newBlockedHook,
+}
+
+// フックを登録する。登録したフックは-hooksで名前を指定して有効にできる
+func RegisterHook(name string, newHook func(cfg Config) (Hook, error)) {
+	hookKinds[name] = newHook
+}
+
+// フックの種類の一覧
+func HookNames() []string {
+	names := make([]string, 0, len(hookKinds))
+	for name := range hookKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 設定で指定されたフックを作成する
+func newHooks(cfg Config) ([]Hook, error) {
+	hooks := make([]Hook, 0, len(cfg.Hooks))
+	for _, name := range cfg.Hooks {
+		newHook, ok := hookKinds[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown hook %q (available: %s)", name, strings.Join(HookNames(), ", "))
+		}
+		h, err := newHook(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("hook %s: %w", name, err)
+		}
+		hooks = append(hooks, h)
+	}
+	return hooks, nil
+}
+
+// 戦略をフックでラップする。先に指定したフックほど外側でタスクの処理をラップする
+func withHooks(s Strategy, hooks []Hook) Strategy {
+	if len(hooks) == 0 {
+		return s
+	}
+	run := s.Run
+	s.Run = func(env Env) error {
+		for _, h := range hooks {
+			h.BeforeRun(s)
+		}
+		process := env.Process
+		for i := len(hooks) - 1; i >= 0; i-- {
+			process = hooks[i].WrapTask(s, process)
+		}
+		env.Process = process
+
+		start := time.Now()
+		err := run(env)
+		d := time.Since(start)
+		for _, h := range hooks {
+			h.AfterRun(s, d, err)
+		}
+		return err
+	}
+	return s
+}
+
+// タスクごとの処理時間を記録し、分布をメトリクスとして追加する
+type timingHook struct {
+	mu    sync.Mutex
+	times map[string][]time.Duration
+}
+
+func (h *timingHook) BeforeRun(Strategy) {}
+
+func (h *timingHook) WrapTask(s Strategy, next func(Task) error) func(Task) error {
+	return func(task Task) error {
+		start := time.Now()
+		err := next(task)
+		d := time.Since(start)
+
+		h.mu.Lock()
+		if h.times == nil {
+			h.times = map[string][]time.Duration{}
+		}
+		h.times[s.Name] = append(h.times[s.Name], d)
+		h.mu.Unlock()
+		return err
+	}
+}
+
+func (h *timingHook) AfterRun(Strategy, time.Duration, error) {}
+
+func (h *timingHook) Collect(res *Result) {
+	h.mu.Lock()
+	times := h.times[res.Strategy]
+	delete(h.times, res.Strategy)
+	h.mu.Unlock()
+	if len(times) == 0 {
+		return
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	res.Metrics["task_time_p50_ns"] = float64(percentileDuration(times, 50))
+	res.Metrics["task_time_p99_ns"] = float64(percentileDuration(times, 99))
+	res.Metrics["task_time_max_ns"] = float64(times[len(times)-1])
+}
+
+// 実行回数、処理したタスク数、エラーを数える
+type counterHook struct {
+	mu     sync.Mutex
+	counts map[string]*hookCounts
+}
+
+type hookCounts struct {
+	runs, runErrors   int64
+	tasks, taskErrors atomic.Int64
+}
+
+func (h *counterHook) get(name string) *hookCounts {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.counts == nil {
+		h.counts = map[string]*hookCounts{}
+	}
+	c, ok := h.counts[name]
+	if !ok {
+		c = &hookCounts{}
+		h.counts[name] = c
+	}
+	return c
+}
+
+func (h *counterHook) BeforeRun(Strategy) {}
+
+func (h *counterHook) WrapTask(s Strategy, next func(Task) error) func(Task) error {
+	c := h.get(s.Name)
+	return func(task Task) error {
+		err := next(task)
+		c.tasks.Add(1)
+		if err != nil {
+			c.taskErrors.Add(1)
+		}
+		return err
+	}
+}
+
+func (h *counterHook) AfterRun(s Strategy, _ time.Duration, err error) {
+	c := h.get(s.Name)
+	c.runs++
+	if err != nil {
+		c.runErrors++
+	}
+}
+
+func (h *counterHook) Collect(res *Result) {
+	c := h.get(res.Strategy)
+	res.Metrics["runs"] = float64(c.runs)
+	res.Metrics["run_errors"] = float64(c.runErrors)
+	res.Metrics["tasks_processed"] = float64(c.tasks.Load())
+	res.Metrics["task_errors"] = float64(c.taskErrors.Load())
+
+	h.mu.Lock()
+	delete(h.counts, res.Strategy)
+	h.mu.Unlock()
+}
+
+// 実行ごとにランタイムのトレースを書き出し、各タスクをリージョンとして記録する。
+// go tool traceでgoroutineのスケジューリングを戦略ごとに比較するために使う
+type traceHook struct {
+	dir   string
+	mu    sync.Mutex
+	file  *os.File
+	n     map[string]int
+	paths map[string][]string
+}
+
+func newTraceHook(cfg Config) (Hook, error) {
+	if cfg.ProfileDir == "" {
+		return nil, fmt.Errorf("trace requires a profile directory")
+	}
+	if err := os.MkdirAll(cfg.ProfileDir, 0o755); err != nil {
+		return nil, err
+	}
+	return &traceHook{dir: cfg.ProfileDir, n: map[string]int{}, paths: map[string][]string{}}, nil
+}
+
+func (h *traceHook) BeforeRun(s Strategy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.n[s.Name]++
+	path := filepath.Join(h.dir, s.Name+"-"+strconv.Itoa(h.n[s.Name])+".trace")
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "trace: %v\n", err)
+		return
+	}
+	if err := trace.Start(f); err != nil {
+		fmt.Fprintf(os.Stderr, "trace: %v\n", err)
+		f.Close()
+		return
+	}
+	h.file = f
+	h.paths[s.Name] = append(h.paths[s.Name], path)
+}
+
+func (h *traceHook) WrapTask(_ Strategy, next func(Task) error) func(Task) error {
+	return func(task Task) error {
+		defer trace.StartRegion(context.Background(), "task").End()
+		return next(task)
+	}
+}
+
+func (h *traceHook) AfterRun(Strategy, time.Duration, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return
+	}
+	trace.Stop()
+	h.file.Close()
+	h.file = nil
+}
+
+func (h *traceHook) Collect(res *Result) {
+	h.mu.Lock()
+	paths := h.paths[res.Strategy]
+	delete(h.paths, res.Strategy)
+	h.mu.Unlock()
+
+	for i, path := range paths {
+		if res.Artifacts == nil {
+			res.Artifacts = map[string]string{}
+		}
+		res.Artifacts["trace-"+strconv.Itoa(i+1)] = path
+	}
+}
@@ -0,0 +1,56 @@
+package benchmark
+
+import (
+	"errors"
+	"testing"
+)
+
+// フックが全ての戦略のタスク処理をラップし、戦略ごとに集計することを確認する
+func TestWithHooksCollectsPerStrategy(t *testing.T) {
+	counter := &counterHook{}
+	timing := &timingHook{}
+	hooks := []Hook{counter, timing}
+
+	failing := errors.New("fail")
+	list := []Strategy{
+		{Name: "ok", Run: DirectGoroutineWithUnlimitedParallelism},
+		{Name: "fail", Run: func(env Env) error {
+			for {
+				task, ok := env.Source.Next()
+				if !ok {
+					return failing
+				}
+				env.Process(task)
+			}
+		}},
+	}
+	for _, s := range list {
+		s = withHooks(s, hooks)
+		for i := 0; i < 2; i++ {
+			s.Run(BatchEnv(50))
+		}
+	}
+
+	for _, c := range []struct {
+		name      string
+		runErrors float64
+	}{{"ok", 0}, {"fail", 2}} {
+		res := Result{Strategy: c.name, Metrics: map[string]float64{}}
+		for _, h := range hooks {
+			h.Collect(&res)
+		}
+		if res.Metrics["runs"] != 2 || res.Metrics["tasks_processed"] != 100 || res.Metrics["run_errors"] != c.runErrors {
+			t.Errorf("%s: unexpected counts %v", c.name, res.Metrics)
+		}
+		if res.Metrics["task_time_p50_ns"] <= 0 {
+			t.Errorf("%s: task time not recorded: %v", c.name, res.Metrics)
+		}
+
+		// 集計は取り出した後にリセットされる
+		again := Result{Strategy: c.name, Metrics: map[string]float64{}}
+		counter.Collect(&again)
+		if again.Metrics["runs"] != 0 {
+			t.Errorf("%s: counts not reset: %v", c.name, again.Metrics)
+		}
+	}
+}
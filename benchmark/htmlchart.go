@@ -0,0 +1,430 @@
+package benchmark
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTMLレポートのグラフの大きさ（ピクセル）
+const (
+	chartWidth      = 760
+	chartLabelWidth = 240
+	chartBarHeight  = 22
+	chartPlotHeight = 260
+	chartMargin     = 30
+)
+
+// グラフで戦略を塗り分ける色
+var chartColors = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"}
+
+// レイテンシの分布のグラフに並べるパーセンタイル（100は最大値）
+var chartPercentiles = []float64{50, 90, 99, 99.9, 100}
+
+// スケッチの種類ごとのグラフの見出し
+var chartSketchTitles = map[string]string{
+	sketchLatency:       "レイテンシ（予定時刻から完了まで）",
+	sketchActualLatency: "レイテンシ（実際の送信から完了まで）",
+	sketchWait:          "処理開始までの待ち時間",
+	sketchService:       "処理開始から完了までの時間",
+	sketchJitter:        "予定時刻から処理開始までの遅れ",
+	sketchRequest:       "要求ごとのレイテンシ",
+	sketchTaskLatency:   "タスクごとのレイテンシ（読み出しから完了まで）",
+}
+
+// SVGで描いたグラフの本体（外側のsvg要素を除く）と大きさ
+type svgChart struct {
+	width, height int
+	body          string
+}
+
+// HTMLに埋め込むsvg要素
+func (c svgChart) inline() string {
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n%s</svg>", c.width, c.height, c.body)
+}
+
+// 単独のSVGファイルの内容。HTMLの見出しの代わりに、タイトルを上に描く
+func (c svgChart) standalone(title string) string {
+	const titleHeight = 30
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", c.width, c.height+titleHeight)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#fff"/><text x="10" y="20" font-size="14" font-weight="bold">%s</text>`+"\n", html.EscapeString(title))
+	fmt.Fprintf(&b, `<g transform="translate(0,%d)">`+"\n%s</g>\n</svg>\n", titleHeight, c.body)
+	return b.String()
+}
+
+// 結果から作るグラフ。HTMLレポートに埋め込み、svgの出力先ではFileの名前で書き出す
+type resultChart struct {
+	Title string
+	File  string
+	chart svgChart
+}
+
+// HTMLレポートに埋め込むSVG
+func (c resultChart) SVG() template.HTML {
+	return template.HTML(c.chart.inline())
+}
+
+// シナリオごとに、処理時間の棒グラフ、並列度ごとのスループットの折れ線、スケッチを記録した戦略の
+// レイテンシの分布のグラフを作る。外部のライブラリやネットワークを使わずに表示できるよう、SVGを直接書き出す
+func resultCharts(results []Result) []resultChart {
+	var scenarios []string
+	groups := map[string][]Result{}
+	for _, r := range results {
+		if _, ok := groups[r.Scenario]; !ok {
+			scenarios = append(scenarios, r.Scenario)
+		}
+		groups[r.Scenario] = append(groups[r.Scenario], r)
+	}
+
+	var charts []resultChart
+	for _, sc := range scenarios {
+		file := chartFileName(sc)
+		if c, ok := durationChart(groups[sc]); ok {
+			charts = append(charts, resultChart{Title: sc + " / 処理時間（棒は中央値、線は最小から最大、点は各回）", File: file + "-duration.svg", chart: c})
+		}
+		for _, axis := range concurrencyAxes {
+			if c, ok := throughputChart(groups[sc], axis); ok {
+				charts = append(charts, resultChart{Title: sc + " / " + axis.title + "ごとのスループット", File: file + "-throughput-" + axis.name + ".svg", chart: c})
+			}
+		}
+		for _, kind := range sketchKinds(groups[sc]) {
+			if c, ok := latencyChart(groups[sc], kind); ok {
+				charts = append(charts, resultChart{Title: sc + " / " + chartSketchTitle(kind) + "の分布（対数目盛）", File: file + "-latency-" + chartFileName(kind) + ".svg", chart: c})
+			}
+		}
+	}
+	return charts
+}
+
+// グラフに表示する結果の名前。同じ戦略の結果が複数ある場合（スイープのタスク数、mergeの-byで
+// 分けたパラメータなど）は、それらの間で値が異なるパラメータを戦略名に添えて区別する
+func seriesLabels(results []Result) []string {
+	labels := make([]string, len(results))
+	for i, r := range results {
+		labels[i] = r.Strategy
+		var keys []string
+		for _, o := range results {
+			if o.Strategy != r.Strategy {
+				continue
+			}
+			for k, v := range r.Params {
+				if o.Params[k] != v && !slices.Contains(keys, k) {
+					keys = append(keys, k)
+				}
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			labels[i] += " " + k + "=" + r.Params[k]
+		}
+	}
+	return labels
+}
+
+// ファイル名に使えない文字を-に置き換える
+func chartFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '-'
+	}, name)
+}
+
+// 結果に含まれるスケッチの種類を整列して返す
+func sketchKinds(results []Result) []string {
+	var kinds []string
+	for _, r := range results {
+		for kind := range r.Sketches {
+			if !slices.Contains(kinds, kind) {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+func chartSketchTitle(kind string) string {
+	if t, ok := chartSketchTitles[kind]; ok {
+		return t
+	}
+	return kind
+}
+
+// 戦略ごとの処理時間の中央値を横棒で描き、繰り返しごとの処理時間を点と範囲の線で重ねる
+func durationChart(results []Result) (svgChart, bool) {
+	var rows []Result
+	var maxV float64
+	for _, r := range results {
+		wall := r.Metrics[metricWall]
+		if wall <= 0 {
+			continue
+		}
+		rows = append(rows, r)
+		maxV = max(maxV, wall)
+		for _, s := range r.Samples {
+			maxV = max(maxV, s)
+		}
+	}
+	if len(rows) == 0 {
+		return svgChart{}, false
+	}
+
+	plotWidth := float64(chartWidth - chartLabelWidth - chartMargin)
+	x := func(v float64) float64 { return chartLabelWidth + v/maxV*plotWidth }
+	height := len(rows)*chartBarHeight + chartMargin
+
+	labels := seriesLabels(rows)
+	var b strings.Builder
+	for i := 0; i <= 4; i++ {
+		v := maxV * float64(i) / 4
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="0" x2="%.1f" y2="%d" stroke="#ddd"/>`, x(v), x(v), height-chartMargin)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" fill="#555">%s</text>`+"\n", x(v), height-chartMargin+16, shortDuration(v))
+	}
+	for i, r := range rows {
+		y := float64(i * chartBarHeight)
+		mid := y + chartBarHeight/2
+		color := chartColors[i%len(chartColors)]
+		wall := r.Metrics[metricWall]
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`, chartLabelWidth-6, mid, html.EscapeString(labels[i]))
+		fmt.Fprintf(&b, `<rect x="%d" y="%.1f" width="%.1f" height="%d" fill="%s" fill-opacity="0.8"><title>%s: %s</title></rect>`,
+			chartLabelWidth, y+4, x(wall)-chartLabelWidth, chartBarHeight-8, color, html.EscapeString(labels[i]), time.Duration(wall))
+		if len(r.Samples) > 1 {
+			lo, hi := slices.Min(r.Samples), slices.Max(r.Samples)
+			fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#333"/>`, x(lo), mid, x(hi), mid)
+			for _, s := range r.Samples {
+				fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="2.5" fill="#333"/>`, x(s), mid)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return svgChart{chartWidth, height, b.String()}, true
+}
+
+// スケッチを記録した戦略ごとに、パーセンタイル（p50からmaxまで）を対数目盛の折れ線で描く
+func latencyChart(results []Result, kind string) (svgChart, bool) {
+	type series struct {
+		name   string
+		values []float64
+	}
+	var lines []series
+	lo, hi := math.Inf(1), 0.0
+	// 負荷ランプはステップごとに結果があり線が重なるため、分布は描かない
+	var rows []Result
+	for _, r := range results {
+		if s, ok := r.Sketches[kind]; ok && s.count > 0 && r.Mode != modeRamp {
+			rows = append(rows, r)
+		}
+	}
+	labels := seriesLabels(rows)
+	for i, r := range rows {
+		s := r.Sketches[kind]
+		var values []float64
+		for _, p := range chartPercentiles {
+			v := math.Max(float64(s.percentile(p)), 1)
+			values = append(values, v)
+			lo, hi = min(lo, v), max(hi, v)
+		}
+		lines = append(lines, series{labels[i], values})
+	}
+	if len(lines) == 0 {
+		return svgChart{}, false
+	}
+
+	// 目盛りは10の累乗ごと
+	minExp, maxExp := math.Floor(math.Log10(lo)), math.Ceil(math.Log10(hi))
+	if maxExp == minExp {
+		maxExp++
+	}
+	plotLeft := float64(chartMargin * 2)
+	plotWidth := float64(chartWidth-chartLabelWidth) - plotLeft
+	x := func(i int) float64 { return plotLeft + plotWidth*float64(i)/float64(len(chartPercentiles)-1) }
+	y := func(v float64) float64 {
+		return chartMargin/2 + (maxExp-math.Log10(v))/(maxExp-minExp)*chartPlotHeight
+	}
+	height := chartPlotHeight + chartMargin*2
+
+	var b strings.Builder
+	for e := minExp; e <= maxExp; e++ {
+		v := math.Pow(10, e)
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#ddd"/>`, plotLeft, y(v), plotLeft+plotWidth, y(v))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="end" dominant-baseline="middle" fill="#555">%s</text>`+"\n", plotLeft-6, y(v), shortDuration(v))
+	}
+	for i, p := range chartPercentiles {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" fill="#555">%s</text>`, x(i), height-chartMargin/2, formatPercentile(p))
+	}
+	b.WriteString("\n")
+	for i, l := range lines {
+		points := make([]chartPoint, len(l.values))
+		for j, v := range l.values {
+			points[j] = chartPoint{x(j), y(v), formatPercentile(chartPercentiles[j]) + ": " + time.Duration(v).String()}
+		}
+		drawSeries(&b, i, l.name, points)
+	}
+	return svgChart{chartWidth, height, b.String()}, true
+}
+
+// スループットのグラフの横軸にする並列度
+type concurrencyAxis struct {
+	name, title string
+	// 結果の並列度とスループット（タスク/秒）。この軸で描かない結果はfalseを返す
+	point func(r Result) (x, throughput float64, ok bool)
+}
+
+var concurrencyAxes = []concurrencyAxis{
+	{"workers", "ワーカー数", func(r Result) (float64, float64, bool) {
+		// 異なる-workersで実行した結果をmergeの-by workersで並べた場合に描ける
+		workers, err := strconv.Atoi(r.Params["workers"])
+		_, throughput, ok := r.perTask()
+		if r.Mode != modeBatch || err != nil || !ok {
+			return 0, 0, false
+		}
+		return float64(workers), throughput, true
+	}},
+	{"clients", "クライアント数（閉ループの負荷ランプ）", func(r Result) (float64, float64, bool) {
+		return r.Metrics[metricLoad], r.Metrics[metricThroughput], r.Mode == modeRamp && r.Params["loop"] == LoopClosed
+	}},
+	{"rate", "投入レート（開ループの負荷ランプ）", func(r Result) (float64, float64, bool) {
+		return r.Metrics[metricLoad], r.Metrics[metricThroughput], r.Mode == modeRamp && r.Params["loop"] == LoopOpen
+	}},
+}
+
+// 戦略ごとに、並列度とスループットの関係を折れ線で描く。
+// 2つ以上の並列度で計測した戦略がなければ描かない
+func throughputChart(results []Result, axis concurrencyAxis) (svgChart, bool) {
+	var names []string
+	series := map[string]map[float64]float64{}
+	for _, r := range results {
+		x, tp, ok := axis.point(r)
+		if !ok || x <= 0 {
+			continue
+		}
+		if series[r.Strategy] == nil {
+			names = append(names, r.Strategy)
+			series[r.Strategy] = map[float64]float64{}
+		}
+		series[r.Strategy][x] = tp
+	}
+	var xs []float64
+	var maxY float64
+	multiple := false
+	for _, points := range series {
+		multiple = multiple || len(points) > 1
+		for x, tp := range points {
+			if !slices.Contains(xs, x) {
+				xs = append(xs, x)
+			}
+			maxY = max(maxY, tp)
+		}
+	}
+	if !multiple || maxY <= 0 {
+		return svgChart{}, false
+	}
+	slices.Sort(xs)
+
+	// 並列度は倍々に増やすことが多いため、10倍以上の幅があれば横軸を対数目盛にする
+	lo, hi := xs[0], xs[len(xs)-1]
+	scale := func(v float64) float64 { return (v - lo) / (hi - lo) }
+	if hi/lo >= 10 {
+		scale = func(v float64) float64 { return math.Log(v/lo) / math.Log(hi/lo) }
+	}
+	plotLeft := float64(chartMargin * 2)
+	plotWidth := float64(chartWidth-chartLabelWidth) - plotLeft
+	x := func(v float64) float64 { return plotLeft + plotWidth*scale(v) }
+	y := func(v float64) float64 { return chartMargin/2 + (1-v/maxY)*chartPlotHeight }
+	height := chartPlotHeight + chartMargin*2
+
+	var b strings.Builder
+	for i := 0; i <= 4; i++ {
+		v := maxY * float64(i) / 4
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#ddd"/>`, plotLeft, y(v), plotLeft+plotWidth, y(v))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="end" dominant-baseline="middle" fill="#555">%s</text>`+"\n", plotLeft-6, y(v), shortCount(v))
+	}
+	for _, v := range xs {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" fill="#555">%s</text>`, x(v), height-chartMargin/2, shortCount(v))
+	}
+	b.WriteString("\n")
+	for i, name := range names {
+		var points []chartPoint
+		for _, v := range xs {
+			if tp, ok := series[name][v]; ok {
+				points = append(points, chartPoint{x(v), y(tp), fmt.Sprintf("%s %s: %s タスク/秒", axis.title, shortCount(v), shortCount(tp))})
+			}
+		}
+		drawSeries(&b, i, name, points)
+	}
+	return svgChart{chartWidth, height, b.String()}, true
+}
+
+// 折れ線の1点（座標はピクセル）と、点に重ねて表示する値
+type chartPoint struct {
+	x, y  float64
+	label string
+}
+
+// i番目の系列の折れ線と点を描き、グラフの右側に凡例を加える
+func drawSeries(b *strings.Builder, i int, name string, points []chartPoint) {
+	color := chartColors[i%len(chartColors)]
+	coords := make([]string, len(points))
+	for j, p := range points {
+		coords[j] = fmt.Sprintf("%.1f,%.1f", p.x, p.y)
+	}
+	fmt.Fprintf(b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(coords, " "), color)
+	for _, p := range points {
+		fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s %s</title></circle>`,
+			p.x, p.y, color, html.EscapeString(name), html.EscapeString(p.label))
+	}
+	legendY := chartMargin/2 + i*18
+	fmt.Fprintf(b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/><text x="%d" y="%d" dominant-baseline="middle">%s</text>`+"\n",
+		chartWidth-chartLabelWidth+10, legendY, color, chartWidth-chartLabelWidth+28, legendY+6, html.EscapeString(name))
+}
+
+// パーセンタイルの表示（100は最大値）
+func formatPercentile(p float64) string {
+	if p == 100 {
+		return "max"
+	}
+	return "p" + formatMetric("", p)
+}
+
+// 目盛りに表示する数（大きい値はk、Mを付けて有効数字3桁程度に丸める）
+func shortCount(v float64) string {
+	switch {
+	case v >= 1e6:
+		return significant(v/1e6) + "M"
+	case v >= 1e3:
+		return significant(v/1e3) + "k"
+	}
+	return significant(v)
+}
+
+// 有効数字3桁で表示する（末尾の0は省く）
+func significant(v float64) string {
+	digits := 0
+	if v > 0 {
+		digits = max(0, 2-int(math.Floor(math.Log10(v))))
+	}
+	s := strconv.FormatFloat(v, 'f', digits, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// 目盛りに表示する時間（有効数字3桁程度に丸める）
+func shortDuration(ns float64) string {
+	d := time.Duration(ns)
+	if d <= 0 {
+		return "0"
+	}
+	unit := time.Duration(math.Pow(10, math.Floor(math.Log10(float64(d)))-2))
+	return d.Round(max(unit, 1)).String()
+}
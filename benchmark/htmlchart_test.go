@@ -0,0 +1,98 @@
+package benchmark
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 処理時間の棒グラフとレイテンシの分布のグラフがHTMLに埋め込まれることを確認する
+func TestHTMLReporterCharts(t *testing.T) {
+	a := testResult("chan-<unlimited>", 2e9)
+	a.Samples = []float64{1.8e9, 2e9, 2.4e9}
+	b := testResult("direct-unlimited", 1e9)
+	var jitters latencySketch
+	for i := 1; i <= 100; i++ {
+		jitters.add(time.Duration(i) * time.Millisecond)
+	}
+	b.Sketches = map[string]*latencySketch{sketchJitter: &jitters}
+	ramp := Result{Mode: modeRamp, Scenario: defaultScenario, Strategy: "ramp", Metrics: map[string]float64{}}
+
+	charts := resultCharts([]Result{a, b, ramp})
+	if len(charts) != 2 {
+		t.Fatalf("got %d charts, want 2 (duration and jitter)", len(charts))
+	}
+	duration := string(charts[0].SVG())
+	if got := strings.Count(duration, "<rect"); got != 2 {
+		t.Errorf("got %d bars, want 2 (results without a wall time are skipped)", got)
+	}
+	if got := strings.Count(duration, "<circle"); got != 3 {
+		t.Errorf("got %d sample points, want 3", got)
+	}
+	if !strings.Contains(duration, "chan-&lt;unlimited&gt;") {
+		t.Errorf("strategy name is not escaped:\n%s", duration)
+	}
+	latency := string(charts[1].SVG())
+	if !strings.Contains(charts[1].Title, chartSketchTitle(sketchJitter)) || !strings.Contains(latency, "<polyline") {
+		t.Errorf("unexpected latency chart %q:\n%s", charts[1].Title, latency)
+	}
+	for _, label := range []string{">p50<", ">p99.9<", ">max<"} {
+		if !strings.Contains(latency, label) {
+			t.Errorf("missing axis label %s", label)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "report.html")
+	rep, err := newHTMLReporter(path, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []Result{a, b} {
+		if err := rep.Report(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if html := string(out); !strings.Contains(html, "<h2>グラフ</h2>") || strings.Count(html, "<svg") != 2 {
+		t.Errorf("charts are not embedded:\n%s", html)
+	}
+}
+
+func TestShortLabels(t *testing.T) {
+	for _, c := range []struct {
+		ns   float64
+		want string
+	}{
+		{0, "0"},
+		{1234567, "1.23ms"},
+		{2e9, "2s"},
+		{999, "999ns"},
+	} {
+		if got := shortDuration(c.ns); got != c.want {
+			t.Errorf("shortDuration(%v) = %q, want %q", c.ns, got, c.want)
+		}
+	}
+	for _, c := range []struct {
+		v    float64
+		want string
+	}{
+		{0, "0"},
+		{12.5, "12.5"},
+		{1000, "1k"},
+		{123456, "123k"},
+		{2500000, "2.5M"},
+	} {
+		if got := shortCount(c.v); got != c.want {
+			t.Errorf("shortCount(%v) = %q, want %q", c.v, got, c.want)
+		}
+	}
+}
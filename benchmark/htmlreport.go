@@ -0,0 +1,129 @@
+package benchmark
+
+import (
+	"errors"
+	"html/template"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 全ての結果を1つのHTMLの表にまとめ、処理時間、スループット、レイテンシの分布のグラフ、各戦略の構成図（Mermaid）を添えて書き出す
+type htmlReporter struct {
+	path    string
+	results []Result
+}
+
+func newHTMLReporter(target string, _ io.Writer) (Reporter, error) {
+	if target == "" {
+		return nil, errors.New("output file is required (e.g. html=report.html)")
+	}
+	return &htmlReporter{path: target}, nil
+}
+
+func (h *htmlReporter) Report(r Result) error {
+	h.results = append(h.results, r)
+	return nil
+}
+
+func (h *htmlReporter) Close() error {
+	f, err := os.Create(h.path)
+	if err != nil {
+		return err
+	}
+	data := struct {
+		Results  []Result
+		Metrics  []string
+		Charts   []resultChart
+		Diagrams []diagram
+		Env      Environment
+		Code     string
+	}{
+		Results:  h.results,
+		Metrics:  metricNames(h.results),
+		Charts:   resultCharts(h.results),
+		Diagrams: resultDiagrams(h.results),
+		Env:      captureEnvironment(),
+	}
+	if len(h.results) > 0 {
+		data.Code = h.results[0].Provenance.label()
+	}
+	err = htmlTemplate.Execute(f, data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// メトリクスの値を表示用に整形する（_nsで終わるものは時間として表示する）
+func formatMetric(name string, v float64) string {
+	if strings.HasSuffix(name, "_ns") {
+		return time.Duration(v).String()
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"metric": func(r Result, name string) string {
+		v, ok := r.Metrics[name]
+		if !ok {
+			return ""
+		}
+		return formatMetric(name, v)
+	},
+	"params": formatParams,
+}).Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<title>ベンチマーク結果</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; font-size: 13px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; white-space: nowrap; }
+th { background: #f4f4f4; }
+td.text { text-align: left; }
+figure { margin: 1em 0 2em; }
+figcaption { font-weight: bold; margin-bottom: 0.5em; }
+</style>
+</head>
+<body>
+<h1>ベンチマーク結果</h1>
+<p>{{.Env.GoVersion}} {{.Env.GOOS}}/{{.Env.GOARCH}}、CPUs: {{.Env.NumCPU}}、GOMAXPROCS: {{.Env.GOMAXPROCS}}、ホスト: {{.Env.Hostname}}</p>
+{{with .Code}}<p>ベンチマークのコード: {{.}}</p>{{end}}
+{{- if .Charts}}
+<h2>グラフ</h2>
+{{- range .Charts}}
+<figure>
+<figcaption>{{.Title}}</figcaption>
+{{.SVG}}
+</figure>
+{{- end}}
+<h2>全ての結果</h2>
+{{- end}}
+<table>
+<tr><th>モード</th><th>シナリオ</th><th>戦略</th><th>パラメータ</th>{{range .Metrics}}<th>{{.}}</th>{{end}}<th>注記</th></tr>
+{{- $metrics := .Metrics}}
+{{- range .Results}}
+<tr><td class="text">{{.Mode}}</td><td class="text">{{.Scenario}}</td><td class="text" title="{{.Title}}">{{.Strategy}}</td><td class="text">{{params .Params}}</td>
+{{- $r := .}}{{range $metrics}}<td>{{metric $r .}}</td>{{end}}<td class="text">{{range .Notes}}{{.}}<br>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- if .Diagrams}}
+<h2>戦略の構成</h2>
+{{- range .Diagrams}}
+<h3>{{.Scenario}} / {{.Strategy}}</h3>
+<p>{{.Title}}</p>
+<pre class="mermaid">
+{{.Mermaid}}</pre>
+{{- end}}
+<script type="module">
+import mermaid from "https://cdn.jsdelivr.net/npm/mermaid@11/dist/mermaid.esm.min.mjs";
+mermaid.initialize({ startOnLoad: true });
+</script>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,52 @@
+package benchmark
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// 供給元から読み出されてから処理を終えるまでのタスク（チャネルに積まれたものと処理中のもの）が保持するバイト数
+type inflightBytes struct {
+	cur, peak atomic.Int64
+}
+
+// タスクが保持するおおよそのバイト数（構造体とデータの文字列）
+func taskBytes(task Task) int64 {
+	return int64(unsafe.Sizeof(task)) + int64(len(task.Data))
+}
+
+func (b *inflightBytes) add(n int64) {
+	cur := b.cur.Add(n)
+	for {
+		peak := b.peak.Load()
+		if cur <= peak || b.peak.CompareAndSwap(peak, cur) {
+			return
+		}
+	}
+}
+
+// 供給元から読み出したタスクのバイト数を加え、処理を終えたタスクのバイト数を差し引くよう実行環境をラップする。
+// 処理されずに破棄されたタスクは差し引かれないため、ピークは多めに見積もられる場合がある
+func trackInflight(env Env, b *inflightBytes) Env {
+	env.Source = &inflightSource{src: env.Source, bytes: b}
+	process := env.Process
+	env.Process = func(task Task) error {
+		defer b.add(-taskBytes(task))
+		return process(task)
+	}
+	return env
+}
+
+// 読み出したタスクのバイト数を加える供給元
+type inflightSource struct {
+	src   Source
+	bytes *inflightBytes
+}
+
+func (s *inflightSource) Next() (Task, bool) {
+	task, ok := s.src.Next()
+	if ok {
+		s.bytes.add(taskBytes(task))
+	}
+	return task, ok
+}
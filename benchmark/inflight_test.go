@@ -0,0 +1,43 @@
+package benchmark
+
+import (
+	"sync"
+	"testing"
+)
+
+// 処理を終えたタスクを差し引き、同時に保持していたバイト数の最大を記録することを確認する
+func TestTrackInflight(t *testing.T) {
+	const n = 10
+	var b inflightBytes
+	env := BatchEnv(n)
+	env.Process = func(Task) error { return nil }
+	env = trackInflight(env, &b)
+
+	// 全てのタスクを読み出してから処理する
+	var tasks []Task
+	var want int64
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks = append(tasks, task)
+		want += taskBytes(task)
+	}
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			env.Process(task)
+		}()
+	}
+	wg.Wait()
+
+	if got := b.peak.Load(); got != want {
+		t.Errorf("peak = %d, want %d", got, want)
+	}
+	if got := b.cur.Load(); got != 0 {
+		t.Errorf("%d bytes still in flight after all tasks were processed", got)
+	}
+}
@@ -0,0 +1,53 @@
+package benchmark
+
+import (
+	"fmt"
+	"time"
+)
+
+// 戦略を1回実行して処理時間を計測する
+func measure(s Strategy, env Env) (time.Duration, error) {
+	start := time.Now()
+	if err := s.Run(env); err != nil {
+		return 0, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	return time.Since(start), nil
+}
+
+// 交互実行する片方の戦略と、その各回の処理時間、厳格モードの違反
+type interleaved struct {
+	s          Strategy
+	durs       []time.Duration
+	violations []string
+}
+
+// 2つの戦略を交互に（ABBA…）実行し、それぞれの各回の処理時間を返す。
+// 全てのAを実行してから全てのBを実行する方式と異なり、サーマルスロットリングや
+// バックグラウンド負荷による時間的なドリフトが両者に均等に影響する。
+// 先に実行する戦略はラウンドごとに入れ替え、直前の実行の影響（キャッシュやGCの状態）が片方に偏らないようにする。
+// 実行環境はnewEnvで作り、厳格モードの場合は各回の違反を記録する
+func runInterleaved(a, b Strategy, reps int, cd *cooldown, newEnv func(n int) (Env, *strictCheck)) (*interleaved, *interleaved, error) {
+	ra := &interleaved{s: a, durs: make([]time.Duration, 0, reps)}
+	rb := &interleaved{s: b, durs: make([]time.Duration, 0, reps)}
+	for i := 0; i < reps; i++ {
+		order := []*interleaved{ra, rb}
+		if i%2 == 1 {
+			order = []*interleaved{rb, ra}
+		}
+		for j, x := range order {
+			if i > 0 || j > 0 {
+				cd.wait()
+			}
+			env, strict := newEnv(x.s.tasks())
+			d, err := measure(x.s, env)
+			if strict != nil {
+				x.violations = addViolations(x.violations, strict.violations(env, err)...)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			x.durs = append(x.durs, d)
+		}
+	}
+	return ra, rb, nil
+}
@@ -0,0 +1,70 @@
+package benchmark
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// 先に実行する戦略をラウンドごとに入れ替え、各戦略に作った実行環境を渡すことを確認する
+func TestRunInterleavedOrder(t *testing.T) {
+	var order []string
+	run := func(name string) Strategy {
+		return Strategy{Name: name, Tasks: 10, Run: func(env Env) error {
+			order = append(order, name)
+			return strictSequential(env)
+		}}
+	}
+	var sizes []int
+	newEnv := func(n int) (Env, *strictCheck) {
+		sizes = append(sizes, n)
+		return batchEnv(n, sprintfData), nil
+	}
+	ra, rb, err := runInterleaved(run("A"), run("B"), 3, &cooldown{}, newEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A", "B", "B", "A", "A", "B"}; !slices.Equal(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if len(ra.durs) != 3 || len(rb.durs) != 3 || len(sizes) != 6 {
+		t.Errorf("durations = %d, %d, environments = %d", len(ra.durs), len(rb.durs), len(sizes))
+	}
+}
+
+// 交互実行でも、設定した処理時間のモデルで処理し、厳格モードの違反を結果に記録することを確認する
+func TestRunInterleavedMode(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Repetitions = 2
+	cfg.Strict = true
+	cfg.Interleave = []string{"good", "bad"}
+	cfg.Processing = &ProcessingProfile{Base: 2 * time.Millisecond}
+	rep := &collectReporter{}
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}, reporters: []Reporter{rep}}
+
+	list := []Strategy{
+		{Name: "good", Tasks: 10, Run: strictSequential},
+		{Name: "bad", Tasks: 10, Run: strictSwallow},
+	}
+	if err := r.runInterleavedMode(list); err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.results) != 2 {
+		t.Fatalf("got %d results, want 2", len(rep.results))
+	}
+	good, bad := rep.results[0], rep.results[1]
+	// 10個のタスクを順に2msずつ処理する
+	if d := time.Duration(good.Metrics[metricWall]); d < 20*time.Millisecond {
+		t.Errorf("wall = %v, want the configured processing time", d)
+	}
+	if v := good.Metrics[metricStrictViolations]; v != 0 {
+		t.Errorf("violations of a correct strategy = %v, notes %q", v, good.Notes)
+	}
+	if v := bad.Metrics[metricStrictViolations]; v != 1 {
+		t.Errorf("violations of a swallowing strategy = %v, notes %q", v, bad.Notes)
+	}
+	if !slices.Equal(r.strictFailed, []string{"/bad"}) {
+		t.Errorf("strict failures = %v", r.strictFailed)
+	}
+}
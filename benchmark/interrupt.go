@@ -0,0 +1,41 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+)
+
+// 実行がシグナルなどで中断されたことを表すエラー
+var ErrInterrupted = errors.New("interrupted")
+
+// 戦略をキャンセル可能にする。キャンセルされると新しいタスクの供給を止め、
+// 戦略のコンテキストもキャンセルして処理中のタスクの完了を待つ。
+// 中断された実行の処理時間は不完全なため、戦略の結果にかかわらずErrInterruptedを返す
+func withInterrupt(s Strategy, ctx context.Context) Strategy {
+	run := s.Run
+	s.Run = func(env Env) error {
+		if env.Ctx == nil {
+			env.Ctx = ctx
+		}
+		env.Source = &cancelSource{Source: env.Source, ctx: ctx}
+		err := run(env)
+		if ctx.Err() != nil {
+			return ErrInterrupted
+		}
+		return err
+	}
+	return s
+}
+
+// コンテキストがキャンセルされたらタスクの供給を止める
+type cancelSource struct {
+	Source
+	ctx context.Context
+}
+
+func (s *cancelSource) Next() (Task, bool) {
+	if s.ctx.Err() != nil {
+		return Task{}, false
+	}
+	return s.Source.Next()
+}
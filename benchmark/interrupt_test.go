@@ -0,0 +1,35 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// キャンセルされると供給が止まり、戦略がErrInterruptedを返すことを確認する
+func TestWithInterruptStopsSource(t *testing.T) {
+	const n = 1000
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var processed atomic.Int64
+	env := BatchEnv(n)
+	env.Process = func(task Task) error {
+		if processed.Add(1) == 10 {
+			cancel()
+		}
+		return processTask(task)
+	}
+
+	run := func(env Env) error {
+		return DirectGoroutineWithLimitedParallelism(env, 2)
+	}
+	s := withInterrupt(Strategy{Name: "direct-limited", Run: run}, ctx)
+	if err := s.Run(env); !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("got %v, want ErrInterrupted", err)
+	}
+	if got := processed.Load(); got >= n {
+		t.Errorf("source was not stopped: processed %d tasks", got)
+	}
+}
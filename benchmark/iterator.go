@@ -0,0 +1,131 @@
+package benchmark
+
+import (
+	"fmt"
+	"iter"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// イテレータのシナリオの戦略一覧。
+// 供給元をiter.Seq[Task]として扱い、1つのgoroutineがrangeで読んでチャネルに送るプッシュ型と、
+// iter.Pullで引き出し関数に変えて各ワーカーが自分で次のタスクを引くプル型を、
+// 同じワーカー数・同じタスクで比べる。処理時間を待機しないタスクでは受け渡しの速さの差がそのまま表れる
+func iteratorStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	var list []Strategy
+	for _, instant := range []bool{false, true} {
+		suffix, note := "", ""
+		if instant {
+			suffix, note = "-instant", "、処理時間の待機なし"
+		}
+		list = append(list,
+			Strategy{
+				Name:  "iter-push" + suffix,
+				Title: fmt.Sprintf("iter.Seqをrangeで読んでチャネルに送るプッシュ型（%dワーカー%s）", numWorkers, note),
+				Func:  "IteratorPushPool",
+				Limit: numWorkers,
+				Topology: Topology{
+					Stages: []Stage{
+						{Role: RoleProducer, Goroutines: 1, Note: "iter.Seqをrange"},
+						{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100)},
+					},
+					Completion: "errgroup.Group",
+				},
+				Run: func(env Env) error {
+					return IteratorPushPool(env, numWorkers)
+				},
+			},
+			Strategy{
+				Name:  "iter-pull" + suffix,
+				Title: fmt.Sprintf("iter.Pullの引き出し関数を各ワーカーが呼ぶプル型（%dワーカー%s）", numWorkers, note),
+				Func:  "IteratorPullPool",
+				Limit: numWorkers,
+				Topology: Topology{
+					Stages: []Stage{
+						{Role: RoleProducer, Goroutines: 1, Note: "iter.Pullのコルーチン"},
+						{Role: RoleWorker, Goroutines: numWorkers, From: "next()の呼び出し（sync.Mutexで保護）"},
+					},
+					Completion: "errgroup.Group",
+				},
+				Run: func(env Env) error {
+					return IteratorPullPool(env, numWorkers)
+				},
+			},
+		)
+		if instant {
+			for i := len(list) - 2; i < len(list); i++ {
+				run := list[i].Run
+				list[i].Run = func(env Env) error {
+					env.Source = &instantSource{src: env.Source}
+					return run(env)
+				}
+			}
+		}
+	}
+	return list
+}
+
+// 供給元の列をrangeで読んでバッファ付きチャネルに送り、numWorkers個のワーカーがチャネルから受け取って処理する
+func IteratorPushPool(env Env, numWorkers int) error {
+	eg, ctx := errgroup.WithContext(env.context())
+	tasks := make(chan Task, 100)
+
+	eg.Go(func() error {
+		defer close(tasks)
+		for task := range sourceSeq(env.Source) {
+			select {
+			case tasks <- task:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		return nil
+	})
+	for i := 0; i < numWorkers; i++ {
+		eg.Go(func() error {
+			for task := range tasks {
+				if ctx.Err() != nil {
+					env.drop(1)
+					continue
+				}
+				if err := env.Process(task); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// 供給元の列をiter.Pullで引き出し関数に変え、numWorkers個のワーカーがそれぞれ次のタスクを引いて処理する。
+// 引き出し関数は並行に呼べないため、sync.Mutexで保護する
+func IteratorPullPool(env Env, numWorkers int) error {
+	next, stop := iter.Pull(sourceSeq(env.Source))
+	defer stop()
+	var mu sync.Mutex
+	pull := func() (Task, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		return next()
+	}
+
+	eg, ctx := errgroup.WithContext(env.context())
+	for i := 0; i < numWorkers; i++ {
+		eg.Go(func() error {
+			for ctx.Err() == nil {
+				task, ok := pull()
+				if !ok {
+					return nil
+				}
+				if err := env.Process(task); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
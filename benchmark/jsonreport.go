@@ -0,0 +1,35 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// 全ての結果をJSONの配列として書き出す
+type jsonReporter struct {
+	w       io.WriteCloser
+	results []Result
+}
+
+func newJSONReporter(target string, stdout io.Writer) (Reporter, error) {
+	w, err := createOutput(target, stdout)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonReporter{w: w, results: []Result{}}, nil
+}
+
+func (j *jsonReporter) Report(r Result) error {
+	j.results = append(j.results, r)
+	return nil
+}
+
+func (j *jsonReporter) Close() error {
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(j.results)
+	if closeErr := j.w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,243 @@
+package benchmark
+
+import (
+	"fmt"
+	"sync"
+)
+
+// タスクの種類（処理時間の異なるタスクを別の型として扱う）
+type taskKind int
+
+const (
+	kindLight taskKind = iota
+	kindMedium
+	kindHeavy
+	numTaskKinds
+)
+
+// processTaskの処理時間の区分に合わせてタスクの種類を決める
+func kindOf(task Task) taskKind {
+	switch {
+	case task.ID%100 == 0:
+		return kindHeavy
+	case task.ID%10 == 0:
+		return kindMedium
+	default:
+		return kindLight
+	}
+}
+
+// 種類ごとの具象型
+type lightTask struct{ Task }
+type mediumTask struct{ Task }
+type heavyTask struct{ Task }
+
+// インターフェースのメソッド呼び出しで処理を振り分けるためのジョブ
+type job interface {
+	run(process func(Task) error) error
+}
+
+func (t lightTask) run(process func(Task) error) error  { return process(t.Task) }
+func (t mediumTask) run(process func(Task) error) error { return process(t.Task) }
+func (t heavyTask) run(process func(Task) error) error  { return process(t.Task) }
+
+// タスクを種類に応じた具象型に変換する
+func newJob(task Task) job {
+	switch kindOf(task) {
+	case kindHeavy:
+		return heavyTask{task}
+	case kindMedium:
+		return mediumTask{task}
+	default:
+		return lightTask{task}
+	}
+}
+
+// 複数種類のタスクを扱うシナリオの戦略一覧
+func taskKindStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	return []Strategy{
+		{
+			Name:  "kinds-type-switch",
+			Title: fmt.Sprintf("単一チャネル（any）+ 型switchで振り分け（%dワーカー）", numWorkers),
+			Func:  "taskKindsTypeSwitch",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("any", 100), Note: "型switchで振り分け"},
+				},
+				Completion: "sync.WaitGroup + ワーカーごとのエラーチャネル",
+			},
+			Run: func(env Env) error {
+				return taskKindsTypeSwitch(env, numWorkers)
+			},
+		},
+		{
+			Name:  "kinds-interface",
+			Title: fmt.Sprintf("単一チャネル（インターフェース）+ メソッド呼び出しで振り分け（%dワーカー）", numWorkers),
+			Func:  "taskKindsInterface",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("job", 100), Note: "メソッド呼び出しで振り分け"},
+				},
+				Completion: "sync.WaitGroup + ワーカーごとのエラーチャネル",
+			},
+			Run: func(env Env) error {
+				return taskKindsInterface(env, numWorkers)
+			},
+		},
+		{
+			Name:  "kinds-channels",
+			Title: fmt.Sprintf("種類ごとのチャネル + 種類ごとのワーカー（合計%dワーカー）", numWorkers),
+			Func:  "taskKindsSeparateChannels",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: max(numWorkers/int(numTaskKinds), 1) * int(numTaskKinds), From: chanFrom("Task", 100) + " × 種類数", Note: "種類ごとにワーカーを割り当て"},
+				},
+				Completion: "sync.WaitGroup + ワーカーごとのエラーチャネル",
+			},
+			Run: func(env Env) error {
+				return taskKindsSeparateChannels(env, numWorkers)
+			},
+		},
+	}
+}
+
+// 単一のチャネルで任意の型を受け渡し、ワーカーが型switchで処理を振り分ける実装
+func taskKindsTypeSwitch(env Env, numWorkers int) error {
+	tasks := make(chan any, 100)
+	errs := make(chan error, numWorkers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var firstErr error
+			for v := range tasks {
+				var err error
+				switch t := v.(type) {
+				case lightTask:
+					err = env.Process(t.Task)
+				case mediumTask:
+					err = env.Process(t.Task)
+				case heavyTask:
+					err = env.Process(t.Task)
+				default:
+					err = fmt.Errorf("unexpected task type %T", v)
+				}
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}
+			errs <- firstErr
+		}()
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks <- newJob(task)
+	}
+	close(tasks)
+
+	wg.Wait()
+	close(errs)
+	return firstError(errs)
+}
+
+// 単一のチャネルでインターフェースを受け渡し、ワーカーがメソッド呼び出しで処理する実装
+func taskKindsInterface(env Env, numWorkers int) error {
+	jobs := make(chan job, 100)
+	errs := make(chan error, numWorkers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var firstErr error
+			for j := range jobs {
+				if err := j.run(env.Process); err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}
+			errs <- firstErr
+		}()
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		jobs <- newJob(task)
+	}
+	close(jobs)
+
+	wg.Wait()
+	close(errs)
+	return firstError(errs)
+}
+
+// 種類ごとに専用のチャネルとワーカーを用意し、供給側で振り分ける実装。
+// ワーカーは種類ごとに均等に割り当てる（各種類に少なくとも1つ）
+func taskKindsSeparateChannels(env Env, numWorkers int) error {
+	perKind := numWorkers / int(numTaskKinds)
+	if perKind < 1 {
+		perKind = 1
+	}
+
+	var chans [numTaskKinds]chan Task
+	errs := make(chan error, perKind*int(numTaskKinds))
+
+	var wg sync.WaitGroup
+	for k := range chans {
+		chans[k] = make(chan Task, 100)
+		for w := 0; w < perKind; w++ {
+			wg.Add(1)
+			go func(ch <-chan Task) {
+				defer wg.Done()
+				var firstErr error
+				for task := range ch {
+					if err := env.Process(task); err != nil && firstErr == nil {
+						firstErr = err
+					}
+				}
+				errs <- firstErr
+			}(chans[k])
+		}
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		chans[kindOf(task)] <- task
+	}
+	for _, ch := range chans {
+		close(ch)
+	}
+
+	wg.Wait()
+	close(errs)
+	return firstError(errs)
+}
+
+// ワーカーから集めたエラーのうち最初のものを返す
+func firstError(errs <-chan error) error {
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
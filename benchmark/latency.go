@@ -0,0 +1,99 @@
+package benchmark
+
+import (
+	"sync"
+	"time"
+)
+
+// タスクごとのレイテンシを記録する。
+// 予定送信時刻を基準にしたレイテンシと、実際の送信時刻を基準にしたレイテンシの両方を記録する。
+// 供給側がブロックして送信が遅れると後者ではその遅れが見えなくなる（Coordinated Omission）ため、
+// 前者を主な指標とする。
+// また、レイテンシを処理開始までの待ち時間（キューイング）と処理そのものの時間に分解して記録する
+type latencyRecorder struct {
+	mu            sync.Mutex
+	fromScheduled latencySketch
+	fromSent      latencySketch
+	waits         latencySketch
+	services      latencySketch
+}
+
+// 結果に含めるスケッチの名前
+const (
+	sketchLatency       = "latency"
+	sketchActualLatency = "actual_latency"
+	sketchWait          = "wait"
+	sketchService       = "service"
+	sketchJitter        = "jitter"
+	sketchRequest       = "request"
+	sketchTaskLatency   = "task_latency"
+)
+
+// 記録したスケッチを名前ごとに返す
+func (r *latencyRecorder) sketches() map[string]*latencySketch {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return map[string]*latencySketch{
+		sketchLatency:       &r.fromScheduled,
+		sketchActualLatency: &r.fromSent,
+		sketchWait:          &r.waits,
+		sketchService:       &r.services,
+	}
+}
+
+// 処理関数をラップし、完了時にレイテンシを記録する
+func (r *latencyRecorder) wrap(process func(Task) error) func(Task) error {
+	return func(task Task) error {
+		start := time.Now()
+		err := process(task)
+		end := time.Now()
+
+		scheduled := task.Scheduled
+		if scheduled.IsZero() {
+			scheduled = task.Sent
+		}
+
+		r.mu.Lock()
+		r.fromScheduled.add(end.Sub(scheduled))
+		r.fromSent.add(end.Sub(task.Sent))
+		r.waits.add(start.Sub(scheduled))
+		r.services.add(end.Sub(start))
+		r.mu.Unlock()
+		return err
+	}
+}
+
+// レイテンシの集計結果
+type latencySummary struct {
+	Count         int
+	P50, P90, P99 time.Duration
+	Min, Max      time.Duration
+}
+
+// 予定送信時刻を基準にしたレイテンシを集計する
+func (r *latencyRecorder) intended() latencySummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fromScheduled.summary()
+}
+
+// 実際の送信時刻を基準にしたレイテンシを集計する
+func (r *latencyRecorder) actual() latencySummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fromSent.summary()
+}
+
+// 予定送信時刻から処理開始までの待ち時間を集計する
+func (r *latencyRecorder) wait() latencySummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.waits.summary()
+}
+
+// 処理開始から完了までのサービス時間を集計する
+func (r *latencyRecorder) service() latencySummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.services.summary()
+}
@@ -0,0 +1,46 @@
+package benchmark
+
+import (
+	"testing"
+	"time"
+)
+
+// 各タスクのレイテンシが、処理開始までの待ち時間とサービス時間の和に分解されることを確認する
+func TestLatencyRecorderDecomposition(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		task    Task
+		service time.Duration
+		// 予定送信時刻から処理開始までの待ち時間の下限
+		minWait time.Duration
+	}{
+		{"queued", Task{Scheduled: now.Add(-20 * time.Millisecond), Sent: now}, 5 * time.Millisecond, 20 * time.Millisecond},
+		{"on time", Task{Scheduled: now, Sent: now}, 2 * time.Millisecond, 0},
+		// 閉ループでは予定送信時刻がなく、実際の送信時刻を基準にする
+		{"closed loop", Task{Sent: now.Add(-3 * time.Millisecond)}, time.Millisecond, 3 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &latencyRecorder{}
+			process := rec.wrap(func(Task) error {
+				time.Sleep(tt.service)
+				return nil
+			})
+			if err := process(tt.task); err != nil {
+				t.Fatal(err)
+			}
+			// 1件だけ記録したため、最大値は記録した値そのもの
+			total, wait, service := rec.intended().Max, rec.wait().Max, rec.service().Max
+			if wait+service != total {
+				t.Errorf("wait %v + service %v = %v, want latency %v", wait, service, wait+service, total)
+			}
+			if service < tt.service {
+				t.Errorf("service = %v, want at least %v", service, tt.service)
+			}
+			if wait < tt.minWait {
+				t.Errorf("wait = %v, want at least %v", wait, tt.minWait)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package benchmark
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ワーカーの貸し出しのシナリオの戦略一覧。
+// いずれも同じ数のワーカーでタスクを処理し、タスクをワーカーに渡す仕組みだけが異なる
+func leaseStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	shared := func(buffer int) Strategy {
+		return Strategy{
+			Name:  fmt.Sprintf("lease-shared-chan-%d", buffer),
+			Title: fmt.Sprintf("ワーカープール（%dワーカー）+ 全ワーカーが受信する共有チャネル（バッファ%d）", numWorkers, buffer),
+			Func:  "SharedChannelPool",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", buffer)},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return SharedChannelPool(env, numWorkers, buffer)
+			},
+		}
+	}
+	return []Strategy{
+		shared(0),
+		shared(100),
+		{
+			Name:  "lease-worker-chan",
+			Title: fmt.Sprintf("ワーカーの貸し出し（%dワーカー）+ 空いたワーカーが自分のチャネルを登録するチャネルのチャネル", numWorkers),
+			Func:  "WorkerLease",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleDispatcher, Goroutines: 1, From: chanFrom("chan Task", numWorkers), Note: "空いたワーカーのチャネルを受信して借りる"},
+					{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 0), Note: "ワーカーごとに1つ"},
+				},
+				Completion: "全ワーカーを借り直して各チャネルを閉じる + sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return WorkerLease(env, numWorkers)
+			},
+		},
+	}
+}
+
+// 全ワーカーが1つの共有チャネルからタスクを受信するワーカープール。
+// バッファが0の場合は、いずれかのワーカーが受信するまで送信側が待つ
+func SharedChannelPool(env Env, numWorkers, buffer int) error {
+	tasks := make(chan Task, buffer)
+	var mu sync.Mutex
+	var firstErr error
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				if err := env.Process(task); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks <- task
+	}
+	close(tasks)
+	wg.Wait()
+	return firstErr
+}
+
+// 各ワーカーが自分専用のチャネルを持ち、空いたら「空きワーカー」のチャネルに自分のチャネルを登録する。
+// ディスパッチャーは空きワーカーのチャネルを受信してワーカーを借り、そのワーカーのチャネルにタスクを送る
+// （Goの古典的な講演で紹介されたチャネルのチャネルによる貸し出し）。
+// 全てのタスクを渡した後は全ワーカーを借り直すことで、処理中のタスクの完了を待ってから各チャネルを閉じる
+func WorkerLease(env Env, numWorkers int) error {
+	idle := make(chan chan Task, numWorkers)
+	var mu sync.Mutex
+	var firstErr error
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mine := make(chan Task)
+			for {
+				idle <- mine
+				task, ok := <-mine
+				if !ok {
+					return
+				}
+				if err := env.Process(task); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		worker := <-idle
+		worker <- task
+	}
+	for i := 0; i < numWorkers; i++ {
+		close(<-idle)
+	}
+	wg.Wait()
+	return firstErr
+}
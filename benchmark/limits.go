@@ -0,0 +1,105 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+// リソース使用量を確認する間隔
+const limitCheckInterval = 10 * time.Millisecond
+
+// 実行中のリソース使用量の上限。0の項目は制限しない。
+// 無制限の並列処理を大きなタスク数で実行したときに、小さなマシンがメモリ不足に陥るのを防ぐ
+type ResourceLimits struct {
+	// ヒープ上のオブジェクトのバイト数の上限
+	MaxHeapBytes uint64
+	// goroutine数の上限
+	MaxGoroutines int
+}
+
+func (l ResourceLimits) enabled() bool {
+	return l.MaxHeapBytes > 0 || l.MaxGoroutines > 0
+}
+
+func (l ResourceLimits) validate() error {
+	if l.MaxGoroutines < 0 {
+		return fmt.Errorf("max goroutines must not be negative, got %d", l.MaxGoroutines)
+	}
+	return nil
+}
+
+// リソースの上限を超えたために戦略の実行を中止したことを表すエラー
+type limitError struct {
+	strategy string
+	reason   string
+}
+
+func (e *limitError) Error() string {
+	return fmt.Sprintf("%s: resource limit exceeded: %s", e.strategy, e.reason)
+}
+
+// 実行中のリソース使用量を監視し、上限を超えたら戦略のコンテキストをキャンセルして
+// タスクの供給を止め、limitErrorを返す
+func withLimits(s Strategy, limits ResourceLimits) Strategy {
+	if !limits.enabled() {
+		return s
+	}
+	run := s.Run
+	s.Run = func(env Env) error {
+		ctx, cancel := context.WithCancelCause(env.context())
+		defer cancel(nil)
+		env.Ctx = ctx
+		env.Source = &cancelSource{Source: env.Source, ctx: ctx}
+
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			watchLimits(limits, done, func(reason string) {
+				cancel(&limitError{strategy: s.Name, reason: reason})
+			})
+		}()
+
+		err := run(env)
+		close(done)
+		<-stopped
+		if cause := context.Cause(ctx); cause != nil {
+			if le, ok := cause.(*limitError); ok {
+				return le
+			}
+		}
+		return err
+	}
+	return s
+}
+
+// doneが閉じられるか上限を超えるまで、一定間隔でリソース使用量を確認する
+func watchLimits(limits ResourceLimits, done <-chan struct{}, exceeded func(reason string)) {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	ticker := time.NewTicker(limitCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if n := runtime.NumGoroutine(); limits.MaxGoroutines > 0 && n > limits.MaxGoroutines {
+			exceeded(fmt.Sprintf("goroutine数 %d が上限 %d を超えました", n, limits.MaxGoroutines))
+			return
+		}
+		if limits.MaxHeapBytes > 0 {
+			// ReadMemStatsと異なりStop The Worldを伴わないため、計測への影響が小さい
+			metrics.Read(sample)
+			if heap := sample[0].Value.Uint64(); heap > limits.MaxHeapBytes {
+				exceeded(fmt.Sprintf("ヒープ使用量 %.1f MB が上限 %.1f MB を超えました",
+					float64(heap)/(1<<20), float64(limits.MaxHeapBytes)/(1<<20)))
+				return
+			}
+		}
+	}
+}
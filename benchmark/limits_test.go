@@ -0,0 +1,33 @@
+package benchmark
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// goroutine数が上限を超えると実行を中止し、理由を含むエラーを返すことを確認する
+func TestWithLimitsAbortsOnGoroutines(t *testing.T) {
+	env := BatchEnv(20000)
+	env.Process = func(Task) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}
+
+	s := withLimits(Strategy{Name: "direct-unlimited", Run: DirectGoroutineWithUnlimitedParallelism}, ResourceLimits{MaxGoroutines: 50})
+	err := s.Run(env)
+	var le *limitError
+	if !errors.As(err, &le) {
+		t.Fatalf("got %v, want limitError", err)
+	}
+	if le.strategy != "direct-unlimited" || le.reason == "" {
+		t.Errorf("unexpected limit error: %+v", le)
+	}
+}
+
+func TestWithLimitsDisabled(t *testing.T) {
+	s := Strategy{Name: "direct-unlimited", Run: DirectGoroutineWithUnlimitedParallelism}
+	if err := withLimits(s, ResourceLimits{}).Run(BatchEnv(100)); err != nil {
+		t.Fatal(err)
+	}
+}
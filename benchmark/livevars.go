@@ -0,0 +1,132 @@
+package benchmark
+
+import (
+	"expvar"
+	"sync"
+)
+
+// 実行中の戦略のカウンタを公開するexpvarの変数（/debug/varsの"benchmark"）。
+// 「シナリオ/戦略」ごとに、繰り返しを通した累計を公開する
+var liveVars = expvar.NewMap("benchmark")
+
+// liveVarsへの戦略の登録を直列化する
+var liveVarsMu sync.Mutex
+
+// 1つの戦略の実行中のカウンタ
+type liveCounters struct {
+	// 供給元から読み出したタスク数
+	submitted expvar.Int
+	// 処理中のタスク数
+	inFlight expvar.Int
+	// 処理が終わったタスク数と、そのうちエラーを返したタスク数
+	completed expvar.Int
+	errored   expvar.Int
+	// 実行中の実行の数（実行中は1）
+	active expvar.Int
+	// 終了した実行で破棄されたタスク数の合計と、実行中の実行のカウンタ
+	mu      sync.Mutex
+	dropped int64
+	stats   *RunStats
+}
+
+// 戦略のカウンタを作成してliveVarsに公開する。同じ戦略を再び実行する場合は既存のカウンタを使う
+func publishLiveCounters(scenario, strategy string) *liveCounters {
+	liveVarsMu.Lock()
+	defer liveVarsMu.Unlock()
+	key := scenario + "/" + strategy
+	if v, ok := liveVars.Get(key).(*liveCountersVar); ok {
+		return v.c
+	}
+	c := &liveCounters{}
+	liveVars.Set(key, &liveCountersVar{c})
+	return c
+}
+
+// 破棄したタスク数（実行中の実行の分を含む）
+func (c *liveCounters) droppedTotal() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.dropped
+	if c.stats != nil {
+		n += c.stats.Dropped.Load()
+	}
+	return n
+}
+
+// 読み出されてから処理を始めるまでのタスク数（チャネルに積まれたものやセマフォを待っているもの）。
+// カウンタを別々に読むため、一時的に負になった場合は0とする
+func (c *liveCounters) queued() int64 {
+	return max(c.submitted.Value()-c.completed.Value()-c.inFlight.Value()-c.droppedTotal(), 0)
+}
+
+// 戦略を実行中か
+func (c *liveCounters) running() bool {
+	return c.active.Value() > 0
+}
+
+// 実行中の実行のカウンタを切り替える。終了した実行の破棄数は合計に移す
+func (c *liveCounters) setStats(st *RunStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats != nil {
+		c.dropped += c.stats.Dropped.Load()
+	}
+	c.stats = st
+}
+
+// カウンタをJSONのオブジェクトとして公開するexpvarの変数
+type liveCountersVar struct {
+	c *liveCounters
+}
+
+func (v *liveCountersVar) String() string {
+	m := new(expvar.Map)
+	m.Set("submitted", &v.c.submitted)
+	m.Set("in_flight", &v.c.inFlight)
+	m.Set("completed", &v.c.completed)
+	m.Set("errored", &v.c.errored)
+	m.Set("dropped", expvar.Func(func() any { return v.c.droppedTotal() }))
+	return m.String()
+}
+
+// 戦略の実行中に、供給したタスク、処理中のタスク、完了したタスク、エラー、破棄したタスクを数えて公開する
+func withLiveCounters(s Strategy, scenario string) Strategy {
+	c := publishLiveCounters(scenario, s.Name)
+	run := s.Run
+	s.Run = func(env Env) error {
+		env.Source = &liveSource{Source: env.Source, c: c}
+		process := env.Process
+		env.Process = func(task Task) error {
+			c.inFlight.Add(1)
+			err := process(task)
+			c.inFlight.Add(-1)
+			c.completed.Add(1)
+			if err != nil {
+				c.errored.Add(1)
+			}
+			return err
+		}
+		if env.Stats != nil {
+			c.setStats(env.Stats)
+			defer c.setStats(nil)
+		}
+		c.active.Add(1)
+		defer c.active.Add(-1)
+		return run(env)
+	}
+	return s
+}
+
+// 供給元から読み出したタスクを数える
+type liveSource struct {
+	Source
+	c *liveCounters
+}
+
+func (s *liveSource) Next() (Task, bool) {
+	task, ok := s.Source.Next()
+	if ok {
+		s.c.submitted.Add(1)
+	}
+	return task, ok
+}
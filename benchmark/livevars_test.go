@@ -0,0 +1,59 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// 実行中は処理中のタスクが見え、終了後は供給、完了、エラー、破棄の累計が繰り返しを通して公開されることを確認する
+func TestLiveCounters(t *testing.T) {
+	const n = 100
+	var inFlight int64
+	s := Strategy{Name: "live-test", Run: func(env Env) error {
+		var firstErr error
+		for {
+			task, ok := env.Source.Next()
+			if !ok {
+				break
+			}
+			if task.ID%10 == 0 {
+				env.drop(1)
+				continue
+			}
+			if err := env.Process(task); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}}
+	s = withLiveCounters(s, "test")
+	live := publishLiveCounters("test", "live-test")
+
+	for i := 0; i < 2; i++ {
+		env := BatchEnv(n)
+		env.Stats = &RunStats{}
+		env.Process = func(task Task) error {
+			inFlight = live.inFlight.Value()
+			if task.ID%10 == 1 {
+				return errors.New("failed")
+			}
+			return nil
+		}
+		s.Run(env)
+	}
+
+	if inFlight != 1 {
+		t.Errorf("in flight during process = %d, want 1", inFlight)
+	}
+	var got map[string]int64
+	if err := json.Unmarshal([]byte(liveVars.Get("test/live-test").String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"submitted": 2 * n, "in_flight": 0, "completed": 2 * n * 9 / 10, "errored": 2 * n / 10, "dropped": 2 * n / 10}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %d, want %d", k, got[k], v)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package benchmark
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ログの出力先
+const (
+	// 標準エラー出力に直接書き出す
+	LogSinkStderr = "stderr"
+	// メモリ上のリングバッファに書き、計測の外で標準エラー出力に書き出す
+	LogSinkRing = "ring"
+)
+
+// ログをメモリ上に保持するリングバッファ。
+// 計測中にログを標準エラー出力へ書き込むと、書き込みのシステムコールの間ワーカーが直列化されて計測が歪むため、
+// 計測中はメモリに書くだけにし、計測の外でまとめて書き出す。容量を超えた場合は古い行から捨てる
+type ringLog struct {
+	mu      sync.Mutex
+	lines   [][]byte
+	next    int
+	n       int
+	dropped int
+}
+
+func newRingLog(size int) *ringLog {
+	return &ringLog{lines: make([][]byte, size)}
+}
+
+// 1回の呼び出しを1行として保持する（logパッケージは1行ずつ書き込む）
+func (l *ringLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// 捨てる行のバッファを使い回し、ログのたびに割り当てない
+	l.lines[l.next] = append(l.lines[l.next][:0], p...)
+	l.next = (l.next + 1) % len(l.lines)
+	if l.n < len(l.lines) {
+		l.n++
+	} else {
+		l.dropped++
+	}
+	return len(p), nil
+}
+
+// 保持している行を古い順に書き出して空にする
+func (l *ringLog) flush(w io.Writer) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.dropped > 0 {
+		if _, err := fmt.Fprintf(w, "（リングバッファの容量を超えた古いログ%d行を破棄しました）\n", l.dropped); err != nil {
+			return err
+		}
+	}
+	start := (l.next - l.n + len(l.lines)) % len(l.lines)
+	for i := 0; i < l.n; i++ {
+		if _, err := w.Write(l.lines[(start+i)%len(l.lines)]); err != nil {
+			return err
+		}
+	}
+	l.n, l.dropped = 0, 0
+	return nil
+}
+
+// 設定に応じて、logパッケージの出力先をリングバッファに切り替える。
+// 戻り値の関数で残りのログを書き出し、出力先を標準エラー出力に戻す
+func installLogSink(cfg Config) (*ringLog, func() error) {
+	if cfg.LogSink != LogSinkRing {
+		return nil, func() error { return nil }
+	}
+	ring := newRingLog(cfg.LogRingSize)
+	log.SetOutput(ring)
+	return ring, func() error {
+		log.SetOutput(os.Stderr)
+		return ring.flush(os.Stderr)
+	}
+}
+
+// 各タスクの処理の終了をログに出力するよう戦略をラップする（デバッグ用）
+func withDebugLog(s Strategy, enabled bool) Strategy {
+	if !enabled {
+		return s
+	}
+	run := s.Run
+	name := s.Name
+	s.Run = func(env Env) error {
+		process := env.Process
+		env.Process = func(task Task) error {
+			start := time.Now()
+			err := process(task)
+			log.Printf("%s: task %d processed in %v (err: %v)", name, task.ID, time.Since(start), err)
+			return err
+		}
+		return run(env)
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// 容量を超えた古い行を捨て、残りを古い順に書き出して空にすることを確認する
+func TestRingLog(t *testing.T) {
+	l := newRingLog(3)
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(l, "line %d\n", i)
+	}
+
+	var b strings.Builder
+	if err := l.flush(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "（リングバッファの容量を超えた古いログ2行を破棄しました）\nline 3\nline 4\nline 5\n"
+	if b.String() != want {
+		t.Errorf("flushed %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	fmt.Fprintf(l, "line 6\n")
+	if err := l.flush(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "line 6\n" {
+		t.Errorf("flushed %q after reset, want only the new line", b.String())
+	}
+}
@@ -0,0 +1,151 @@
+package benchmark
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// 全ての結果を1つのMarkdownの表にまとめ、各戦略の構成図をMermaidのコードブロックで添えて書き出す。
+// ブログやGitHubにそのまま貼り付けられる形式にする
+type markdownReporter struct {
+	w       io.WriteCloser
+	results []Result
+}
+
+func newMarkdownReporter(target string, stdout io.Writer) (Reporter, error) {
+	w, err := createOutput(target, stdout)
+	if err != nil {
+		return nil, err
+	}
+	return &markdownReporter{w: w}, nil
+}
+
+func (m *markdownReporter) Report(r Result) error {
+	m.results = append(m.results, r)
+	return nil
+}
+
+func (m *markdownReporter) Close() error {
+	err := writeMarkdown(m.w, m.results, captureEnvironment())
+	if closeErr := m.w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// 表のセルに書けるよう、区切り文字と改行を置き換える
+var markdownCell = strings.NewReplacer("|", `\|`, "\n", " ")
+
+func writeMarkdown(w io.Writer, results []Result, env Environment) error {
+	var b strings.Builder
+	b.WriteString("# ベンチマーク結果\n\n")
+	fmt.Fprintf(&b, "%s %s/%s、CPUs: %d、GOMAXPROCS: %d\n\n", env.GoVersion, env.GOOS, env.GOARCH, env.NumCPU, env.GOMAXPROCS)
+	if len(results) > 0 {
+		fmt.Fprintf(&b, "ベンチマークのコード: %s\n\n", results[0].Provenance.label())
+	}
+
+	if cmp := markdownComparison(results); cmp != "" {
+		b.WriteString("## 比較\n\n" + cmp + "\n## 全てのメトリクス\n\n")
+	}
+	metrics := metricNames(results)
+	header := append([]string{"モード", "シナリオ", "戦略", "パラメータ"}, metrics...)
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, r := range results {
+		row := []string{r.Mode, r.Scenario, r.Strategy, formatParams(r.Params)}
+		for _, name := range metrics {
+			v, ok := r.Metrics[name]
+			if !ok {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, formatMetric(name, v))
+		}
+		for i, cell := range row {
+			row[i] = markdownCell.Replace(cell)
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+
+	if diagrams := resultDiagrams(results); len(diagrams) > 0 {
+		b.WriteString("\n## 戦略の構成\n")
+		for _, d := range diagrams {
+			fmt.Fprintf(&b, "\n### %s / %s\n\n%s\n\n```mermaid\n%s```\n", d.Scenario, d.Strategy, d.Title, d.Mermaid)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// 戦略を比較する表だけをGitHub形式のMarkdownで書き出す。IssueやPRの議論にそのまま貼り付けられるよう、
+// 見出しや構成図は付けない
+type markdownTableReporter struct {
+	w       io.WriteCloser
+	results []Result
+}
+
+func newMarkdownTableReporter(target string, stdout io.Writer) (Reporter, error) {
+	w, err := createOutput(target, stdout)
+	if err != nil {
+		return nil, err
+	}
+	return &markdownTableReporter{w: w}, nil
+}
+
+func (m *markdownTableReporter) Report(r Result) error {
+	m.results = append(m.results, r)
+	return nil
+}
+
+func (m *markdownTableReporter) Close() error {
+	_, err := io.WriteString(m.w, markdownComparison(m.results))
+	if closeErr := m.w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// 処理時間の中央値を記録した結果を、シナリオごとに処理時間、1タスクあたりの処理時間、1秒あたりのタスク数、最速の戦略に対する倍率の表にする。
+// 複数のシナリオがある場合は、表の前にシナリオ名を太字で付ける。比較できる結果がない場合は空文字列を返す
+func markdownComparison(results []Result) string {
+	var scenarios []string
+	groups := map[string][]Result{}
+	for _, r := range results {
+		if _, _, ok := r.perTask(); !ok {
+			continue
+		}
+		if _, ok := groups[r.Scenario]; !ok {
+			scenarios = append(scenarios, r.Scenario)
+		}
+		groups[r.Scenario] = append(groups[r.Scenario], r)
+	}
+
+	var b strings.Builder
+	for i, sc := range scenarios {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if len(scenarios) > 1 {
+			fmt.Fprintf(&b, "**%s**\n\n", markdownCell.Replace(sc))
+		}
+		fastest := groups[sc][0].Metrics[metricWall]
+		for _, r := range groups[sc] {
+			fastest = min(fastest, r.Metrics[metricWall])
+		}
+		b.WriteString("| 戦略 | 処理時間 | 1タスクあたり | タスク/秒 | 最速比 |\n")
+		b.WriteString("| --- | ---: | ---: | ---: | ---: |\n")
+		for _, r := range groups[sc] {
+			wall := r.Metrics[metricWall]
+			slowdown := fmt.Sprintf("%.2fx", wall/fastest)
+			if wall == fastest {
+				slowdown += "（最速）"
+			}
+			nsPerTask, throughput, _ := r.perTask()
+			fmt.Fprintf(&b, "| %s | %v | %s | %.0f | %s |\n", markdownCell.Replace(r.Strategy),
+				time.Duration(wall).Round(time.Microsecond), shortDuration(nsPerTask), throughput, slowdown)
+		}
+	}
+	return b.String()
+}
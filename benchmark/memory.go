@@ -0,0 +1,147 @@
+package benchmark
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"time"
+)
+
+// メモリ使用量を確認する間隔
+const memSampleInterval = 5 * time.Millisecond
+
+// 実行中のメモリ使用量のピーク（バイト）。
+// ヒープの統計だけではgoroutineのスタックなどが含まれず、10万goroutineを起動する戦略の
+// メモリ使用量を過小評価するため、プロセスの常駐メモリ（RSS）も記録する
+type memPeak struct {
+	RSS   uint64
+	Heap  uint64
+	Stack uint64
+}
+
+func (p memPeak) max(o memPeak) memPeak {
+	return memPeak{RSS: max(p.RSS, o.RSS), Heap: max(p.Heap, o.Heap), Stack: max(p.Stack, o.Stack)}
+}
+
+// 実行中のメモリ使用量を一定間隔で記録する
+type memSampler struct {
+	done    chan struct{}
+	stopped chan struct{}
+	peak    memPeak
+	// goroutine数のピーク
+	goroutines int
+	// /proc/self/clear_refsでピークRSSをリセットできた場合はVmHWMも使う
+	hwm bool
+}
+
+// 前の実行で確保したメモリをOSに返し、ピークRSSをリセットしてから記録を始める
+func startMemSampler() *memSampler {
+	debug.FreeOSMemory()
+	m := &memSampler{
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		hwm:     os.WriteFile("/proc/self/clear_refs", []byte("5"), 0) == nil,
+	}
+	go func() {
+		defer close(m.stopped)
+		ticker := time.NewTicker(memSampleInterval)
+		defer ticker.Stop()
+		for {
+			m.peak = m.peak.max(readMem())
+			m.goroutines = max(m.goroutines, runtime.NumGoroutine())
+			select {
+			case <-m.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return m
+}
+
+// 記録を止めてピークを返す
+func (m *memSampler) stop() memPeak {
+	close(m.done)
+	<-m.stopped
+	peak := m.peak.max(readMem())
+	if m.hwm {
+		if _, hwm, ok := readProcRSS(); ok {
+			peak.RSS = max(peak.RSS, hwm)
+		}
+	}
+	return peak
+}
+
+var memSamples = []metrics.Sample{
+	{Name: "/memory/classes/heap/objects:bytes"},
+	{Name: "/memory/classes/heap/stacks:bytes"},
+	{Name: "/memory/classes/total:bytes"},
+	{Name: "/memory/classes/heap/released:bytes"},
+}
+
+// 現在のメモリ使用量を読み取る。/procがない環境では、ランタイムがOSから確保している
+// メモリ（返却済みの分を除く）をRSSの近似値とする
+func readMem() memPeak {
+	samples := make([]metrics.Sample, len(memSamples))
+	copy(samples, memSamples)
+	metrics.Read(samples)
+
+	p := memPeak{
+		Heap:  samples[0].Value.Uint64(),
+		Stack: samples[1].Value.Uint64(),
+		RSS:   samples[2].Value.Uint64() - samples[3].Value.Uint64(),
+	}
+	if rss, _, ok := readProcRSS(); ok {
+		p.RSS = rss
+	}
+	return p
+}
+
+// /proc/self/statusから現在のRSS（VmRSS）とピークRSS（VmHWM）を読み取る
+func readProcRSS() (rss, hwm uint64, ok bool) {
+	b, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0, 0, false
+	}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		fields := bytes.Fields(sc.Bytes())
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseUint(string(fields[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch string(fields[0]) {
+		case "VmRSS:":
+			rss = kb << 10
+		case "VmHWM:":
+			hwm = kb << 10
+		}
+	}
+	return rss, hwm, rss > 0
+}
+
+// 処理を終えてからプールの終了を始めるまでの待機中のメモリ使用量
+type idleMemory struct {
+	enabled  bool
+	recorded bool
+	mem      memPeak
+}
+
+// 不要になったメモリを回収してOSに返してから、残っているメモリ使用量を読み取る。
+// 待機中のプールが保持し続けるgoroutineのスタックやコンテキストなどの分だけが残る
+func readIdleMem() memPeak {
+	debug.FreeOSMemory()
+	return readMem()
+}
+
+// バイトをMBに変換する
+func toMB(b uint64) float64 {
+	return float64(b) / (1 << 20)
+}
@@ -0,0 +1,26 @@
+package benchmark
+
+import (
+	"runtime"
+	"testing"
+)
+
+// 記録中に確保したメモリがピークに反映されることを確認する
+func TestMemSamplerPeak(t *testing.T) {
+	const size = 64 << 20
+
+	m := startMemSampler()
+	buf := make([]byte, size)
+	for i := range buf {
+		buf[i] = 1
+	}
+	peak := m.stop()
+	runtime.KeepAlive(buf)
+
+	if peak.RSS < size {
+		t.Errorf("peak RSS %d is smaller than the allocated %d bytes", peak.RSS, size)
+	}
+	if peak.Heap < size {
+		t.Errorf("peak heap %d is smaller than the allocated %d bytes", peak.Heap, size)
+	}
+}
@@ -0,0 +1,207 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// スケッチから求め直すメトリクス（パーセンタイルが100の場合は最大値）
+var sketchMetrics = map[string][]struct {
+	p      float64
+	metric string
+}{
+	sketchLatency: {
+		{50, metricLatencyP50}, {90, metricLatencyP90}, {99, metricLatencyP99}, {100, metricLatencyMax},
+	},
+	sketchActualLatency: {{99, metricActualLatencyP99}},
+	sketchWait:          {{50, metricWaitP50}, {99, metricWaitP99}},
+	sketchService:       {{50, metricServiceP50}, {99, metricServiceP99}},
+	sketchJitter:        {{50, metricJitterP50}, {99, metricJitterP99}, {100, metricJitterMax}},
+	sketchRequest:       {{50, metricRequestP50}, {99, metricRequestP99}, {100, metricRequestMax}},
+	sketchTaskLatency:   {{50, metricTaskLatencyP50}, {95, metricTaskLatencyP95}, {99, metricTaskLatencyP99}},
+}
+
+// 複数の結果ファイル（jsonの出力先に書き出したもの）を読み込み、同じ戦略の結果を合算して出力する。
+// 繰り返しを別々に実行した結果や、別々のホストで実行した結果をまとめるために使う。
+// 分位数は各結果のスケッチを合算して求め直すため、結果ごとの分位数を平均するのと異なり、
+// 全てのタスクをまとめて計測した場合と同じ値になる。byに指定したパラメータの値が異なる結果は別々に合算する
+// コミットの異なるコードで計測した結果は、allowMixedを指定しない限り合算しない
+func RunMerge(files, reports, by []string, allowMixed bool) (err error) {
+	if len(files) == 0 {
+		return fmt.Errorf("merge requires at least 1 result file")
+	}
+	var all []Result
+	for _, path := range files {
+		results, err := loadResults(path)
+		if err != nil {
+			return err
+		}
+		all = append(all, results...)
+	}
+	warnings, err := checkProvenance(all)
+	if err != nil && !allowMixed {
+		return fmt.Errorf("%w (pass -allow-mixed-versions to merge anyway)", err)
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "警告: %s\n", w)
+	}
+
+	reporters, err := newReporters(reports, os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, closeReporters(reporters))
+	}()
+	for _, group := range groupResults(all, by) {
+		merged := mergeResults(group)
+		for _, rep := range reporters {
+			if err := rep.Report(merged); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 結果ファイルを読み込む
+func loadResults(path string) ([]Result, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var results []Result
+	if err := json.Unmarshal(b, &results); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, r := range results {
+		if r.SchemaVersion != ResultSchemaVersion {
+			return nil, fmt.Errorf("%s: schema version %d is not supported (want %d)", path, r.SchemaVersion, ResultSchemaVersion)
+		}
+	}
+	return results, nil
+}
+
+// 合算する結果をまとめる。モード、シナリオ、戦略（負荷ランプでは負荷、スイープではタスク数も）と、
+// byに指定したパラメータの値が同じ結果を1つの組とし、組は最初に現れた順に返す。
+// 中止した結果は途中までの計測値のため合算しない
+func groupResults(results []Result, by []string) [][]Result {
+	index := map[string]int{}
+	var groups [][]Result
+	for _, r := range results {
+		if r.AbortReason != "" {
+			continue
+		}
+		key := resultKey(r, r.Strategy)
+		for _, p := range by {
+			key = append(key, r.Params[p])
+		}
+		k := strings.Join(key, "\x00")
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], r)
+	}
+	return groups
+}
+
+// 同じ条件の計測を表すキー。モード、シナリオ、strategy（負荷ランプでは負荷、スイープではタスク数も）からなる。
+// strategyを空にすると、同じ条件で比べた戦略の組を表す
+func resultKey(r Result, strategy string) []string {
+	key := []string{r.Mode, r.Scenario, strategy}
+	switch r.Mode {
+	case modeRamp:
+		key = append(key, strconv.FormatFloat(r.Metrics[metricLoad], 'f', -1, 64))
+	case modeSweep:
+		key = append(key, strconv.FormatFloat(r.Metrics[metricTasks], 'f', -1, 64))
+	}
+	return key
+}
+
+// 同じ戦略の結果を合算する。スケッチを合算して分位数を求め直し、繰り返しごとの処理時間は
+// 全てを合わせた中央値を求める。それ以外のメトリクスは結果の平均とする
+func mergeResults(group []Result) Result {
+	merged := group[0]
+	merged.Params = map[string]string{}
+	for k, v := range group[0].Params {
+		merged.Params[k] = v
+	}
+	merged.Metrics = map[string]float64{}
+	merged.Samples = nil
+	merged.Notes = nil
+	merged.Artifacts = nil
+	merged.Sketches = nil
+
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	hosts := map[string]bool{}
+	notes := map[string]bool{}
+	for _, r := range group {
+		for name, v := range r.Metrics {
+			sums[name] += v
+			counts[name]++
+		}
+		merged.Samples = append(merged.Samples, r.Samples...)
+		for name, s := range r.Sketches {
+			if merged.Sketches == nil {
+				merged.Sketches = map[string]*latencySketch{}
+			}
+			if merged.Sketches[name] == nil {
+				merged.Sketches[name] = &latencySketch{}
+			}
+			merged.Sketches[name].merge(s)
+		}
+		for _, note := range r.Notes {
+			if !notes[note] {
+				notes[note] = true
+				merged.Notes = append(merged.Notes, note)
+			}
+		}
+		hosts[r.Environment.Hostname] = true
+		if r.Provenance != merged.Provenance {
+			// 異なるコードの結果を合算した場合は、どのコードの結果か特定できない
+			merged.Provenance = Provenance{Version: "mixed"}
+		}
+		if r.Time.After(merged.Time) {
+			merged.Time = r.Time
+		}
+	}
+	for name, sum := range sums {
+		merged.Metrics[name] = sum / float64(counts[name])
+	}
+
+	if len(merged.Samples) > 0 {
+		durs := make([]time.Duration, len(merged.Samples))
+		for i, ns := range merged.Samples {
+			durs[i] = time.Duration(ns)
+		}
+		merged.Metrics[metricWall] = float64(medianDuration(durs))
+	}
+	merged.normalize()
+	for name, s := range merged.Sketches {
+		for _, m := range sketchMetrics[name] {
+			merged.Metrics[m.metric] = float64(s.percentile(m.p))
+		}
+		if name == sketchLatency {
+			merged.Metrics[metricCompleted] = float64(s.count)
+		}
+	}
+
+	names := make([]string, 0, len(hosts))
+	for h := range hosts {
+		names = append(names, h)
+	}
+	sort.Strings(names)
+	merged.Params["merged_results"] = itoa(len(group))
+	merged.Params["hosts"] = strings.Join(names, ",")
+	return merged
+}
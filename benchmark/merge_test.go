@@ -0,0 +1,100 @@
+package benchmark
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// ホストごとの結果を書き出したファイルを合算すると、分位数が全ての値をまとめて記録した
+// スケッチと一致し、合算した結果の数とホストが記録されることを確認する
+func TestRunMerge(t *testing.T) {
+	dir := t.TempDir()
+	var all latencySketch
+	var files []string
+	for i, host := range []string{"host-b", "host-a"} {
+		var s latencySketch
+		for j := 1; j <= 1000; j++ {
+			// ホストごとに分布をずらし、分位数の平均と合算後の分位数が異なるようにする
+			d := time.Duration(j*(i*9+1)) * time.Microsecond
+			s.add(d)
+			all.add(d)
+		}
+		res := Result{
+			SchemaVersion: ResultSchemaVersion,
+			Mode:          modeBatch,
+			Scenario:      "sleep",
+			Strategy:      "sleep-timer",
+			Params:        map[string]string{"workers": "4"},
+			Metrics: map[string]float64{
+				metricWall:      float64(i + 1),
+				metricTasks:     10,
+				metricJitterP99: float64(s.percentile(99)),
+			},
+			Samples:     []float64{float64(i + 1)},
+			Sketches:    map[string]*latencySketch{sketchJitter: &s},
+			Environment: Environment{Hostname: host},
+		}
+		path := filepath.Join(dir, host+".json")
+		reporters, err := newReporters([]string{"json=" + path}, io.Discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := reporters[0].Report(res); err != nil {
+			t.Fatal(err)
+		}
+		if err := closeReporters(reporters); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	out := filepath.Join(dir, "merged.json")
+	if err := RunMerge(files, []string{"json=" + out}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := loadResults(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 1 {
+		t.Fatalf("got %d results, want 1", len(merged))
+	}
+	res := merged[0]
+	if got, want := res.Metrics[metricJitterP99], float64(all.percentile(99)); got != want {
+		t.Errorf("jitter p99 = %v, want %v", got, want)
+	}
+	if got, want := res.Sketches[sketchJitter].count, all.count; got != want {
+		t.Errorf("sketch count = %d, want %d", got, want)
+	}
+	if got := res.Params["merged_results"]; got != "2" {
+		t.Errorf("merged_results = %q, want 2", got)
+	}
+	if got := res.Params["hosts"]; got != "host-a,host-b" {
+		t.Errorf("hosts = %q, want host-a,host-b", got)
+	}
+	if len(res.Samples) != 2 {
+		t.Errorf("got %d samples, want 2", len(res.Samples))
+	}
+	// 1タスクあたりの処理時間は、合算した処理時間の中央値から求め直す
+	if got, want := res.Metrics[metricNsPerTask], res.Metrics[metricWall]/10; got != want {
+		t.Errorf("ns per task = %v, want %v", got, want)
+	}
+}
+
+// byに指定したパラメータの値が異なる結果は別々に合算することを確認する
+func TestGroupResults(t *testing.T) {
+	result := func(strategy, workers string) Result {
+		return Result{Mode: modeBatch, Strategy: strategy, Params: map[string]string{"workers": workers}}
+	}
+	results := []Result{
+		result("a", "1"), result("b", "1"), result("a", "2"), result("a", "1"),
+	}
+	if got := groupResults(results, nil); len(got) != 2 || len(got[0]) != 3 || got[1][0].Strategy != "b" {
+		t.Errorf("without by: got %d groups", len(got))
+	}
+	if got := groupResults(results, []string{"workers"}); len(got) != 3 || len(got[0]) != 2 {
+		t.Errorf("by workers: got %d groups", len(got))
+	}
+}
@@ -0,0 +1,111 @@
+package benchmark
+
+import (
+	"bytes"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 実行中の戦略のカウンタとgoroutine数を、Prometheusのテキスト形式（/metrics）で公開するHTTPサーバー。
+// 長く続く実行をPrometheusで収集し、Grafanaなどで進み具合を見るために使う。
+// カウンタはliveCounters（-expvarと同じもの）から読む
+type metricsServer struct {
+	srv *http.Server
+	ln  net.Listener
+}
+
+// 指定したアドレスで/metricsを公開するHTTPサーバーを起動する。
+// 公開するのはカウンタとgoroutine数だけのため、pprofのサーバーと違ってループバックアドレス以外でも待ち受ける
+func startMetricsServer(addr string) (*metricsServer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics server: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(liveMetricsText())
+	})
+	s := &metricsServer{
+		srv: &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+		ln:  ln,
+	}
+	go s.srv.Serve(ln)
+	return s, nil
+}
+
+// /metricsのURL
+func (s *metricsServer) url() string {
+	if s == nil {
+		return ""
+	}
+	return "http://" + s.ln.Addr().String() + "/metrics"
+}
+
+// サーバーを停止する
+func (s *metricsServer) close() error {
+	if s == nil {
+		return nil
+	}
+	if err := s.srv.Close(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// 戦略ごとに公開するメトリクス
+var liveMetrics = []struct {
+	name, typ, help string
+	value           func(c *liveCounters) int64
+}{
+	{"bench_live_tasks_submitted_total", "counter", "供給元から読み出したタスク数", func(c *liveCounters) int64 { return c.submitted.Value() }},
+	{"bench_live_tasks_completed_total", "counter", "処理が終わったタスク数", func(c *liveCounters) int64 { return c.completed.Value() }},
+	{"bench_live_tasks_errored_total", "counter", "処理がエラーを返したタスク数", func(c *liveCounters) int64 { return c.errored.Value() }},
+	{"bench_live_tasks_dropped_total", "counter", "処理されずに破棄されたタスク数", (*liveCounters).droppedTotal},
+	{"bench_live_tasks_in_flight", "gauge", "処理中のタスク数", func(c *liveCounters) int64 { return c.inFlight.Value() }},
+	{"bench_live_queue_depth", "gauge", "読み出されてから処理を始めるまでのタスク数（チャネルやセマフォで待っているもの）", (*liveCounters).queued},
+	{"bench_live_running", "gauge", "実行中の戦略は1", func(c *liveCounters) int64 {
+		if c.running() {
+			return 1
+		}
+		return 0
+	}},
+}
+
+// 全ての戦略のカウンタとgoroutine数をPrometheusのテキスト形式に変換する
+func liveMetricsText() []byte {
+	type entry struct {
+		scenario, strategy string
+		c                  *liveCounters
+	}
+	var entries []entry
+	liveVars.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*liveCountersVar); ok {
+			scenario, strategy, _ := strings.Cut(kv.Key, "/")
+			entries = append(entries, entry{scenario, strategy, v.c})
+		}
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].scenario != entries[j].scenario {
+			return entries[i].scenario < entries[j].scenario
+		}
+		return entries[i].strategy < entries[j].strategy
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# HELP bench_live_goroutines 現在のgoroutine数\n# TYPE bench_live_goroutines gauge\nbench_live_goroutines %d\n", runtime.NumGoroutine())
+	for _, m := range liveMetrics {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.typ)
+		for _, e := range entries {
+			fmt.Fprintf(&buf, "%s{scenario=%q,strategy=%q} %d\n", m.name, e.scenario, e.strategy, m.value(e.c))
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,70 @@
+package benchmark
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// 実行中の戦略のカウンタ、待っているタスク数、goroutine数を/metricsで取得できることを確認する
+func TestMetricsServer(t *testing.T) {
+	const n = 10
+	var body string
+	s, err := startMetricsServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.close()
+
+	// 全てのタスクを読み出してから処理し、1つ目の処理中に/metricsを取得する
+	strategy := withLiveCounters(Strategy{Name: "metrics-test", Run: func(env Env) error {
+		var tasks []Task
+		for {
+			task, ok := env.Source.Next()
+			if !ok {
+				break
+			}
+			tasks = append(tasks, task)
+		}
+		for _, task := range tasks {
+			if err := env.Process(task); err != nil {
+				return err
+			}
+		}
+		return nil
+	}}, "test")
+	env := BatchEnv(n)
+	env.Process = func(task Task) error {
+		if task.ID != 0 {
+			return nil
+		}
+		resp, err := http.Get(s.url())
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		body = string(b)
+		return err
+	}
+	if err := strategy.Run(env); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"# TYPE bench_live_tasks_submitted_total counter",
+		`bench_live_tasks_submitted_total{scenario="test",strategy="metrics-test"} 10`,
+		`bench_live_tasks_in_flight{scenario="test",strategy="metrics-test"} 1`,
+		`bench_live_queue_depth{scenario="test",strategy="metrics-test"} 9`,
+		`bench_live_running{scenario="test",strategy="metrics-test"} 1`,
+		"bench_live_goroutines ",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+	if !strings.Contains(string(liveMetricsText()), `bench_live_running{scenario="test",strategy="metrics-test"} 0`) {
+		t.Error("strategy is still reported as running after it finished")
+	}
+}
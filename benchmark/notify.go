@@ -0,0 +1,183 @@
+package benchmark
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// 完了通知のシナリオの戦略一覧。
+// いずれもワーカープールでタスクを処理し、投入側が各タスクの完了を知る仕組みだけが異なる
+func notifyStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	pool := func(completion string) Topology {
+		return Topology{
+			Stages: []Stage{
+				producerStage,
+				{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100)},
+			},
+			Completion: completion,
+		}
+	}
+	return []Strategy{
+		{
+			Name:     "notify-results-chan",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ 結果チャネルを投入側が受信して完了を通知", numWorkers),
+			Func:     "NotifyResultsChannel",
+			Limit:    numWorkers,
+			Topology: pool("結果チャネル（バッファ100）を投入側が受信し、ワーカーの終了後に閉じる"),
+			Run: func(env Env) error {
+				return NotifyResultsChannel(env, numWorkers)
+			},
+		},
+		{
+			Name:     "notify-callback",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ ワーカー内でコールバックを呼び出して完了を通知", numWorkers),
+			Func:     "NotifyCallback",
+			Limit:    numWorkers,
+			Topology: pool("ワーカー内でコールバック（sync.Mutexで保護）+ sync.WaitGroup"),
+			Run: func(env Env) error {
+				return NotifyCallback(env, numWorkers)
+			},
+		},
+		{
+			Name:     "notify-atomic",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ atomicカウンタで完了数を数え、単一の待機者に通知", numWorkers),
+			Func:     "NotifyAtomicCounter",
+			Limit:    numWorkers,
+			Topology: pool("atomicカウンタ（最後に完了したワーカーが完了チャネルを閉じる）"),
+			Run: func(env Env) error {
+				return NotifyAtomicCounter(env, numWorkers)
+			},
+		},
+	}
+}
+
+// 完了したタスクの結果
+type taskResult struct {
+	id  int
+	err error
+}
+
+// 各ワーカーが処理の結果を結果チャネルに送信し、投入側が受信して完了を知る。
+// 投入と受信を並行して行うため、供給元の読み出しは別のgoroutineで行う
+func NotifyResultsChannel(env Env, numWorkers int) error {
+	tasks := make(chan Task, 100)
+	results := make(chan taskResult, 100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				results <- taskResult{id: task.ID, err: env.Process(task)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(tasks)
+		for {
+			task, ok := env.Source.Next()
+			if !ok {
+				return
+			}
+			tasks <- task
+		}
+	}()
+
+	// 受信した時点で、そのタスクの完了を投入側が知る
+	var firstErr error
+	for r := range results {
+		if r.err != nil && firstErr == nil {
+			firstErr = r.err
+		}
+	}
+	return firstErr
+}
+
+// 投入側が渡したコールバックを、各ワーカーがタスクの処理後にそのgoroutine内で呼び出す。
+// コールバックは複数のワーカーから同時に呼ばれるため、投入側の状態はミューテックスで保護する
+func NotifyCallback(env Env, numWorkers int) error {
+	var mu sync.Mutex
+	var firstErr error
+	onDone := func(_ Task, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	tasks := make(chan Task, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				onDone(task, env.Process(task))
+			}
+		}()
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks <- task
+	}
+	close(tasks)
+	wg.Wait()
+	return firstErr
+}
+
+// 各ワーカーがタスクの処理後に未完了数をatomicに減らし、0にしたワーカーが完了チャネルを閉じて
+// 単一の待機者に通知する。投入側は個々のタスクではなく、完了数だけを知ることができる
+func NotifyAtomicCounter(env Env, numWorkers int) error {
+	// 投入の終了前に0にならないよう、投入側の分として1から始める
+	var pending atomic.Int64
+	pending.Store(1)
+	var firstErr atomic.Pointer[error]
+	allDone := make(chan struct{})
+	complete := func() {
+		if pending.Add(-1) == 0 {
+			close(allDone)
+		}
+	}
+
+	tasks := make(chan Task, 100)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			for task := range tasks {
+				if err := env.Process(task); err != nil {
+					firstErr.CompareAndSwap(nil, &err)
+				}
+				complete()
+			}
+		}()
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		pending.Add(1)
+		tasks <- task
+	}
+	close(tasks)
+	complete()
+
+	<-allDone
+	if err := firstErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OpenTelemetryの計装のデフォルトで、スパンを送るタスクの間隔（100個に1つ）
+const defaultOTelSample = 100
+
+// スパンの送信を待つ時間の上限
+const otelFlushTimeout = 10 * time.Second
+
+// 戦略の実行とタスクの処理をOpenTelemetryのスパンとして送る計装。
+// 戦略ごとに1つのスパンを作り、その下に計測した各回のスパン、さらにその下に間隔ごとに選んだタスクのスパンを置く。
+// タスクのスパンは、供給元から読み出されてから処理を始めるまで（ディスパッチャーからワーカーへの受け渡し）と
+// 処理の2つの子スパンに分ける
+type otelTracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	every    int
+	// 計装して実行中の戦略のスパンのコンテキスト。計装しない実行の間はnil
+	parent atomic.Pointer[context.Context]
+}
+
+// 出力先の指定（「file=ファイル」または「otlp」「otlp=エンドポイント」）からエクスポーターを作り、計装を始める
+func startOTel(spec string, every int) (*otelTracer, error) {
+	exp, err := newOTelExporter(spec)
+	if err != nil {
+		return nil, fmt.Errorf("otel: %w", err)
+	}
+	return newOTelTracer(exp, every), nil
+}
+
+func newOTelExporter(spec string) (sdktrace.SpanExporter, error) {
+	kind, arg, _ := strings.Cut(spec, "=")
+	switch kind {
+	case "file":
+		if arg == "" {
+			return nil, fmt.Errorf("file requires a path (e.g. file=spans.json)")
+		}
+		f, err := os.Create(arg)
+		if err != nil {
+			return nil, err
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &closingExporter{SpanExporter: exp, f: f}, nil
+	case "otlp":
+		// エンドポイントを省略した場合は、OTEL_EXPORTER_OTLP_ENDPOINTなどの環境変数かデフォルト（localhost:4318）に送る
+		var opts []otlptracehttp.Option
+		switch {
+		case strings.Contains(arg, "://"):
+			opts = append(opts, otlptracehttp.WithEndpointURL(arg))
+		case arg != "":
+			opts = append(opts, otlptracehttp.WithEndpoint(arg), otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+	}
+	return nil, fmt.Errorf("unknown exporter %q (want file=PATH, otlp or otlp=ENDPOINT)", spec)
+}
+
+// 終了時に書き込み先のファイルを閉じるエクスポーター
+type closingExporter struct {
+	sdktrace.SpanExporter
+	f *os.File
+}
+
+func (e *closingExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if cerr := e.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func newOTelTracer(exp sdktrace.SpanExporter, every int) *otelTracer {
+	// 戦略と各回のスパンは必ず送り、タスクのスパンはIDの間隔で選ぶため、サンプラーでは間引かない
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp, sdktrace.WithMaxQueueSize(1<<16)),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "go-speed-chan-vs-goroutine"))),
+	)
+	return &otelTracer{
+		provider: provider,
+		tracer:   provider.Tracer("github.com/go-to-k/go-speed-chan-vs-goroutine/benchmark"),
+		every:    every,
+	}
+}
+
+// 計装して実行する戦略。プロファイルなどの成果物が計装なしの実行のものを上書きしないよう、戦略名に-otelを付ける
+func otelStrategy(s Strategy) Strategy {
+	s.Name += "-otel"
+	s.Title += "（OpenTelemetryの計装あり）"
+	return s
+}
+
+// 計装して戦略を繰り返し実行し、計装なしの結果（off）と並べられるよう、計装なしの処理時間を結果に加える。
+// 溜まったスパンは計測の外で送り切る
+func (r *runner) runOTel(s Strategy, off Result) (Result, error) {
+	end := r.otel.startStrategy(r.ctx, r.scenario.Name, s)
+	res, err := r.runProfiled(s)
+	end(err)
+	if err != nil {
+		return Result{}, err
+	}
+	if err := r.otel.flush(r.ctx); err != nil {
+		return Result{}, err
+	}
+	res.Params["otel"] = "on"
+	res.Params["otel_sample"] = itoa(r.otel.every)
+	res.Metrics[metricOTelOffWall] = off.Metrics[metricWall]
+	return res, nil
+}
+
+// 戦略のスパンを始め、以降の実行を計装する。戻り値の関数でスパンを終え、計装をやめる
+func (t *otelTracer) startStrategy(ctx context.Context, scenario string, s Strategy) func(error) {
+	ctx, span := t.tracer.Start(ctx, "strategy", trace.WithAttributes(
+		attribute.String("bench.scenario", scenario),
+		attribute.String("bench.strategy", s.Name),
+		attribute.String("bench.func", s.Func),
+		attribute.Int("bench.tasks", s.tasks()),
+	))
+	t.parent.Store(&ctx)
+	return func(err error) {
+		t.parent.Store(nil)
+		endSpan(span, err, time.Now())
+	}
+}
+
+// 計装して実行中か
+func (t *otelTracer) active() bool {
+	return t != nil && t.parent.Load() != nil
+}
+
+// 1回の実行のスパンを始め、間隔ごとに選んだタスクのスパンを送るよう実行環境をラップする。
+// 選ばれなかったタスクのコストは、IDの剰余を1回求めるだけにとどめる。戻り値の関数で実行のスパンを終える
+func (t *otelTracer) instrument(env Env, rep int) (Env, func(error)) {
+	ctx, span := t.tracer.Start(*t.parent.Load(), "run", trace.WithAttributes(
+		attribute.Int("bench.rep", rep),
+		attribute.Int("bench.tasks", env.Tasks),
+	))
+	every := t.every
+	// 選んだタスクを供給元から読み出した時刻（UnixNano）。ID/everyの位置に置く
+	reads := make([]atomic.Int64, env.Tasks/every+1)
+	env.Source = &otelSource{Source: env.Source, every: every, reads: reads}
+	process := env.Process
+	env.Process = func(task Task) error {
+		if task.ID < 0 || task.ID%every != 0 {
+			return process(task)
+		}
+		start := time.Now()
+		err := process(task)
+		end := time.Now()
+		read := start
+		if i := task.ID / every; i < len(reads) {
+			if ns := reads[i].Load(); ns != 0 {
+				read = time.Unix(0, ns)
+			}
+		}
+		taskCtx, taskSpan := t.tracer.Start(ctx, "task", trace.WithTimestamp(read), trace.WithAttributes(attribute.Int("bench.task_id", task.ID)))
+		_, handoff := t.tracer.Start(taskCtx, "handoff", trace.WithTimestamp(read))
+		handoff.End(trace.WithTimestamp(start))
+		_, proc := t.tracer.Start(taskCtx, "process", trace.WithTimestamp(start))
+		endSpan(proc, err, end)
+		endSpan(taskSpan, err, end)
+		return err
+	}
+	return env, func(err error) { endSpan(span, err, time.Now()) }
+}
+
+// エラーがあればスパンに記録して終える
+func endSpan(span trace.Span, err error, at time.Time) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(at))
+}
+
+// 間隔ごとに選んだタスクを読み出した時刻を記録する供給元
+type otelSource struct {
+	Source
+	every int
+	reads []atomic.Int64
+}
+
+func (s *otelSource) Next() (Task, bool) {
+	task, ok := s.Source.Next()
+	if ok && task.ID >= 0 && task.ID%s.every == 0 {
+		if i := task.ID / s.every; i < len(s.reads) {
+			s.reads[i].Store(time.Now().UnixNano())
+		}
+	}
+	return task, ok
+}
+
+// 溜まったスパンを送り切る
+func (t *otelTracer) flush(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, otelFlushTimeout)
+	defer cancel()
+	if err := t.provider.ForceFlush(ctx); err != nil {
+		return fmt.Errorf("otel: %w", err)
+	}
+	return nil
+}
+
+// 残りのスパンを送り、エクスポーターを閉じる
+func (t *otelTracer) close() error {
+	if t == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), otelFlushTimeout)
+	defer cancel()
+	if err := t.provider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("otel: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package benchmark
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// 計装して実行すると、戦略、各回、間隔ごとに選んだタスクとその受け渡しと処理のスパンを親子関係を付けて送り、
+// 計装なしの処理時間を結果に加えることを確認する
+func TestRunOTel(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	cfg := DefaultConfig()
+	cfg.Repetitions = 2
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}, scenario: Scenario{Name: "dispatch"}}
+	r.otel = newOTelTracer(exp, 10)
+	defer r.otel.close()
+
+	s := Strategy{Name: "probe", Tasks: 100, Run: strictSequential}
+	off, err := r.runRepeated(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(exp.GetSpans()); n != 0 {
+		t.Fatalf("uninstrumented run exported %d spans", n)
+	}
+	on, err := r.runOTel(otelStrategy(s), off)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if on.Strategy != "probe-otel" || on.Params["otel"] != "on" || on.Params["otel_sample"] != "10" {
+		t.Errorf("unexpected result: %s %v", on.Strategy, on.Params)
+	}
+	if on.Metrics[metricOTelOffWall] != off.Metrics[metricWall] {
+		t.Errorf("otel off wall = %v, want %v", on.Metrics[metricOTelOffWall], off.Metrics[metricWall])
+	}
+
+	spans := exp.GetSpans()
+	count := map[string]int{}
+	parents := map[string]string{}
+	names := map[string]string{}
+	for _, sp := range spans {
+		count[sp.Name]++
+		names[sp.SpanContext.SpanID().String()] = sp.Name
+	}
+	for _, sp := range spans {
+		if sp.Parent.IsValid() {
+			parents[sp.Name] = names[sp.Parent.SpanID().String()]
+		}
+	}
+	want := map[string]int{"strategy": 1, "run": 2, "task": 20, "handoff": 20, "process": 20}
+	for name, n := range want {
+		if count[name] != n {
+			t.Errorf("%s spans = %d, want %d", name, count[name], n)
+		}
+	}
+	for child, parent := range map[string]string{"run": "strategy", "task": "run", "handoff": "task", "process": "task"} {
+		if parents[child] != parent {
+			t.Errorf("parent of %s = %q, want %q", child, parents[child], parent)
+		}
+	}
+	if r.otel.active() {
+		t.Error("tracer is still active after the instrumented run")
+	}
+}
+
+// 送信先の指定を解釈することを確認する
+func TestNewOTelExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+	exp, err := newOTelExporter("file=" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newOTelExporter("otlp=localhost:4318"); err != nil {
+		t.Fatal(err)
+	}
+	for _, spec := range []string{"file", "jaeger=localhost:14268"} {
+		if _, err := newOTelExporter(spec); err == nil {
+			t.Errorf("newOTelExporter(%q) succeeded", spec)
+		}
+	}
+}
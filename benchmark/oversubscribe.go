@@ -0,0 +1,174 @@
+package benchmark
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CPUを使うタスクのシナリオで、同時実行数をGOMAXPROCSの何倍にするか
+var oversubscriptionFactors = []int{1, 4, 16, 64, 256}
+
+const (
+	// CPUを使うタスクのシナリオのタスク数（1タスクあたりの計算が重いため、既定のタスク数より少なくする）
+	cpuBoundTasks = 150
+	// 1タスクが読み書きする作業領域の要素数（1MB）と、作業領域を走査する回数。
+	// 1タスクの計算がスケジューラーのタイムスライス（10ms）を超えるようにし、同時に実行可能なタスクが
+	// CPU数より多い場合はプリエンプションでタスクが切り替わって作業領域がキャッシュから追い出されるようにする
+	cpuWorkingSetSize = 1 << 17
+	cpuWorkingPasses  = 48
+	// 作業領域の数。タスクIDごとに異なる作業領域を使う
+	cpuWorkingSets = 16
+)
+
+// タスクが読み書きする作業領域（cpuWorkingSets × 1MB）。シナリオを実行する場合のみ確保する
+var cpuWorkingSet = sync.OnceValue(func() []uint64 {
+	return make([]uint64, cpuWorkingSets*cpuWorkingSetSize)
+})
+
+// CPUを使うタスクの計算結果の書き込み先（最適化で計算が省かれないようにする）
+var cpuSink atomic.Uint64
+
+// タスクIDに対応する作業領域を走査して計算する。処理時間を待機せず、CPUを使い続ける
+func cpuBoundWork(id int) {
+	region := cpuWorkingSet()[id%cpuWorkingSets*cpuWorkingSetSize:][:cpuWorkingSetSize]
+	x := uint64(id) | 1
+	for pass := 0; pass < cpuWorkingPasses; pass++ {
+		for i := range region {
+			x ^= x << 13
+			x ^= x >> 7
+			x ^= x << 17
+			region[i] += x
+		}
+	}
+	cpuSink.Add(x)
+}
+
+// CPUを使うタスクのシナリオの戦略一覧。
+// ワーカープールとタスクごとのgoroutine + semaphoreで、同時実行数をGOMAXPROCSの1倍から256倍まで増やし、
+// CPU数を大きく超える同時実行（オーバーサブスクリプション）によるスケジューリングとキャッシュの競合のコストを比べる
+func oversubscribeStrategies(p Params) []Strategy {
+	procs := runtime.GOMAXPROCS(0)
+	var list []Strategy
+	for _, factor := range oversubscriptionFactors {
+		n := procs * factor
+		params := map[string]string{"oversubscription": itoa(factor)}
+		list = append(list, Strategy{
+			Name:  fmt.Sprintf("oversub-pool-x%d", factor),
+			Title: fmt.Sprintf("ワーカープール（%dワーカー、GOMAXPROCSの%d倍）+ CPUを使うタスク", n, factor),
+			Func:  "SharedChannelPool",
+			Limit: n,
+			Tasks: cpuBoundTasks,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: n, From: chanFrom("Task", defaultTaskBuffer), Note: "作業領域を走査して計算する"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Params: params,
+			Run: cpuBound(func(env Env) error {
+				return SharedChannelPool(env, n, defaultTaskBuffer)
+			}),
+		}, Strategy{
+			Name:  fmt.Sprintf("oversub-limited-x%d", factor),
+			Title: fmt.Sprintf("タスクごとのgoroutine + semaphore.Weightedで同時実行数を制限（%d同時実行、GOMAXPROCSの%d倍）+ CPUを使うタスク", n, factor),
+			Func:  "WeightedSemaphore",
+			Limit: n,
+			Tasks: cpuBoundTasks,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文", Note: "作業領域を走査して計算する"},
+				},
+				Limiter:    fmt.Sprintf("semaphore.Weighted(%d)（各goroutineが取得）", n),
+				Completion: "sync.WaitGroup",
+			},
+			Params: params,
+			Run: cpuBound(func(env Env) error {
+				return WeightedSemaphore(env, int64(n))
+			}),
+		})
+	}
+	return list
+}
+
+// 処理時間の待機をCPUを使う計算に置き換え、計算の開始から完了までの時間を記録するよう戦略をラップする。
+// 同時に実行可能なタスクがCPU数より多いと、タスクがプリエンプションで中断されて待つため、この時間が延びる
+func cpuBound(run func(Env) error) func(Env) error {
+	return func(env Env) error {
+		env.Source = &instantSource{src: env.Source}
+		process := env.Process
+		env.Process = func(task Task) error {
+			start := time.Now()
+			cpuBoundWork(task.ID)
+			env.serviceTime(time.Since(start))
+			return process(task)
+		}
+		return run(env)
+	}
+}
+
+// 最速から何%以内の処理時間を、最速と同等とみなすか
+const oversubscriptionTolerance = 0.05
+
+// 同時実行数の倍率ごとの処理時間とタスクの処理開始から完了までの時間（p99）を実装ごとに並べ、推奨する同時実行数を表示する。
+// 処理時間が最速から5%以内に収まる最小の倍率を推奨とする（それ以上増やしても全体は速くならず、個々のタスクの完了が遅れるだけ）
+func printOversubscription(w io.Writer, results []Result) {
+	type entry struct {
+		factor    int
+		wall, p99 float64
+	}
+	var order []string
+	groups := map[string][]entry{}
+	for _, r := range results {
+		f, ok := r.Params["oversubscription"]
+		if !ok {
+			continue
+		}
+		factor, _ := strconv.Atoi(f)
+		impl := strings.TrimSuffix(r.Strategy, "-x"+f)
+		if _, ok := groups[impl]; !ok {
+			order = append(order, impl)
+		}
+		groups[impl] = append(groups[impl], entry{factor, r.Metrics[metricWall], r.Metrics[metricServiceP99]})
+	}
+	if len(order) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "同時実行数（GOMAXPROCSの倍率）ごとの処理時間 / タスクの処理開始から完了までの時間のp99")
+	recommended := 0
+	var worst, atRecommended float64
+	for _, impl := range order {
+		entries := groups[impl]
+		slices.SortFunc(entries, func(a, b entry) int { return cmp.Compare(a.factor, b.factor) })
+		best := slices.MinFunc(entries, func(a, b entry) int { return cmp.Compare(a.wall, b.wall) })
+		var cols []string
+		var rec entry
+		for _, e := range entries {
+			cols = append(cols, fmt.Sprintf("×%d %v / %v", e.factor, time.Duration(e.wall).Round(time.Millisecond), time.Duration(e.p99).Round(time.Millisecond)))
+			if rec.factor == 0 && e.wall <= best.wall*(1+oversubscriptionTolerance) {
+				rec = e
+			}
+		}
+		fmt.Fprintf(w, "  %-16s %s\n", impl, strings.Join(cols, "  "))
+		if rec.factor > recommended {
+			recommended, atRecommended = rec.factor, rec.p99
+		}
+		worst = max(worst, entries[len(entries)-1].p99)
+	}
+	procs := runtime.GOMAXPROCS(0)
+	fmt.Fprintf(w, "推奨: CPUを使うタスクの同時実行数はGOMAXPROCSの%d倍（%d）まで。", recommended, procs*recommended)
+	if atRecommended > 0 && worst > atRecommended {
+		fmt.Fprintf(w, "これを超えて増やしても全体の処理時間は縮まらず、タスクの完了までの時間（p99）が最大%.0f倍に延びます", worst/atRecommended)
+	}
+	fmt.Fprint(w, "\n\n")
+}
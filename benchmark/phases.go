@@ -0,0 +1,213 @@
+package benchmark
+
+import (
+	"fmt"
+	"sync"
+)
+
+// フェーズ分割シナリオのフェーズ数（全タスクをこの数のフェーズに均等に分ける）
+const numPhases = 10
+
+// フェーズ分割シナリオの戦略一覧。
+// フェーズkの全タスクが完了するまでフェーズk+1のタスクは開始できない
+func phaseStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	return []Strategy{
+		{
+			Name:  "phases-recreate",
+			Title: fmt.Sprintf("フェーズごとにワーカープールを作り直す（%dワーカー、%dフェーズ）", numWorkers, numPhases),
+			Func:  "phasesRecreatePool",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100), Note: "フェーズごとにチャネルとワーカーを作り直す"},
+				},
+				Completion: "フェーズごとのワーカーのsync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return phasesRecreatePool(env, numWorkers, phaseSize(env.Tasks))
+			},
+		},
+		{
+			Name:  "phases-waitgroup",
+			Title: fmt.Sprintf("永続ワーカープール + WaitGroupのバリア（%dワーカー、%dフェーズ）", numWorkers, numPhases),
+			Func:  "phasesWaitGroupBarrier",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("item", 100)},
+				},
+				Completion: "フェーズごとのsync.WaitGroup（タスクと一緒に渡す）",
+			},
+			Run: func(env Env) error {
+				return phasesWaitGroupBarrier(env, numWorkers, phaseSize(env.Tasks))
+			},
+		},
+		{
+			Name:  "phases-chan-barrier",
+			Title: fmt.Sprintf("永続ワーカープール + 完了チャネルのバリア（%dワーカー、%dフェーズ）", numWorkers, numPhases),
+			Func:  "phasesChannelBarrier",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100), Note: "フェーズごとに送信用のgoroutineを起動"},
+				},
+				Completion: "完了チャネル（バッファ100）をフェーズのタスク数だけ受信",
+			},
+			Run: func(env Env) error {
+				return phasesChannelBarrier(env, numWorkers, phaseSize(env.Tasks))
+			},
+		},
+	}
+}
+
+// 1回の実行のタスク数をnumPhases個以下のフェーズに分けたときの1フェーズのタスク数（端数は切り上げ、最後のフェーズが小さくなる）。
+// タスク数が分からない供給元では、デフォルトのタスク数で求める
+func phaseSize(tasks int) int {
+	if tasks <= 0 {
+		tasks = numTasks
+	}
+	return max((tasks+numPhases-1)/numPhases, 1)
+}
+
+// 供給元から最大n個のタスクを読み出す（1フェーズ分）
+func nextPhase(src Source, n int) []Task {
+	phase := make([]Task, 0, n)
+	for len(phase) < n {
+		task, ok := src.Next()
+		if !ok {
+			break
+		}
+		phase = append(phase, task)
+	}
+	return phase
+}
+
+// フェーズごとにワーカーとチャネルを作成し、フェーズの終了時に破棄する実装
+func phasesRecreatePool(env Env, numWorkers, phaseSize int) error {
+	for {
+		phase := nextPhase(env.Source, phaseSize)
+		if len(phase) == 0 {
+			return nil
+		}
+
+		tasks := make(chan Task, 100)
+		errs := make(chan error, numWorkers)
+		var wg sync.WaitGroup
+		for w := 0; w < numWorkers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var firstErr error
+				for task := range tasks {
+					if err := env.Process(task); err != nil && firstErr == nil {
+						firstErr = err
+					}
+				}
+				errs <- firstErr
+			}()
+		}
+
+		for _, task := range phase {
+			tasks <- task
+		}
+		close(tasks)
+
+		// ワーカーの終了をフェーズのバリアとする
+		wg.Wait()
+		close(errs)
+		if err := firstError(errs); err != nil {
+			return err
+		}
+	}
+}
+
+// ワーカーを全フェーズで使い回し、フェーズごとのWaitGroupで完了を待つ実装
+func phasesWaitGroupBarrier(env Env, numWorkers, phaseSize int) error {
+	type item struct {
+		task Task
+		wg   *sync.WaitGroup
+	}
+	items := make(chan item, 100)
+
+	var mu sync.Mutex
+	var firstErr error
+
+	var workers sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for it := range items {
+				if err := env.Process(it.task); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+				it.wg.Done()
+			}
+		}()
+	}
+
+	for {
+		phase := nextPhase(env.Source, phaseSize)
+		if len(phase) == 0 {
+			break
+		}
+
+		var barrier sync.WaitGroup
+		barrier.Add(len(phase))
+		for _, task := range phase {
+			items <- item{task: task, wg: &barrier}
+		}
+		barrier.Wait()
+	}
+	close(items)
+	workers.Wait()
+	return firstErr
+}
+
+// ワーカーを全フェーズで使い回し、ワーカーが送る完了通知を数えてフェーズの完了を待つ実装
+func phasesChannelBarrier(env Env, numWorkers, phaseSize int) error {
+	tasks := make(chan Task, 100)
+	done := make(chan error, 100)
+
+	var workers sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for task := range tasks {
+				done <- env.Process(task)
+			}
+		}()
+	}
+
+	var firstErr error
+	for {
+		phase := nextPhase(env.Source, phaseSize)
+		if len(phase) == 0 {
+			break
+		}
+
+		// 送信と完了通知の受信を並行させる（完了チャネルが埋まってワーカーが止まらないように）
+		go func() {
+			for _, task := range phase {
+				tasks <- task
+			}
+		}()
+		for range phase {
+			if err := <-done; err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	close(tasks)
+	workers.Wait()
+	return firstErr
+}
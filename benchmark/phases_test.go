@@ -0,0 +1,50 @@
+package benchmark
+
+import (
+	"sync"
+	"testing"
+)
+
+// 実行のタスク数からフェーズの大きさを求め、前のフェーズの全てのタスクが完了してから次のフェーズを始めることを確認する
+func TestPhaseStrategies(t *testing.T) {
+	const tasks = 25
+	size := phaseSize(tasks)
+	if size != 3 {
+		t.Fatalf("phaseSize(%d) = %d, want 3", tasks, size)
+	}
+	if got := phaseSize(0); got != numTasks/numPhases {
+		t.Errorf("phaseSize(0) = %d, want %d", got, numTasks/numPhases)
+	}
+
+	for _, s := range phaseStrategies(Params{Workers: 4}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var mu sync.Mutex
+			done := make([]bool, tasks)
+			env := BatchEnv(tasks)
+			process := env.Process
+			env.Process = func(task Task) error {
+				mu.Lock()
+				for id := 0; id < task.ID/size*size; id++ {
+					if !done[id] {
+						t.Errorf("task %d started before task %d of an earlier phase completed", task.ID, id)
+						break
+					}
+				}
+				mu.Unlock()
+				err := process(task)
+				mu.Lock()
+				done[task.ID] = true
+				mu.Unlock()
+				return err
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			for id, ok := range done {
+				if !ok {
+					t.Errorf("task %d was not processed", id)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+
+	"github.com/go-to-k/go-speed-chan-vs-goroutine/pipeline"
+)
+
+// パイプラインのシナリオの戦略一覧。
+// 供給元のタスクを準備するステージと処理するステージをつないだ2段のパイプラインを、
+// pipelineパッケージで宣言的に組み立てる方法と、同じ構成をチャネルとsync.WaitGroupで直接書く方法で比べ、
+// 組み立てのAPIのオーバーヘッドを確認する
+func pipelineStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	topology := Topology{
+		Stages: []Stage{
+			{Role: RoleProducer, Goroutines: 1},
+			{Role: RoleDispatcher, Goroutines: 1, From: chanFrom("Task", 0), Note: "準備のステージ"},
+			{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100), Note: "処理のステージ"},
+		},
+		Completion: "出力のチャネル（バッファ100）を呼び出し元が読み切り、全てのgoroutineの終了を待つ",
+	}
+	return []Strategy{
+		{
+			Name:     "pipeline-builder",
+			Title:    fmt.Sprintf("pipelineパッケージで組み立てた2段のパイプライン（準備1 + 処理%dgoroutine）", numWorkers),
+			Func:     "BuilderPipeline",
+			Limit:    numWorkers,
+			Topology: topology,
+			Run: func(env Env) error {
+				return BuilderPipeline(env, numWorkers)
+			},
+		},
+		{
+			Name:     "pipeline-manual",
+			Title:    fmt.Sprintf("チャネルとsync.WaitGroupで直接書いた2段のパイプライン（準備1 + 処理%dgoroutine）", numWorkers),
+			Func:     "ManualPipeline",
+			Limit:    numWorkers,
+			Topology: topology,
+			Run: func(env Env) error {
+				return ManualPipeline(env, numWorkers)
+			},
+		},
+	}
+}
+
+// 準備のステージ。タスクIDが正しいかを確かめる
+func prepareTask(task Task) (Task, error) {
+	if task.ID < 0 {
+		return Task{}, fmt.Errorf("task %d: invalid task", task.ID)
+	}
+	return task, nil
+}
+
+// 供給元を読み切るまでタスクを返す列
+func sourceSeq(src Source) iter.Seq[Task] {
+	return func(yield func(Task) bool) {
+		for {
+			task, ok := src.Next()
+			if !ok || !yield(task) {
+				return
+			}
+		}
+	}
+}
+
+// pipelineパッケージで、準備と処理の2段のパイプラインを組み立てて実行する
+func BuilderPipeline(env Env, numWorkers int) error {
+	p := pipeline.Connect(
+		pipeline.New(pipeline.Stage[Task, Task]{
+			Name: "prepare",
+			Fn: func(_ context.Context, task Task) (Task, error) {
+				return prepareTask(task)
+			},
+		}),
+		pipeline.Stage[Task, int]{
+			Name:    "process",
+			Workers: numWorkers,
+			Buffer:  100,
+			Fn: func(_ context.Context, task Task) (int, error) {
+				return task.ID, env.Process(task)
+			},
+		},
+	)
+	completed := 0
+	return pipeline.RunPipeline(env.context(), p, sourceSeq(env.Source), func(int) error {
+		completed++
+		return nil
+	})
+}
+
+// BuilderPipelineと同じ構成のパイプラインを、チャネルとsync.WaitGroupで直接書く
+func ManualPipeline(env Env, numWorkers int) error {
+	ctx, cancel := context.WithCancelCause(env.context())
+	defer cancel(nil)
+
+	// 供給元と準備のgoroutine
+	var feeders sync.WaitGroup
+	in := make(chan Task)
+	feeders.Add(1)
+	go func() {
+		defer feeders.Done()
+		defer close(in)
+		for task := range sourceSeq(env.Source) {
+			select {
+			case in <- task:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	prepared := make(chan Task)
+	feeders.Add(1)
+	go func() {
+		defer feeders.Done()
+		defer close(prepared)
+		for task := range in {
+			task, err := prepareTask(task)
+			if err != nil {
+				cancel(err)
+				return
+			}
+			select {
+			case prepared <- task:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	out := make(chan int, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range prepared {
+				if err := env.Process(task); err != nil {
+					cancel(err)
+					return
+				}
+				select {
+				case out <- task.ID:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	completed := 0
+	for range out {
+		completed++
+	}
+	feeders.Wait()
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package benchmark
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// 無制限の並列処理で1タスクあたりにかかるgoroutineの起動とスケジューリングのおおよそのコスト
+const goroutineOverhead = time.Microsecond
+
+// 実行計画の1項目（1つの戦略）
+type planItem struct {
+	Strategy Strategy
+	// 実行回数（負荷ランプではステップ数の上限）
+	Runs int
+	// 全ての実行にかかる時間の見積もり
+	Estimate time.Duration
+}
+
+// 設定から実行計画を組み立てる
+func buildPlan(cfg Config, list []Strategy) ([]planItem, error) {
+	if len(cfg.Interleave) == 2 {
+		a, err := findStrategy(list, cfg.Interleave[0])
+		if err != nil {
+			return nil, err
+		}
+		b, err := findStrategy(list, cfg.Interleave[1])
+		if err != nil {
+			return nil, err
+		}
+		list = []Strategy{a, b}
+	}
+
+	items := make([]planItem, len(list))
+	for i, s := range list {
+		item := planItem{Strategy: s, Runs: cfg.Repetitions}
+		if cfg.Ramp.Enabled {
+			// 飽和すると途中で打ち切るため、全ステップを実行した場合の上限
+			item.Runs = cfg.Ramp.Steps
+			item.Estimate = time.Duration(cfg.Ramp.Steps) * cfg.Ramp.StepDuration
+		} else if cfg.Sweep.Enabled {
+			item.Runs = cfg.Repetitions * len(cfg.Sweep.Tasks)
+			for _, n := range cfg.Sweep.Tasks {
+				item.Estimate += time.Duration(cfg.Repetitions) * estimateRun(s, n)
+			}
+		} else {
+			item.Estimate = time.Duration(item.Runs) * estimateRun(s, s.tasks())
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// タスクの処理時間のモデルから1回の実行時間を見積もる。
+// 並列度の上限がある場合は処理時間の合計を上限で割り、無制限の場合は最も重いタスクの処理時間と
+// goroutineのコストの合計とする。投入間隔がある場合は全タスクの投入にかかる時間を下限とする。
+// スリープの精度やCPU数の影響は含まないため目安として扱う
+func estimateRun(s Strategy, tasks int) time.Duration {
+	return max(estimateProcessing(s, tasks), time.Duration(tasks)*s.Pace)
+}
+
+// 処理時間のモデルから、待ち時間なしで投入した場合の実行時間を見積もる
+func estimateProcessing(s Strategy, tasks int) time.Duration {
+	var total, longest time.Duration
+	for id := 0; id < tasks; id++ {
+		d := taskDuration(id)
+		total += d
+		if d > longest {
+			longest = d
+		}
+	}
+	if s.Limit > 0 {
+		return total / time.Duration(s.Limit)
+	}
+	return longest + time.Duration(tasks)*goroutineOverhead
+}
+
+// 実行せずに全てのシナリオの実行計画をwに表示する
+func printPlans(w io.Writer, cfg Config, list []Scenario) error {
+	var total time.Duration
+	for i, sc := range list {
+		items, err := buildPlan(cfg, cfg.strategies(sc))
+		if err != nil {
+			return fmt.Errorf("%s: %w", sc.Name, err)
+		}
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		total += printPlan(w, cfg, sc, items)
+	}
+	if len(list) > 1 {
+		fmt.Fprintf(w, "\n全シナリオの所要時間の見積もり: %v\n", total.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// 実行せずに実行計画をwに表示し、所要時間の見積もりを返す
+func printPlan(w io.Writer, cfg Config, sc Scenario, items []planItem) time.Duration {
+	mode := "通常"
+	switch {
+	case cfg.Ramp.Enabled:
+		mode = fmt.Sprintf("負荷ランプ（%s、最大 %d ステップ、各 %v）", cfg.Ramp.Loop, cfg.Ramp.Steps, cfg.Ramp.StepDuration)
+	case cfg.Sweep.Enabled:
+		mode = fmt.Sprintf("タスク数のスイープ（%s）", joinInts(cfg.Sweep.Tasks))
+	case len(cfg.Interleave) == 2:
+		mode = fmt.Sprintf("交互実行（%dラウンド）", cfg.Repetitions)
+	}
+
+	fmt.Fprintln(w, "実行計画（ドライラン）")
+	if p, ok := findPreset(cfg.Preset); ok {
+		fmt.Fprintf(w, "プリセット: %s（%s）\n", p.Name, p.Description)
+	}
+	fmt.Fprintf(w, "シナリオ: %s（%s）\n", sc.Name, sc.Title)
+	fmt.Fprintf(w, "モード: %s\n", mode)
+	switch {
+	case cfg.Sweep.Enabled:
+		fmt.Fprintf(w, "繰り返し: %d（タスク数ごと）\n", cfg.Repetitions)
+	case !cfg.Ramp.Enabled:
+		fmt.Fprintf(w, "処理タスク数: %d、繰り返し: %d\n", cfg.tasks(), cfg.Repetitions)
+	}
+	fmt.Fprintf(w, "タスクのデータ: %s\n", cfg.TaskData.label(cfg.TaskDataSize))
+	if len(cfg.CPUQuotas) > 0 {
+		quotas := make([]string, len(cfg.CPUQuotas))
+		for i, q := range cfg.CPUQuotas {
+			quotas[i] = formatQuota(q)
+		}
+		fmt.Fprintf(w, "CPUクォータ: %s CPU（クォータごとに全体を実行）\n", strings.Join(quotas, ", "))
+		if cfg.QuotaGOMAXPROCS {
+			fmt.Fprintf(w, "GOMAXPROCS: CPU数（%d）とクォータに合わせた値で比較\n", runtime.NumCPU())
+		}
+	}
+	if cfg.MaxDuration > 0 {
+		fmt.Fprintf(w, "実行時間の上限: %v（残りの時間をシナリオに均等に割り振る）\n", cfg.MaxDuration)
+	}
+	if cfg.Chaos.Enabled {
+		fmt.Fprintf(w, "外乱: 平均 %v ごとにGCかCPUの占有（%v）、タスクごとに確率 %g でGosched\n", cfg.Chaos.Interval, cfg.Chaos.HogDuration, cfg.Chaos.YieldProbability)
+	}
+	if cfg.AllocBudget >= 0 {
+		fmt.Fprintf(w, "割り当て予算: %g allocs/タスク以内\n", cfg.AllocBudget)
+	}
+	fmt.Fprintln(w)
+
+	var runs int
+	var work time.Duration
+	for i, item := range items {
+		limit := "無制限"
+		if item.Strategy.Limit > 0 {
+			limit = fmt.Sprintf("%d同時実行", item.Strategy.Limit)
+		}
+		fmt.Fprintf(w, "%d. %-26s 実行回数 %3d  見積もり %-10v %s\n",
+			i+1, item.Strategy.Name, item.Runs, item.Estimate.Round(time.Millisecond), limit)
+		runs += item.Runs
+		work += item.Estimate
+	}
+
+	cooldowns := time.Duration(0)
+	if !cfg.Ramp.Enabled && runs > 1 {
+		cooldowns = time.Duration(runs-1) * cfg.Cooldown
+	}
+	fmt.Fprintf(w, "\n合計実行回数: %d\n", runs)
+	fmt.Fprintf(w, "所要時間の見積もり: %v（処理 %v + クールダウン %v）\n",
+		(work + cooldowns).Round(time.Millisecond), work.Round(time.Millisecond), cooldowns)
+	fmt.Fprintln(w, "  ※ タスクの処理時間のモデルに基づく目安です（スリープの精度やスケジューリングの遅れは含みません）")
+	if cfg.ExtendCooldown {
+		fmt.Fprintln(w, "  ※ クールダウンの自動延長が有効なため、実際の時間はこれより長くなる場合があります")
+	}
+	if len(cfg.Hooks) > 0 {
+		fmt.Fprintf(w, "フック: %s\n", strings.Join(cfg.Hooks, ", "))
+	}
+	fmt.Fprintf(w, "出力先: %s\n", strings.Join(cfg.Reports, ", "))
+	if cfg.ProfileDir != "" {
+		fmt.Fprintf(w, "プロファイル: %s\n", cfg.ProfileDir)
+	}
+	return work + cooldowns
+}
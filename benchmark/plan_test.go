@@ -0,0 +1,40 @@
+package benchmark
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateRun(t *testing.T) {
+	// ID 0〜99: 重いタスク1個、少し重いタスク9個、軽いタスク90個
+	const tasks = 100
+	total := 200*time.Microsecond + 9*50*time.Microsecond + 90*10*time.Microsecond
+
+	if got := estimateRun(Strategy{Limit: 4}, tasks); got != total/4 {
+		t.Errorf("limited: got %v, want %v", got, total/4)
+	}
+	if got, want := estimateRun(Strategy{}, tasks), 200*time.Microsecond+tasks*goroutineOverhead; got != want {
+		t.Errorf("unlimited: got %v, want %v", got, want)
+	}
+}
+
+func TestBuildPlanInterleave(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Repetitions = 3
+	cfg.Interleave = []string{"direct-limited", "chan-limited"}
+
+	items, err := buildPlan(cfg, strategies(Params{Workers: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Strategy.Name != "direct-limited" || items[0].Runs != 3 {
+		t.Errorf("unexpected plan: %+v", items)
+	}
+}
+
+func TestEstimateRunPace(t *testing.T) {
+	s := Strategy{Pace: time.Millisecond}
+	if got, want := estimateRun(s, 1000), time.Second; got != want {
+		t.Errorf("estimateRun = %v, want %v", got, want)
+	}
+}
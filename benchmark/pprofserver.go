@@ -0,0 +1,91 @@
+package benchmark
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// 実行中にプロファイルやgoroutineのダンプ、expvarの変数（/debug/vars）を取得できるpprofのHTTPサーバー。
+// 負荷ランプのように長く続く実行が遅くなったり止まったりしたときに、その場で原因を調べるために使う
+type pprofServer struct {
+	srv *http.Server
+	ln  net.Listener
+}
+
+// 指定したアドレスでpprofのHTTPサーバーを起動する。
+// プロファイルには実行中のプログラムの内部が含まれるため、ループバックアドレス以外では待ち受けない
+func startPprofServer(addr string) (*pprofServer, error) {
+	if err := validatePprofAddr(addr); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("pprof server: %w", err)
+	}
+
+	// net/http/pprofのinitが登録するDefaultServeMuxは使わず、専用のハンドラーを作る
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	s := &pprofServer{
+		srv: &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+		ln:  ln,
+	}
+	go s.srv.Serve(ln)
+	return s, nil
+}
+
+// 待ち受けているURL
+func (s *pprofServer) url() string {
+	if s == nil {
+		return ""
+	}
+	return "http://" + s.ln.Addr().String() + "/debug/pprof/"
+}
+
+// expvarの変数を取得するURL
+func (s *pprofServer) varsURL() string {
+	if s == nil {
+		return ""
+	}
+	return "http://" + s.ln.Addr().String() + "/debug/vars"
+}
+
+// サーバーを停止する。取得中のプロファイルは待たずに打ち切る
+func (s *pprofServer) close() error {
+	if s == nil {
+		return nil
+	}
+	if err := s.srv.Close(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// 待ち受けるアドレスがループバックアドレスかを確認する
+func validatePprofAddr(addr string) error {
+	if addr == "" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid pprof address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("pprof address must be a loopback address (e.g. localhost:6060), got %q", addr)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package benchmark
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// 起動したサーバーからgoroutineのダンプを取得でき、停止後は接続できないことを確認する
+func TestPprofServer(t *testing.T) {
+	s, err := startPprofServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(s.url() + "goroutine?debug=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "goroutine") {
+		t.Errorf("status %d, body %.100q", resp.StatusCode, body)
+	}
+
+	if err := s.close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := http.Get(s.url()); err == nil {
+		t.Error("server still serving after close")
+	}
+}
+
+func TestValidatePprofAddr(t *testing.T) {
+	for _, addr := range []string{"", "localhost:6060", "127.0.0.1:0", "[::1]:6060"} {
+		if err := validatePprofAddr(addr); err != nil {
+			t.Errorf("%q: %v", addr, err)
+		}
+	}
+	for _, addr := range []string{":6060", "0.0.0.0:6060", "example.com:6060", "6060"} {
+		if err := validatePprofAddr(addr); err == nil {
+			t.Errorf("%q: want error", addr)
+		}
+	}
+}
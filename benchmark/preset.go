@@ -0,0 +1,109 @@
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 名前付きの実行設定のひな形。各項目の意味を知らなくても、目的に合った比較を実行できるようにする
+type Preset struct {
+	// -presetで指定する名前
+	Name string
+	// 一覧に表示する説明
+	Description string
+	// デフォルトの設定をプリセットの設定に書き換える
+	apply func(cfg *Config)
+}
+
+// 利用できるプリセット一覧
+var presets = []Preset{
+	{
+		Name:        "quick",
+		Description: "基本のシナリオを少ないタスク数で1回だけ実行し、数秒で傾向をつかむ",
+		apply: func(cfg *Config) {
+			cfg.Scenario = defaultScenario
+			cfg.Tasks = 10000
+			cfg.Repetitions = 1
+			cfg.Cooldown = 0
+		},
+	},
+	{
+		Name:        "blog-post",
+		Description: "全てのシナリオを十分な繰り返しとクールダウンで実行し、記事に貼れるMarkdownとJSONを書き出す",
+		apply: func(cfg *Config) {
+			cfg.Scenario = allScenarios
+			cfg.Repetitions = 5
+			cfg.Cooldown = 2 * time.Second
+			cfg.ExtendCooldown = true
+			cfg.Reports = []string{"console", "markdown=results.md", "json=results.json"}
+		},
+	},
+	{
+		Name:        "io-bound-server",
+		Description: "待機が中心のタスクを一定のレートで投入するサーバーを想定し、投入レートを上げたときのレイテンシを比べる",
+		apply: func(cfg *Config) {
+			cfg.Scenario = strings.Join([]string{defaultScenario, "semaphore", "completion"}, ",")
+			cfg.Ramp.Enabled = true
+			cfg.Ramp.Loop = LoopOpen
+			cfg.Ramp.StartRate = 1000
+			cfg.Ramp.Factor = 2
+			cfg.Ramp.Steps = 8
+			cfg.Ramp.StepDuration = time.Second
+		},
+	},
+	{
+		Name:        "cpu-bound-batch",
+		Description: "データを生成するタスクの一括処理を想定し、振り分けの仕組みのオーバーヘッドと割り当てを比べる",
+		apply: func(cfg *Config) {
+			cfg.Scenario = strings.Join([]string{defaultScenario, "task-kinds", "weighted"}, ",")
+			cfg.Repetitions = 5
+			cfg.Cooldown = time.Second
+			cfg.ExtendCooldown = true
+			cfg.TaskData = DataBytes
+			cfg.TaskDataSize = 256
+		},
+	},
+	{
+		Name:        "memory-pressure",
+		Description: "大きなデータを持つ100万タスクを処理し、ピークメモリとGCの負荷を比べる（ヒープ4GBで中止）",
+		apply: func(cfg *Config) {
+			cfg.Scenario = strings.Join([]string{defaultScenario, "completion"}, ",")
+			cfg.Tasks = 1000000
+			cfg.Repetitions = 1
+			cfg.TaskData = DataBytes
+			cfg.TaskDataSize = 1024
+			cfg.Limits.MaxHeapBytes = 4 << 30
+		},
+	},
+}
+
+// プリセット名の一覧
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for _, p := range presets {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// 名前からプリセットを探す
+func findPreset(name string) (Preset, bool) {
+	for _, p := range presets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// 設定にプリセットを適用する。プリセットで指定しない項目は元の設定のまま残る
+func ApplyPreset(cfg *Config, name string) error {
+	p, ok := findPreset(name)
+	if !ok {
+		return fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(PresetNames(), ", "))
+	}
+	p.apply(cfg)
+	cfg.Preset = name
+	return nil
+}
@@ -0,0 +1,24 @@
+package benchmark
+
+import "testing"
+
+// 全てのプリセットが妥当な設定に展開され、名前が記録されることを確認する
+func TestApplyPreset(t *testing.T) {
+	for _, name := range PresetNames() {
+		cfg := DefaultConfig()
+		if err := ApplyPreset(&cfg, name); err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if cfg.Preset != name {
+			t.Errorf("%s: preset = %q", name, cfg.Preset)
+		}
+	}
+
+	cfg := DefaultConfig()
+	if err := ApplyPreset(&cfg, "unknown"); err == nil {
+		t.Error("unknown preset: want error")
+	}
+}
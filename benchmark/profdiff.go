@@ -0,0 +1,230 @@
+package benchmark
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// 差分レポートに表示する上位の関数の数
+const profDiffTopN = 15
+
+// 戦略間の差が現れやすいランタイム関数（チャネル送受信、goroutine生成、semaphore待ちなど）
+var profDiffHighlights = []string{
+	"runtime.chansend",
+	"runtime.chanrecv",
+	"runtime.selectgo",
+	"runtime.newproc",
+	"runtime.semacquire",
+	"runtime.semrelease",
+	"runtime.gopark",
+	"runtime.mallocgc",
+	"runtime.schedule",
+}
+
+// 関数ごとのCPU時間（flat: 関数自身、cum: 呼び出し先を含む）
+type funcCost struct {
+	flat, cum int64
+}
+
+// プロファイルから関数ごとのCPU時間を集計する
+func functionCosts(p *profile.Profile) (map[string]funcCost, int64) {
+	costs := map[string]funcCost{}
+	var total int64
+	valueIdx := len(p.SampleType) - 1
+	for _, s := range p.Sample {
+		v := s.Value[valueIdx]
+		total += v
+
+		// 再帰呼び出しでcumを二重に数えないよう、スタック内の関数は1度だけ加算する
+		seen := map[string]bool{}
+		for i, loc := range s.Location {
+			for j, line := range loc.Line {
+				if line.Function == nil {
+					continue
+				}
+				name := line.Function.Name
+				c := costs[name]
+				if i == 0 && j == 0 {
+					c.flat += v
+				}
+				if !seen[name] {
+					c.cum += v
+					seen[name] = true
+				}
+				costs[name] = c
+			}
+		}
+	}
+	return costs, total
+}
+
+// 2つの戦略のCPUプロファイルを同じ設定で取得し、関数ごとの差分を表示する。
+// あわせて、Bの値からAの値を差し引いた差分プロファイルを書き出す（go tool pprofで閲覧できる）
+func RunProfileDiff(cfg Config, nameA, nameB string) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	// プロファイルの書き出し先が指定されていない場合は、各戦略のプロファイルを一時ディレクトリに書いて終了時に削除し、
+	// 差分プロファイルだけをカレントディレクトリに残す
+	dir, diffDir := cfg.ProfileDir, cfg.ProfileDir
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "profdiff")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		dir, diffDir = tmp, "."
+	}
+
+	sc, err := findScenario(cfg.Scenario)
+	if err != nil {
+		return err
+	}
+	list := cfg.strategies(sc)
+	a, err := findStrategy(list, nameA)
+	if err != nil {
+		return err
+	}
+	b, err := findStrategy(list, nameB)
+	if err != nil {
+		return err
+	}
+
+	data := cfg.dataGen()
+	pa, err := captureProfile(a, "a", cfg.Repetitions, dir, data)
+	if err != nil {
+		return err
+	}
+	pb, err := captureProfile(b, "b", cfg.Repetitions, dir, data)
+	if err != nil {
+		return err
+	}
+
+	printProfileDiff(a, b, pa, pb)
+
+	diffPath := filepath.Join(diffDir, fmt.Sprintf("%s-vs-%s.diff.pprof", a.Name, b.Name))
+	if err := writeDiffProfile(pa, pb, diffPath); err != nil {
+		return err
+	}
+	fmt.Printf("差分プロファイル: %s（go tool pprof -top %s で確認できます）\n", diffPath, diffPath)
+	return nil
+}
+
+// 戦略をreps回実行する間のCPUプロファイルを取得して読み込む。
+// AとBに同じ名前の戦略を指定しても上書きしないよう、ファイル名には比較のどちら側か（sideのaまたはb）を付ける
+func captureProfile(s Strategy, side string, reps int, dir string, data dataGen) (*profile.Profile, error) {
+	path, stop, err := startCPUProfile(dir, side+"-"+s.Name)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < reps; i++ {
+		if _, err := measure(s, batchEnv(s.tasks(), data)); err != nil {
+			stop()
+			return nil, err
+		}
+	}
+	if err := stop(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return profile.Parse(f)
+}
+
+// 関数ごとのCPU時間の差分を表示する
+func printProfileDiff(a, b Strategy, pa, pb *profile.Profile) {
+	ca, totalA := functionCosts(pa)
+	cb, totalB := functionCosts(pb)
+
+	fmt.Printf("A: %s\n", a.Title)
+	fmt.Printf("B: %s\n\n", b.Title)
+	fmt.Printf("CPU時間合計: A=%v B=%v 差分(B-A)=%v\n\n", nsDuration(totalA), nsDuration(totalB), nsDuration(totalB-totalA))
+
+	fmt.Println("注目するランタイム関数（cum: 呼び出し先を含むCPU時間）")
+	fmt.Printf("%-24s %14s %14s %14s\n", "関数", "A", "B", "B-A")
+	for _, name := range profDiffHighlights {
+		va, vb := ca[name].cum, cb[name].cum
+		if va == 0 && vb == 0 {
+			continue
+		}
+		fmt.Printf("%-24s %14v %14v %14v\n", name, nsDuration(va), nsDuration(vb), nsDuration(vb-va))
+	}
+	fmt.Println()
+
+	// flatの差が大きい関数から順に表示する
+	names := make([]string, 0, len(ca)+len(cb))
+	for name := range ca {
+		names = append(names, name)
+	}
+	for name := range cb {
+		if _, ok := ca[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	delta := func(name string) int64 { return cb[name].flat - ca[name].flat }
+	abs := func(v int64) int64 {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	sort.Slice(names, func(i, j int) bool {
+		di, dj := abs(delta(names[i])), abs(delta(names[j]))
+		if di != dj {
+			return di > dj
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > profDiffTopN {
+		names = names[:profDiffTopN]
+	}
+
+	fmt.Printf("flatの差分が大きい関数（上位%d件）\n", profDiffTopN)
+	fmt.Printf("%14s %14s %14s  %s\n", "A", "B", "B-A", "関数")
+	for _, name := range names {
+		fmt.Printf("%14v %14v %14v  %s\n", nsDuration(ca[name].flat), nsDuration(cb[name].flat), nsDuration(delta(name)), shortFuncName(name))
+	}
+	fmt.Println()
+}
+
+// Bのプロファイルから、符号を反転したAのプロファイルを合成して差分プロファイルを書き出す
+func writeDiffProfile(pa, pb *profile.Profile, path string) error {
+	base := pa.Copy()
+	base.Scale(-1)
+	diff, err := profile.Merge([]*profile.Profile{pb.Copy(), base})
+	if err != nil {
+		return fmt.Errorf("merge profiles: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := diff.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// プロファイルのナノ秒値をtime.Durationとして扱う
+func nsDuration(ns int64) time.Duration {
+	return time.Duration(ns)
+}
+
+// モジュールパスを除いた関数名を返す
+func shortFuncName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
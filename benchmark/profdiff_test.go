@@ -0,0 +1,43 @@
+package benchmark
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// AとBに同じ戦略を指定しても、それぞれのプロファイルを別のファイルに書き出すことを確認する
+func TestRunProfileDiffSameStrategy(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.Tasks = 200
+	cfg.Repetitions = 1
+	cfg.ProfileDir = dir
+	if err := RunProfileDiff(cfg, "direct-unlimited", "direct-unlimited"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a-direct-unlimited.cpu.pprof", "b-direct-unlimited.cpu.pprof", "direct-unlimited-vs-direct-unlimited.diff.pprof"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// 書き出し先を指定しない場合は、一時ディレクトリを残さず、差分プロファイルだけをカレントディレクトリに書き出すことを確認する
+func TestRunProfileDiffTempDir(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Chdir(t.TempDir())
+	cfg := DefaultConfig()
+	cfg.Tasks = 200
+	cfg.Repetitions = 1
+	if err := RunProfileDiff(cfg, "chan-unlimited", "direct-unlimited"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := os.ReadDir(tmp); err != nil || len(entries) != 0 {
+		t.Errorf("temporary directory was left behind: %v %v", entries, err)
+	}
+	if _, err := os.Stat("chan-unlimited-vs-direct-unlimited.diff.pprof"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,109 @@
+package benchmark
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/google/pprof/profile"
+)
+
+// 戦略の実行中のCPUプロファイルを dir/<name>.cpu.pprof に書き出し始める。
+// 戻り値の関数でプロファイルを停止してファイルを閉じる
+func startCPUProfile(dir, name string) (string, func() error, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", nil, err
+	}
+	path := filepath.Join(dir, name+".cpu.pprof")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return "", nil, fmt.Errorf("start cpu profile: %w", err)
+	}
+	stop := func() error {
+		pprof.StopCPUProfile()
+		return f.Close()
+	}
+	return path, stop, nil
+}
+
+// ブロックプロファイルのサンプリング間隔（ナノ秒）と、ミューテックスの競合を記録する割合の逆数
+const (
+	blockProfileRate     = 10000
+	mutexProfileFraction = 5
+)
+
+// 競合のプロファイルの種類（runtime/pprofのプロファイル名）
+var contentionProfiles = []string{"block", "mutex"}
+
+// ブロックとミューテックスの競合の記録を有効にし、戦略の実行中の差分を書き出せるよう開始時点の
+// プロファイルを保存する。ランタイムのプロファイルはプロセスの開始からの累計でリセットできないため、
+// 戻り値の関数で終了時点との差分を dir/<name>.<種類>.pprof に書き出し、記録を無効に戻す
+func startContentionProfile(dir, name string) (func() (map[string]string, error), error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	base := map[string]*profile.Profile{}
+	for _, kind := range contentionProfiles {
+		p, err := readRuntimeProfile(kind)
+		if err != nil {
+			return nil, err
+		}
+		base[kind] = p
+	}
+	runtime.SetBlockProfileRate(blockProfileRate)
+	runtime.SetMutexProfileFraction(mutexProfileFraction)
+
+	stop := func() (map[string]string, error) {
+		runtime.SetBlockProfileRate(0)
+		runtime.SetMutexProfileFraction(0)
+		paths := map[string]string{}
+		for _, kind := range contentionProfiles {
+			path := filepath.Join(dir, name+"."+kind+".pprof")
+			if err := writeProfileDelta(kind, base[kind], path); err != nil {
+				return nil, fmt.Errorf("%s profile: %w", kind, err)
+			}
+			paths[kind] = path
+		}
+		return paths, nil
+	}
+	return stop, nil
+}
+
+// ランタイムのプロファイルを読み込む
+func readRuntimeProfile(kind string) (*profile.Profile, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup(kind).WriteTo(&buf, 0); err != nil {
+		return nil, err
+	}
+	return profile.Parse(&buf)
+}
+
+// 現在のプロファイルから基準時点のプロファイルを引いた差分を書き出す
+func writeProfileDelta(kind string, base *profile.Profile, path string) error {
+	cur, err := readRuntimeProfile(kind)
+	if err != nil {
+		return err
+	}
+	neg := base.Copy()
+	neg.Scale(-1)
+	delta, err := profile.Merge([]*profile.Profile{cur, neg})
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = delta.Write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
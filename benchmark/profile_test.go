@@ -0,0 +1,93 @@
+package benchmark
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// 実行中の競合だけが差分として書き出され、終了後は記録が無効に戻ることを確認する
+func TestContentionProfile(t *testing.T) {
+	dir := t.TempDir()
+	stop, err := startContentionProfile(dir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				mu.Lock()
+				time.Sleep(10 * time.Microsecond)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	paths, err := stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kind := range contentionProfiles {
+		f, err := os.Open(paths[kind])
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := profile.Parse(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if len(p.Sample) == 0 {
+			t.Errorf("%s profile has no samples", kind)
+		}
+	}
+	// 負の値を渡すと現在の設定を変えずに返す
+	if rate := runtime.SetMutexProfileFraction(-1); rate != 0 {
+		t.Errorf("mutex profile fraction = %d after stop, want 0", rate)
+	}
+}
+
+// 同じ名前の戦略を複数のシナリオで実行しても、プロファイルをシナリオごとのファイルに書き出すことを確認する
+func TestRunProfiledScenarios(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ProfileDir = t.TempDir()
+	cfg.ContentionProfile = true
+	cfg.Repetitions = 1
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}}
+
+	s := Strategy{Name: "chan-limited", Tasks: 100, Run: strictSequential}
+	seen := map[string]string{}
+	for _, name := range []string{"dispatch", "errgroup"} {
+		r.scenario = Scenario{Name: name}
+		res, err := r.runProfiled(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for kind, path := range res.Artifacts {
+			if want := filepath.Join(cfg.ProfileDir, name); filepath.Dir(path) != want {
+				t.Errorf("%s %s = %s, want it under %s", name, kind, path, want)
+			}
+			if other, ok := seen[path]; ok {
+				t.Errorf("%s %s overwrites the profile of %s: %s", name, kind, other, path)
+			}
+			seen[path] = name
+			if _, err := os.Stat(path); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	if len(seen) != 2*(1+len(contentionProfiles)) {
+		t.Errorf("profiles = %v", seen)
+	}
+}
@@ -0,0 +1,112 @@
+package benchmark
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pushgatewayに送るときのジョブ名
+const prometheusJob = "go_speed_chan_vs_goroutine"
+
+// 全ての結果をPrometheusのテキスト形式でPushgatewayに送る。
+// 同じグループへの送信は前回の値を置き換えるため、Closeでまとめて1回だけ送る
+type prometheusReporter struct {
+	url     string
+	client  *http.Client
+	results []Result
+}
+
+func newPrometheusReporter(target string, _ io.Writer) (Reporter, error) {
+	if target == "" {
+		return nil, errors.New("pushgateway URL is required (e.g. prometheus=http://localhost:9091)")
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("pushgateway URL must be http or https, got %q", target)
+	}
+	return &prometheusReporter{
+		url:    strings.TrimSuffix(target, "/") + "/metrics/job/" + prometheusJob,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (p *prometheusReporter) Report(r Result) error {
+	p.results = append(p.results, r)
+	return nil
+}
+
+func (p *prometheusReporter) Close() error {
+	if len(p.results) == 0 {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodPut, p.url, bytes.NewReader(prometheusText(p.results)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("push to %s failed: %s", p.url, resp.Status)
+	}
+	return nil
+}
+
+// 結果をPrometheusのテキスト形式に変換する。
+// メトリクスごとにまとめ、戦略などをラベルとして付ける
+func prometheusText(results []Result) []byte {
+	var buf bytes.Buffer
+	for _, name := range metricNames(results) {
+		metric := "bench_" + name
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", metric)
+		for _, r := range results {
+			v, ok := r.Metrics[name]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&buf, "%s{%s} %s\n", metric, prometheusLabels(r), strconv.FormatFloat(v, 'g', -1, 64))
+		}
+	}
+	return buf.Bytes()
+}
+
+// 結果を識別するラベル。負荷ランプではステップを区別するため負荷を、スイープではタスク数も含める
+func prometheusLabels(r Result) string {
+	labels := map[string]string{
+		"mode":     r.Mode,
+		"scenario": r.Scenario,
+		"strategy": r.Strategy,
+	}
+	if r.Mode == modeRamp {
+		labels["load"] = strconv.FormatFloat(r.Metrics[metricLoad], 'f', -1, 64)
+	}
+	if r.Mode == modeSweep {
+		labels["tasks"] = r.Params["tasks"]
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%q", k, labels[k])
+	}
+	return strings.Join(pairs, ",")
+}
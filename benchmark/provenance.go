@@ -0,0 +1,123 @@
+package benchmark
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// 結果を作ったベンチマークのコードと設定。
+// 異なるコードで計測した結果は、戦略の実装やメトリクスの求め方が違う可能性があるため比較しない
+type Provenance struct {
+	// モジュールのパスとバージョン（ソースからビルドした場合は(devel)など）
+	Module  string `json:"module,omitempty"`
+	Version string `json:"version,omitempty"`
+	// ビルドしたコミットのハッシュ。VCSの情報がない場合（go runなど）は空
+	Commit string `json:"commit,omitempty"`
+	// コミットされていない変更を含むか
+	Modified bool `json:"modified,omitempty"`
+	// 結果に影響する設定のハッシュ
+	ConfigHash string `json:"config_hash,omitempty"`
+}
+
+// 実行中のバイナリのビルド情報と設定から、結果の出どころを作成する
+func captureProvenance(cfg Config) Provenance {
+	p := Provenance{ConfigHash: cfg.settingsHash()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return p
+	}
+	p.Module = info.Main.Path
+	p.Version = info.Main.Version
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			p.Commit = s.Value
+		case "vcs.modified":
+			p.Modified = s.Value == "true"
+		}
+	}
+	return p
+}
+
+// 結果に影響する設定のハッシュ（チェックポイントの互換性の判定と同じ項目）。
+// 実行するシナリオの選び方は各結果に影響しないため含めない
+func (c Config) settingsHash() string {
+	s := c.settings()
+	s.Scenario = ""
+	b, err := json.Marshal(s)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:6])
+}
+
+// 表示用の短い説明（コミットの先頭12文字、なければバージョン）
+func (p Provenance) label() string {
+	var s string
+	switch {
+	case p.Commit != "":
+		s = p.Commit[:min(12, len(p.Commit))]
+	case p.Version != "":
+		s = p.Version
+	default:
+		s = "不明"
+	}
+	if p.Modified {
+		s += "（未コミットの変更あり）"
+	}
+	if p.ConfigHash != "" {
+		s += "、設定 " + p.ConfigHash
+	}
+	return s
+}
+
+// 結果を作ったコードの互換性を確認する。コミットの異なる結果が混ざっている場合はエラーを返す。
+// コミットが分からない結果、未コミットの変更を含む結果、設定の異なる結果は比較できるが警告を返す
+func checkProvenance(results []Result) (warnings []string, err error) {
+	commits := map[string]bool{}
+	configs := map[string]bool{}
+	var unknown, modified int
+	for _, r := range results {
+		p := r.Provenance
+		if p.Commit == "" {
+			unknown++
+		} else {
+			commits[p.Commit] = true
+		}
+		if p.Modified {
+			modified++
+		}
+		if p.ConfigHash != "" {
+			configs[p.ConfigHash] = true
+		}
+	}
+	if len(commits) > 1 {
+		return nil, fmt.Errorf("results were produced by different benchmark code (commits %s)", strings.Join(sortedSet(commits), ", "))
+	}
+	if unknown > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d件の結果はベンチマークのコードのコミットが不明のため、同じコードで計測したか確認できません", unknown))
+	}
+	if modified > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d件の結果はコミットされていない変更を含むコードで計測されています", modified))
+	}
+	if len(configs) > 1 {
+		warnings = append(warnings, fmt.Sprintf("結果の設定が異なります（%s）", strings.Join(sortedSet(configs), ", ")))
+	}
+	return warnings, nil
+}
+
+// 集合の要素を並べ替えて返す
+func sortedSet(set map[string]bool) []string {
+	list := make([]string, 0, len(set))
+	for k := range set {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,46 @@
+package benchmark
+
+import (
+	"testing"
+)
+
+func TestCheckProvenance(t *testing.T) {
+	result := func(p Provenance) Result { return Result{Provenance: p} }
+	cases := []struct {
+		name     string
+		results  []Result
+		wantErr  bool
+		warnings int
+	}{
+		{"same commit", []Result{result(Provenance{Commit: "a", ConfigHash: "x"}), result(Provenance{Commit: "a", ConfigHash: "x"})}, false, 0},
+		{"different commits", []Result{result(Provenance{Commit: "a"}), result(Provenance{Commit: "b"})}, true, 0},
+		{"unknown and modified", []Result{result(Provenance{}), result(Provenance{Commit: "a", Modified: true})}, false, 2},
+		{"different settings", []Result{result(Provenance{Commit: "a", ConfigHash: "x"}), result(Provenance{Commit: "a", ConfigHash: "y"})}, false, 1},
+	}
+	for _, c := range cases {
+		warnings, err := checkProvenance(c.results)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: err = %v, want error %v", c.name, err, c.wantErr)
+		}
+		if len(warnings) != c.warnings {
+			t.Errorf("%s: got warnings %q, want %d", c.name, warnings, c.warnings)
+		}
+	}
+}
+
+// 結果に影響する設定だけがハッシュを変えることを確認する
+func TestSettingsHash(t *testing.T) {
+	cfg := DefaultConfig()
+	base := cfg.settingsHash()
+
+	other := cfg
+	other.Scenario = allScenarios
+	other.Reports = []string{"json"}
+	if got := other.settingsHash(); got != base {
+		t.Errorf("scenario and reports changed the hash: %s != %s", got, base)
+	}
+	other.Repetitions = 5
+	if got := other.settingsHash(); got == base {
+		t.Error("repetitions did not change the hash")
+	}
+}
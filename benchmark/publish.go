@@ -0,0 +1,163 @@
+package benchmark
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 結果の送信先の設定
+type PublishConfig struct {
+	// 全ての結果のJSON配列をPOSTするURL。空の場合は送信しない
+	URL string
+	// 送信時に付けるヘッダー（「名前: 値」）。値の$VARや${VAR}は環境変数で置き換える
+	Headers []string
+}
+
+// 送信先の設定を確認する
+func (c PublishConfig) validate() error {
+	if c.URL == "" {
+		if len(c.Headers) > 0 {
+			return errors.New("publish headers require a publish URL")
+		}
+		return nil
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("publish URL must be http or https, got %q", c.URL)
+	}
+	for _, h := range c.Headers {
+		if _, _, err := parseHeader(h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 「名前: 値」の形式のヘッダーを分解し、値の環境変数を展開する
+func parseHeader(h string) (name, value string, err error) {
+	name, value, ok := strings.Cut(h, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("invalid publish header %q (want \"Name: value\")", h)
+	}
+	return textproto.CanonicalMIMEHeaderKey(name), os.ExpandEnv(strings.TrimSpace(value)), nil
+}
+
+// 全ての結果をJSONの配列として、実行の最後にまとめて1回だけHTTPのPOSTで送る
+type publishReporter struct {
+	url     string
+	header  http.Header
+	client  *http.Client
+	results []Result
+}
+
+func newPublishReporter(c PublishConfig) (Reporter, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	for _, h := range c.Headers {
+		name, value, _ := parseHeader(h)
+		header.Add(name, value)
+	}
+	header.Set("Content-Type", "application/json")
+	return &publishReporter{
+		url:     c.URL,
+		header:  header,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		results: []Result{},
+	}, nil
+}
+
+func (p *publishReporter) Report(r Result) error {
+	p.results = append(p.results, r)
+	return nil
+}
+
+func (p *publishReporter) Close() error {
+	if len(p.results) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(p.results)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header = p.header.Clone()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("publish to %s failed: %s", p.url, resp.Status)
+	}
+	return nil
+}
+
+// 実行設定の出力先を全て作成する。結果の送信先、成果物のアップロード先、Webhookが指定されていれば、
+// ファイルを書き出す出力先の後に閉じるよう最後に加える。Webhookはアップロードの完了後に送る
+func newConfigReporters(cfg Config) ([]Reporter, error) {
+	reports := cfg.Reports
+	if cfg.Format == FormatJSON {
+		// 標準出力には全ての結果のJSON配列を1つだけ書くため、標準出力に書くjsonの出力先は1つにまとめる
+		reports = slices.DeleteFunc(slices.Clone(reports), func(spec string) bool {
+			kind, target, _ := parseReportSpec(spec)
+			return kind == "json" && (target == "" || target == "-")
+		})
+	}
+	reporters, err := newReporters(reports, cfg.stdout())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Format == FormatJSON {
+		rep, err := newJSONReporter("", os.Stdout)
+		if err != nil {
+			closeReporters(reporters)
+			return nil, err
+		}
+		reporters = append(reporters, rep)
+	}
+	if cfg.Publish.URL != "" {
+		rep, err := newPublishReporter(cfg.Publish)
+		if err != nil {
+			closeReporters(reporters)
+			return nil, fmt.Errorf("publish: %w", err)
+		}
+		reporters = append(reporters, rep)
+	}
+	var link string
+	if cfg.Upload.URL != "" {
+		rep, err := newUploadReporter(cfg.Upload, cfg.Reports)
+		if err != nil {
+			closeReporters(reporters)
+			return nil, fmt.Errorf("upload: %w", err)
+		}
+		reporters = append(reporters, rep)
+		link = rep.(*uploadReporter).location()
+	}
+	if cfg.Webhook.URL != "" {
+		rep, err := newWebhookReporter(cfg.Webhook, link)
+		if err != nil {
+			closeReporters(reporters)
+			return nil, fmt.Errorf("webhook: %w", err)
+		}
+		reporters = append(reporters, rep)
+	}
+	return reporters, nil
+}
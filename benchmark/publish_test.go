@@ -0,0 +1,74 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 全ての結果をJSONの配列としてPOSTし、環境変数を展開したヘッダーを付けることを確認する
+func TestPublishReporter(t *testing.T) {
+	var method, auth, contentType string
+	var got []Result
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, auth, contentType = r.Method, r.Header.Get("Authorization"), r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("BENCH_TOKEN", "secret")
+	rep, err := newPublishReporter(PublishConfig{URL: srv.URL, Headers: []string{"authorization: Bearer $BENCH_TOKEN"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.Report(testResult("a", 100))
+	rep.Report(testResult("b", 200))
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if method != http.MethodPost {
+		t.Errorf("method = %s, want POST", method)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", auth, "Bearer secret")
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	if len(got) != 2 || got[1].Strategy != "b" || got[1].Metrics[metricWall] != 200 {
+		t.Errorf("published %+v", got)
+	}
+}
+
+// 送信先が2xx以外を返した場合にエラーになることを確認する
+func TestPublishReporterStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	rep, err := newPublishReporter(PublishConfig{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.Report(testResult("a", 100))
+	if err := rep.Close(); err == nil {
+		t.Error("expected error for 401 response")
+	}
+}
+
+func TestPublishConfigValidate(t *testing.T) {
+	for _, c := range []PublishConfig{
+		{URL: "ftp://example.com"},
+		{URL: "http://example.com", Headers: []string{"no colon"}},
+		{Headers: []string{"Authorization: Bearer x"}},
+	} {
+		if err := c.validate(); err == nil {
+			t.Errorf("%+v: expected error", c)
+		}
+	}
+}
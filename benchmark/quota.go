@@ -0,0 +1,294 @@
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CPUクォータによる停止（スロットリング）の累計
+type cpuThrottling struct {
+	// 停止させられた周期の数
+	Periods int64
+	// 停止していた時間（ナノ秒）
+	Nanos int64
+}
+
+func (t cpuThrottling) sub(other cpuThrottling) cpuThrottling {
+	return cpuThrottling{Periods: t.Periods - other.Periods, Nanos: t.Nanos - other.Nanos}
+}
+
+// CPUクォータの表示用の文字列
+func formatQuota(q float64) string {
+	return strconv.FormatFloat(q, 'f', -1, 64)
+}
+
+// CPUクォータの下での1回の実行（子プロセス）
+type quotaRun struct {
+	quota float64
+	// 子プロセスのGOMAXPROCS。0の場合は指定しない（Goのバージョンの既定の動作に任せる）
+	procs int
+}
+
+// 設定からクォータごとの実行を作成する。GOMAXPROCSを比べる場合は、CPU数と
+// クォータに合わせた値（automaxprocsと同じくクォータの小数点以下を切り捨て、最小1）の2通りで実行する
+func quotaRuns(cfg Config) []quotaRun {
+	var runs []quotaRun
+	for _, q := range cfg.CPUQuotas {
+		if !cfg.QuotaGOMAXPROCS {
+			runs = append(runs, quotaRun{quota: q})
+			continue
+		}
+		numCPU := runtime.NumCPU()
+		runs = append(runs, quotaRun{quota: q, procs: numCPU})
+		// クォータに合わせた値がCPU数と同じなら、同じ条件の実行になるため1回だけ実行する
+		if aligned := quotaProcs(q, numCPU); aligned != numCPU {
+			runs = append(runs, quotaRun{quota: q, procs: aligned})
+		}
+	}
+	return runs
+}
+
+// クォータに合わせたGOMAXPROCS（小数点以下を切り捨て、1以上CPU数以下）
+func quotaProcs(quota float64, numCPU int) int {
+	return min(max(1, int(math.Floor(quota))), numCPU)
+}
+
+func (q quotaRun) label() string {
+	if q.procs == 0 {
+		return formatQuota(q.quota) + " CPU"
+	}
+	return fmt.Sprintf("%s CPU, P=%d", formatQuota(q.quota), q.procs)
+}
+
+// 結果がこの実行で計測したものかを返す
+func (q quotaRun) matches(r Result) bool {
+	return r.Params["cpu_quota"] == formatQuota(q.quota) && (q.procs == 0 || r.Environment.GOMAXPROCS == q.procs)
+}
+
+// CPUクォータごとに、cgroupでクォータを設定した子プロセスで全体を実行し、結果を出力先に集める。
+// Kubernetesのようにコンテナに割り当てられたCPUを使い切ると周期の残りで停止させられる環境で、
+// 戦略ごとの処理時間とスロットリングを比べる
+func runQuotas(ctx context.Context, cfg Config) (err error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	reporters, err := newConfigReporters(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, closeReporters(reporters))
+	}()
+	var out *console
+	for _, rep := range reporters {
+		if c, ok := rep.(*console); ok {
+			out = c
+		}
+	}
+
+	runs := quotaRuns(cfg)
+	var all []Result
+	for i, run := range runs {
+		if i > 0 && cfg.Cooldown > 0 {
+			(&cooldown{d: cfg.Cooldown, ctx: ctx}).wait()
+		}
+		if ctx.Err() != nil {
+			return ErrInterrupted
+		}
+		out.quotaStart(os.Stderr, run)
+		results, err := runQuotaChild(ctx, exe, cfg, run, out != nil)
+		all = append(all, results...)
+		for _, res := range results {
+			for _, rep := range reporters {
+				// 子プロセスがコンソールに表示済み
+				if _, ok := rep.(*console); ok {
+					continue
+				}
+				if err := rep.Report(res); err != nil {
+					return err
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	out.quotaSummary(os.Stderr, runs, all)
+	return nil
+}
+
+// CPUクォータを設定したcgroupで子プロセスを実行し、その結果を読み込む。
+// 子プロセスは標準入力が閉じられるまで待ってから実行を始めるため、cgroupに移してから計測が始まる
+func runQuotaChild(ctx context.Context, exe string, cfg Config, run quotaRun, console bool) ([]Result, error) {
+	quota := run.quota
+	dir, err := os.MkdirTemp("", "go-speed-chan-vs-goroutine-quota")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	child := cfg
+	child.CPUQuotas = nil
+	child.CPUQuota = quota
+	resultsPath := filepath.Join(dir, "results.json")
+	child.Reports = []string{"json=" + resultsPath}
+	// 結果のJSON配列は親プロセスがまとめて書くため、子プロセスは人が読む形式でコンソールに表示する
+	child.Format = FormatText
+	if console {
+		child.Reports = append(child.Reports, "console")
+	}
+	if cfg.ProfileDir != "" {
+		name := "quota-" + formatQuota(quota)
+		if run.procs > 0 {
+			name += "-p" + strconv.Itoa(run.procs)
+		}
+		child.ProfileDir = filepath.Join(cfg.ProfileDir, name)
+	}
+	b, err := json.Marshal(child)
+	if err != nil {
+		return nil, err
+	}
+	cfgPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(cfgPath, b, 0o644); err != nil {
+		return nil, err
+	}
+
+	cg, err := newCPUCgroup(quota)
+	if err != nil {
+		return nil, fmt.Errorf("CPU quota %s: %w", formatQuota(quota), err)
+	}
+	defer cg.remove()
+
+	cmd := exec.CommandContext(ctx, exe, QuotaChildCommand, cfgPath)
+	// 中断されたら、子プロセスにも完了した結果を書き出してから終了させる
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = 30 * time.Second
+	cmd.Stdout = cfg.stdout()
+	cmd.Stderr = os.Stderr
+	if run.procs > 0 {
+		cmd.Env = append(os.Environ(), "GOMAXPROCS="+strconv.Itoa(run.procs))
+	}
+	gate, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	if err := cg.add(cmd.Process.Pid); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	gate.Close()
+	runErr := cmd.Wait()
+
+	// 中断された場合も、子プロセスが書き出した途中までの結果は出力する
+	results, err := loadResults(resultsPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	switch {
+	case ctx.Err() != nil:
+		return results, ErrInterrupted
+	case runErr != nil:
+		return results, fmt.Errorf("CPU quota %s: %w", formatQuota(quota), runErr)
+	}
+	return results, nil
+}
+
+// runQuotasが子プロセスを起動するときのサブコマンド名
+const QuotaChildCommand = "quota-child"
+
+// runQuotasが起動した子プロセスとして、渡された設定ファイルの設定で実行する。
+// 親プロセスがcgroupに移し終えて標準入力を閉じるまで待ってから始める
+func RunQuotaChild(ctx context.Context, cfgPath string) error {
+	if _, err := io.Copy(io.Discard, os.Stdin); err != nil {
+		return err
+	}
+	b, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return err
+	}
+	var cfg Config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return fmt.Errorf("%s: %w", cfgPath, err)
+	}
+	return RunContext(ctx, cfg)
+}
+
+// CPUクォータの下での実行を始めることをwに表示する
+func (c *console) quotaStart(w io.Writer, run quotaRun) {
+	if c == nil {
+		return
+	}
+	if run.procs > 0 {
+		fmt.Fprintf(w, "=== CPUクォータ: %s CPU、GOMAXPROCS: %d ===\n\n", formatQuota(run.quota), run.procs)
+		return
+	}
+	fmt.Fprintf(w, "=== CPUクォータ: %s CPU ===\n\n", formatQuota(run.quota))
+}
+
+// 戦略ごとに、CPUクォータごとの処理時間とスロットリングで停止した時間をwに並べて表示する
+func (c *console) quotaSummary(w io.Writer, runs []quotaRun, results []Result) {
+	if c == nil {
+		return
+	}
+	type row struct {
+		name  string
+		cells []string
+	}
+	var rows []*row
+	index := map[string]*row{}
+	for _, r := range results {
+		if r.Mode != modeBatch || r.AbortReason != "" {
+			continue
+		}
+		key := r.Scenario + "/" + r.Strategy
+		rw, ok := index[key]
+		if !ok {
+			rw = &row{name: r.Strategy, cells: make([]string, len(runs))}
+			index[key] = rw
+			rows = append(rows, rw)
+		}
+		for i, run := range runs {
+			if run.matches(r) {
+				rw.cells[i] = fmt.Sprintf("%v (%v)",
+					r.duration(metricWall).Round(time.Microsecond), r.duration(metricThrottledNs).Round(time.Microsecond))
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "CPUクォータごとの処理時間（括弧内はスロットリングで停止した時間）")
+	header := []string{fmt.Sprintf("  %-26s", "戦略")}
+	for _, run := range runs {
+		header = append(header, fmt.Sprintf("%24s", run.label()))
+	}
+	fmt.Fprintln(w, strings.Join(header, " "))
+	for _, rw := range rows {
+		line := []string{fmt.Sprintf("  %-26s", rw.name)}
+		for _, cell := range rw.cells {
+			if cell == "" {
+				cell = "-"
+			}
+			line = append(line, fmt.Sprintf("%24s", cell))
+		}
+		fmt.Fprintln(w, strings.Join(line, " "))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,78 @@
+package benchmark
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestQuotaProcs(t *testing.T) {
+	cases := []struct {
+		quota  float64
+		numCPU int
+		want   int
+	}{
+		{quota: 0.5, numCPU: 8, want: 1},
+		{quota: 1, numCPU: 8, want: 1},
+		{quota: 1.5, numCPU: 8, want: 1},
+		{quota: 2.9, numCPU: 8, want: 2},
+		{quota: 16, numCPU: 8, want: 8},
+	}
+	for _, c := range cases {
+		if got := quotaProcs(c.quota, c.numCPU); got != c.want {
+			t.Errorf("quotaProcs(%v, %d) = %d, want %d", c.quota, c.numCPU, got, c.want)
+		}
+	}
+}
+
+func TestQuotaRuns(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CPUQuotas = []float64{0.5, 2}
+	if runs := quotaRuns(cfg); len(runs) != 2 || runs[0].procs != 0 || runs[1].procs != 0 {
+		t.Fatalf("runs without GOMAXPROCS comparison = %+v", runs)
+	}
+
+	cfg.QuotaGOMAXPROCS = true
+	numCPU := runtime.NumCPU()
+	var want []quotaRun
+	for _, q := range cfg.CPUQuotas {
+		want = append(want, quotaRun{quota: q, procs: numCPU})
+		if p := quotaProcs(q, numCPU); p != numCPU {
+			want = append(want, quotaRun{quota: q, procs: p})
+		}
+	}
+	runs := quotaRuns(cfg)
+	if len(runs) != len(want) {
+		t.Fatalf("runs = %+v, want %+v", runs, want)
+	}
+	for i := range want {
+		if runs[i] != want[i] {
+			t.Errorf("runs[%d] = %+v, want %+v", i, runs[i], want[i])
+		}
+	}
+
+	r := Result{Params: map[string]string{"cpu_quota": "0.5"}, Environment: Environment{GOMAXPROCS: 1}}
+	if !(quotaRun{quota: 0.5, procs: 1}).matches(r) || (quotaRun{quota: 0.5, procs: 4}).matches(r) || (quotaRun{quota: 2}).matches(r) {
+		t.Errorf("matches returned unexpected results for %+v", r)
+	}
+}
+
+// 見出しと表を標準出力ではなく渡されたwに書くことを確認する
+func TestQuotaSummaryWriter(t *testing.T) {
+	runs := []quotaRun{{quota: 0.5}, {quota: 2}}
+	results := []Result{
+		{Scenario: "dispatch", Strategy: "chan-unlimited", Mode: modeBatch, Params: map[string]string{"cpu_quota": "0.5"},
+			Metrics: map[string]float64{metricWall: 2e6, metricThrottledNs: 1e6}},
+	}
+	var buf bytes.Buffer
+	c := &console{}
+	c.quotaStart(&buf, runs[0])
+	c.quotaSummary(&buf, runs, results)
+	out := buf.String()
+	for _, want := range []string{"CPUクォータ: 0.5 CPU", "chan-unlimited", "2ms (1ms)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// 開ループで飽和とみなす達成スループットの比率（投入レートに対する割合）
+	rampSaturationRatio = 0.9
+	// 閉ループで飽和とみなすスループットの伸び率（前のステップに対する割合）
+	rampPlateauRatio = 1.1
+)
+
+// 負荷の生成方式
+const (
+	// 処理の完了を待たず、一定のスケジュールでタスクを投入する
+	LoopOpen = "open"
+	// 一定数のクライアントが、前のタスクの完了を待ってから次のタスクを投入する
+	LoopClosed = "closed"
+)
+
+// 負荷ランプの設定
+type RampConfig struct {
+	// 負荷ランプを実行するか
+	Enabled bool
+	// 負荷の生成方式（LoopOpen または LoopClosed）
+	Loop string
+	// 開ループの最初のステップの投入レート（タスク/秒）
+	StartRate float64
+	// 閉ループの最初のステップのクライアント数
+	StartClients int
+	// ステップごとに負荷（投入レートまたはクライアント数）に掛ける倍率
+	Factor float64
+	// 最大ステップ数
+	Steps int
+	// 各ステップでタスクを投入し続ける時間
+	StepDuration time.Duration
+}
+
+func (c RampConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Loop {
+	case LoopOpen:
+		if c.StartRate <= 0 {
+			return fmt.Errorf("ramp start rate must be positive, got %v", c.StartRate)
+		}
+	case LoopClosed:
+		if c.StartClients < 1 {
+			return fmt.Errorf("ramp start clients must be at least 1, got %d", c.StartClients)
+		}
+	default:
+		return fmt.Errorf("unknown loop %q (available: %s, %s)", c.Loop, LoopOpen, LoopClosed)
+	}
+	if c.Factor <= 1 {
+		return fmt.Errorf("ramp factor must be greater than 1, got %v", c.Factor)
+	}
+	if c.Steps < 1 {
+		return fmt.Errorf("ramp steps must be at least 1, got %d", c.Steps)
+	}
+	if c.StepDuration <= 0 {
+		return fmt.Errorf("ramp step duration must be positive, got %v", c.StepDuration)
+	}
+	return nil
+}
+
+// 負荷ランプの1ステップの結果
+type rampStep struct {
+	// 投入レート（開ループ）またはクライアント数（閉ループ）
+	Load       float64
+	Throughput float64
+	// 予定送信時刻を基準にしたレイテンシ
+	Latency latencySummary
+	// 実際の送信時刻を基準にしたレイテンシ
+	ActualLatency latencySummary
+	// 処理開始までの待ち時間とサービス時間
+	Wait, Service latencySummary
+	Saturated     bool
+	// 集計に使ったスケッチ
+	Sketches map[string]*latencySketch
+}
+
+// 負荷を段階的に上げながら各戦略のレイテンシとスループットを計測する
+func (r *runner) runRamp(list []Strategy) error {
+	cfg := r.cfg.Ramp
+	r.out.rampStart(cfg)
+	data := r.cfg.dataGen()
+	for i, s := range list {
+		r.out.strategyStart(i, s)
+		err := rampStrategy(r.ctx, s, cfg, data, func(st rampStep) error {
+			return r.report(r.rampResult(s, st))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ランプの1ステップを結果に変換する
+func (r *runner) rampResult(s Strategy, st rampStep) Result {
+	cfg := r.cfg.Ramp
+	res := r.newResult(modeRamp, s)
+	res.Params["loop"] = cfg.Loop
+	res.Params["step_duration"] = cfg.StepDuration.String()
+	res.Metrics[metricLoad] = st.Load
+	res.Metrics[metricThroughput] = st.Throughput
+	res.Metrics[metricCompleted] = float64(st.Latency.Count)
+	res.Metrics[metricLatencyP50] = float64(st.Latency.P50)
+	res.Metrics[metricLatencyP90] = float64(st.Latency.P90)
+	res.Metrics[metricLatencyP99] = float64(st.Latency.P99)
+	res.Metrics[metricLatencyMax] = float64(st.Latency.Max)
+	res.Metrics[metricActualLatencyP99] = float64(st.ActualLatency.P99)
+	res.Metrics[metricWaitP50] = float64(st.Wait.P50)
+	res.Metrics[metricWaitP99] = float64(st.Wait.P99)
+	res.Metrics[metricServiceP50] = float64(st.Service.P50)
+	res.Metrics[metricServiceP99] = float64(st.Service.P99)
+	res.Metrics[metricSaturated] = boolMetric(st.Saturated)
+	res.Sketches = st.Sketches
+	return res
+}
+
+// 負荷の大きさに応じた実行環境を作成する
+func (c RampConfig) newEnv(ctx context.Context, load float64, rec *latencyRecorder, data dataGen) Env {
+	if c.Loop == LoopClosed {
+		src := newClosedSource(ctx, int(load), c.StepDuration, data)
+		return Env{
+			Source:  src,
+			Process: src.wrap(rec.wrap(processTask)),
+			Ctx:     ctx,
+		}
+	}
+	return Env{
+		Source:  newRateSource(load, c.StepDuration, data),
+		Process: rec.wrap(processTask),
+		Ctx:     ctx,
+	}
+}
+
+// 1つの戦略について、飽和するか最大ステップに達するまで負荷を上げる。
+// 各ステップの結果は完了するたびにonStepに渡す
+func rampStrategy(ctx context.Context, s Strategy, cfg RampConfig, data dataGen, onStep func(rampStep) error) error {
+	var steps []rampStep
+	load := cfg.StartRate
+	if cfg.Loop == LoopClosed {
+		load = float64(cfg.StartClients)
+	}
+	for i := 0; i < cfg.Steps; i++ {
+		rec := &latencyRecorder{}
+		d, err := measure(s, cfg.newEnv(ctx, load, rec, data))
+		if err != nil {
+			return err
+		}
+
+		lat := rec.intended()
+		step := rampStep{
+			Load:          load,
+			Throughput:    float64(lat.Count) / d.Seconds(),
+			Latency:       lat,
+			ActualLatency: rec.actual(),
+			Wait:          rec.wait(),
+			Service:       rec.service(),
+			Sketches:      rec.sketches(),
+		}
+		if cfg.Loop == LoopClosed {
+			// 閉ループでは投入量が処理に追従するため、スループットの頭打ちを飽和とみなす
+			step.Saturated = len(steps) > 0 && step.Throughput < steps[len(steps)-1].Throughput*rampPlateauRatio
+		} else {
+			step.Saturated = step.Throughput < load*rampSaturationRatio
+		}
+		steps = append(steps, step)
+		if err := onStep(step); err != nil {
+			return err
+		}
+		if step.Saturated {
+			break
+		}
+
+		next := load * cfg.Factor
+		if cfg.Loop == LoopClosed && int(next) == int(load) {
+			next = load + 1
+		}
+		load = next
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// 1タスクずつ処理の前にdだけ待つ、投入に追いつけない遅い消費者
+func slowConsumer(d time.Duration) func(env Env) error {
+	return func(env Env) error {
+		for {
+			task, ok := env.Source.Next()
+			if !ok {
+				return nil
+			}
+			time.Sleep(d)
+			if err := env.Process(task); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// 一定の間隔で1つずつ処理する消費者。開始時刻からの予定に合わせるため、スリープの遅れが積み重ならずスループットが安定する
+func pacedConsumer(d time.Duration) func(env Env) error {
+	return func(env Env) error {
+		start := time.Now()
+		for i := 1; ; i++ {
+			task, ok := env.Source.Next()
+			if !ok {
+				return nil
+			}
+			time.Sleep(time.Until(start.Add(time.Duration(i) * d)))
+			if err := env.Process(task); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// 開ループで消費者が投入に追いつけない場合、予定送信時刻を基準にしたレイテンシにはキューイングの遅れが現れ、
+// 実際の送信時刻を基準にしたレイテンシには現れないことを確認する
+func TestRampIntendedLatency(t *testing.T) {
+	// 1msごとに50個投入する予定のタスクを、5msずつかけて処理する。最後のタスクは予定より約200ms遅れる
+	cfg := RampConfig{Enabled: true, Loop: LoopOpen, StartRate: 1000, Factor: 2, Steps: 1, StepDuration: 50 * time.Millisecond}
+	var steps []rampStep
+	err := rampStrategy(context.Background(), Strategy{Name: "slow", Run: slowConsumer(5 * time.Millisecond)}, cfg, sprintfData, func(st rampStep) error {
+		steps = append(steps, st)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 1 {
+		t.Fatalf("got %d steps, want 1", len(steps))
+	}
+	st := steps[0]
+	if st.Latency.Count != 50 {
+		t.Errorf("completed = %d, want 50", st.Latency.Count)
+	}
+	if st.Latency.Max < 150*time.Millisecond {
+		t.Errorf("intended latency max = %v, want the queueing delay behind the slow consumer", st.Latency.Max)
+	}
+	if st.Wait.Max < 150*time.Millisecond {
+		t.Errorf("wait max = %v, want the queueing delay", st.Wait.Max)
+	}
+	if st.ActualLatency.P50 > 50*time.Millisecond {
+		t.Errorf("actual latency p50 = %v, want only the consumer's own delay", st.ActualLatency.P50)
+	}
+	if !st.Saturated {
+		t.Errorf("throughput %.0f/s against 1000/s was not reported as saturated", st.Throughput)
+	}
+}
+
+// 負荷を上げながら各ステップを実行し、開ループでは投入レート、閉ループではスループットの頭打ちで飽和を判定することを確認する
+func TestRampLoops(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   RampConfig
+		run   func(env Env) error
+		loads []float64
+		// 最後のステップで飽和したか
+		saturated bool
+	}{
+		{
+			// 処理時間の短いタスクを順に処理する消費者は、100/秒と200/秒の投入に追いつく
+			name:  "open",
+			cfg:   RampConfig{Enabled: true, Loop: LoopOpen, StartRate: 100, Factor: 2, Steps: 2, StepDuration: 100 * time.Millisecond},
+			run:   strictSequential,
+			loads: []float64{100, 200},
+		},
+		{
+			// 1つずつ順に処理する消費者は、クライアントを増やしてもスループットが伸びない
+			name:      "closed",
+			cfg:       RampConfig{Enabled: true, Loop: LoopClosed, StartClients: 1, Factor: 2, Steps: 3, StepDuration: 200 * time.Millisecond},
+			run:       pacedConsumer(2 * time.Millisecond),
+			loads:     []float64{1, 2},
+			saturated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var steps []rampStep
+			err := rampStrategy(context.Background(), Strategy{Name: tt.name, Run: tt.run}, tt.cfg, sprintfData, func(st rampStep) error {
+				steps = append(steps, st)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(steps) != len(tt.loads) {
+				t.Fatalf("got %d steps, want %d", len(steps), len(tt.loads))
+			}
+			for i, st := range steps {
+				if st.Load != tt.loads[i] {
+					t.Errorf("step %d load = %v, want %v", i, st.Load, tt.loads[i])
+				}
+				if st.Latency.Count == 0 || st.Throughput <= 0 {
+					t.Errorf("step %d completed %d tasks at %.0f/s", i, st.Latency.Count, st.Throughput)
+				}
+			}
+			if last := steps[len(steps)-1]; last.Saturated != tt.saturated {
+				t.Errorf("saturated = %v, want %v (throughput %.0f/s)", last.Saturated, tt.saturated, last.Throughput)
+			}
+		})
+	}
+}
+
+// 閉ループの供給元は、戦略がタスクを破棄してクライアントが空きに戻らなくても、期間の終わりかキャンセルで終了することを確認する
+func TestClosedSourceDropped(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		cancel   bool
+	}{
+		{"end", 50 * time.Millisecond, false},
+		{"cancel", time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			src := newClosedSource(ctx, 2, tt.duration, sprintfData)
+			// 2つのクライアントのタスクを処理せずに破棄する
+			for i := 0; i < 2; i++ {
+				if _, ok := src.Next(); !ok {
+					t.Fatalf("task %d was not supplied", i)
+				}
+			}
+			if tt.cancel {
+				time.AfterFunc(10*time.Millisecond, cancel)
+			}
+			done := make(chan bool)
+			go func() {
+				_, ok := src.Next()
+				done <- ok
+			}()
+			select {
+			case ok := <-done:
+				if ok {
+					t.Error("supplied a task without a free client")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Next blocked after the clients' tasks were dropped")
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 計測結果の出力先。1回の実行で複数の出力先に同じ結果を渡せる
+type Reporter interface {
+	// 結果を1件受け取る
+	Report(r Result) error
+	// 出力を完了する。ファイルへの書き出しや送信はここで行うものもある
+	Close() error
+}
+
+// 出力先の種類ごとのコンストラクタと、出力先をファイルとして書き出すか。targetは「種類=出力先」の出力先部分、
+// stdoutは出力先を省略した（または-の）場合と、コンソールの書き込み先
+type reporterKind struct {
+	new func(target string, stdout io.Writer) (Reporter, error)
+	// 出力先にファイル（svgはファイルを置くディレクトリ）を書き出す。-upload-urlでアップロードする対象になる
+	writesFile bool
+}
+
+var reporterKinds = map[string]reporterKind{
+	"console": {new: func(target string, stdout io.Writer) (Reporter, error) {
+		return &console{w: stdout}, nil
+	}},
+	"json":           {new: newJSONReporter, writesFile: true},
+	"csv":            {new: newCSVReporter, writesFile: true},
+	"csv-summary":    {new: newCSVSummaryReporter, writesFile: true},
+	"html":           {new: newHTMLReporter, writesFile: true},
+	"markdown":       {new: newMarkdownReporter, writesFile: true},
+	"markdown-table": {new: newMarkdownTableReporter, writesFile: true},
+	"sqlite":         {new: newSQLiteReporter, writesFile: true},
+	"prometheus":     {new: newPrometheusReporter},
+	"benchstat":      {new: newBenchstatReporter, writesFile: true},
+	"svg":            {new: newSVGReporter, writesFile: true},
+}
+
+// 結果の標準出力の形式のうち、全ての結果のJSON配列だけを書くもの（人が読む形式はFormatText）
+const FormatJSON = "json"
+
+// 出力先の種類の一覧
+func ReporterKinds() []string {
+	names := make([]string, 0, len(reporterKinds))
+	for name := range reporterKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 「種類」または「種類=出力先」の形式の指定を分解する
+func parseReportSpec(spec string) (kind, target string, err error) {
+	kind, target, _ = strings.Cut(spec, "=")
+	if _, ok := reporterKinds[kind]; !ok {
+		return "", "", fmt.Errorf("unknown reporter %q (available: %s)", kind, strings.Join(ReporterKinds(), ", "))
+	}
+	return kind, target, nil
+}
+
+// 指定された出力先をすべて作成する。出力先を省略した出力先とコンソールはstdoutに書く
+func newReporters(specs []string, stdout io.Writer) ([]Reporter, error) {
+	var reporters []Reporter
+	for _, spec := range specs {
+		kind, target, err := parseReportSpec(spec)
+		if err != nil {
+			closeReporters(reporters)
+			return nil, err
+		}
+		rep, err := reporterKinds[kind].new(target, stdout)
+		if err != nil {
+			closeReporters(reporters)
+			return nil, fmt.Errorf("reporter %s: %w", kind, err)
+		}
+		reporters = append(reporters, rep)
+	}
+	return reporters, nil
+}
+
+// 結果をすべての出力先に渡す
+func fanOut(reporters []Reporter, r Result) error {
+	var errs []error
+	for _, rep := range reporters {
+		errs = append(errs, rep.Report(r))
+	}
+	return errors.Join(errs...)
+}
+
+// すべての出力先を閉じる
+func closeReporters(reporters []Reporter) error {
+	var errs []error
+	for _, rep := range reporters {
+		errs = append(errs, rep.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// 人が読む表示と、出力先を省略した出力先の書き込み先。標準出力に結果のJSON配列だけを書く場合は標準エラー出力
+func (c Config) stdout() io.Writer {
+	if c.Format == FormatJSON {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// 出力先のファイルを作成する。「-」または空の場合はstdout（通常は標準出力）に書き出す
+func createOutput(target string, stdout io.Writer) (io.WriteCloser, error) {
+	if target == "" || target == "-" {
+		return nopCloser{stdout}, nil
+	}
+	return os.Create(target)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// 結果に含まれるメトリクス名を整列して返す
+func metricNames(results []Result) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, r := range results {
+		for name := range r.Metrics {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
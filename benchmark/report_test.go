@@ -0,0 +1,242 @@
+package benchmark
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testResult(strategy string, wall float64) Result {
+	return Result{
+		SchemaVersion: ResultSchemaVersion,
+		Mode:          modeBatch,
+		Scenario:      defaultScenario,
+		Strategy:      strategy,
+		Params:        map[string]string{"workers": "4"},
+		Metrics:       map[string]float64{metricWall: wall, metricDroppedTasks: 0},
+	}
+}
+
+// 複数の出力先に同じ結果が書き出されることを確認する
+func TestReportersFanOut(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "results.json")
+	csvPath := filepath.Join(dir, "results.csv")
+
+	reporters, err := newReporters([]string{"json=" + jsonPath, "csv=" + csvPath}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []Result{testResult("a", 100), testResult("b", 200)} {
+		if err := fanOut(reporters, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := closeReporters(reporters); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Result
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Strategy != "b" || got[1].Metrics[metricWall] != 200 {
+		t.Errorf("unexpected json results: %+v", got)
+	}
+
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ヘッダー + 2結果 × 2メトリクス
+	if len(rows) != 5 {
+		t.Fatalf("got %d csv rows, want 5", len(rows))
+	}
+	if last := rows[4]; last[4] != "b" || last[6] != metricWall || last[7] != "200" {
+		t.Errorf("unexpected csv row: %v", last)
+	}
+}
+
+// 要約CSVに戦略ごとに1行を書き出し、処理時間の中央値がない結果を除くことを確認する
+func TestCSVSummaryReporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.csv")
+	rep, err := newCSVSummaryReporter(path, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testResult("a", 2e9)
+	r.Metrics[metricTasks] = 1000
+	ramp := Result{Mode: modeRamp, Strategy: "b", Metrics: map[string]float64{metricThroughput: 10}}
+	for _, r := range []Result{r, ramp} {
+		if err := rep.Report(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d csv rows, want 2", len(rows))
+	}
+	want := []string{"a", "4", "1000", "2000000000", "500.0", "2000000.0"}
+	if got := rows[1][4:]; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got row %v, want %v", got, want)
+	}
+}
+
+// シナリオごとの比較表に、1秒あたりのタスク数と最速の戦略に対する倍率を書き出すことを確認する
+func TestMarkdownComparison(t *testing.T) {
+	var results []Result
+	for _, c := range []struct {
+		scenario, strategy string
+		wall               float64
+	}{
+		{"dispatch", "a", 2e9},
+		{"dispatch", "b|c", 1e9},
+		{"semaphore", "d", 4e9},
+	} {
+		r := testResult(c.strategy, c.wall)
+		r.Scenario = c.scenario
+		r.Metrics[metricTasks] = 1000
+		results = append(results, r)
+	}
+	results = append(results, Result{Mode: modeRamp, Scenario: "dispatch", Strategy: "ramp", Metrics: map[string]float64{}})
+
+	got := markdownComparison(results)
+	for _, want := range []string{
+		"**dispatch**\n\n| 戦略 | 処理時間 | 1タスクあたり | タスク/秒 | 最速比 |",
+		"| a | 2s | 2ms | 500 | 2.00x |",
+		`| b\|c | 1s | 1ms | 1000 | 1.00x（最速） |`,
+		"**semaphore**",
+		"| d | 4s | 4ms | 250 | 1.00x（最速） |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "ramp") {
+		t.Errorf("results without a wall time were included:\n%s", got)
+	}
+}
+
+// 繰り返しごとに1行、go test -benchと同じ形式で1タスクあたりの処理時間を書き出すことを確認する
+func TestBenchstatReporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bench.txt")
+	rep, err := newBenchstatReporter(path, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testResult("chan-unlimited", 2e6)
+	r.Metrics[metricTasks] = 1000
+	r.Samples = []float64{2e6, 3e6}
+	r.Environment = Environment{GOOS: "linux", GOARCH: "amd64", GOMAXPROCS: 8}
+	r.Provenance.Module = "example.com/bench"
+	ramp := Result{Mode: modeRamp, Strategy: "ramp", Metrics: map[string]float64{}}
+	for _, r := range []Result{r, ramp} {
+		if err := rep.Report(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "goos: linux\ngoarch: amd64\npkg: example.com/bench\n" +
+		"BenchmarkDispatch/chan-unlimited-8\t1000\t2000.00 ns/op\n" +
+		"BenchmarkDispatch/chan-unlimited-8\t1000\t3000.00 ns/op\n"
+	if string(b) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b, want)
+	}
+}
+
+func TestPrometheusReporterPush(t *testing.T) {
+	var path, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		path, body = r.URL.Path, string(b)
+	}))
+	defer srv.Close()
+
+	rep, err := newPrometheusReporter(srv.URL, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.Report(testResult("a", 100))
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/metrics/job/"+prometheusJob {
+		t.Errorf("pushed to %q", path)
+	}
+	want := `bench_wall_ns{mode="batch",scenario="dispatch",strategy="a"} 100`
+	if !strings.Contains(body, want) {
+		t.Errorf("body does not contain %q:\n%s", want, body)
+	}
+}
+
+func TestParseReportSpecUnknown(t *testing.T) {
+	if _, _, err := parseReportSpec("xml=out.xml"); err == nil {
+		t.Error("expected error for unknown reporter")
+	}
+}
+
+// Markdownの表に結果が並び、構成図が戦略ごとに1つだけ埋め込まれることを確認する
+func TestMarkdownReporterDiagrams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.md")
+	rep, err := newMarkdownReporter(path, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	topo := strategies(defaultParams())[0].Topology
+	for _, r := range []Result{testResult("a", 100), testResult("a", 150), testResult("b", 200)} {
+		if r.Strategy == "a" {
+			r.Topology = &topo
+		}
+		rep.Report(r)
+	}
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	if got := strings.Count(out, "\n| batch |"); got != 3 {
+		t.Errorf("got %d result rows, want 3:\n%s", got, out)
+	}
+	if got := strings.Count(out, "```mermaid"); got != 1 {
+		t.Errorf("got %d diagrams, want 1:\n%s", got, out)
+	}
+}
@@ -0,0 +1,255 @@
+package benchmark
+
+import (
+	"os"
+	"runtime"
+	"time"
+)
+
+// 結果のスキーマバージョン。フィールドやメトリクスの意味を変えたら上げる
+const ResultSchemaVersion = 1
+
+// 実行モード
+const (
+	modeBatch      = "batch"
+	modeInterleave = "interleave"
+	modeRamp       = "ramp"
+	modeSweep      = "sweep"
+)
+
+// メトリクス名（時間はナノ秒）
+const (
+	metricWall              = "wall_ns"
+	metricColdWall          = "cold_wall_ns"
+	metricWarmWall          = "warm_wall_ns"
+	metricTasks             = "tasks"
+	metricAllocsPerTask     = "allocs_per_task"
+	metricBytesPerTask      = "bytes_per_task"
+	metricAllocBudgetMet    = "alloc_budget_met"
+	metricGoroutinesCreated = "goroutines_created"
+	metricDroppedTasks      = "dropped_tasks"
+	metricDeduplicatedTasks = "deduplicated_tasks"
+	metricTimedOutTasks     = "timed_out_tasks"
+	metricGCCycles          = "gc_cycles"
+	metricGCOnWall          = "gc_on_wall_ns"
+	// OpenTelemetryの計装をして実行した戦略の、計装なしの処理時間
+	metricOTelOffWall       = "otel_off_wall_ns"
+	metricSleepOvershoot    = "sleep_overshoot_ns"
+	metricJitterP50         = "jitter_p50_ns"
+	metricJitterP99         = "jitter_p99_ns"
+	metricJitterMax         = "jitter_max_ns"
+	metricAcquireThroughput = "acquire_throughput"
+	metricTeardown          = "cancel_propagation_ns"
+	metricSubmit            = "submit_ns"
+	metricRampUp            = "rampup_ns"
+	metricCancelWasted      = "cancel_wasted_units"
+	metricCancelAbandoned   = "cancel_abandoned_tasks"
+	metricCancelStop        = "cancel_stop_ns"
+	metricProcessedTasks    = "processed_tasks"
+	metricStrictViolations  = "strict_violations"
+	metricInflightPeak      = "inflight_bytes_peak"
+	metricIdleRSS           = "idle_rss_mb"
+	metricIdleHeap          = "idle_heap_mb"
+	metricIdleStack         = "idle_stack_mb"
+	metricDispatchBusy      = "dispatch_busy_ns"
+	metricDispatchShare     = "dispatch_share"
+	metricOutageFailures    = "downstream_failures"
+	metricWastedWork        = "wasted_work_ns"
+	metricBreakerRejected   = "breaker_rejected"
+	metricRecovery          = "recovery_ns"
+	metricWorkerUtilMin     = "worker_util_min"
+	metricWorkerUtilMean    = "worker_util_mean"
+	metricWorkerUtilMax     = "worker_util_max"
+	metricCacheHitRatio     = "cache_hit_ratio"
+	metricRequestP50        = "request_p50_ns"
+	metricRequestP99        = "request_p99_ns"
+	metricRequestMax        = "request_max_ns"
+	metricSlowdownSuspected = "slowdown_suspected"
+	metricRatioMedian       = "ratio_median"
+	metricFasterRounds      = "faster_rounds"
+	metricLoad              = "load"
+	metricThroughput        = "throughput"
+	metricCompleted         = "completed"
+	metricSaturated         = "saturated"
+	metricLatencyP50        = "latency_p50_ns"
+	metricLatencyP90        = "latency_p90_ns"
+	metricLatencyP99        = "latency_p99_ns"
+	metricLatencyMax        = "latency_max_ns"
+	metricActualLatencyP99  = "actual_latency_p99_ns"
+	metricWaitP50           = "wait_p50_ns"
+	metricWaitP99           = "wait_p99_ns"
+	metricServiceP50        = "service_p50_ns"
+	metricServiceP99        = "service_p99_ns"
+	metricTaskLatencyP50    = "task_latency_p50_ns"
+	metricTaskLatencyP95    = "task_latency_p95_ns"
+	metricTaskLatencyP99    = "task_latency_p99_ns"
+	metricAborted           = "aborted"
+	metricSkipped           = "skipped"
+	metricPeakRSS           = "peak_rss_mb"
+	metricPeakHeap          = "peak_heap_mb"
+	metricPeakStack         = "peak_stack_mb"
+	metricNsPerTask         = "ns_per_task"
+	metricScalingExponent   = "scaling_exponent"
+	metricSuperlinear       = "superlinear"
+	metricThrottledPeriods  = "throttled_periods"
+	metricThrottledNs       = "throttled_ns"
+)
+
+// 1つの戦略（負荷ランプでは1ステップ）の計測結果。
+// 全ての出力はこの構造体から生成するため、下流のツールはこのスキーマに依存してよい
+type Result struct {
+	SchemaVersion int    `json:"schema_version"`
+	Mode          string `json:"mode"`
+	Scenario      string `json:"scenario"`
+	Strategy      string `json:"strategy"`
+	Title         string `json:"title"`
+	// 実行時のパラメータ（同時実行数、投入レートなど）
+	Params map[string]string `json:"params,omitempty"`
+	// 集計済みのメトリクス
+	Metrics map[string]float64 `json:"metrics"`
+	// 繰り返しごとの処理時間（ナノ秒）
+	Samples []float64 `json:"samples_ns,omitempty"`
+	// 結果に付ける注記（スロットリングの疑い、エラーの握りつぶしなど）
+	Notes []string `json:"notes,omitempty"`
+	// 実行中に書き出したファイル（プロファイルなど）
+	Artifacts map[string]string `json:"artifacts,omitempty"`
+	// リソースの上限を超えて実行を中止した理由。中止しなかった場合は空
+	AbortReason string `json:"abort_reason,omitempty"`
+	// レイテンシなどの分位数のスケッチ（名前はsketchLatencyなど）。
+	// 別の実行や別のホストの結果と合算して、分位数を正しく求め直すために使う
+	Sketches map[string]*latencySketch `json:"sketches,omitempty"`
+	// goroutineの生成元ごとの、ピーク時と完了後の数（Config.GoroutineProfileの場合のみ）
+	GoroutineSites []GoroutineSite `json:"goroutine_sites,omitempty"`
+	// 戦略の構成（goroutine、チャネル、同時実行数の制限）
+	Topology    *Topology   `json:"topology,omitempty"`
+	Environment Environment `json:"environment"`
+	// 結果を作ったベンチマークのコードと設定
+	Provenance Provenance `json:"provenance"`
+	Time       time.Time  `json:"time"`
+}
+
+// 計測を行った環境
+type Environment struct {
+	GoVersion  string `json:"go_version"`
+	GOOS       string `json:"goos"`
+	GOARCH     string `json:"goarch"`
+	NumCPU     int    `json:"num_cpu"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	Hostname   string `json:"hostname"`
+}
+
+// 現在の実行環境を取得する
+func captureEnvironment() Environment {
+	host, _ := os.Hostname()
+	return Environment{
+		GoVersion:  runtime.Version(),
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Hostname:   host,
+	}
+}
+
+// 戦略の結果を作成する
+func (r *runner) newResult(mode string, s Strategy) Result {
+	p := r.cfg.params()
+	var topo *Topology
+	if len(s.Topology.Stages) > 0 {
+		topo = &s.Topology
+	}
+	params := map[string]string{
+		"workers":        itoa(p.Workers),
+		"acquire_policy": string(p.AcquirePolicy),
+		"task_data":      r.cfg.TaskData.label(r.cfg.TaskDataSize),
+	}
+	for k, v := range s.Params {
+		params[k] = v
+	}
+	if r.cfg.Preset != "" {
+		params["preset"] = r.cfg.Preset
+	}
+	if r.cfg.ConfigFile != "" {
+		params["config"] = r.cfg.ConfigFile
+	}
+	if r.cfg.Processing != nil {
+		params["processing"] = r.cfg.Processing.label()
+	}
+	if r.cfg.Buffer > 0 {
+		params["buffer"] = itoa(p.Buffer)
+	}
+	if r.cfg.Dispatchers > 0 {
+		params["dispatchers"] = itoa(p.Dispatchers)
+	}
+	if r.cfg.CPUQuota > 0 {
+		params["cpu_quota"] = formatQuota(r.cfg.CPUQuota)
+		if r.cfg.QuotaGOMAXPROCS {
+			params["gomaxprocs"] = itoa(runtime.GOMAXPROCS(0))
+		}
+	}
+	if r.cfg.Chaos.Enabled {
+		params["chaos"] = r.cfg.Chaos.label()
+	}
+	return Result{
+		SchemaVersion: ResultSchemaVersion,
+		Mode:          mode,
+		Scenario:      r.scenario.Name,
+		Strategy:      s.Name,
+		Title:         s.Title,
+		Params:        params,
+		Metrics:       map[string]float64{},
+		Topology:      topo,
+		Environment:   r.env,
+		Provenance:    r.prov,
+		Time:          time.Now(),
+	}
+}
+
+// 処理時間の列をナノ秒のサンプルに変換する
+func durationSamples(durs []time.Duration) []float64 {
+	samples := make([]float64, len(durs))
+	for i, d := range durs {
+		samples[i] = float64(d)
+	}
+	return samples
+}
+
+// 結果のメトリクスを処理時間として取り出す
+func (r Result) duration(name string) time.Duration {
+	return time.Duration(r.Metrics[name])
+}
+
+// 処理時間の中央値をタスク数で割った1タスクあたりの処理時間（ナノ秒）と、スループット（タスク/秒）。
+// 途中でキャンセルしたなど一部のタスクしか処理しなかった結果は、処理したタスク数で割る。
+// 処理時間かタスク数を記録していない結果（負荷ランプなど）はfalseを返す
+func (r Result) perTask() (nsPerTask, throughput float64, ok bool) {
+	wall, tasks := r.Metrics[metricWall], r.Metrics[metricTasks]
+	if processed, ok := r.Metrics[metricProcessedTasks]; ok {
+		tasks = processed
+	}
+	if wall <= 0 || tasks <= 0 {
+		return 0, 0, false
+	}
+	return wall / tasks, tasks / time.Duration(wall).Seconds(), true
+}
+
+// 1タスクあたりの処理時間とスループットをメトリクスに加える。タスク数の異なるシナリオや
+// 別のマシンの結果と比べられるよう、出力先に渡す前に全ての結果に適用する
+func (r *Result) normalize() {
+	if ns, tp, ok := r.perTask(); ok {
+		r.Metrics[metricNsPerTask] = ns
+		r.Metrics[metricThroughput] = tp
+	}
+}
+
+// 結果のメトリクスが真（非ゼロ）かを返す
+func (r Result) flag(name string) bool {
+	return r.Metrics[name] != 0
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,134 @@
+package benchmark
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// 乱数を使うワークロードで、1タスクあたりに引く乱数の数
+const randDrawsPerTask = 64
+
+// ワーカーごとの乱数の生成器を作る関数。ワークロードは乱数の生成器をこの関数から受け取り、
+// 乱数の引き方（共有の生成器か、ワーカーごとの生成器か）を差し替えられるようにする
+type rngFactory func(worker int) *rand.Rand
+
+// 乱数の生成器の種類
+type rngKind struct {
+	name  string
+	title string
+	new   func(seed uint64) rngFactory
+}
+
+var rngKinds = []rngKind{
+	{name: "locked", title: "全ワーカーで共有しsync.Mutexで保護した生成器（math/randのグローバルな生成器と同じ）", new: lockedRNG},
+	{name: "runtime", title: "math/rand/v2のトップレベル関数（ランタイムのスレッドごとの状態、ロックなし）", new: runtimeRNG},
+	{name: "per-worker", title: "ワーカーごとに作ったrand.PCG（ロックなし、シードから再現可能）", new: perWorkerRNG},
+}
+
+// 全ワーカーで1つの生成器を共有し、ミューテックスで保護する
+func lockedRNG(seed uint64) rngFactory {
+	r := rand.New(&lockedSource{src: rand.NewPCG(seed, 0)})
+	return func(int) *rand.Rand { return r }
+}
+
+// ミューテックスで保護した乱数の元
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+// math/rand/v2のトップレベル関数を使う。シードは指定できず、実行ごとに異なる乱数になる
+func runtimeRNG(uint64) rngFactory {
+	r := rand.New(runtimeSource{})
+	return func(int) *rand.Rand { return r }
+}
+
+// math/rand/v2のトップレベル関数による乱数の元
+type runtimeSource struct{}
+
+func (runtimeSource) Uint64() uint64 { return rand.Uint64() }
+
+// ワーカーごとに、シードとワーカーの番号から作った生成器を使う
+func perWorkerRNG(seed uint64) rngFactory {
+	return func(worker int) *rand.Rand {
+		return rand.New(rand.NewPCG(seed, uint64(worker)))
+	}
+}
+
+// 乱数のシナリオの戦略一覧。
+// 各タスクで乱数を引くワークロード（1タスクあたり64回）を同じワーカープールで実行し、
+// 乱数の生成器の共有によるロックの競合が並行処理の計測をどれだけ歪めるかを比べる
+func rngStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	var list []Strategy
+	for _, kind := range rngKinds {
+		list = append(list, Strategy{
+			Name:  "rand-" + kind.name,
+			Title: fmt.Sprintf("ワーカープール（%dワーカー）+ %s", numWorkers, kind.title),
+			Func:  "RandomizedPool",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100), Note: "タスクごとに乱数を引く"},
+				},
+				Completion: "errgroup.Group",
+			},
+			Run: func(env Env) error {
+				env.Source = &instantSource{src: env.Source}
+				return RandomizedPool(env, numWorkers, kind.new(1))
+			},
+		})
+	}
+	return list
+}
+
+// 乱数を使うワークロードの結果の書き込み先（最適化で乱数の生成が省かれないようにする）
+var randSink atomic.Uint64
+
+// numWorkers個のワーカーでタスクを処理する。各ワーカーはnewRNGから受け取った生成器で、
+// タスクごとにrandDrawsPerTask回の乱数を引いてから処理する
+func RandomizedPool(env Env, numWorkers int, newRNG rngFactory) error {
+	eg, ctx := errgroup.WithContext(env.context())
+	tasks := make(chan Task, 100)
+	for i := 0; i < numWorkers; i++ {
+		r := newRNG(i)
+		eg.Go(func() error {
+			var sum uint64
+			defer func() { randSink.Add(sum) }()
+			for task := range tasks {
+				if ctx.Err() != nil {
+					env.drop(1)
+					continue
+				}
+				for j := 0; j < randDrawsPerTask; j++ {
+					sum += r.Uint64N(1 << 20)
+				}
+				if err := env.Process(task); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks <- task
+	}
+	close(tasks)
+	return eg.Wait()
+}
@@ -0,0 +1,50 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 全てのタスクを処理してから戻り、エラーが返されることを確認する
+func TestRNGStrategies(t *testing.T) {
+	const n = 2000
+
+	for _, s := range rngStrategies(Params{Workers: 100}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var completed atomic.Int64
+			env := BatchEnv(n)
+			env.Process = func(task Task) error {
+				defer completed.Add(1)
+				return processTask(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := completed.Load(); got != n {
+				t.Errorf("returned after %d tasks completed, want %d", got, n)
+			}
+
+			var processed atomic.Int64
+			env = BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
+
+// ワーカーごとの生成器は、同じシードとワーカーの番号から同じ乱数を引き、ワーカーごとに異なる乱数を引くことを確認する（共有の生成器は全ワーカーで同じものを返す）
+func TestPerWorkerRNG(t *testing.T) {
+	a, b := perWorkerRNG(1), perWorkerRNG(1)
+	if x, y := a(3).Uint64(), b(3).Uint64(); x != y {
+		t.Errorf("same seed and worker drew %d and %d", x, y)
+	}
+	if x, y := a(0).Uint64(), a(1).Uint64(); x == y {
+		t.Errorf("workers 0 and 1 drew the same value %d", x)
+	}
+	if locked := lockedRNG(1); locked(0) != locked(1) {
+		t.Error("locked generator is not shared between workers")
+	}
+}
@@ -0,0 +1,310 @@
+package benchmark
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// ルーティングのシナリオのワーカー数
+	routingWorkers = 16
+	// タスクのキーの種類と、キーの出現頻度の偏り（Zipf分布のパラメータ）
+	routingKeys = 256
+	routingSkew = 1.1
+	// ワーカーごとに状態を保持できるキーの数と、保持していないキーの状態を読み込む時間
+	workerCacheSize = 16
+	cacheMissCost   = 50 * time.Microsecond
+)
+
+// タスクIDからキーを決める表。実行ごとに同じ分布になるよう固定のシードで生成する
+var routingKeyTable = func() []int {
+	r := rand.New(rand.NewPCG(1, 2))
+	z := rand.NewZipf(r, routingSkew, 1, routingKeys-1)
+	keys := make([]int, 1<<12)
+	for i := range keys {
+		keys[i] = int(z.Uint64())
+	}
+	return keys
+}()
+
+// タスクのキー（少数のキーに集中する）
+func routingKey(task Task) int {
+	return routingKeyTable[task.ID%len(routingKeyTable)]
+}
+
+// キーのハッシュで決まるワーカー
+func affinityWorker(key, numWorkers int) int {
+	return int((uint64(key) * 0x9E3779B97F4A7C15 >> 32) % uint64(numWorkers))
+}
+
+// ワーカーが保持するキーごとの状態。上限を超えたら最も古く読み込んだキーから捨てる
+type workerCache struct {
+	keys  map[int]struct{}
+	order []int
+}
+
+func newWorkerCache() *workerCache {
+	return &workerCache{keys: make(map[int]struct{}, workerCacheSize)}
+}
+
+// キーの状態を保持していればtrueを返す。保持していなければ読み込んで保持し、falseを返す
+func (c *workerCache) access(key int) bool {
+	if _, ok := c.keys[key]; ok {
+		return true
+	}
+	if len(c.order) == workerCacheSize {
+		delete(c.keys, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.keys[key] = struct{}{}
+	c.order = append(c.order, key)
+	return false
+}
+
+// ワーカーごとの稼働時間と、状態の読み込みの要否の記録
+type workerStats struct {
+	mu   sync.Mutex
+	busy []time.Duration
+	// 状態を保持していたアクセスと、読み込みが必要だったアクセスの数
+	Hits, Misses atomic.Int64
+}
+
+// ワーカーの稼働時間を記録する
+func (e Env) workerBusy(worker int, d time.Duration) {
+	if e.Stats == nil {
+		return
+	}
+	s := &e.Stats.Workers
+	s.mu.Lock()
+	if len(s.busy) <= worker {
+		s.busy = append(s.busy, make([]time.Duration, worker+1-len(s.busy))...)
+	}
+	s.busy[worker] += d
+	s.mu.Unlock()
+}
+
+// 状態へのアクセスを数える
+func (e Env) cacheAccess(hit bool) {
+	if e.Stats == nil {
+		return
+	}
+	if hit {
+		e.Stats.Workers.Hits.Add(1)
+	} else {
+		e.Stats.Workers.Misses.Add(1)
+	}
+}
+
+// 全ての回のワーカーごとの稼働率と状態へのアクセスの合計
+type workerTotals struct {
+	// ワーカーごとの稼働率（稼働時間 / 処理時間）の合計
+	util         []float64
+	reps         int
+	hits, misses int64
+}
+
+// 1回の実行の記録を加える
+func (t *workerTotals) add(s *workerStats, wall time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.hits += s.Hits.Load()
+	t.misses += s.Misses.Load()
+	if len(s.busy) == 0 || wall <= 0 {
+		return
+	}
+	if len(t.util) < len(s.busy) {
+		t.util = append(t.util, make([]float64, len(s.busy)-len(t.util))...)
+	}
+	for i, d := range s.busy {
+		t.util[i] += float64(d) / float64(wall)
+	}
+	t.reps++
+}
+
+// ワーカーごとの稼働時間を記録した戦略の場合のみ、稼働率の最小、平均、最大と、状態を保持していた割合を記録する
+func (t workerTotals) setMetrics(res *Result) {
+	if t.reps == 0 {
+		return
+	}
+	util := make([]float64, len(t.util))
+	var sum float64
+	for i, u := range t.util {
+		util[i] = u / float64(t.reps)
+		sum += util[i]
+	}
+	res.Metrics[metricWorkerUtilMin] = slices.Min(util)
+	res.Metrics[metricWorkerUtilMean] = sum / float64(len(util))
+	res.Metrics[metricWorkerUtilMax] = slices.Max(util)
+	if t.hits+t.misses > 0 {
+		res.Metrics[metricCacheHitRatio] = float64(t.hits) / float64(t.hits+t.misses)
+	}
+	cells := make([]string, len(util))
+	for i, u := range util {
+		cells[i] = fmt.Sprintf("%.0f%%", u*100)
+	}
+	res.Notes = append(res.Notes, "ワーカーごとの稼働率: "+strings.Join(cells, " "))
+}
+
+// ルーティングのシナリオの戦略一覧。
+// キーの出現頻度が偏ったタスクを、ワーカーごとのチャネルに振り分けて処理する。各ワーカーは一部のキーの状態だけを保持でき、
+// 保持していないキーのタスクは状態の読み込みの分だけ遅くなる。同じキーを常に同じワーカーに送る方法（状態を使い回せるが、
+// 負荷が偏る）とランダムなワーカーに送る方法（負荷は均等だが、状態を使い回せない）、キューの最も短いワーカーに送る方法、
+// 全ワーカーが1つの共有チャネルから受信する方法を比べる
+func routingStrategies(p Params) []Strategy {
+	routed := func(name, title, route string, pick func(Task, []atomic.Int64) int) Strategy {
+		return Strategy{
+			Name:  name,
+			Title: fmt.Sprintf("ワーカーごとのチャネル（%dワーカー）+ %s", routingWorkers, title),
+			Func:  "RoutedPool",
+			Limit: routingWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					{Role: RoleProducer, Goroutines: 1, Note: route},
+					{Role: RoleWorker, Goroutines: routingWorkers, From: chanFrom("Task", 100), Note: "ワーカーごとに1つ"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return RoutedPool(env, routingWorkers, pick)
+			},
+		}
+	}
+	return []Strategy{
+		routed("routing-affinity", "キーのハッシュで決まるワーカーに送信", "キーのハッシュでワーカーを選んで送信",
+			func(task Task, _ []atomic.Int64) int { return affinityWorker(routingKey(task), routingWorkers) }),
+		routed("routing-random", "ランダムなワーカーに送信", "ランダムにワーカーを選んで送信",
+			func(Task, []atomic.Int64) int { return rand.IntN(routingWorkers) }),
+		routed("routing-least-loaded", "キューの最も短いワーカーに送信", "ワーカーごとのキューの長さ（送信済みで処理を終えていないタスク数）が最も短いワーカーを選んで送信",
+			leastLoaded()),
+		{
+			Name:  "routing-shared",
+			Title: fmt.Sprintf("ワーカープール（%dワーカー）+ 全ワーカーが受信する共有チャネル", routingWorkers),
+			Func:  "SharedPullPool",
+			Limit: routingWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: routingWorkers, From: chanFrom("Task", 100), Note: "空いたワーカーが受信"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return SharedPullPool(env, routingWorkers)
+			},
+		},
+	}
+}
+
+// キューの最も短いワーカーを選ぶ関数を作成する。同じ長さのワーカーが複数あれば、前回選んだ次のワーカーから順に探して
+// 最初に見つかったものを選び、特定のワーカーに偏らないようにする
+func leastLoaded() func(Task, []atomic.Int64) int {
+	next := 0
+	return func(_ Task, depth []atomic.Int64) int {
+		best, bestDepth := next, depth[next].Load()
+		for i := 1; i < len(depth) && bestDepth > 0; i++ {
+			w := (next + i) % len(depth)
+			if d := depth[w].Load(); d < bestDepth {
+				best, bestDepth = w, d
+			}
+		}
+		next = (best + 1) % len(depth)
+		return best
+	}
+}
+
+// ワーカーの状態を使ってタスクを処理し、稼働時間と状態へのアクセスを記録する
+func processRouted(env Env, worker int, cache *workerCache, task Task) error {
+	start := time.Now()
+	hit := cache.access(routingKey(task))
+	if !hit {
+		time.Sleep(cacheMissCost)
+	}
+	err := env.Process(task)
+	env.cacheAccess(hit)
+	env.workerBusy(worker, time.Since(start))
+	return err
+}
+
+// 各ワーカーが自分のチャネルからタスクを受信して処理し、pickで選んだワーカーのチャネルに送信する。
+// pickにはワーカーごとのキューの長さ（送信済みで処理を終えていないタスク数）を渡す
+func RoutedPool(env Env, numWorkers int, pick func(Task, []atomic.Int64) int) error {
+	chans := make([]chan Task, numWorkers)
+	depth := make([]atomic.Int64, numWorkers)
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for i := range chans {
+		chans[i] = make(chan Task, 100)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache := newWorkerCache()
+			for task := range chans[i] {
+				err := processRouted(env, i, cache, task)
+				depth[i].Add(-1)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		w := pick(task, depth)
+		depth[w].Add(1)
+		chans[w] <- task
+	}
+	for _, ch := range chans {
+		close(ch)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// 全ワーカーが1つの共有チャネルから受信し、空いたワーカーが次のタスクを取る
+func SharedPullPool(env Env, numWorkers int) error {
+	tasks := make(chan Task, 100)
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache := newWorkerCache()
+			for task := range tasks {
+				if err := processRouted(env, i, cache, task); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks <- task
+	}
+	close(tasks)
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,108 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 全てのタスクを処理してから戻り、エラーが返されることを確認する
+func TestRoutingStrategies(t *testing.T) {
+	const n = 2000
+
+	for _, s := range routingStrategies(Params{Workers: 100}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var completed atomic.Int64
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			env.Process = func(task Task) error {
+				defer completed.Add(1)
+				return processTask(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := completed.Load(); got != n {
+				t.Errorf("returned after %d tasks completed, want %d", got, n)
+			}
+			if got := env.Stats.Workers.Hits.Load() + env.Stats.Workers.Misses.Load(); got != n {
+				t.Errorf("recorded %d state accesses, want %d", got, n)
+			}
+
+			var processed atomic.Int64
+			env = BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
+
+// キューの最も短いワーカーを選び、同じ長さなら前回の次のワーカーから選ぶことを確認する
+func TestLeastLoaded(t *testing.T) {
+	pick := leastLoaded()
+	depth := make([]atomic.Int64, 3)
+	depth[0].Store(2)
+	depth[1].Store(1)
+	depth[2].Store(3)
+	if got := pick(Task{}, depth); got != 1 {
+		t.Errorf("picked worker %d, want 1", got)
+	}
+	depth[1].Store(2)
+	if got := pick(Task{}, depth); got != 0 {
+		t.Errorf("picked worker %d, want 0 (ties start after the last pick)", got)
+	}
+	depth[0].Store(0)
+	depth[1].Store(0)
+	if got := pick(Task{}, depth); got != 1 {
+		t.Errorf("picked worker %d, want 1", got)
+	}
+}
+
+// 上限を超えたら最も古く読み込んだキーを捨てることを確認する
+func TestWorkerCache(t *testing.T) {
+	c := newWorkerCache()
+	for key := 0; key < workerCacheSize; key++ {
+		if c.access(key) {
+			t.Fatalf("key %d hit before it was loaded", key)
+		}
+	}
+	if !c.access(0) {
+		t.Fatal("key 0 missed while within capacity")
+	}
+	c.access(workerCacheSize)
+	if c.access(0) {
+		t.Error("oldest key was not evicted")
+	}
+}
+
+// 稼働率をワーカーごとに平均し、最小、平均、最大を記録することを確認する
+func TestWorkerTotals(t *testing.T) {
+	var totals workerTotals
+	for i := 0; i < 2; i++ {
+		var s workerStats
+		env := Env{Stats: &RunStats{}}
+		env.workerBusy(0, 10*time.Millisecond)
+		env.workerBusy(1, 50*time.Millisecond)
+		env.cacheAccess(true)
+		env.cacheAccess(false)
+		totals.add(&env.Stats.Workers, 100*time.Millisecond)
+		totals.add(&s, 100*time.Millisecond)
+	}
+	res := Result{Metrics: map[string]float64{}}
+	totals.setMetrics(&res)
+	if got := res.Metrics[metricWorkerUtilMin]; got != 0.1 {
+		t.Errorf("min utilization = %v, want 0.1", got)
+	}
+	if got := res.Metrics[metricWorkerUtilMax]; got != 0.5 {
+		t.Errorf("max utilization = %v, want 0.5", got)
+	}
+	if got := res.Metrics[metricCacheHitRatio]; got != 0.5 {
+		t.Errorf("hit ratio = %v, want 0.5", got)
+	}
+	if len(res.Notes) != 1 {
+		t.Errorf("got notes %q, want one line of per-worker utilization", res.Notes)
+	}
+}
@@ -0,0 +1,723 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ベンチマークの実行状態
+type runner struct {
+	ctx      context.Context
+	cfg      Config
+	scenario Scenario
+	env      Environment
+	prov     Provenance
+	cd       *cooldown
+	// 進捗を表示するコンソール。出力先にコンソールがない場合はnil
+	out       *console
+	reporters []Reporter
+	hooks     []Hook
+	// 計測中のログを保持するリングバッファ。ログを直接書き出す場合はnil
+	logs *ringLog
+	// 実行全体の時間の上限。上限がない場合はnil
+	budget *runBudget
+	// 実行中のシナリオで出力した結果（チェックポイントに保存する）
+	collected []Result
+	// 厳格モードで違反があった戦略（「シナリオ/戦略」）
+	strictFailed []string
+	// OpenTelemetryの計装。計装しない場合はnil
+	otel *otelTracer
+}
+
+// ベンチマークを実行する関数
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// ベンチマークを実行する。ctxがキャンセルされると実行中の戦略を中断し、
+// それまでに完了した結果だけを出力してErrInterruptedを返す
+func RunContext(ctx context.Context, cfg Config) (err error) {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	list, err := cfg.scenarios()
+	if err != nil {
+		return err
+	}
+	if cfg.DryRun {
+		if err := printPlans(cfg.stdout(), cfg, list); err != nil {
+			return err
+		}
+		if cfg.Format == FormatJSON {
+			// 実行しないため結果はなく、標準出力には空の配列を書く
+			fmt.Println("[]")
+		}
+		return nil
+	}
+	if len(cfg.CPUQuotas) > 0 {
+		return runQuotas(ctx, cfg)
+	}
+	cp, err := loadCheckpoint(cfg)
+	if err != nil {
+		return err
+	}
+	hooks, err := newHooks(cfg)
+	if err != nil {
+		return err
+	}
+	reporters, err := newConfigReporters(cfg)
+	if err != nil {
+		return err
+	}
+	var r *runner
+	var pprofSrv *pprofServer
+	var metricsSrv *metricsServer
+	var tracer *otelTracer
+	logs, restoreLog := installLogSink(cfg)
+	defer func() {
+		if errors.Is(err, ErrInterrupted) {
+			r.out.interrupted()
+		}
+		err = errors.Join(err, restoreLog(), pprofSrv.close(), metricsSrv.close(), tracer.close(), closeReporters(reporters))
+	}()
+	if cfg.PprofAddr != "" {
+		if pprofSrv, err = startPprofServer(cfg.PprofAddr); err != nil {
+			return err
+		}
+	}
+	if cfg.ListenAddr != "" {
+		if metricsSrv, err = startMetricsServer(cfg.ListenAddr); err != nil {
+			return err
+		}
+	}
+	if cfg.OTel != "" {
+		if tracer, err = startOTel(cfg.OTel, cfg.OTelSample); err != nil {
+			return err
+		}
+	}
+
+	r = &runner{
+		ctx:       ctx,
+		cfg:       cfg,
+		env:       captureEnvironment(),
+		prov:      captureProvenance(cfg),
+		cd:        &cooldown{d: cfg.Cooldown, extend: cfg.ExtendCooldown, ctx: ctx, w: os.Stderr},
+		reporters: reporters,
+		hooks:     hooks,
+		logs:      logs,
+		budget:    newRunBudget(cfg.MaxDuration),
+		otel:      tracer,
+	}
+	for _, rep := range reporters {
+		if c, ok := rep.(*console); ok {
+			r.out = c
+		}
+	}
+	r.out.header(r, list)
+	r.out.pprofServer(pprofSrv)
+	r.out.metricsServer(metricsSrv)
+
+	if err := r.resume(cp); err != nil {
+		return err
+	}
+	remaining := 0
+	for _, sc := range list {
+		if !cp.done(sc.Name) {
+			remaining++
+		}
+	}
+	for _, sc := range list {
+		if cp.done(sc.Name) {
+			r.out.scenarioSkipped(sc)
+			continue
+		}
+		if ctx.Err() != nil {
+			return ErrInterrupted
+		}
+		r.scenario = sc
+		r.collected = nil
+		if r.budget.exhausted() {
+			if err := r.skipScenario(sc); err != nil {
+				return err
+			}
+			continue
+		}
+		r.budget.startScenario(remaining)
+		remaining--
+		r.out.scenarioStart(sc, len(list) > 1)
+		if err := r.runScenario(sc); err != nil {
+			return err
+		}
+		if err := cp.complete(sc.Name, r.collected); err != nil {
+			return err
+		}
+	}
+	if err := cp.remove(); err != nil {
+		return err
+	}
+	return strictError(r.strictFailed)
+}
+
+// 1つのシナリオの全ての戦略を設定されたモードで実行する。
+// リソースの上限を超えた場合は、その戦略の中止を結果に記録して残りの戦略を飛ばす
+func (r *runner) runScenario(sc Scenario) error {
+	list := r.cfg.strategies(sc)
+	for i := range list {
+		list[i] = withInterrupt(withLimits(withHooks(withChaos(withDebugLog(list[i], r.cfg.DebugLog), r.cfg.Chaos), r.hooks), r.cfg.Limits), r.ctx)
+		if r.cfg.LiveCounters || r.cfg.ListenAddr != "" {
+			list[i] = withLiveCounters(list[i], sc.Name)
+		}
+	}
+	err := r.runStrategies(list)
+	var le *limitError
+	if errors.As(err, &le) {
+		return r.reportAbort(list, le)
+	}
+	return err
+}
+
+// 設定されたモードで戦略を実行する
+func (r *runner) runStrategies(list []Strategy) error {
+	switch {
+	case r.cfg.Ramp.Enabled:
+		return r.runRamp(list)
+	case r.cfg.Sweep.Enabled:
+		return r.runSweep(list)
+	case len(r.cfg.Interleave) == 2:
+		return r.runInterleavedMode(list)
+	}
+
+	for i, s := range list {
+		if i > 0 {
+			r.cd.wait()
+		}
+		if r.ctx.Err() != nil {
+			return ErrInterrupted
+		}
+		if r.budget.exhausted() {
+			if err := r.skipStrategy(s); err != nil {
+				return err
+			}
+			continue
+		}
+		r.out.strategyStart(i, s)
+		res, err := r.runProfiled(s)
+		if err != nil {
+			return err
+		}
+		if r.cfg.GCOff {
+			res.Params["gc"] = "on"
+		}
+		if r.otel != nil {
+			res.Params["otel"] = "off"
+		}
+		if err := r.report(res); err != nil {
+			return err
+		}
+		if r.cfg.GCOff {
+			r.cd.wait()
+			s := gcOffStrategy(s)
+			r.out.strategyStart(i, s)
+			off, err := r.runGCOff(s, res)
+			if err != nil {
+				return err
+			}
+			if err := r.report(off); err != nil {
+				return err
+			}
+		}
+		if r.otel != nil {
+			r.cd.wait()
+			s := otelStrategy(s)
+			r.out.strategyStart(i, s)
+			on, err := r.runOTel(s, res)
+			if err != nil {
+				return err
+			}
+			if err := r.report(on); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// リソースの上限を超えて中止した戦略の結果を出力する
+func (r *runner) reportAbort(list []Strategy, le *limitError) error {
+	s, err := findStrategy(list, le.strategy)
+	if err != nil {
+		return err
+	}
+	res := r.newResult(r.mode(), s)
+	res.AbortReason = le.reason
+	res.Metrics[metricAborted] = 1
+	return r.report(res)
+}
+
+// 設定された実行モード
+func (r *runner) mode() string {
+	switch {
+	case r.cfg.Ramp.Enabled:
+		return modeRamp
+	case r.cfg.Sweep.Enabled:
+		return modeSweep
+	case len(r.cfg.Interleave) == 2:
+		return modeInterleave
+	}
+	return modeBatch
+}
+
+// 設定に応じてCPUプロファイルを取りながら戦略を繰り返し実行する
+func (r *runner) runProfiled(s Strategy) (Result, error) {
+	if r.cfg.ProfileDir == "" {
+		return r.runRepeated(s)
+	}
+
+	// 同じ名前の戦略が複数のシナリオにあるため、シナリオごとのディレクトリに書き出す
+	dir := filepath.Join(r.cfg.ProfileDir, r.scenario.Name)
+	var stopContention func() (map[string]string, error)
+	if r.cfg.ContentionProfile {
+		stop, err := startContentionProfile(dir, s.Name)
+		if err != nil {
+			return Result{}, err
+		}
+		stopContention = stop
+	}
+	path, stop, err := startCPUProfile(dir, s.Name)
+	if err != nil {
+		return Result{}, err
+	}
+	res, err := r.runRepeated(s)
+	if stopErr := stop(); err == nil {
+		err = stopErr
+	}
+	var contention map[string]string
+	if stopContention != nil {
+		paths, stopErr := stopContention()
+		if err == nil {
+			err = stopErr
+		}
+		contention = paths
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	res.Artifacts = map[string]string{"cpu_profile": path}
+	for kind, p := range contention {
+		res.Artifacts[kind+"_profile"] = p
+	}
+
+	if r.cfg.Flamegraph {
+		svgPath := strings.TrimSuffix(path, ".pprof") + ".svg"
+		if err := writeFlamegraph(path, svgPath, s.Title); err != nil {
+			return Result{}, err
+		}
+		res.Artifacts["flamegraph"] = svgPath
+	}
+	return res, nil
+}
+
+// 戦略を指定回数繰り返し実行して結果をまとめる
+func (r *runner) runRepeated(s Strategy) (Result, error) {
+	reps := r.cfg.Repetitions
+	res := r.newResult(modeBatch, s)
+	n := s.tasks()
+	res.Params["tasks"] = itoa(n)
+	res.Params["reps"] = itoa(reps)
+
+	durs := make([]time.Duration, 0, reps)
+	var allocs allocStats
+	var dropped, deduplicated, timedOut, overshoot, sleeps, acquires, teardown, submit, rampUp, inflightPeak int64
+	var jitters, requests, services, taskLatency latencySketch
+	var downstream downstreamTotals
+	var cancels cancelTotals
+	var workers workerTotals
+	errs := map[ErrorKind]int64{}
+	var created uint64
+	createdOK := true
+	var dispatchBusy, dispatchTasks int64
+	var peak, idle memPeak
+	peakGoroutines := 0
+	var gcCycles uint64
+	idleReps := 0
+	var violations []string
+	data := r.cfg.dataGen()
+	// CPUクォータの下で実行している場合は、繰り返しの間のスロットリングを数える
+	var throttleBefore cpuThrottling
+	throttleOK := false
+	if r.cfg.CPUQuota > 0 {
+		throttleBefore, throttleOK = readCPUThrottling()
+	}
+	for i := 0; i < reps; i++ {
+		if i > 0 {
+			r.cd.wait()
+		}
+		var processed atomic.Int64
+		env, strict := r.newEnv(n, data, r.cfg.FailAt, &processed)
+		if r.cfg.InflightBytes {
+			env = trackInflight(env, &env.Stats.Inflight)
+		}
+		var latencies *taskLatencies
+		if r.cfg.TaskLatency {
+			env, latencies = trackTaskLatency(env)
+		}
+		env.Stats.Idle.enabled = r.cfg.IdleMemory
+		env.Stats.Dispatch.enabled = r.cfg.DispatchTime
+
+		endSpan := func(error) {}
+		if r.otel.active() {
+			env, endSpan = r.otel.instrument(env, i)
+		}
+
+		mem := startMemSampler()
+		before := readAllocs()
+		createdBefore, ok := readGoroutinesCreated()
+		gcBefore := readGCCycles()
+		d, err := measure(s, env)
+		endSpan(err)
+		gcCycles += readGCCycles() - gcBefore
+		createdAfter, _ := readGoroutinesCreated()
+		peak = peak.max(mem.stop())
+		peakGoroutines = max(peakGoroutines, mem.goroutines)
+		if strict != nil {
+			violations = addViolations(violations, strict.violations(env, err)...)
+		}
+		if r.cfg.FailAt >= 0 && (err == nil || errors.Is(err, errInjectedFailure)) {
+			res.Notes = append(res.Notes, failureNote(err, processed.Load()))
+			err = nil
+		}
+		if err != nil {
+			return Result{}, err
+		}
+		allocs = allocs.add(readAllocs().sub(before))
+		created += createdAfter - createdBefore
+		createdOK = createdOK && ok
+		dropped += env.Stats.Dropped.Load()
+		deduplicated += env.Stats.Deduplicated.Load()
+		timedOut += env.Stats.TimedOut.Load()
+		overshoot += env.Stats.SleepOvershoot.Load()
+		sleeps += env.Stats.Sleeps.Load()
+		acquires += env.Stats.Acquires.Load()
+		teardown += env.Stats.Teardown.Load()
+		submit += env.Stats.Submit.Load()
+		rampUp += env.Stats.RampUp.Load()
+		inflightPeak = max(inflightPeak, env.Stats.Inflight.peak.Load())
+		dispatchBusy += env.Stats.Dispatch.busy.Load()
+		dispatchTasks += env.Stats.Dispatch.tasks.Load()
+		if env.Stats.Idle.recorded {
+			m := env.Stats.Idle.mem
+			idle = memPeak{RSS: idle.RSS + m.RSS, Heap: idle.Heap + m.Heap, Stack: idle.Stack + m.Stack}
+			idleReps++
+		}
+		downstream.add(&env.Stats.Downstream)
+		cancels.add(&env.Stats.Cancel)
+		workers.add(&env.Stats.Workers, d)
+		env.Stats.Errors.addTo(errs)
+		env.Stats.mergeJitters(&jitters)
+		env.Stats.mergeRequests(&requests)
+		env.Stats.mergeServices(&services)
+		if latencies != nil {
+			latencies.addTo(&taskLatency)
+		}
+		durs = append(durs, d)
+	}
+
+	if r.cfg.GoroutineProfile {
+		r.cd.wait()
+		env := r.cfg.withProcessing(batchEnv(n, data))
+		env.Stats = &RunStats{}
+		sites, err := profileGoroutineSites(s, env, peakGoroutines)
+		if err != nil {
+			return Result{}, err
+		}
+		res.GoroutineSites = sites
+	}
+
+	if r.cfg.Strict {
+		// 計測した実行でエラーが起きなければ握りつぶしを確かめられないため、別にエラーを発生させて確かめる
+		if r.cfg.FailAt < 0 && !s.SkipErrorPropagation {
+			v, err := r.verifyErrorPropagation(s, n, data)
+			if err != nil {
+				return Result{}, err
+			}
+			if v != "" {
+				violations = addViolations(violations, v)
+			}
+		}
+		applyStrict(&res, violations)
+	}
+
+	suspected := detectSlowdown(durs)
+	if suspected {
+		r.cd.onSlowdown(s.Name)
+	}
+	if throttleOK {
+		if after, ok := readCPUThrottling(); ok {
+			t := after.sub(throttleBefore)
+			res.Metrics[metricThrottledPeriods] = float64(t.Periods) / float64(reps)
+			res.Metrics[metricThrottledNs] = float64(t.Nanos) / float64(reps)
+		}
+	}
+
+	tasks := float64(reps * n)
+	res.Samples = durationSamples(durs)
+	res.Metrics[metricWall] = float64(medianDuration(durs))
+	// 初回はページフォールト、goroutineのスタックのプールの準備、タイマーの初期化などで系統的に遅くなるため、
+	// 2回目以降と分けて記録する
+	if len(durs) > 1 {
+		res.Metrics[metricColdWall] = float64(durs[0])
+		res.Metrics[metricWarmWall] = float64(medianDuration(durs[1:]))
+	}
+	res.Metrics[metricTasks] = float64(n)
+	res.Metrics[metricAllocsPerTask] = float64(allocs.Mallocs) / tasks
+	res.Metrics[metricBytesPerTask] = float64(allocs.Bytes) / tasks
+	applyAllocBudget(&res, r.cfg.AllocBudget)
+	res.Metrics[metricDroppedTasks] = float64(dropped) / float64(reps)
+	if deduplicated > 0 {
+		res.Metrics[metricDeduplicatedTasks] = float64(deduplicated) / float64(reps)
+	}
+	if r.cfg.GCOff {
+		res.Metrics[metricGCCycles] = float64(gcCycles) / float64(reps)
+	}
+	if timedOut > 0 {
+		res.Metrics[metricTimedOutTasks] = float64(timedOut) / float64(reps)
+	}
+	if createdOK {
+		res.Metrics[metricGoroutinesCreated] = float64(created) / float64(reps)
+	}
+	if sleeps > 0 {
+		res.Metrics[metricSleepOvershoot] = float64(overshoot) / float64(sleeps)
+	}
+	if acquires > 0 {
+		var total time.Duration
+		for _, d := range durs {
+			total += d
+		}
+		res.Metrics[metricAcquireThroughput] = float64(acquires) / total.Seconds()
+	}
+	var errTotal int64
+	for _, n := range errs {
+		errTotal += n
+	}
+	if errTotal > 0 {
+		for _, k := range errorKinds {
+			res.Metrics[k.metric()] = float64(errs[k]) / float64(reps)
+		}
+	}
+	if teardown > 0 {
+		res.Metrics[metricTeardown] = float64(teardown) / float64(reps)
+	}
+	if submit > 0 {
+		res.Metrics[metricSubmit] = float64(submit) / float64(reps)
+	}
+	if rampUp > 0 {
+		res.Metrics[metricRampUp] = float64(rampUp) / float64(reps)
+	}
+	if r.cfg.InflightBytes {
+		res.Metrics[metricInflightPeak] = float64(inflightPeak)
+	}
+	if dispatchTasks > 0 {
+		var total time.Duration
+		for _, d := range durs {
+			total += d
+		}
+		res.Metrics[metricDispatchBusy] = float64(dispatchBusy) / float64(reps)
+		res.Metrics[metricDispatchShare] = float64(dispatchBusy) / float64(total)
+	}
+	if idleReps > 0 {
+		res.Metrics[metricIdleRSS] = toMB(idle.RSS) / float64(idleReps)
+		res.Metrics[metricIdleHeap] = toMB(idle.Heap) / float64(idleReps)
+		res.Metrics[metricIdleStack] = toMB(idle.Stack) / float64(idleReps)
+	}
+	downstream.setMetrics(&res, reps)
+	cancels.setMetrics(&res)
+	workers.setMetrics(&res)
+	if jitters.count > 0 {
+		res.Sketches = map[string]*latencySketch{sketchJitter: &jitters}
+		j := jitters.summary()
+		res.Metrics[metricJitterP50] = float64(j.P50)
+		res.Metrics[metricJitterP99] = float64(j.P99)
+		res.Metrics[metricJitterMax] = float64(j.Max)
+	}
+	if requests.count > 0 {
+		if res.Sketches == nil {
+			res.Sketches = map[string]*latencySketch{}
+		}
+		res.Sketches[sketchRequest] = &requests
+		for _, m := range sketchMetrics[sketchRequest] {
+			res.Metrics[m.metric] = float64(requests.percentile(m.p))
+		}
+	}
+	if services.count > 0 {
+		if res.Sketches == nil {
+			res.Sketches = map[string]*latencySketch{}
+		}
+		res.Sketches[sketchService] = &services
+		for _, m := range sketchMetrics[sketchService] {
+			res.Metrics[m.metric] = float64(services.percentile(m.p))
+		}
+	}
+	if taskLatency.count > 0 {
+		if res.Sketches == nil {
+			res.Sketches = map[string]*latencySketch{}
+		}
+		res.Sketches[sketchTaskLatency] = &taskLatency
+		for _, m := range sketchMetrics[sketchTaskLatency] {
+			res.Metrics[m.metric] = float64(taskLatency.percentile(m.p))
+		}
+	}
+	res.Metrics[metricSlowdownSuspected] = boolMetric(suspected)
+	res.Metrics[metricPeakRSS] = toMB(peak.RSS)
+	res.Metrics[metricPeakHeap] = toMB(peak.Heap)
+	res.Metrics[metricPeakStack] = toMB(peak.Stack)
+	return res, nil
+}
+
+// 2つの戦略を交互に実行して比較する
+func (r *runner) runInterleavedMode(list []Strategy) error {
+	a, err := findStrategy(list, r.cfg.Interleave[0])
+	if err != nil {
+		return err
+	}
+	b, err := findStrategy(list, r.cfg.Interleave[1])
+	if err != nil {
+		return err
+	}
+
+	reps := r.cfg.Repetitions
+	r.out.interleaveStart(reps)
+	data := r.cfg.dataGen()
+	ra, rb, err := runInterleaved(a, b, reps, r.cd, func(n int) (Env, *strictCheck) {
+		return r.newEnv(n, data, -1, nil)
+	})
+	if err != nil {
+		return err
+	}
+	da, db := ra.durs, rb.durs
+
+	resA := r.newResult(modeInterleave, a)
+	resB := r.newResult(modeInterleave, b)
+	for _, x := range []struct {
+		res  *Result
+		role string
+		*interleaved
+	}{{&resA, "A", ra}, {&resB, "B", rb}} {
+		suspected := detectSlowdown(x.durs)
+		if suspected {
+			r.cd.onSlowdown(x.s.Name)
+		}
+		n := x.s.tasks()
+		x.res.Params["role"] = x.role
+		x.res.Params["tasks"] = itoa(n)
+		x.res.Params["reps"] = itoa(reps)
+		x.res.Metrics[metricTasks] = float64(n)
+		x.res.Samples = durationSamples(x.durs)
+		x.res.Metrics[metricWall] = float64(medianDuration(x.durs))
+		x.res.Metrics[metricSlowdownSuspected] = boolMetric(suspected)
+		if r.cfg.Strict {
+			if !x.s.SkipErrorPropagation {
+				v, err := r.verifyErrorPropagation(x.s, n, data)
+				if err != nil {
+					return err
+				}
+				if v != "" {
+					x.violations = addViolations(x.violations, v)
+				}
+			}
+			applyStrict(x.res, x.violations)
+		}
+	}
+
+	// 同じラウンド内のペアごとに比率を求める（ドリフトの影響を打ち消すため）
+	ratios := make([]float64, len(da))
+	bFaster := 0
+	for i := range da {
+		ratios[i] = float64(db[i]) / float64(da[i])
+		if db[i] < da[i] {
+			bFaster++
+		}
+	}
+	resB.Metrics[metricRatioMedian] = medianFloat(ratios)
+	resB.Metrics[metricFasterRounds] = float64(bFaster)
+
+	if err := r.report(resA); err != nil {
+		return err
+	}
+	return r.report(resB)
+}
+
+// 計測する1回の実行環境を作る。設定した処理時間のモデルで処理して記録を集め、厳格モードでは処理の漏れを確かめる。
+// failAtが0以上の場合は、そのタスクで処理関数がエラーを返すようにし、処理関数を呼んだ回数をprocessedに数える
+func (r *runner) newEnv(n int, data dataGen, failAt int, processed *atomic.Int64) (Env, *strictCheck) {
+	env := r.cfg.withProcessing(batchEnv(n, data))
+	env.Stats = &RunStats{}
+	if failAt >= 0 {
+		env.Process = injectFailure(env.Process, failAt, r.cfg.FailKind, processed)
+	}
+	var strict *strictCheck
+	if r.cfg.Strict {
+		env, strict = withStrictCheck(env)
+	}
+	return countErrors(env), strict
+}
+
+// フックが集計したメトリクスを追加して、結果を全ての出力先に渡す
+func (r *runner) report(res Result) error {
+	// 計測中に保持したログは、計測の外にあたる結果の出力の前に書き出す
+	if err := r.logs.flush(os.Stderr); err != nil {
+		return err
+	}
+	for _, h := range r.hooks {
+		h.Collect(&res)
+	}
+	res.normalize()
+	if res.Metrics[metricStrictViolations] > 0 {
+		r.strictFailed = append(r.strictFailed, res.Scenario+"/"+res.Strategy)
+	}
+	r.collected = append(r.collected, res)
+	return fanOut(r.reporters, res)
+}
+
+// チェックポイントに保存された前回までの結果を、コンソール以外の出力先に渡す。
+// コンソールには完了済みのシナリオとして表示する
+func (r *runner) resume(cp *checkpoint) error {
+	if len(cp.Completed) == 0 {
+		return nil
+	}
+	r.out.resumed(cp)
+	var reporters []Reporter
+	for _, rep := range r.reporters {
+		if rep != Reporter(r.out) {
+			reporters = append(reporters, rep)
+		}
+	}
+	for _, res := range cp.Results {
+		if res.Metrics[metricStrictViolations] > 0 {
+			r.strictFailed = append(r.strictFailed, res.Scenario+"/"+res.Strategy)
+		}
+		if err := fanOut(reporters, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func itoa(n int) string {
+	return strconv.Itoa(n)
+}
+
+// 故障注入した実行の結果に付ける注記。
+// エラーが返されなかった場合は、戦略がエラーを握りつぶしていることを示す
+func failureNote(err error, processed int64) string {
+	if err == nil {
+		return fmt.Sprintf("警告: 注入したエラーが返されませんでした（エラーが握りつぶされ、キャンセルも行われていません）。処理されたタスク: %d", processed)
+	}
+	return fmt.Sprintf("エラーを検出（%s）: %v。処理されたタスク: %d", classifyError(err), err, processed)
+}
@@ -0,0 +1,70 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// 初回の実行が2回目以降と分けて記録され、1回だけの実行では記録されないことを確認する
+func TestRunRepeatedColdWarm(t *testing.T) {
+	calls := 0
+	s := Strategy{Name: "cold-start", Tasks: 1, Run: func(env Env) error {
+		calls++
+		if calls == 1 {
+			time.Sleep(20 * time.Millisecond)
+		}
+		return nil
+	}}
+
+	cfg := DefaultConfig()
+	cfg.Repetitions = 3
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}}
+	res, err := r.runRepeated(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cold := res.duration(metricColdWall); cold < 20*time.Millisecond {
+		t.Errorf("cold wall = %v, want at least 20ms", cold)
+	}
+	if warm := res.duration(metricWarmWall); warm >= 10*time.Millisecond {
+		t.Errorf("warm wall = %v, want the later runs without the first", warm)
+	}
+
+	cfg.Repetitions = 1
+	r.cfg = cfg
+	res, err = r.runRepeated(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := res.Metrics[metricColdWall]; ok {
+		t.Error("cold wall recorded for a single repetition")
+	}
+}
+
+// 破棄したタスク数を、隣の重複排除やタイムアウトのタスク数と同じく1回あたりで記録することを確認する
+func TestRunRepeatedDroppedPerRun(t *testing.T) {
+	s := Strategy{Name: "dropping", Tasks: 100, Run: func(env Env) error {
+		for {
+			task, ok := env.Source.Next()
+			if !ok {
+				return nil
+			}
+			if task.ID < 10 {
+				env.drop(1)
+				continue
+			}
+			env.Process(task)
+		}
+	}}
+	cfg := DefaultConfig()
+	cfg.Repetitions = 3
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}}
+	res, err := r.runRepeated(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Metrics[metricDroppedTasks]; got != 10 {
+		t.Errorf("dropped = %v, want 10 per run", got)
+	}
+}
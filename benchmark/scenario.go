@@ -0,0 +1,253 @@
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+)
+
+// デフォルトのシナリオ名
+const defaultScenario = "dispatch"
+
+// 全てのシナリオを実行するときの指定
+const allScenarios = "all"
+
+// 比較シナリオ（同じ問題に対する複数の実装の組）
+type Scenario struct {
+	// コマンドラインから指定する際の識別子
+	Name string
+	// 結果表示に使う説明
+	Title string
+	// シナリオに含まれる戦略
+	Strategies func(p Params) []Strategy
+}
+
+// 利用できるシナリオ一覧（ビルドタグで有効にしたシナリオはRegisterScenarioで追加される）
+var scenarios = []Scenario{
+	{
+		Name:       defaultScenario,
+		Title:      "チャネル + ディスパッチャー vs 直接goroutine起動",
+		Strategies: strategies,
+	},
+	{
+		Name:       "task-kinds",
+		Title:      "複数種類のタスクの振り分け（型switch vs インターフェース vs 種類別チャネル）",
+		Strategies: taskKindStrategies,
+	},
+	{
+		Name:       "phases",
+		Title:      "依存関係のあるフェーズの逐次処理（プールの作り直し vs 永続プール + バリア）",
+		Strategies: phaseStrategies,
+	},
+	{
+		Name:       "errgroup",
+		Title:      "errgroupのエラー伝播とキャンセル（現行の実装 vs 修正版）",
+		Strategies: errgroupStrategies,
+	},
+	{
+		Name:       "weighted",
+		Title:      "処理時間の偏ったタスクの並列度制限（一律の重み vs タスクの重さに応じた重み）",
+		Strategies: weightedStrategies,
+	},
+	{
+		Name:       "dedup",
+		Title:      "同じキーの重複タスクの排除（ディスパッチャーで排除 vs 処理時にsingleflightで排除）",
+		Strategies: dedupStrategies,
+	},
+	{
+		Name:       "sleep",
+		Title:      "処理時間の待機の仕組み（time.Sleep vs <-time.After vs time.NewTimer vs タイマーの使い回し）",
+		Strategies: sleepStrategies,
+	},
+	{
+		Name:       "ticker",
+		Title:      "一定間隔で生成されるタスクのディスパッチのジッター（time.Tickerによる定期実行）",
+		Strategies: tickerStrategies,
+	},
+	{
+		Name:       "scheduled",
+		Title:      "開始予定時刻のあるタスクの予約実行（time.AfterFunc vs タスクごとのgoroutine vs タイマーホイール + ワーカープール）",
+		Strategies: scheduledStrategies,
+	},
+	{
+		Name:       "semaphore",
+		Title:      "高い競合下でのセマフォの取得と解放（chan struct{}のトークン vs semaphore.Weighted）",
+		Strategies: semaphoreStrategies,
+	},
+	{
+		Name:       "completion",
+		Title:      "直接goroutine起動の完了待ちの仕組み（sync.WaitGroup vs errgroup vs 完了チャネル）",
+		Strategies: completionStrategies,
+	},
+	{
+		Name:       "notify",
+		Title:      "ワーカープールの各タスクの完了通知（結果チャネル vs ワーカー内のコールバック vs atomicカウンタ + 単一の待機者）",
+		Strategies: notifyStrategies,
+	},
+	{
+		Name:       "shutdown",
+		Title:      "ワーカープールの停止の順序（送信後に閉じて処理し切る vs sync.Onceで閉じる停止チャネル vs コンテキストのキャンセル）",
+		Strategies: shutdownStrategies,
+	},
+	{
+		Name:       "lease",
+		Title:      "ワーカーへのタスクの渡し方（共有チャネルのプール vs チャネルのチャネルによるワーカーの貸し出し）",
+		Strategies: leaseStrategies,
+	},
+	{
+		Name:       "dynamic-select",
+		Title:      "実行時に決まる数のチャネルからの受信（reflect.Select vs 固定のselect文の木 vs 1つのチャネルへの合流）",
+		Strategies: dynamicSelectStrategies,
+	},
+	{
+		Name:       "fanin",
+		Title:      "N個のチャネルの1つのストリームへの合流（チャネルごとのgoroutine vs 単一のselectループ）",
+		Strategies: fanInStrategies,
+	},
+	{
+		Name:       "context-tree",
+		Title:      "コンテキストの木の一斉キャンセル（タスクごとの子 vs 子の使い回し vs ルートの共有 vs ワーカーごとの子）",
+		Strategies: contextTreeStrategies,
+	},
+	{
+		Name:       "concurrent-map",
+		Title:      "共有マップの読み書き（sync.Map vs シャーディングしたマップ vs 所有者のgoroutine）× ディスパッチの方法",
+		Strategies: concurrentMapStrategies,
+	},
+	{
+		Name:       "aggregate",
+		Title:      "ワーカーの結果の1つのスライスへの集約（ミューテックスで追加 vs 単一の書き込みgoroutine vs ワーカーごとのバッファ）",
+		Strategies: aggregateStrategies,
+	},
+	{
+		Name:       "buffer",
+		Title:      "タスクのチャネルのバッファ（既定の100 vs タスク数と同じで送信側が待たされない）",
+		Strategies: bufferStrategies,
+	},
+	{
+		Name:       "generation",
+		Title:      "タスクの生成と処理の重なり（全て生成してから投入 vs 生成するgoroutineが並行して投入）× 生成の重さ",
+		Strategies: generationStrategies,
+	},
+	{
+		Name:       "slow-start",
+		Title:      "一斉に到着するタスクの処理開始の遅れ（すぐに全ての同時実行数で処理 vs スロースタートで同時実行数を広げる）",
+		Strategies: slowStartStrategies,
+	},
+	{
+		Name:       "breaker",
+		Title:      "処理先の障害中の呼び出し（毎回呼び出す vs サーキットブレーカー）",
+		Strategies: breakerStrategies,
+	},
+	{
+		Name:       "bulkhead",
+		Title:      "重いタスクの急増時の軽いタスクの遅れ（1つの共有プール vs 重さごとのプール（バルクヘッド））",
+		Strategies: bulkheadStrategies,
+	},
+	{
+		Name:       "routing",
+		Title:      "キーの偏ったタスクのワーカーへの振り分け（キーのハッシュで固定 vs ランダム vs キューの最も短いワーカー vs 共有チャネル）",
+		Strategies: routingStrategies,
+	},
+	{
+		Name:       "server",
+		Title:      "ソケットサーバーの要求の処理（接続ごとのgoroutine vs チャネルで渡す共有のワーカープール）",
+		Strategies: serverStrategies,
+	},
+	{
+		Name:       "pipeline",
+		Title:      "2段のパイプライン（pipelineパッケージで組み立て vs チャネルで直接記述）",
+		Strategies: pipelineStrategies,
+	},
+	{
+		Name:       "iterator",
+		Title:      "iter.Seqによるタスクの受け渡し（rangeでチャネルに送るプッシュ型 vs iter.Pullで各ワーカーが引くプル型）",
+		Strategies: iteratorStrategies,
+	},
+	{
+		Name:       "afterfunc",
+		Title:      "context.AfterFuncによる後始末の登録（登録なし vs タスクごとに登録と解除 vs ワーカーごとに1回登録）",
+		Strategies: afterFuncStrategies,
+	},
+	{
+		Name:       "rand",
+		Title:      "乱数を使うワークロードでの生成器の共有（ロックで保護した共有の生成器 vs math/rand/v2 vs ワーカーごとの生成器）",
+		Strategies: rngStrategies,
+	},
+	{
+		Name:       "oversubscribe",
+		Title:      "CPUを使うタスクでの同時実行数（GOMAXPROCSの1倍から256倍まで）",
+		Strategies: oversubscribeStrategies,
+	},
+	{
+		Name:       "cancel",
+		Title:      "長いタスクの途中キャンセル（ctxを確認する間隔ごとのキャンセル後の無駄な作業）",
+		Strategies: cancelStrategies,
+	},
+	{
+		Name:       "timeout",
+		Title:      "タスクごとのタイムアウト（タスクごとのtime.After vs time.AfterFunc vs 共有のティッカーで進めるタイマーホイール）",
+		Strategies: timeoutStrategies,
+	},
+	{
+		Name:       "spawn",
+		Title:      "ワーカーの起動方法（最初に全て起動 vs 需要に応じて上限まで起動）と一定の負荷、一斉に到着する負荷",
+		Strategies: spawnStrategies,
+	},
+}
+
+// このビルドに含まれていないシナリオと、含めるために必要なビルドタグ
+var unavailableScenarios = map[string]string{}
+
+// シナリオを登録する。重いシナリオやプラットフォームに依存するシナリオは、
+// ビルドタグで有効にしたファイルのinitから登録し、通常のビルドには含めない
+func RegisterScenario(sc Scenario) {
+	if _, err := findScenario(sc.Name); err == nil || sc.Name == allScenarios {
+		panic(fmt.Sprintf("scenario %q is already registered", sc.Name))
+	}
+	scenarios = append(scenarios, sc)
+}
+
+// ビルドタグで無効になっているシナリオを記録する。指定されたときに、有効にする方法をエラーで示す
+func registerUnavailableScenario(name, tags string) {
+	unavailableScenarios[name] = tags
+}
+
+// 名前からシナリオを探す
+func findScenario(name string) (Scenario, error) {
+	names := make([]string, 0, len(scenarios))
+	for _, sc := range scenarios {
+		if sc.Name == name {
+			return sc, nil
+		}
+		names = append(names, sc.Name)
+	}
+	if tags, ok := unavailableScenarios[name]; ok {
+		return Scenario{}, fmt.Errorf("scenario %q is not available in this build (rebuild with -tags %s)", name, tags)
+	}
+	return Scenario{}, fmt.Errorf("unknown scenario %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// このビルドで利用できるシナリオ名の一覧
+func ScenarioNames() []string {
+	names := make([]string, 0, len(scenarios))
+	for _, sc := range scenarios {
+		names = append(names, sc.Name)
+	}
+	return names
+}
+
+// 実行するシナリオの指定（カンマ区切りの名前、または全てを表すall）を解決する
+func resolveScenarios(spec string) ([]Scenario, error) {
+	if spec == allScenarios {
+		return scenarios, nil
+	}
+	var list []Scenario
+	for _, name := range strings.Split(spec, ",") {
+		sc, err := findScenario(name)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, sc)
+	}
+	return list, nil
+}
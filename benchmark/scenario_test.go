@@ -0,0 +1,27 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+)
+
+// 登録済みの名前で登録するとpanicすることを確認する
+func TestRegisterScenarioDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic for duplicate scenario")
+		}
+	}()
+	RegisterScenario(Scenario{Name: defaultScenario, Strategies: strategies})
+}
+
+// ビルドタグで無効になっているシナリオを指定すると、有効にするタグをエラーで示すことを確認する
+func TestUnavailableScenario(t *testing.T) {
+	registerUnavailableScenario("optional-test", "optionaltest")
+	defer delete(unavailableScenarios, "optional-test")
+
+	_, err := resolveScenarios(defaultScenario + ",optional-test")
+	if err == nil || !strings.Contains(err.Error(), "-tags optionaltest") {
+		t.Errorf("got %v, want error naming the build tag", err)
+	}
+}
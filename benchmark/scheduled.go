@@ -0,0 +1,301 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// 予定実行シナリオで全タスクの開始予定時刻を散らばらせる期間
+	scheduleWindow = time.Second
+	// 開始予定時刻の順番をタスクIDの順番と無関係にするための係数（numTasksと互いに素）
+	scheduleStride = 7919
+	// タイマーホイールの1スロットの時間幅とスロット数
+	wheelTick  = time.Millisecond
+	wheelSlots = 256
+)
+
+// 開始予定時刻を設定してタスクを供給する。
+// 予定時刻は最初のタスクを要求された時刻からscheduleWindowの範囲に散らばり、供給の順番とは一致しない
+type scheduledSource struct {
+	src   Source
+	start time.Time
+}
+
+func (s *scheduledSource) Next() (Task, bool) {
+	if s.start.IsZero() {
+		s.start = time.Now()
+	}
+	task, ok := s.src.Next()
+	if !ok {
+		return Task{}, false
+	}
+	task.Scheduled = s.start.Add(scheduleDelay(task.ID))
+	return task, true
+}
+
+// タスクの開始予定時刻の、供給開始からの遅延
+func scheduleDelay(id int) time.Duration {
+	return time.Duration(id*scheduleStride%numTasks) * scheduleWindow / numTasks
+}
+
+// 予定実行シナリオの戦略一覧。
+// 各タスクは開始予定時刻より前に処理してはならず、予定時刻から処理開始までの遅れ（タイマーの精度）を計測する
+func scheduledStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	list := []Strategy{
+		{
+			Name:  "scheduled-afterfunc",
+			Title: "タスクごとにtime.AfterFuncで処理を予約",
+			Func:  "ScheduledAfterFunc",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "time.AfterFunc"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: ScheduledAfterFunc,
+		},
+		{
+			Name:  "scheduled-goroutine",
+			Title: "タスクごとにgoroutineを起動し、予定時刻までタイマーで待機",
+			Func:  "ScheduledGoroutine",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文", Note: "予定時刻までタイマーで待機"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: ScheduledGoroutine,
+		},
+		{
+			Name:  "scheduled-wheel",
+			Title: fmt.Sprintf("タイマーホイール（%v × %dスロット）+ ワーカープール（%dワーカー）", wheelTick, wheelSlots, numWorkers),
+			Func:  "ScheduledTimerWheel",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					{Role: RoleProducer, Goroutines: 1, Note: "タイマーホイールに追加"},
+					{Role: RoleDispatcher, Goroutines: 1, From: fmt.Sprintf("タイマーホイール（%v × %dスロット）", wheelTick, wheelSlots), Note: "time.Tickerでホイールを進める"},
+					{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100)},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return ScheduledTimerWheel(env, numWorkers)
+			},
+		},
+	}
+	for i, s := range list {
+		run := s.Run
+		list[i].Pace = scheduleWindow / numTasks
+		list[i].Run = func(env Env) error {
+			env.Source = &scheduledSource{src: env.Source}
+			return run(withJitter(env))
+		}
+	}
+	return list
+}
+
+// タスクごとにtime.AfterFuncで処理を予約する。
+// 待機中はgoroutineを持たず、ランタイムのタイマーだけが残る
+func ScheduledAfterFunc(env Env) error {
+	ctx, cancel := context.WithCancel(env.context())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		wg.Add(1)
+		time.AfterFunc(time.Until(task.Scheduled), func() {
+			defer wg.Done()
+			if ctx.Err() != nil {
+				return
+			}
+			if err := env.Process(task); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		})
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// タスクごとにgoroutineを起動し、各goroutineが予定時刻までタイマーで待機してから処理する。
+// 待機中のgoroutineがそれぞれスタックを持つ
+func ScheduledGoroutine(env Env) error {
+	ctx, cancel := context.WithCancel(env.context())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t := time.NewTimer(time.Until(task.Scheduled))
+			defer t.Stop()
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			if err := env.Process(task); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// 単一のティッカーが一定間隔でタイマーホイールを進め、予定時刻を過ぎたタスクを
+// チャネルでワーカープールに渡す。予定時刻はスロットの時間幅に切り上げられる
+func ScheduledTimerWheel(env Env, numWorkers int) error {
+	ctx, cancel := context.WithCancel(env.context())
+	defer cancel()
+
+	wheel := newTimerWheel(time.Now(), wheelTick, wheelSlots)
+	go func() {
+		for {
+			task, ok := env.Source.Next()
+			if !ok || ctx.Err() != nil {
+				break
+			}
+			wheel.add(task)
+		}
+		wheel.close()
+	}()
+
+	ready := make(chan Task, 100)
+	go func() {
+		defer close(ready)
+		ticker := time.NewTicker(wheelTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				due, done := wheel.advance(now)
+				for _, task := range due {
+					select {
+					case ready <- task:
+					case <-ctx.Done():
+						return
+					}
+				}
+				if done {
+					return
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range ready {
+				if err := env.Process(task); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// タイマーホイールのエントリ（何スロット目に発火するか）
+type wheelEntry struct {
+	task Task
+	tick int64
+}
+
+// ハッシュ化タイマーホイール。予定時刻をスロット番号に切り上げ、スロット数で割った余りの
+// スロットに入れる。1周より先のエントリは、そのスロットを通過するたびに読み飛ばす
+type timerWheel struct {
+	mu      sync.Mutex
+	start   time.Time
+	tick    time.Duration
+	slots   [][]wheelEntry
+	current int64
+	pending int
+	closed  bool
+}
+
+func newTimerWheel(start time.Time, tick time.Duration, numSlots int) *timerWheel {
+	return &timerWheel{
+		start: start,
+		tick:  tick,
+		slots: make([][]wheelEntry, numSlots),
+	}
+}
+
+// タスクを予定時刻のスロットに追加する。既に通過したスロットの場合は次に発火するスロットに入れる
+func (w *timerWheel) add(task Task) {
+	tick := int64((task.Scheduled.Sub(w.start) + w.tick - 1) / w.tick)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	tick = max(tick, w.current)
+	i := tick % int64(len(w.slots))
+	w.slots[i] = append(w.slots[i], wheelEntry{task: task, tick: tick})
+	w.pending++
+}
+
+// これ以上タスクが追加されないことを通知する
+func (w *timerWheel) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+}
+
+// nowまでのスロットを進め、発火したタスクを返す。
+// 全てのタスクが追加済みで、全て発火した場合はdoneにtrueを返す
+func (w *timerWheel) advance(now time.Time) (due []Task, done bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	upTo := int64(now.Sub(w.start) / w.tick)
+	for ; w.current <= upTo; w.current++ {
+		i := w.current % int64(len(w.slots))
+		kept := w.slots[i][:0]
+		for _, e := range w.slots[i] {
+			if e.tick <= w.current {
+				due = append(due, e.task)
+			} else {
+				kept = append(kept, e)
+			}
+		}
+		w.slots[i] = kept
+	}
+	w.pending -= len(due)
+	return due, w.closed && w.pending == 0
+}
@@ -0,0 +1,60 @@
+package benchmark
+
+import (
+	"testing"
+	"time"
+)
+
+// スロットの時間幅に切り上げて発火し、1周より先のタスクは周回後に発火することを確認する
+func TestTimerWheel(t *testing.T) {
+	start := time.Now()
+	w := newTimerWheel(start, time.Millisecond, 4)
+	for _, ms := range []float64{0.5, 2, 6} {
+		w.add(Task{ID: int(ms * 10), Scheduled: start.Add(time.Duration(ms * float64(time.Millisecond)))})
+	}
+	w.close()
+
+	ids := func(tasks []Task) []int {
+		var ids []int
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+	if due, done := w.advance(start); len(due) != 0 || done {
+		t.Errorf("advance(0ms) = %v, %v", ids(due), done)
+	}
+	if due, done := w.advance(start.Add(2 * time.Millisecond)); len(due) != 2 || done {
+		t.Errorf("advance(2ms) = %v, %v, want [5 20]", ids(due), done)
+	}
+	// 6msのタスクは2msと同じスロットに入っているが、1周後まで発火しない
+	if due, done := w.advance(start.Add(5 * time.Millisecond)); len(due) != 0 || done {
+		t.Errorf("advance(5ms) = %v, %v", ids(due), done)
+	}
+	if due, done := w.advance(start.Add(6 * time.Millisecond)); len(due) != 1 || due[0].ID != 60 || !done {
+		t.Errorf("advance(6ms) = %v, %v, want [60] true", ids(due), done)
+	}
+}
+
+// 全ての戦略が予定時刻より前にタスクを処理しないことを確認する
+func TestScheduledStrategies(t *testing.T) {
+	const n = 20
+
+	for _, s := range scheduledStrategies(Params{Workers: 2}) {
+		t.Run(s.Name, func(t *testing.T) {
+			t.Parallel()
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			j := env.Stats.jitterSummary()
+			if j.Count != n {
+				t.Fatalf("processed %d tasks, want %d", j.Count, n)
+			}
+			if j.Min < 0 {
+				t.Errorf("task started %v before its scheduled time", -j.Min)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// セマフォのシナリオの戦略一覧。
+// 処理時間の待機をなくしたごく短いタスクごとにgoroutineを起動し、全てのgoroutineが
+// 同時実行数の上限を奪い合うことで、タスクの処理ではなくセマフォ自体の取得と解放の速さを比べる
+func semaphoreStrategies(p Params) []Strategy {
+	limit := p.Workers
+	list := []Strategy{
+		{
+			Name:  "sem-chan",
+			Title: fmt.Sprintf("chan struct{}のトークンで同時実行数を制限（%d同時実行）", limit),
+			Func:  "ChannelSemaphore",
+			Limit: limit,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文"},
+				},
+				Limiter:    fmt.Sprintf("chan struct{}（バッファ%d、各goroutineが取得）", limit),
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return ChannelSemaphore(env, limit)
+			},
+		},
+		{
+			Name:  "sem-weighted",
+			Title: fmt.Sprintf("semaphore.Weightedで同時実行数を制限（%d同時実行）", limit),
+			Func:  "WeightedSemaphore",
+			Limit: limit,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文"},
+				},
+				Limiter:    fmt.Sprintf("semaphore.Weighted(%d)（各goroutineが取得）", limit),
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return WeightedSemaphore(env, int64(limit))
+			},
+		},
+	}
+	for i, s := range list {
+		run := s.Run
+		list[i].Run = func(env Env) error {
+			env.Source = &instantSource{src: env.Source}
+			return run(env)
+		}
+	}
+	return list
+}
+
+// 処理時間を待機しないタスクを供給する
+type instantSource struct {
+	src Source
+}
+
+func (s *instantSource) Next() (Task, bool) {
+	task, ok := s.src.Next()
+	task.sleep = func(time.Duration) {}
+	return task, ok
+}
+
+// タスクごとにgoroutineを起動し、各goroutineがバッファ付きチャネルへの送信でトークンを取得し、
+// 受信で解放する
+func ChannelSemaphore(env Env, limit int) error {
+	ctx, cancel := context.WithCancel(env.context())
+	defer cancel()
+	tokens := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	var acquires int64
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		acquires++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case tokens <- struct{}{}:
+			case <-ctx.Done():
+				env.drop(1)
+				return
+			}
+			defer func() { <-tokens }()
+			if err := env.Process(task); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	env.acquired(acquires)
+	return firstErr
+}
+
+// タスクごとにgoroutineを起動し、各goroutineがsemaphore.Weightedを取得して解放する
+func WeightedSemaphore(env Env, limit int64) error {
+	ctx, cancel := context.WithCancel(env.context())
+	defer cancel()
+	sem := semaphore.NewWeighted(limit)
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	var acquires int64
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		acquires++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := sem.Acquire(ctx, 1); err != nil {
+				env.drop(1)
+				return
+			}
+			defer sem.Release(1)
+			if err := env.Process(task); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	env.acquired(acquires)
+	return firstErr
+}
@@ -0,0 +1,48 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 同時実行数が上限を超えず、取得回数が記録され、エラーが返されることを確認する
+func TestSemaphoreStrategies(t *testing.T) {
+	const n, limit = 5000, 3
+
+	for _, s := range semaphoreStrategies(Params{Workers: limit}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var running, peak atomic.Int64
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			process := env.Process
+			env.Process = func(task Task) error {
+				cur := running.Add(1)
+				defer running.Add(-1)
+				for {
+					p := peak.Load()
+					if cur <= p || peak.CompareAndSwap(p, cur) {
+						break
+					}
+				}
+				return process(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if p := peak.Load(); p > limit {
+				t.Errorf("peak concurrency %d exceeds limit %d", p, limit)
+			}
+			if got := env.Stats.Acquires.Load(); got != n {
+				t.Errorf("acquires = %d, want %d", got, n)
+			}
+
+			var processed atomic.Int64
+			env = BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
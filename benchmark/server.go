@@ -0,0 +1,217 @@
+package benchmark
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// ソケットサーバーのシナリオで同時に接続するクライアントの数
+const serverConns = 100
+
+// 要求と応答の大きさ（要求はタスクID、応答は処理の成否）
+const (
+	requestSize  = 8
+	responseSize = 1
+)
+
+// 応答で返す処理の成否
+const (
+	responseOK byte = iota
+	responseError
+)
+
+// ソケットサーバーのシナリオの戦略一覧。
+// serverConns個のクライアントがそれぞれ接続を開き、前の応答を待ってから次の小さな要求を送る。
+// サーバーが接続ごとのgoroutineで要求を読んで処理する方法と、接続ごとのgoroutineは要求を読むだけにして
+// 処理を共有のワーカープールにチャネルで渡す方法で、要求ごとのレイテンシの分布を比べる
+func serverStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	clients := Stage{Role: RoleProducer, Goroutines: serverConns, Note: "クライアントごとに1つ。net.Pipeの接続で要求を送り、応答を待つ"}
+	return []Strategy{
+		{
+			Name:  "server-conn-goroutine",
+			Title: fmt.Sprintf("接続ごとのgoroutineが要求を読んで処理（%d接続）", serverConns),
+			Func:  "ConnGoroutineServer",
+			Topology: Topology{
+				Stages: []Stage{
+					clients,
+					{Role: RoleWorker, Goroutines: serverConns, From: "net.Conn", Note: "接続ごとに1つ"},
+				},
+				Completion: "sync.WaitGroup（クライアントが接続を閉じたら終了）",
+			},
+			Run: func(env Env) error {
+				return ConnGoroutineServer(env, serverConns)
+			},
+		},
+		{
+			Name:  "server-worker-pool",
+			Title: fmt.Sprintf("接続ごとのgoroutineが要求を読み、ワーカープール（%dワーカー）が処理（%d接続）", numWorkers, serverConns),
+			Func:  "WorkerPoolServer",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					clients,
+					{Role: RoleDispatcher, Goroutines: serverConns, From: "net.Conn", Note: "接続ごとに1つ。要求を読んでチャネルに送信"},
+					{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("serverRequest", 100), Note: "処理して接続に応答を書く"},
+				},
+				Completion: "sync.WaitGroup（クライアントが接続を閉じたら終了）",
+			},
+			Run: func(env Env) error {
+				return WorkerPoolServer(env, serverConns, numWorkers)
+			},
+		},
+	}
+}
+
+// サーバーが読んだ要求と、応答を書く接続
+type serverRequest struct {
+	task Task
+	conn net.Conn
+}
+
+// 供給元のタスクを接続ごとに振り分ける（i番目のタスクはi % conns番目の接続が送る）
+func splitTasks(src Source, conns int) [][]Task {
+	perConn := make([][]Task, conns)
+	for i := 0; ; i++ {
+		task, ok := src.Next()
+		if !ok {
+			return perConn
+		}
+		perConn[i%conns] = append(perConn[i%conns], task)
+	}
+}
+
+// 接続ごとにクライアントとサーバーの接続を開き、各クライアントが割り当てられたタスクの要求を1つずつ送って応答を待つ。
+// serveは接続ごとに、その接続に割り当てられたタスクとともに1回呼ばれ、クライアントが接続を閉じるまで要求に応答する。
+// 要求の送信から応答の受信までの時間を要求ごとのレイテンシとして記録する
+func runClients(env Env, perConn [][]Task, serve func(conn net.Conn, tasks []Task)) error {
+	var servers, clients sync.WaitGroup
+	var mu sync.Mutex
+	var clientErr error
+	for _, tasks := range perConn {
+		client, server := net.Pipe()
+		servers.Add(1)
+		go func() {
+			defer servers.Done()
+			defer server.Close()
+			serve(server, tasks)
+		}()
+		clients.Add(1)
+		go func() {
+			defer clients.Done()
+			defer client.Close()
+			if err := sendRequests(env, client, len(tasks)); err != nil {
+				mu.Lock()
+				clientErr = errors.Join(clientErr, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	clients.Wait()
+	servers.Wait()
+	return clientErr
+}
+
+// 接続に割り当てられたn個のタスクの要求（接続内の番号）を1つずつ送り、応答を待ってレイテンシを記録する
+func sendRequests(env Env, conn net.Conn, n int) error {
+	var req [requestSize]byte
+	var resp [responseSize]byte
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		binary.BigEndian.PutUint64(req[:], uint64(i))
+		if _, err := conn.Write(req[:]); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, resp[:]); err != nil {
+			return err
+		}
+		env.requestLatency(time.Since(start))
+	}
+	return nil
+}
+
+// 要求を1つ読み、対応するタスクを返す。クライアントが接続を閉じた場合はfalseを返す
+func readRequest(conn net.Conn, tasks []Task) (Task, bool) {
+	var req [requestSize]byte
+	if _, err := io.ReadFull(conn, req[:]); err != nil {
+		return Task{}, false
+	}
+	return tasks[binary.BigEndian.Uint64(req[:])], true
+}
+
+// 処理の成否を応答として書く
+func writeResponse(conn net.Conn, err error) {
+	status := responseOK
+	if err != nil {
+		status = responseError
+	}
+	conn.Write([]byte{status})
+}
+
+// 接続ごとのgoroutineが、要求を読んで処理し、応答を書く
+func ConnGoroutineServer(env Env, conns int) error {
+	var mu sync.Mutex
+	var firstErr error
+	err := runClients(env, splitTasks(env.Source, conns), func(conn net.Conn, tasks []Task) {
+		for {
+			task, ok := readRequest(conn, tasks)
+			if !ok {
+				return
+			}
+			err := env.Process(task)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+			writeResponse(conn, err)
+		}
+	})
+	return errors.Join(err, firstErr)
+}
+
+// 接続ごとのgoroutineは要求を読んでチャネルに送るだけにし、共有のワーカープールが処理して応答を書く。
+// クライアントは前の応答を待ってから次の要求を送るため、1つの接続に同時に書くワーカーは1つだけになる
+func WorkerPoolServer(env Env, conns, numWorkers int) error {
+	requests := make(chan serverRequest, 100)
+	var mu sync.Mutex
+	var firstErr error
+	var workers sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for req := range requests {
+				err := env.Process(req.task)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+				writeResponse(req.conn, err)
+			}
+		}()
+	}
+
+	err := runClients(env, splitTasks(env.Source, conns), func(conn net.Conn, tasks []Task) {
+		for {
+			task, ok := readRequest(conn, tasks)
+			if !ok {
+				return
+			}
+			requests <- serverRequest{task: task, conn: conn}
+		}
+	})
+	close(requests)
+	workers.Wait()
+	return errors.Join(err, firstErr)
+}
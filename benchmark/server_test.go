@@ -0,0 +1,46 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 全ての要求を処理して応答し、要求ごとのレイテンシを記録して、処理のエラーを返すことを確認する
+func TestServerStrategies(t *testing.T) {
+	const n = 2000
+
+	for _, s := range serverStrategies(Params{Workers: 10}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var completed atomic.Int64
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			seen := make([]atomic.Bool, n)
+			env.Process = func(task Task) error {
+				defer completed.Add(1)
+				if seen[task.ID].Swap(true) {
+					t.Errorf("task %d processed twice", task.ID)
+				}
+				return processTask(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := completed.Load(); got != n {
+				t.Errorf("returned after %d tasks completed, want %d", got, n)
+			}
+			var requests latencySketch
+			env.Stats.mergeRequests(&requests)
+			if requests.count != n {
+				t.Errorf("recorded %d request latencies, want %d", requests.count, n)
+			}
+
+			var processed atomic.Int64
+			env = BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
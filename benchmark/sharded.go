@@ -0,0 +1,88 @@
+package benchmark
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// 2段階のディスパッチ：タスクをK個のシャードのチャネルに振り分け、
+// 各シャードの中ではタスクごとにgoroutineを起動してシャードごとの上限で同時実行数を制限する。
+// 単一のディスパッチャー（チャネル）とタスクごとのgoroutine起動（直接）の中間にあたる構成で、
+// ディスパッチャーの処理とsemaphoreの競合をシャード数に分散する
+func TwoLevelDispatch(env Env, numShards, perShard int) error {
+	// 最初のエラーでキャンセルし、そのエラーを結果として返す
+	ctx, cancel := context.WithCancelCause(env.context())
+	defer cancel(nil)
+
+	var shardsWG sync.WaitGroup
+	shards := make([]chan Task, numShards)
+	for i := range shards {
+		shards[i] = make(chan Task, 100)
+		tasks := shards[i]
+
+		// シャードごとのディスパッチャー
+		shardsWG.Add(1)
+		go func() {
+			defer shardsWG.Done()
+			sem := semaphore.NewWeighted(int64(perShard))
+			var wg sync.WaitGroup
+			defer wg.Wait()
+
+			for task := range tasks {
+				// シャード内の空きを待つ（キャンセルされたら残りのタスクを読み捨てる）
+				if err := sem.Acquire(ctx, 1); err != nil {
+					env.drop(1 + drain(tasks))
+					return
+				}
+				wg.Add(1)
+				go func() {
+					defer sem.Release(1)
+					defer wg.Done()
+					if ctx.Err() != nil {
+						env.drop(1)
+						return
+					}
+					if err := env.Process(task); err != nil {
+						cancel(err)
+					}
+				}()
+			}
+		}()
+	}
+
+	// タスクIDでシャードを決めて送信する（キャンセルされたら送信をやめる）
+	dispatch(ctx, env, shards)
+	for _, tasks := range shards {
+		close(tasks)
+	}
+	shardsWG.Wait()
+	return context.Cause(ctx)
+}
+
+// 供給元のタスクをタスクIDに応じたシャードのチャネルに送信する。
+// キャンセルされたら、読み出したが送信できなかったタスクを破棄として数えて送信をやめる
+func dispatch(ctx context.Context, env Env, shards []chan Task) {
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			return
+		}
+		select {
+		case shards[task.ID%len(shards)] <- task:
+		case <-ctx.Done():
+			env.drop(1)
+			return
+		}
+	}
+}
+
+// チャネルに残っているタスクを読み捨て、その数を返す
+func drain(tasks <-chan Task) int {
+	n := 0
+	for range tasks {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,56 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 2段階のディスパッチが全てのタスクを処理し、エラー時は残りのタスクを破棄して返すことを確認する
+func TestTwoLevelDispatch(t *testing.T) {
+	const n = 1000
+
+	var processed atomic.Int64
+	env := BatchEnv(n)
+	env.Process = func(Task) error {
+		processed.Add(1)
+		return nil
+	}
+	if err := TwoLevelDispatch(env, 3, 4); err != nil {
+		t.Fatal(err)
+	}
+	if got := processed.Load(); got != n {
+		t.Errorf("processed = %d, want %d", got, n)
+	}
+
+	processed.Store(0)
+	env = BatchEnv(n)
+	env.Stats = &RunStats{}
+	env.Process = injectFailure(processTask, 0, ErrorFatal, &processed)
+	src := &countingSource{src: env.Source}
+	env.Source = src
+	if err := TwoLevelDispatch(env, 3, 2); !errors.Is(err, errInjectedFailure) {
+		t.Fatalf("got %v, want injected failure", err)
+	}
+	if got := processed.Load(); got >= n {
+		t.Errorf("processed = %d, want fewer than %d after cancellation", got, n)
+	}
+	// 読み出したタスクは、処理したか破棄したかのどちらかとして数える
+	if got, read := processed.Load()+env.Stats.Dropped.Load(), src.taken; got != read {
+		t.Errorf("processed(%d) + dropped(%d) = %d, want the %d tasks read", processed.Load(), env.Stats.Dropped.Load(), got, read)
+	}
+}
+
+// キャンセルされたときに、読み出したが送信できなかったタスクを破棄として数えることを確認する
+func TestDispatchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	env := BatchEnv(10)
+	env.Stats = &RunStats{}
+	// 受信するシャードがないため、送信はキャンセルまで進まない
+	dispatch(ctx, env, []chan Task{make(chan Task)})
+	if got := env.Stats.Dropped.Load(); got != 1 {
+		t.Errorf("dropped = %d, want the task read before cancellation", got)
+	}
+}
@@ -0,0 +1,196 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// 停止の順序のシナリオの戦略一覧。
+// いずれもワーカープールでタスクを処理し、チャネルを閉じる順序と途中で停止する仕組みだけが異なる。
+// 途中で停止しない通常の実行では、安全な停止のための仕組み自体のコストを比べる
+func shutdownStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	pool := func(note, completion string) Topology {
+		return Topology{
+			Stages: []Stage{
+				{Role: RoleProducer, Goroutines: 1, Note: note},
+				{Role: RoleWorker, Goroutines: numWorkers, From: chanFrom("Task", 100)},
+			},
+			Completion: completion,
+		}
+	}
+	return []Strategy{
+		{
+			Name:     "shutdown-close-drain",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ 送信後にチャネルを閉じ、ワーカーが残りを処理してから終了", numWorkers),
+			Func:     "ShutdownCloseDrain",
+			Limit:    numWorkers,
+			Topology: pool("送信が終わったらチャネルを閉じる", "sync.WaitGroup"),
+			Run: func(env Env) error {
+				return ShutdownCloseDrain(env, numWorkers)
+			},
+		},
+		{
+			Name:     "shutdown-once-stop",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ sync.Onceで一度だけ閉じる停止チャネル", numWorkers),
+			Func:     "ShutdownOnceStop",
+			Limit:    numWorkers,
+			Topology: pool("送信は停止チャネルとselect", "sync.WaitGroup（停止後はチャネルの残りを破棄）"),
+			Run: func(env Env) error {
+				return ShutdownOnceStop(env, numWorkers)
+			},
+		},
+		{
+			Name:     "shutdown-context",
+			Title:    fmt.Sprintf("ワーカープール（%dワーカー）+ コンテキストのキャンセルで送信と処理を停止", numWorkers),
+			Func:     "ShutdownContextCancel",
+			Limit:    numWorkers,
+			Topology: pool("送信はctx.Done()とselect", "sync.WaitGroup（停止後はチャネルの残りを破棄）"),
+			Run: func(env Env) error {
+				return ShutdownContextCancel(env, numWorkers)
+			},
+		},
+	}
+}
+
+// 供給元のタスクを全て送信してからチャネルを閉じる。閉じた時点でチャネルに残っているタスクも
+// ワーカーがrangeで受信して処理するため、閉じる順序によってタスクが失われることはない。
+// 途中で停止する仕組みを持たないため、エラーが発生しても全てのタスクを処理する
+func ShutdownCloseDrain(env Env, numWorkers int) error {
+	tasks := make(chan Task, 100)
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				if err := env.Process(task); err != nil {
+					errOnce.Do(func() { firstErr = err })
+				}
+			}
+		}()
+	}
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks <- task
+	}
+	// ワーカーが処理し終える前に閉じてよい
+	close(tasks)
+	wg.Wait()
+	return firstErr
+}
+
+// エラーが発生したワーカーと、戦略全体のコンテキストのキャンセルの両方が停止チャネルを閉じる。
+// 複数の箇所から閉じられるため、sync.Onceで二重に閉じること（panic）を防ぐ。
+// 送信側は停止チャネルとselectして、ワーカーが受信しなくなった後に送信でブロックし続けないようにする
+func ShutdownOnceStop(env Env, numWorkers int) error {
+	quit := make(chan struct{})
+	var stopOnce sync.Once
+	stop := func() { stopOnce.Do(func() { close(quit) }) }
+	defer context.AfterFunc(env.context(), stop)()
+
+	tasks := make(chan Task, 100)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-quit:
+					return
+				case task, ok := <-tasks:
+					if !ok {
+						return
+					}
+					if err := env.Process(task); err != nil {
+						errOnce.Do(func() { firstErr = err })
+						stop()
+					}
+				}
+			}
+		}()
+	}
+
+	sendUntilStopped(env, tasks, quit)
+	wg.Wait()
+	// 停止した場合に残ったタスクを破棄する
+	for range tasks {
+		env.drop(1)
+	}
+	if firstErr == nil {
+		return env.context().Err()
+	}
+	return firstErr
+}
+
+// エラーが発生したワーカーがコンテキストをキャンセルし、送信側とワーカーはctx.Done()で停止する。
+// contextのキャンセルは何度呼んでもよいため、閉じる処理を自前で保護する必要がない
+func ShutdownContextCancel(env Env, numWorkers int) error {
+	ctx, cancel := context.WithCancel(env.context())
+	defer cancel()
+
+	tasks := make(chan Task, 100)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case task, ok := <-tasks:
+					if !ok {
+						return
+					}
+					if err := env.Process(task); err != nil {
+						errOnce.Do(func() { firstErr = err })
+						cancel()
+					}
+				}
+			}
+		}()
+	}
+
+	sendUntilStopped(env, tasks, ctx.Done())
+	wg.Wait()
+	// 停止した場合に残ったタスクを破棄する
+	for range tasks {
+		env.drop(1)
+	}
+	if firstErr == nil {
+		return env.context().Err()
+	}
+	return firstErr
+}
+
+// 供給元のタスクをstopが閉じられるまで送信し、最後にチャネルを閉じる。
+// 送信中に停止した場合、送信できなかったタスクは破棄する
+func sendUntilStopped(env Env, tasks chan<- Task, stop <-chan struct{}) {
+	defer close(tasks)
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			return
+		}
+		select {
+		case tasks <- task:
+		case <-stop:
+			env.drop(1)
+			return
+		}
+	}
+}
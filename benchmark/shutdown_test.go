@@ -0,0 +1,145 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 全てのgoroutineが終了し、goroutine数が実行前の値に戻るまで待つ
+func waitGoroutines(t *testing.T, base int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: %d, want <= %d", runtime.NumGoroutine(), base)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// 供給元から読み出されたタスクを数える
+type countingSource struct {
+	src   Source
+	taken int64
+}
+
+func (s *countingSource) Next() (Task, bool) {
+	task, ok := s.src.Next()
+	if ok {
+		s.taken++
+	}
+	return task, ok
+}
+
+// 供給元が尽きた直後にチャネルを閉じても、チャネルに残ったタスクを含めて全て処理されることを確認する
+func TestShutdownCloseBeforeDrain(t *testing.T) {
+	const n = 500
+
+	for _, s := range shutdownStrategies(Params{Workers: 4}) {
+		t.Run(s.Name, func(t *testing.T) {
+			base := runtime.NumGoroutine()
+			var processed atomic.Int64
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			env.Process = func(task Task) error {
+				processed.Add(1)
+				// チャネルが閉じられた時点で、バッファにタスクが残るよう処理を遅らせる
+				time.Sleep(10 * time.Microsecond)
+				return nil
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := processed.Load(); got != n {
+				t.Errorf("processed %d tasks, want %d", got, n)
+			}
+			if got := env.Stats.Dropped.Load(); got != 0 {
+				t.Errorf("dropped %d tasks, want 0", got)
+			}
+			waitGoroutines(t, base)
+		})
+	}
+}
+
+// 送信側がチャネルへの送信でブロックしている間にキャンセルされても、停止して戻ることを確認する
+func TestShutdownCancelDuringSend(t *testing.T) {
+	const n = 1000
+	const workers = 2
+
+	for _, s := range shutdownStrategies(Params{Workers: workers}) {
+		if s.Name == "shutdown-close-drain" {
+			// 途中で停止する仕組みを持たない
+			continue
+		}
+		t.Run(s.Name, func(t *testing.T) {
+			base := runtime.NumGoroutine()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// ワーカーが処理中のまま止まり、バッファが埋まって送信側がブロックする
+			var started atomic.Int64
+			env := BatchEnv(n)
+			src := &countingSource{src: env.Source}
+			env.Source = src
+			env.Ctx = ctx
+			env.Stats = &RunStats{}
+			env.Process = func(task Task) error {
+				if started.Add(1) == workers {
+					time.AfterFunc(10*time.Millisecond, cancel)
+				}
+				<-ctx.Done()
+				return nil
+			}
+
+			errc := make(chan error, 1)
+			go func() { errc <- s.Run(env) }()
+			select {
+			case err := <-errc:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("got %v, want context.Canceled", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("did not return after cancellation")
+			}
+			if got := started.Load() + env.Stats.Dropped.Load(); got != src.taken {
+				t.Errorf("processed + dropped = %d, want %d (taken from source)", got, src.taken)
+			}
+			if got := started.Load(); got >= n {
+				t.Errorf("processed %d tasks after cancellation, want fewer than %d", got, n)
+			}
+			waitGoroutines(t, base)
+		})
+	}
+}
+
+// 複数のワーカーで同時にエラーが発生し、停止の処理が何度も呼ばれてもpanicせずにエラーを返すことを確認する
+func TestShutdownMultipleFailures(t *testing.T) {
+	const n = 500
+
+	for _, s := range shutdownStrategies(Params{Workers: 16}) {
+		t.Run(s.Name, func(t *testing.T) {
+			base := runtime.NumGoroutine()
+			env := BatchEnv(n)
+			src := &countingSource{src: env.Source}
+			env.Source = src
+			env.Stats = &RunStats{}
+			var processed atomic.Int64
+			env.Process = func(task Task) error {
+				processed.Add(1)
+				return errInjectedFailure
+			}
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+			// 読み出したタスクは、処理されるか破棄として数えられ、黙って失われないこと
+			if got := processed.Load() + env.Stats.Dropped.Load(); got != src.taken {
+				t.Errorf("processed + dropped = %d, want %d (taken from source)", got, src.taken)
+			}
+			waitGoroutines(t, base)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// 分位数のスケッチの相対誤差。分位数として返す値は、実際の値との差がこの割合以内に収まる
+const sketchAccuracy = 0.01
+
+// 値の範囲を対数スケールのバケットに分けて数える分位数のスケッチ（DDSketch）。
+// バケット数は値の範囲の対数にしか比例しないため、数千万タスクでもメモリ使用量は一定に収まり、
+// 同じ相対誤差のスケッチどうしはバケットごとの件数を足すだけで誤差なく合算できる。
+// 件数、最小値、最大値は全ての値から正確に求める。並行して呼び出す場合は呼び出し側で排他する
+type latencySketch struct {
+	// 正の値と負の値のバケットごとの件数（キーはバケットの番号）
+	positive map[int]uint64
+	negative map[int]uint64
+	zero     uint64
+	count    int
+	min, max time.Duration
+}
+
+// バケットの幅の比（隣り合うバケットの境界の比）
+var sketchGamma = (1 + sketchAccuracy) / (1 - sketchAccuracy)
+
+// 正の値が入るバケットの番号
+func sketchIndex(v float64) int {
+	return int(math.Ceil(math.Log(v) / math.Log(sketchGamma)))
+}
+
+// バケットを代表する値（相対誤差が最小になる値）
+func sketchValue(i int) float64 {
+	return 2 * math.Pow(sketchGamma, float64(i)) / (sketchGamma + 1)
+}
+
+func (s *latencySketch) add(d time.Duration) {
+	if s.count == 0 || d < s.min {
+		s.min = d
+	}
+	if s.count == 0 || d > s.max {
+		s.max = d
+	}
+	s.count++
+	switch {
+	case d > 0:
+		if s.positive == nil {
+			s.positive = map[int]uint64{}
+		}
+		s.positive[sketchIndex(float64(d))]++
+	case d < 0:
+		if s.negative == nil {
+			s.negative = map[int]uint64{}
+		}
+		s.negative[sketchIndex(float64(-d))]++
+	default:
+		s.zero++
+	}
+}
+
+// 他のスケッチを合算する
+func (s *latencySketch) merge(o *latencySketch) {
+	if o.count == 0 {
+		return
+	}
+	if s.count == 0 || o.min < s.min {
+		s.min = o.min
+	}
+	if s.count == 0 || o.max > s.max {
+		s.max = o.max
+	}
+	s.count += o.count
+	s.zero += o.zero
+	for i, n := range o.positive {
+		if s.positive == nil {
+			s.positive = map[int]uint64{}
+		}
+		s.positive[i] += n
+	}
+	for i, n := range o.negative {
+		if s.negative == nil {
+			s.negative = map[int]uint64{}
+		}
+		s.negative[i] += n
+	}
+}
+
+// パーセンタイル値（pは0〜100）を求める。percentileDurationと同じく、
+// 小さい方から数えて(件数-1)×p/100番目の値を返す
+func (s *latencySketch) percentile(p float64) time.Duration {
+	if s.count == 0 {
+		return 0
+	}
+	rank := uint64(float64(s.count-1) * p / 100)
+
+	// 負の値は絶対値の大きい順、0、正の値は小さい順に数える
+	var seen uint64
+	for _, i := range sortedKeys(s.negative, true) {
+		seen += s.negative[i]
+		if seen > rank {
+			return s.clamp(-sketchValue(i))
+		}
+	}
+	seen += s.zero
+	if seen > rank {
+		return 0
+	}
+	for _, i := range sortedKeys(s.positive, false) {
+		seen += s.positive[i]
+		if seen > rank {
+			return s.clamp(sketchValue(i))
+		}
+	}
+	return s.max
+}
+
+// バケットの代表値を、実際に記録された値の範囲に収める
+func (s *latencySketch) clamp(v float64) time.Duration {
+	return min(max(time.Duration(math.Round(v)), s.min), s.max)
+}
+
+func (s *latencySketch) summary() latencySummary {
+	return latencySummary{
+		Count: s.count,
+		P50:   s.percentile(50),
+		P90:   s.percentile(90),
+		P99:   s.percentile(99),
+		Min:   s.min,
+		Max:   s.max,
+	}
+}
+
+// バケットの番号を整列して返す
+func sortedKeys(m map[int]uint64, desc bool) []int {
+	keys := make([]int, 0, len(m))
+	for i := range m {
+		keys = append(keys, i)
+	}
+	if desc {
+		sort.Sort(sort.Reverse(sort.IntSlice(keys)))
+	} else {
+		sort.Ints(keys)
+	}
+	return keys
+}
+
+// 結果に含めて書き出すスケッチの形式。別の実行や別のホストの結果と合算するために使う
+type sketchJSON struct {
+	Accuracy float64        `json:"accuracy"`
+	Positive map[int]uint64 `json:"positive,omitempty"`
+	Negative map[int]uint64 `json:"negative,omitempty"`
+	Zero     uint64         `json:"zero,omitempty"`
+	Count    int            `json:"count"`
+	MinNs    int64          `json:"min_ns"`
+	MaxNs    int64          `json:"max_ns"`
+}
+
+func (s *latencySketch) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sketchJSON{
+		Accuracy: sketchAccuracy,
+		Positive: s.positive,
+		Negative: s.negative,
+		Zero:     s.zero,
+		Count:    s.count,
+		MinNs:    int64(s.min),
+		MaxNs:    int64(s.max),
+	})
+}
+
+// 相対誤差が異なるスケッチはバケットの境界が一致せず合算できないため、読み込み時に拒否する
+func (s *latencySketch) UnmarshalJSON(b []byte) error {
+	var v sketchJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if v.Accuracy != sketchAccuracy {
+		return fmt.Errorf("sketch accuracy %v does not match %v", v.Accuracy, sketchAccuracy)
+	}
+	*s = latencySketch{
+		positive: v.Positive,
+		negative: v.Negative,
+		zero:     v.Zero,
+		count:    v.Count,
+		min:      time.Duration(v.MinNs),
+		max:      time.Duration(v.MaxNs),
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package benchmark
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"time"
+)
+
+// 分位数が実際の値に対して相対誤差の範囲に収まり、件数と最小値、最大値が正確なことを確認する
+func TestLatencySketchAccuracy(t *testing.T) {
+	var s latencySketch
+	var values []time.Duration
+	for i := 0; i < 100000; i++ {
+		// 10µs〜約10msの範囲に偏りのある分布
+		d := time.Duration(10000 * math.Exp(float64(i%997)/997*7))
+		values = append(values, d)
+		s.add(d)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	sum := s.summary()
+	if sum.Count != len(values) || sum.Min != values[0] || sum.Max != values[len(values)-1] {
+		t.Errorf("count/min/max = %d/%v/%v, want %d/%v/%v",
+			sum.Count, sum.Min, sum.Max, len(values), values[0], values[len(values)-1])
+	}
+	for _, p := range []float64{0, 50, 90, 99, 100} {
+		want := percentileDuration(values, p)
+		got := s.percentile(p)
+		if math.Abs(float64(got-want)) > float64(want)*sketchAccuracy {
+			t.Errorf("p%v = %v, want %v within %v", p, got, want, sketchAccuracy)
+		}
+	}
+	if n := len(s.positive); n > 1000 {
+		t.Errorf("%d buckets, want bounded by the value range", n)
+	}
+}
+
+// 負の値と0を含んでも、小さい方から順に数えた分位数を返すことを確認する
+func TestLatencySketchNegative(t *testing.T) {
+	var s latencySketch
+	for _, d := range []time.Duration{-300, -100, 0, 0, 100, 200, 400} {
+		s.add(d)
+	}
+	for _, c := range []struct {
+		p    float64
+		want time.Duration
+	}{{0, -300}, {20, -100}, {50, 0}, {100, 400}} {
+		got := s.percentile(c.p)
+		if math.Abs(float64(got-c.want)) > math.Abs(float64(c.want))*sketchAccuracy {
+			t.Errorf("p%v = %v, want %v", c.p, got, c.want)
+		}
+	}
+}
+
+// 分けて記録したスケッチを合算すると、まとめて記録した場合と同じ結果になることを確認する
+func TestLatencySketchMerge(t *testing.T) {
+	var a, b, all, merged latencySketch
+	for i := 1; i <= 1000; i++ {
+		d := time.Duration(i * 1000)
+		all.add(d)
+		if i%3 == 0 {
+			a.add(d)
+		} else {
+			b.add(d)
+		}
+	}
+	merged.merge(&a)
+	merged.merge(&b)
+
+	if got, want := merged.summary(), all.summary(); got != want {
+		t.Errorf("merged = %+v, want %+v", got, want)
+	}
+}
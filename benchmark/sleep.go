@@ -0,0 +1,153 @@
+package benchmark
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 処理時間の待機の仕組み。キャンセルされた場合は待機を打ち切る
+type sleeper func(ctx context.Context, d time.Duration)
+
+// time.Sleepで待機する（キャンセルには反応しない）
+func sleepTimeSleep(_ context.Context, d time.Duration) {
+	time.Sleep(d)
+}
+
+// <-time.Afterで待機する。呼び出しごとにタイマーとチャネルを確保する
+func sleepTimeAfter(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// time.NewTimerで待機し、終了時にStopで解放する
+func sleepNewTimer(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
+
+// 使い回すタイマー。Go 1.23以降はStopやResetの後に古い発火値が届かないため、
+// チャネルを空にせずにResetできる
+var timerPool = sync.Pool{
+	New: func() any {
+		t := time.NewTimer(time.Hour)
+		t.Stop()
+		return t
+	},
+}
+
+// プールから取り出したタイマーをResetして待機する
+func sleepPooledTimer(ctx context.Context, d time.Duration) {
+	t := timerPool.Get().(*time.Timer)
+	t.Reset(d)
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+	t.Stop()
+	timerPool.Put(t)
+}
+
+// スリープシナリオの戦略一覧。
+// タスクごとにgoroutineを起動し、処理時間の待機の仕組みだけを差し替える
+func sleepStrategies(Params) []Strategy {
+	return []Strategy{
+		{
+			Name:  "sleep-time-sleep",
+			Title: "time.Sleepで待機（直接goroutine起動）",
+			Func:  "sleepTimeSleep",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文", Note: "time.Sleepで待機"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: withSleeper(sleepTimeSleep),
+		},
+		{
+			Name:  "sleep-time-after",
+			Title: "select + <-time.Afterで待機（呼び出しごとにタイマーを確保、直接goroutine起動）",
+			Func:  "sleepTimeAfter",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文", Note: "select + <-time.Afterで待機"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: withSleeper(sleepTimeAfter),
+		},
+		{
+			Name:  "sleep-new-timer",
+			Title: "select + time.NewTimer + Stopで待機（直接goroutine起動）",
+			Func:  "sleepNewTimer",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文", Note: "select + time.NewTimerで待機"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: withSleeper(sleepNewTimer),
+		},
+		{
+			Name:  "sleep-pooled-timer",
+			Title: "select + sync.Poolで使い回すタイマーで待機（直接goroutine起動）",
+			Func:  "sleepPooledTimer",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文", Note: "select + 使い回すタイマーで待機"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: withSleeper(sleepPooledTimer),
+		},
+	}
+}
+
+// 指定した仕組みで処理時間を待機させ、タスクごとにgoroutineを起動して処理する。
+// 待機が指定時間を超過した分を数え、仕組みごとのオーバーヘッドとして報告する
+func withSleeper(sleep sleeper) func(env Env) error {
+	return func(env Env) error {
+		ctx, cancel := context.WithCancel(env.context())
+		defer cancel()
+		wait := func(d time.Duration) {
+			start := time.Now()
+			sleep(ctx, d)
+			if ctx.Err() == nil {
+				env.overshoot(time.Since(start) - d)
+			}
+		}
+
+		var wg sync.WaitGroup
+		var errOnce sync.Once
+		var firstErr error
+		for {
+			task, ok := env.Source.Next()
+			if !ok {
+				break
+			}
+			task.sleep = wait
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := env.Process(task); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}()
+		}
+		wg.Wait()
+		return firstErr
+	}
+}
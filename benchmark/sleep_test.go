@@ -0,0 +1,35 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 全てのタスクが差し替えた仕組みで待機し、エラーが返されることを確認する
+func TestSleepStrategies(t *testing.T) {
+	const n = 500
+
+	for _, s := range sleepStrategies(Params{}) {
+		t.Run(s.Name, func(t *testing.T) {
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := env.Stats.Sleeps.Load(); got != n {
+				t.Errorf("sleeps = %d, want %d", got, n)
+			}
+			if env.Stats.SleepOvershoot.Load() < 0 {
+				t.Errorf("negative overshoot %d", env.Stats.SleepOvershoot.Load())
+			}
+
+			var processed atomic.Int64
+			env = BatchEnv(n)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package benchmark
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// 一斉に到着するタスクの数と、到着の間隔
+	burstSize = 1000
+	burstGap  = 20 * time.Millisecond
+	// スロースタートのシナリオで1回の実行で処理するタスク数
+	burstTasks = 20 * burstSize
+	// スロースタートの同時実行数の初期値と上限
+	slowStartInitial = 4
+	slowStartMax     = 1024
+	// 処理中のタスクがない状態がこの時間続いたら、同時実行数を初期値に戻す（TCPのアイドル後の再スロースタート）
+	slowStartIdle = burstGap / 2
+)
+
+// burstSize個ずつのタスクを、burstGapの間隔で一斉に供給する。
+// 各タスクの予定時刻はその塊の到着予定時刻とし、供給が遅れた場合も予定時刻は動かさない
+type burstySource struct {
+	src   Source
+	start time.Time
+	n     int
+}
+
+func (s *burstySource) Next() (Task, bool) {
+	if s.start.IsZero() {
+		s.start = time.Now()
+	}
+	arrival := s.start.Add(time.Duration(s.n/burstSize) * burstGap)
+	if d := time.Until(arrival); d > 0 {
+		time.Sleep(d)
+	}
+	task, ok := s.src.Next()
+	if !ok {
+		return Task{}, false
+	}
+	s.n++
+	task.Scheduled = arrival
+	return task, true
+}
+
+// スロースタートのシナリオの戦略一覧。
+// 一斉に到着するタスクを、すぐに全ての同時実行数で処理する方法と、TCPのスロースタートのように
+// 少ない同時実行数から始めて完了ごとに上限を広げる方法で処理し、到着から処理開始までの遅れの分布を比べる
+func slowStartStrategies(p Params) []Strategy {
+	list := []Strategy{
+		{
+			Name:  "burst-unlimited",
+			Title: "直接goroutine起動 + 無制限の並列処理（errgroup.Go）",
+			Func:  "DirectGoroutineWithUnlimitedParallelism",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "errgroup.Go"},
+				},
+				Completion: "errgroup.Wait",
+			},
+			Run: DirectGoroutineWithUnlimitedParallelism,
+		},
+		{
+			Name:  "burst-full",
+			Title: fmt.Sprintf("直接goroutine起動 + 最初から上限の同時実行数（semaphore、%d同時実行）", slowStartMax),
+			Func:  "DirectGoroutineWithLimitedParallelism",
+			Limit: slowStartMax,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文"},
+				},
+				Limiter:    fmt.Sprintf("semaphore.Weighted(%d)（供給元を読み出すgoroutineが取得）", slowStartMax),
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return DirectGoroutineWithLimitedParallelism(env, slowStartMax)
+			},
+		},
+		{
+			Name:  "burst-slow-start",
+			Title: fmt.Sprintf("直接goroutine起動 + スロースタート（%d同時実行から完了ごとに1ずつ広げ、最大%d同時実行）", slowStartInitial, slowStartMax),
+			Func:  "SlowStartDispatch",
+			Limit: slowStartMax,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文"},
+				},
+				Limiter: fmt.Sprintf("sync.Cond（同時実行数の上限を%dから%dまで広げ、%vのアイドル後に初期値に戻す）",
+					slowStartInitial, slowStartMax, slowStartIdle),
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return SlowStartDispatch(env, slowStartInitial, slowStartMax, slowStartIdle)
+			},
+		},
+	}
+	for i, s := range list {
+		run := s.Run
+		list[i].Pace = burstGap / burstSize
+		list[i].Tasks = burstTasks
+		stages := append([]Stage(nil), s.Topology.Stages...)
+		stages[0].Note = fmt.Sprintf("%d個ずつ%v間隔で一斉に到着", burstSize, burstGap)
+		list[i].Topology.Stages = stages
+		list[i].Run = func(env Env) error {
+			env.Source = &burstySource{src: env.Source}
+			return run(withJitter(env))
+		}
+	}
+	return list
+}
+
+// 完了したタスクの数だけ同時実行数の上限を広げる制限（TCPのスロースタートと同じく、往復ごとにおよそ倍になる）
+type slowStartLimiter struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	inflight int
+	limit    int
+	initial  int
+	max      int
+	idle     time.Duration
+	// 処理中のタスクがなくなった時刻
+	idleSince time.Time
+}
+
+func newSlowStartLimiter(initial, max int, idle time.Duration) *slowStartLimiter {
+	l := &slowStartLimiter{limit: initial, initial: initial, max: max, idle: idle}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// 上限に空きができるまで待って1つ取得する。長くアイドルだった場合は上限を初期値に戻してから取得する
+func (l *slowStartLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight == 0 && !l.idleSince.IsZero() && time.Since(l.idleSince) >= l.idle {
+		l.limit = l.initial
+	}
+	for l.inflight >= l.limit {
+		l.cond.Wait()
+	}
+	l.inflight++
+}
+
+// 1つ解放し、上限を1つ広げる
+func (l *slowStartLimiter) release() {
+	l.mu.Lock()
+	l.inflight--
+	if l.limit < l.max {
+		l.limit++
+	}
+	if l.inflight == 0 {
+		l.idleSince = time.Now()
+	}
+	l.mu.Unlock()
+	// 待つのは供給元を読み出すgoroutineだけ
+	l.cond.Signal()
+}
+
+// スロースタートで同時実行数を制限しながら、タスクごとにgoroutineを起動する
+func SlowStartDispatch(env Env, initial, max int, idle time.Duration) error {
+	l := newSlowStartLimiter(initial, max, idle)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		l.acquire()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer l.release()
+			if err := env.Process(task); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
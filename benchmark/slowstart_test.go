@@ -0,0 +1,97 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 全てのタスクを処理してから戻り、エラーが返されることを確認する
+func TestSlowStartDispatch(t *testing.T) {
+	const n = 2000
+
+	var completed, running, peak atomic.Int64
+	env := BatchEnv(n)
+	env.Process = func(task Task) error {
+		defer completed.Add(1)
+		cur := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if cur <= p || peak.CompareAndSwap(p, cur) {
+				break
+			}
+		}
+		return processTask(task)
+	}
+	if err := SlowStartDispatch(env, 4, 64, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got := completed.Load(); got != n {
+		t.Errorf("returned after %d tasks completed, want %d", got, n)
+	}
+	if got := peak.Load(); got > 64 {
+		t.Errorf("ran %d tasks at once, want at most 64", got)
+	}
+
+	var processed atomic.Int64
+	env = BatchEnv(n)
+	env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+	if err := SlowStartDispatch(env, 4, 64, time.Second); !errors.Is(err, errInjectedFailure) {
+		t.Errorf("got %v, want injected failure", err)
+	}
+}
+
+// 完了ごとに上限を広げ、アイドルの後は初期値に戻すことを確認する
+func TestSlowStartLimiter(t *testing.T) {
+	l := newSlowStartLimiter(2, 5, time.Hour)
+	l.acquire()
+	l.acquire()
+	if l.limit != 2 {
+		t.Fatalf("limit = %d, want 2", l.limit)
+	}
+	l.release()
+	l.release()
+	if l.limit != 4 {
+		t.Fatalf("limit = %d after 2 releases, want 4", l.limit)
+	}
+	for i := 0; i < 4; i++ {
+		l.acquire()
+	}
+	for i := 0; i < 4; i++ {
+		l.release()
+	}
+	if l.limit != 5 {
+		t.Fatalf("limit = %d, want it capped at 5", l.limit)
+	}
+
+	l.idle = time.Millisecond
+	time.Sleep(2 * time.Millisecond)
+	l.acquire()
+	if l.limit != 2 {
+		t.Errorf("limit = %d after idle, want reset to 2", l.limit)
+	}
+}
+
+// 塊ごとに予定時刻をそろえ、次の塊の到着まで供給を待つことを確認する
+func TestBurstySource(t *testing.T) {
+	src := &burstySource{src: BatchEnv(burstSize + 1).Source}
+	first, _ := src.Next()
+	for i := 1; i < burstSize; i++ {
+		task, _ := src.Next()
+		if !task.Scheduled.Equal(first.Scheduled) {
+			t.Fatalf("task %d scheduled at %v, want %v", task.ID, task.Scheduled, first.Scheduled)
+		}
+	}
+	task, ok := src.Next()
+	if !ok || task.Scheduled.Sub(first.Scheduled) != burstGap {
+		t.Fatalf("next burst scheduled %v after the first, want %v", task.Scheduled.Sub(first.Scheduled), burstGap)
+	}
+	if time.Now().Before(task.Scheduled) {
+		t.Error("next burst was delivered before its arrival")
+	}
+	if _, ok := src.Next(); ok {
+		t.Error("source did not end")
+	}
+}
@@ -0,0 +1,379 @@
+package benchmark
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// タスクの供給元
+type Source interface {
+	// 次のタスクを返す。タスクが尽きた場合はfalseを返す
+	Next() (Task, bool)
+}
+
+// 戦略の実行環境（タスクの供給元と各タスクに適用する処理）
+type Env struct {
+	Source  Source
+	Process func(Task) error
+	// 戦略全体のコンテキスト。nilの場合はcontext.Background()を使う
+	Ctx context.Context
+	// 実行中に数えるカウンタ。nilの場合は数えない
+	Stats *RunStats
+	// 供給元が供給するタスク数。0は不明（レートを指定した供給元など）
+	Tasks int
+}
+
+// 戦略の実行中に数えるカウンタ
+type RunStats struct {
+	// 処理されずに破棄されたタスク数
+	Dropped atomic.Int64
+	// 処理中の同じキーのタスクと結果を共有し、処理しなかったタスク数
+	Deduplicated atomic.Int64
+	// 処理がタイムアウトまでに終わらなかったタスク数（タイムアウトのシナリオのみ記録）
+	TimedOut atomic.Int64
+	// 処理時間の待機が指定時間を超過した合計（ナノ秒）と待機回数
+	SleepOvershoot atomic.Int64
+	Sleeps         atomic.Int64
+	// セマフォの取得を要求した回数（セマフォのシナリオのみ記録）
+	Acquires atomic.Int64
+	// 処理関数が返したエラーの分類ごとの件数
+	Errors errorCounts
+	// ルートのコンテキストのキャンセルから全てのgoroutineの終了までの時間（ナノ秒、コンテキストの木のシナリオのみ記録）
+	Teardown atomic.Int64
+	// 開始から供給元を読み切るまでの時間（ナノ秒、チャネルのバッファのシナリオのみ記録）
+	Submit atomic.Int64
+	// 最初のワーカー数分のタスクの予定時刻から処理開始までの遅れの最大（ナノ秒、ワーカーの起動方法のシナリオのみ記録）
+	RampUp atomic.Int64
+	// 供給元から読み出されてから処理を終えるまでのタスクが保持するバイト数（Config.InflightBytesの場合のみ記録）
+	Inflight inflightBytes
+	// 処理を終えてからプールの終了を始めるまでの待機中のメモリ使用量（Config.IdleMemoryの場合のみ、プールを残す戦略で記録）
+	Idle idleMemory
+	// ディスパッチャーのgoroutineがタスクの受け渡しに使った時間（Config.DispatchTimeの場合のみ、チャネルの戦略で記録）
+	Dispatch dispatchTime
+	// 障害を起こす処理先の呼び出し（サーキットブレーカーのシナリオのみ記録）
+	Downstream downstreamStats
+	// 途中キャンセル（途中キャンセルのシナリオのみ記録）
+	Cancel cancelStats
+	// ワーカーごとの稼働時間と状態へのアクセス（ルーティングのシナリオのみ記録）
+	Workers workerStats
+
+	// 予定時刻から処理開始までの遅れ（ティッカーのシナリオのみ記録）
+	jitterMu sync.Mutex
+	jitters  latencySketch
+	// 要求の送信から応答の受信までの時間（ソケットサーバーのシナリオのみ記録）
+	requestMu sync.Mutex
+	requests  latencySketch
+	// タスクの処理開始から完了までの時間（CPUを使うタスクのシナリオのみ記録）
+	serviceMu sync.Mutex
+	services  latencySketch
+}
+
+// 記録した遅れを集計する
+func (s *RunStats) jitterSummary() latencySummary {
+	s.jitterMu.Lock()
+	defer s.jitterMu.Unlock()
+	return s.jitters.summary()
+}
+
+// 記録した遅れを他の記録に合算する
+func (s *RunStats) mergeJitters(into *latencySketch) {
+	s.jitterMu.Lock()
+	defer s.jitterMu.Unlock()
+	into.merge(&s.jitters)
+}
+
+// 記録した要求ごとのレイテンシを他の記録に合算する
+func (s *RunStats) mergeRequests(into *latencySketch) {
+	s.requestMu.Lock()
+	defer s.requestMu.Unlock()
+	into.merge(&s.requests)
+}
+
+// 記録したタスクごとの処理開始から完了までの時間を他の記録に合算する
+func (s *RunStats) mergeServices(into *latencySketch) {
+	s.serviceMu.Lock()
+	defer s.serviceMu.Unlock()
+	into.merge(&s.services)
+}
+
+func (e Env) context() context.Context {
+	if e.Ctx == nil {
+		return context.Background()
+	}
+	return e.Ctx
+}
+
+// 破棄したタスクを数える
+func (e Env) drop(n int) {
+	if e.Stats != nil {
+		e.Stats.Dropped.Add(int64(n))
+	}
+}
+
+// 重複排除したタスクを数える
+func (e Env) dedup(n int) {
+	if e.Stats != nil {
+		e.Stats.Deduplicated.Add(int64(n))
+	}
+}
+
+// 待機の超過時間を数える
+func (e Env) overshoot(d time.Duration) {
+	if e.Stats != nil {
+		e.Stats.SleepOvershoot.Add(int64(d))
+		e.Stats.Sleeps.Add(1)
+	}
+}
+
+// 立ち上がりの遅れを記録する（最大値を残す）
+func (e Env) rampUp(d time.Duration) {
+	if e.Stats == nil {
+		return
+	}
+	for {
+		cur := e.Stats.RampUp.Load()
+		if int64(d) <= cur || e.Stats.RampUp.CompareAndSwap(cur, int64(d)) {
+			return
+		}
+	}
+}
+
+// セマフォの取得を要求した回数を数える
+func (e Env) acquired(n int64) {
+	if e.Stats != nil {
+		e.Stats.Acquires.Add(n)
+	}
+}
+
+// ディスパッチャーのgoroutineがタスクの受け渡しに使った時間。
+// ディスパッチャーのCPU時間の近似として、チャネルからの受信とセマフォの空きを待つ時間（CPUを使わない待ち）を除いて合計する
+type dispatchTime struct {
+	enabled bool
+	busy    atomic.Int64
+	tasks   atomic.Int64
+}
+
+// タスクの処理がタイムアウトしたことを数える
+func (e Env) timedOut() {
+	if e.Stats != nil {
+		e.Stats.TimedOut.Add(1)
+	}
+}
+
+// ディスパッチャーがタスクの受け渡しを始めた時刻（計測しない場合はゼロ値）
+func (e Env) dispatchStart() time.Time {
+	if e.Stats == nil || !e.Stats.Dispatch.enabled {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// ディスパッチャーがタスクの受け渡しを終えたことを記録する
+func (e Env) dispatched(start time.Time) {
+	if !start.IsZero() {
+		e.Stats.Dispatch.busy.Add(int64(time.Since(start)))
+		e.Stats.Dispatch.tasks.Add(1)
+	}
+}
+
+// キャンセルの伝播にかかった時間を記録する
+func (e Env) tornDown(d time.Duration) {
+	if e.Stats != nil {
+		e.Stats.Teardown.Add(int64(d))
+	}
+}
+
+// 処理を終えてプールを残したまま待機している間のメモリ使用量を記録する
+func (e Env) idle() {
+	if e.Stats != nil && e.Stats.Idle.enabled {
+		e.Stats.Idle.mem = readIdleMem()
+		e.Stats.Idle.recorded = true
+	}
+}
+
+// 供給元を読み切るまでの時間を記録する
+func (e Env) submitted(d time.Duration) {
+	if e.Stats != nil {
+		e.Stats.Submit.Add(int64(d))
+	}
+}
+
+// 予定時刻から処理開始までの遅れを記録する
+func (e Env) jitter(d time.Duration) {
+	if e.Stats != nil {
+		e.Stats.jitterMu.Lock()
+		e.Stats.jitters.add(d)
+		e.Stats.jitterMu.Unlock()
+	}
+}
+
+// 要求の送信から応答の受信までの時間を記録する
+func (e Env) requestLatency(d time.Duration) {
+	if e.Stats != nil {
+		e.Stats.requestMu.Lock()
+		e.Stats.requests.add(d)
+		e.Stats.requestMu.Unlock()
+	}
+}
+
+// タスクの処理開始から完了までの時間を記録する
+func (e Env) serviceTime(d time.Duration) {
+	if e.Stats != nil {
+		e.Stats.serviceMu.Lock()
+		e.Stats.services.add(d)
+		e.Stats.serviceMu.Unlock()
+	}
+}
+
+// 処理の開始時に、タスクの予定時刻からの遅れを記録するよう処理関数をラップする
+func withJitter(env Env) Env {
+	process := env.Process
+	env.Process = func(task Task) error {
+		env.jitter(time.Since(task.Scheduled))
+		return process(task)
+	}
+	return env
+}
+
+// 指定数のタスクを一括で処理するための実行環境
+func BatchEnv(n int) Env {
+	return batchEnv(n, sprintfData)
+}
+
+// データの生成方法を指定して、指定数のタスクを一括で処理するための実行環境を作成する
+func batchEnv(n int, data dataGen) Env {
+	return Env{
+		Source:  &batchSource{n: n, data: data},
+		Process: processTask,
+		Tasks:   n,
+	}
+}
+
+// 指定数のタスクを待ち時間なしで供給する
+type batchSource struct {
+	n    int
+	data dataGen
+	next int
+}
+
+func (s *batchSource) Next() (Task, bool) {
+	if s.next >= s.n {
+		return Task{}, false
+	}
+	i := s.next
+	s.next++
+	return Task{
+		ID:   i,
+		Data: s.data(i),
+	}, true
+}
+
+// 一定の投入レートでタスクを供給する（処理の完了を待たない開ループ）
+type rateSource struct {
+	interval time.Duration
+	start    time.Time
+	end      time.Time
+	data     dataGen
+	next     int
+}
+
+// rate（タスク/秒）でduration の間タスクを供給する供給元を作成する
+func newRateSource(rate float64, duration time.Duration, data dataGen) *rateSource {
+	start := time.Now()
+	return &rateSource{
+		interval: time.Duration(float64(time.Second) / rate),
+		start:    start,
+		end:      start.Add(duration),
+		data:     data,
+	}
+}
+
+func (s *rateSource) Next() (Task, bool) {
+	at := s.start.Add(time.Duration(s.next) * s.interval)
+	if !at.Before(s.end) {
+		return Task{}, false
+	}
+	// 予定時刻まで待つ（遅れている場合は即座に送り出す）
+	if d := time.Until(at); d > 0 {
+		time.Sleep(d)
+	}
+	i := s.next
+	s.next++
+	return Task{
+		ID:        i,
+		Data:      s.data(i),
+		Scheduled: at,
+		Sent:      time.Now(),
+	}, true
+}
+
+// 一定数のクライアントが、前のタスクの完了を待ってから次のタスクを供給する（閉ループ）
+type closedSource struct {
+	slots chan struct{}
+	end   time.Time
+	data  dataGen
+	next  int
+	// キャンセルされたら空きを待つのをやめる（nilの場合はやめない）
+	ctx context.Context
+}
+
+// clients個のクライアントでduration の間タスクを供給する供給元を作成する
+func newClosedSource(ctx context.Context, clients int, duration time.Duration, data dataGen) *closedSource {
+	slots := make(chan struct{}, clients)
+	for i := 0; i < clients; i++ {
+		slots <- struct{}{}
+	}
+	return &closedSource{
+		slots: slots,
+		end:   time.Now().Add(duration),
+		data:  data,
+		ctx:   ctx,
+	}
+}
+
+func (s *closedSource) Next() (Task, bool) {
+	if !time.Now().Before(s.end) {
+		return Task{}, false
+	}
+	// 空いているクライアントが出るまで待つ。
+	// 戦略が破棄したタスクのクライアントは空きに戻らないため、期間の終わりかキャンセルで待つのをやめる
+	select {
+	case <-s.slots:
+	default:
+		var done <-chan struct{}
+		if s.ctx != nil {
+			done = s.ctx.Done()
+		}
+		t := time.NewTimer(time.Until(s.end))
+		defer t.Stop()
+		select {
+		case <-s.slots:
+		case <-t.C:
+			return Task{}, false
+		case <-done:
+			return Task{}, false
+		}
+	}
+	if !time.Now().Before(s.end) {
+		s.slots <- struct{}{}
+		return Task{}, false
+	}
+	i := s.next
+	s.next++
+	return Task{
+		ID:   i,
+		Data: s.data(i),
+		Sent: time.Now(),
+	}, true
+}
+
+// 処理関数をラップし、タスクの完了時にクライアントを空きに戻す
+func (s *closedSource) wrap(process func(Task) error) func(Task) error {
+	return func(task Task) error {
+		err := process(task)
+		s.slots <- struct{}{}
+		return err
+	}
+}
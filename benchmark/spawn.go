@@ -0,0 +1,177 @@
+package benchmark
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ワーカーの起動方法のシナリオのワーカー数の上限
+const spawnWorkers = 256
+
+// ワーカーの起動方法のシナリオの負荷。平均の到着レートはどちらも同じ
+var spawnLoads = []struct {
+	name, note string
+	wrap       func(Source) Source
+}{
+	{"steady", fmt.Sprintf("%v間隔で1つずつ到着", burstGap/burstSize), func(src Source) Source {
+		return &pacedSource{src: src, interval: burstGap / burstSize}
+	}},
+	{"burst", fmt.Sprintf("%d個ずつ%v間隔で一斉に到着", burstSize, burstGap), func(src Source) Source {
+		return &burstySource{src: src}
+	}},
+}
+
+// ワーカーの起動方法のシナリオの戦略一覧。
+// 全てのワーカーを最初に起動しておくプールと、待機中のワーカーがいない場合だけ上限まで追加で起動するプールを、
+// 一定の間隔で到着する負荷と一斉に到着する負荷で比べる。最初のワーカー数分のタスクの予定時刻から処理開始までの
+// 遅れの最大を立ち上がりの遅れとして記録する
+func spawnStrategies(p Params) []Strategy {
+	pools := []Strategy{
+		{
+			Name:  "eager",
+			Title: fmt.Sprintf("全てのワーカー（%d）を最初に起動するプール", spawnWorkers),
+			Func:  "EagerPool",
+			Limit: spawnWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: spawnWorkers, From: "chan Task（バッファなし）", Note: "タスクの到着前に起動"},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return EagerPool(env, spawnWorkers)
+			},
+		},
+		{
+			Name:  "lazy",
+			Title: fmt.Sprintf("待機中のワーカーがいない場合だけワーカーを追加で起動するプール（最大%d）", spawnWorkers),
+			Func:  "LazyPool",
+			Limit: spawnWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, Goroutines: spawnWorkers, From: "chan Task（バッファなし）", Note: fmt.Sprintf("受け取るワーカーがいなければ起動し、最初のタスクを渡す（最大%d）", spawnWorkers)},
+				},
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return LazyPool(env, spawnWorkers)
+			},
+		},
+	}
+
+	var list []Strategy
+	for _, load := range spawnLoads {
+		for _, s := range pools {
+			run, wrap := s.Run, load.wrap
+			s.Name = "spawn-" + s.Name + "-" + load.name
+			s.Title += "（" + load.note + "）"
+			s.Pace = burstGap / burstSize
+			s.Tasks = burstTasks
+			s.Params = map[string]string{"load": load.name}
+			stages := append([]Stage(nil), s.Topology.Stages...)
+			stages[0].Note = load.note
+			s.Topology.Stages = stages
+			s.Run = func(env Env) error {
+				env.Source = wrap(env.Source)
+				return run(withRampUp(env, spawnWorkers))
+			}
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// 予定時刻から処理開始までの遅れを記録し、最初のn個のタスクの遅れの最大を立ち上がりの遅れとして記録するよう
+// 処理関数をラップする
+func withRampUp(env Env, n int) Env {
+	process := env.Process
+	env.Process = func(task Task) error {
+		d := time.Since(task.Scheduled)
+		env.jitter(d)
+		if task.ID < n {
+			env.rampUp(d)
+		}
+		return process(task)
+	}
+	return env
+}
+
+// 全てのワーカーを最初に起動し、バッファのないチャネルでタスクを渡す
+func EagerPool(env Env, numWorkers int) error {
+	var mu sync.Mutex
+	var firstErr error
+	record := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	tasks := make(chan Task)
+	runPool(env, tasks, numWorkers, &wg, record)
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		tasks <- task
+	}
+	close(tasks)
+	wg.Wait()
+	return firstErr
+}
+
+// 待機中のワーカーにタスクを渡せない場合だけ、上限までワーカーを起動して最初のタスクを渡す。
+// 上限に達した後は、いずれかのワーカーが受け取るまで待つ。起動したワーカーは最後まで残す
+func LazyPool(env Env, maxWorkers int) error {
+	var mu sync.Mutex
+	var firstErr error
+	record := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	tasks := make(chan Task)
+	spawned := 0
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		// バッファがないため、送信できるのは受信を待っているワーカーがいる場合だけ
+		select {
+		case tasks <- task:
+			continue
+		default:
+		}
+		if spawned == maxWorkers {
+			tasks <- task
+			continue
+		}
+		spawned++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := env.Process(task); err != nil {
+				record(err)
+			}
+			for task := range tasks {
+				if err := env.Process(task); err != nil {
+					record(err)
+				}
+			}
+		}()
+	}
+	close(tasks)
+	wg.Wait()
+	return firstErr
+}
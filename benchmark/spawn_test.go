@@ -0,0 +1,83 @@
+package benchmark
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// どのプールも全てのタスクを処理し、ワーカー数の上限を超えて同時に処理しないことを確認する
+func TestSpawnPools(t *testing.T) {
+	const n, workers = 200, 4
+	for _, pool := range []struct {
+		name string
+		run  func(Env, int) error
+	}{
+		{"eager", EagerPool},
+		{"lazy", LazyPool},
+	} {
+		t.Run(pool.name, func(t *testing.T) {
+			var completed, running, peak atomic.Int64
+			env := BatchEnv(n)
+			env.Process = func(task Task) error {
+				cur := running.Add(1)
+				for {
+					p := peak.Load()
+					if cur <= p || peak.CompareAndSwap(p, cur) {
+						break
+					}
+				}
+				time.Sleep(100 * time.Microsecond)
+				running.Add(-1)
+				completed.Add(1)
+				return nil
+			}
+			if err := pool.run(env, workers); err != nil {
+				t.Fatal(err)
+			}
+			if got := completed.Load(); got != n {
+				t.Errorf("processed %d tasks, want %d", got, n)
+			}
+			if got := peak.Load(); got > workers {
+				t.Errorf("ran %d tasks at once, want at most %d", got, workers)
+			}
+		})
+	}
+}
+
+// 処理を待たずに終わるタスクだけなら、需要に応じて起動するプールは上限までワーカーを起動しないことを確認する
+func TestLazyPoolSpawnsOnDemand(t *testing.T) {
+	env := BatchEnv(50)
+	env.Source = &pacedSource{src: env.Source, interval: time.Millisecond}
+	env.Process = func(Task) error { return nil }
+	before, ok := readGoroutinesCreated()
+	if !ok {
+		t.Skip("goroutine creation count is not available")
+	}
+	if err := LazyPool(env, 16); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := readGoroutinesCreated()
+	// タスクの間隔（1ms）より処理がずっと短いため、ほとんどのタスクは待機中のワーカーが受け取る
+	if created := after - before; created >= 16 {
+		t.Errorf("created %d goroutines, want fewer than the limit of 16", created)
+	}
+}
+
+// 最初のn個のタスクの遅れの最大だけを立ち上がりの遅れとして記録することを確認する
+func TestWithRampUp(t *testing.T) {
+	env := withRampUp(Env{Stats: &RunStats{}, Process: func(Task) error { return nil }}, 2)
+	now := time.Now()
+	for i, late := range []time.Duration{time.Millisecond, 3 * time.Millisecond, time.Second} {
+		if err := env.Process(Task{ID: i, Scheduled: now.Add(-late)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := time.Duration(env.Stats.RampUp.Load())
+	if got < 3*time.Millisecond || got >= time.Second {
+		t.Errorf("ramp-up = %v, want the delay of the second task", got)
+	}
+	if count := env.Stats.jitterSummary().Count; count != 3 {
+		t.Errorf("recorded %d delays, want 3", count)
+	}
+}
@@ -0,0 +1,161 @@
+//go:build sqlite
+
+package benchmark
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS results (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	schema_version INTEGER NOT NULL,
+	time           TEXT NOT NULL,
+	mode           TEXT NOT NULL,
+	scenario       TEXT NOT NULL,
+	strategy       TEXT NOT NULL,
+	title          TEXT NOT NULL,
+	params         TEXT NOT NULL,
+	samples_ns     TEXT NOT NULL,
+	notes          TEXT NOT NULL,
+	artifacts      TEXT NOT NULL,
+	environment    TEXT NOT NULL,
+	provenance     TEXT NOT NULL DEFAULT '{}'
+);
+CREATE TABLE IF NOT EXISTS metrics (
+	result_id INTEGER NOT NULL REFERENCES results(id),
+	name      TEXT NOT NULL,
+	value     REAL NOT NULL
+);
+`
+
+// 結果をSQLiteのデータベースに追記する。
+// 複数回の実行結果を1つのファイルに蓄積して、後からSQLで比較できるようにする
+type sqliteReporter struct {
+	db *sql.DB
+}
+
+func newSQLiteReporter(target string, _ io.Writer) (Reporter, error) {
+	if target == "" {
+		return nil, errors.New("database file is required (e.g. sqlite=results.db)")
+	}
+	db, err := sql.Open("sqlite3", target)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	// 結果の出どころの列がない古いデータベースには列を追加する
+	if _, err := db.Exec(`ALTER TABLE results ADD COLUMN provenance TEXT NOT NULL DEFAULT '{}'`); err != nil &&
+		!strings.Contains(err.Error(), "duplicate column") {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteReporter{db: db}, nil
+}
+
+func (s *sqliteReporter) Report(r Result) error {
+	cols := make([]any, 0, 6)
+	for _, v := range []any{r.Params, r.Samples, r.Notes, r.Artifacts, r.Environment, r.Provenance} {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		cols = append(cols, string(b))
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := append([]any{r.SchemaVersion, r.Time.Format(time.RFC3339Nano), r.Mode, r.Scenario, r.Strategy, r.Title}, cols...)
+	res, err := tx.Exec(`INSERT INTO results
+		(schema_version, time, mode, scenario, strategy, title, params, samples_ns, notes, artifacts, environment, provenance)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for name, v := range r.Metrics {
+		if _, err := tx.Exec(`INSERT INTO metrics (result_id, name, value) VALUES (?, ?, ?)`, id, name, v); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteReporter) Close() error {
+	return s.db.Close()
+}
+
+// sqliteの出力先に蓄積した結果を読み込む（推移の表示に使う項目のみ）
+func loadSQLiteResults(path string) ([]Result, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT id, schema_version, time, mode, scenario, strategy, title, environment, provenance FROM results ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var results []Result
+	index := map[int64]int{}
+	for rows.Next() {
+		var id int64
+		var r Result
+		var t, env, prov string
+		if err := rows.Scan(&id, &r.SchemaVersion, &t, &r.Mode, &r.Scenario, &r.Strategy, &r.Title, &env, &prov); err != nil {
+			return nil, err
+		}
+		if r.Time, err = time.Parse(time.RFC3339Nano, t); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(env), &r.Environment); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(prov), &r.Provenance); err != nil {
+			return nil, err
+		}
+		r.Metrics = map[string]float64{}
+		index[id] = len(results)
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	metrics, err := db.Query(`SELECT result_id, name, value FROM metrics`)
+	if err != nil {
+		return nil, err
+	}
+	defer metrics.Close()
+	for metrics.Next() {
+		var id int64
+		var name string
+		var v float64
+		if err := metrics.Scan(&id, &name, &v); err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok {
+			results[i].Metrics[name] = v
+		}
+	}
+	return results, metrics.Err()
+}
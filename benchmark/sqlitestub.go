@@ -0,0 +1,17 @@
+//go:build !sqlite
+
+package benchmark
+
+import (
+	"errors"
+	"io"
+)
+
+// SQLiteドライバはcgoを必要とするため、-tags sqliteを付けてビルドした場合のみ有効にする
+func newSQLiteReporter(target string, _ io.Writer) (Reporter, error) {
+	return nil, errors.New("not available in this build (rebuild with -tags sqlite)")
+}
+
+func loadSQLiteResults(path string) ([]Result, error) {
+	return nil, errors.New("reading SQLite is not available in this build (rebuild with -tags sqlite)")
+}
@@ -0,0 +1,181 @@
+package benchmark
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ベンチマーク対象となる実装（戦略）
+type Strategy struct {
+	// コマンドラインから指定する際の識別子
+	Name string
+	// 結果表示に使う説明
+	Title string
+	// 実装している関数名（エスケープ解析の集計に使う）
+	Func string
+	// 実装本体
+	Run func(env Env) error
+	// 同時に処理するタスク数の上限。0は無制限（実行計画の所要時間の見積もりに使う）
+	Limit int
+	// タスクを投入する間隔。0は待ち時間なし（実行計画の所要時間の見積もりに使う）
+	Pace time.Duration
+	// 1回の実行で処理するタスク数。0はnumTasks
+	Tasks int
+	// goroutine、チャネル、同時実行数の制限の構成（describeサブコマンドで表示する）
+	Topology Topology
+	// 結果のパラメータに加える戦略固有の値（シナリオの分析で戦略をまとめるために使う）
+	Params map[string]string
+	// 厳格モードで、途中のタスクで発生させたエラーを返すかを確かめない（途中で供給をやめ、エラーを発生させるタスクまで処理が進まない戦略）
+	SkipErrorPropagation bool
+}
+
+// 1回の実行で処理するタスク数
+func (s Strategy) tasks() int {
+	if s.Tasks > 0 {
+		return s.Tasks
+	}
+	return numTasks
+}
+
+// 戦略の生成に使うパラメータ
+type Params struct {
+	// 並列度を制限する戦略の同時実行数
+	Workers int
+	// dispatchシナリオのチャネルを使う戦略のタスクのチャネルのバッファの大きさ
+	Buffer int
+	// dispatchシナリオのチャネルを使う戦略で、同じタスクのチャネルから受信するディスパッチャーの数（0の場合は1つ）
+	Dispatchers int
+	// semaphoreの取得に失敗したときの方針
+	AcquirePolicy AcquirePolicy
+	// 2段階のディスパッチのシャード数と、シャードごとの同時実行数
+	Shards, ShardLimit int
+	// 途中キャンセルのシナリオで比べる、タスクがctxを確認する間隔（作業の単位数）。空の場合は既定値
+	CheckpointUnits []int
+}
+
+// 2段階のディスパッチのシャードごとの同時実行数のデフォルト
+const defaultShardLimit = 64
+
+// デフォルトのパラメータ（同時実行数とシャード数はCPU数、チャネルのバッファは100、ディスパッチャーは1つ）
+func defaultParams() Params {
+	return Params{
+		Workers:       runtime.NumCPU(),
+		Buffer:        defaultTaskBuffer,
+		Dispatchers:   1,
+		AcquirePolicy: AcquireCount,
+		Shards:        runtime.NumCPU(),
+		ShardLimit:    defaultShardLimit,
+	}
+}
+
+// 比較対象の戦略一覧
+func strategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	dispatchers := max(p.Dispatchers, 1)
+	return []Strategy{
+		{
+			Name:  "chan-unlimited",
+			Title: fmt.Sprintf("チャネル + %s + 無制限の並列処理（errgroup.Go）", dispatcherLabel(dispatchers)),
+			Func:  "ChannelWithUnlimitedParallelism",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleDispatcher, Goroutines: dispatchers, From: chanFrom("Task", p.Buffer)},
+					{Role: RoleWorker, From: "errgroup.Go"},
+				},
+				Completion: "errgroup.Wait（エラーはログに出力するのみ）",
+			},
+			Run: func(env Env) error {
+				return ChannelWithUnlimitedParallelism(env, ChannelOptions{Buffer: p.Buffer, Dispatchers: dispatchers})
+			},
+		},
+		{
+			Name:  "direct-unlimited",
+			Title: "直接goroutine起動 + 無制限の並列処理（errgroup.Go）",
+			Func:  "DirectGoroutineWithUnlimitedParallelism",
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "errgroup.Go"},
+				},
+				Completion: "errgroup.Wait",
+			},
+			Run: DirectGoroutineWithUnlimitedParallelism,
+		},
+		{
+			Name:  "chan-limited",
+			Title: fmt.Sprintf("チャネル + %s + 制限付き並列処理（errgroup.Go + semaphore、%d同時実行）", dispatcherLabel(dispatchers), numWorkers),
+			Func:  "ChannelWithLimitedParallelism",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleDispatcher, Goroutines: dispatchers, From: chanFrom("Task", p.Buffer)},
+					{Role: RoleWorker, From: "errgroup.Go"},
+				},
+				Limiter:    fmt.Sprintf("semaphore.Weighted(%d)（ディスパッチャーが取得）", numWorkers),
+				Completion: "errgroup.Wait（エラーはログに出力するのみ）",
+			},
+			Run: func(env Env) error {
+				return ChannelWithLimitedParallelism(env, numWorkers, ChannelOptions{Buffer: p.Buffer, Dispatchers: dispatchers, Policy: p.AcquirePolicy})
+			},
+		},
+		{
+			Name:  "direct-limited",
+			Title: fmt.Sprintf("直接goroutine起動 + 制限付き並列処理（semaphore、%d同時実行）", numWorkers),
+			Func:  "DirectGoroutineWithLimitedParallelism",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文"},
+				},
+				Limiter:    fmt.Sprintf("semaphore.Weighted(%d)（供給元を読み出すgoroutineが取得）", numWorkers),
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return DirectGoroutineWithLimitedParallelism(env, int64(numWorkers))
+			},
+		},
+		{
+			Name:  "sharded",
+			Title: fmt.Sprintf("シャードごとのチャネル + シャード内で直接goroutine起動（%dシャード × %d同時実行）", p.Shards, p.ShardLimit),
+			Func:  "TwoLevelDispatch",
+			Limit: p.Shards * p.ShardLimit,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleDispatcher, Goroutines: p.Shards, From: chanFrom("Task", 100), Note: "シャードごとに1つ"},
+					{Role: RoleWorker, From: "go文"},
+				},
+				Limiter:    fmt.Sprintf("semaphore.Weighted(%d)（シャードごと）", p.ShardLimit),
+				Completion: "sync.WaitGroup（シャードごと）+ エラーでキャンセル",
+			},
+			Run: func(env Env) error {
+				return TwoLevelDispatch(env, p.Shards, p.ShardLimit)
+			},
+		},
+	}
+}
+
+// 名前から戦略を探す
+func findStrategy(list []Strategy, name string) (Strategy, error) {
+	names := make([]string, 0, len(list))
+	for _, s := range list {
+		if s.Name == name {
+			return s, nil
+		}
+		names = append(names, s.Name)
+	}
+	return Strategy{}, fmt.Errorf("unknown strategy %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// チャネルの戦略のタイトルに使うディスパッチャーの表示
+func dispatcherLabel(n int) string {
+	if n == 1 {
+		return "単一ディスパッチャー"
+	}
+	return fmt.Sprintf("%d個のディスパッチャー", n)
+}
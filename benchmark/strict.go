@@ -0,0 +1,125 @@
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// 厳格モードで、供給した全てのタスクの行方と、処理関数が返したエラーの扱いを1回の実行ごとに確かめる
+type strictCheck struct {
+	// 供給元から読み出したタスク数
+	submitted atomic.Int64
+	// タスクのIDごとに、処理関数を呼び出したか
+	processed []atomic.Bool
+}
+
+// 供給元の読み出しと処理関数の呼び出しを記録するよう実行環境をラップする。タスク数が分かる供給元でのみ使う
+func withStrictCheck(env Env) (Env, *strictCheck) {
+	c := &strictCheck{processed: make([]atomic.Bool, env.Tasks)}
+	env.Source = &strictSource{Source: env.Source, c: c}
+	process := env.Process
+	env.Process = func(task Task) error {
+		if task.ID >= 0 && task.ID < len(c.processed) {
+			c.processed[task.ID].Store(true)
+		}
+		return process(task)
+	}
+	return env, c
+}
+
+type strictSource struct {
+	Source
+	c *strictCheck
+}
+
+func (s *strictSource) Next() (Task, bool) {
+	task, ok := s.Source.Next()
+	if ok {
+		s.c.submitted.Add(1)
+	}
+	return task, ok
+}
+
+// 1回の実行の違反を返す。戦略がエラーを返した場合は途中で打ち切ってよいため、タスクの行方は確かめない
+func (c *strictCheck) violations(env Env, err error) []string {
+	var v []string
+	if n := env.Stats.Dropped.Load(); n > 0 {
+		v = append(v, fmt.Sprintf("%d個のタスクを処理せずに破棄しました", n))
+	}
+	if err != nil {
+		return v
+	}
+	errs := map[ErrorKind]int64{}
+	env.Stats.Errors.addTo(errs)
+	var swallowed int64
+	for _, n := range errs {
+		swallowed += n
+	}
+	if swallowed > 0 {
+		v = append(v, fmt.Sprintf("処理関数が返した%d件のエラーを返さずに完了しました", swallowed))
+	}
+	// 途中でキャンセルした供給元が渡さなかったタスクは、読み出す必要も処理する必要もない
+	unsupplied := env.Stats.Cancel.Unsupplied.Load()
+	if submitted := c.submitted.Load(); submitted+unsupplied < int64(len(c.processed)) {
+		v = append(v, fmt.Sprintf("エラーを返さずに、供給元の%d個のタスクのうち%d個しか読み出しませんでした", len(c.processed), submitted))
+	}
+	unprocessed := int64(0)
+	for i := range c.processed {
+		if !c.processed[i].Load() {
+			unprocessed++
+		}
+	}
+	// 重複排除したタスクは、同じキーのタスクの結果を共有するため処理しなくてよい
+	// 途中でキャンセルに気付いてやめたタスクは、処理関数の呼び出しの前に作業をやめるため処理済みとして記録されない
+	abandoned := env.Stats.Cancel.Abandoned.Load()
+	if lost := unprocessed - env.Stats.Dropped.Load() - env.Stats.Deduplicated.Load() - unsupplied - abandoned; lost > 0 {
+		v = append(v, fmt.Sprintf("%d個のタスクを処理も破棄もせずに完了しました", lost))
+	}
+	return v
+}
+
+// 途中のタスクで致命的なエラーを発生させて戦略を1回実行し、戦略がそのエラーを返すかを確かめる。
+// 計測とは別の実行のため、処理時間には含めない。エラーを返さなかった場合は違反を返す
+func (r *runner) verifyErrorPropagation(s Strategy, n int, data dataGen) (string, error) {
+	env := r.cfg.withProcessing(batchEnv(n, data))
+	env.Stats = &RunStats{}
+	var processed atomic.Int64
+	env.Process = injectFailure(env.Process, n/2, ErrorFatal, &processed)
+	_, err := measure(s, env)
+	switch {
+	case err == nil:
+		return fmt.Sprintf("タスク%dで発生させたエラーを返しませんでした（処理されたタスク: %d）", n/2, processed.Load()), nil
+	case errors.Is(err, errInjectedFailure):
+		return "", nil
+	}
+	return "", err
+}
+
+// 厳格モードの違反を結果に記録する
+func applyStrict(res *Result, violations []string) {
+	res.Metrics[metricStrictViolations] = float64(len(violations))
+	for _, v := range violations {
+		res.Notes = append(res.Notes, "厳格モードの違反: "+v)
+	}
+}
+
+// 違反を重複なく加える
+func addViolations(list []string, v ...string) []string {
+	for _, s := range v {
+		if !slices.Contains(list, s) {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// 厳格モードで違反があった戦略の一覧から、実行を失敗させるエラーを作る。違反がなければnil
+func strictError(failed []string) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("strict mode: %d strategies failed verification: %s", len(failed), strings.Join(failed, ", "))
+}
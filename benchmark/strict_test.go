@@ -0,0 +1,158 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// 全てのタスクを処理し、最初のエラーを返す戦略
+func strictSequential(env Env) error {
+	var firstErr error
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			return firstErr
+		}
+		if err := env.Process(task); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+}
+
+// 処理関数のエラーを無視する戦略
+func strictSwallow(env Env) error {
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			return nil
+		}
+		env.Process(task)
+	}
+}
+
+// タスクの破棄、エラーの握りつぶし、読み残し、行方不明のタスクをそれぞれ違反として返すことを確認する
+func TestStrictViolations(t *testing.T) {
+	failing := errors.New("boom")
+	tests := []struct {
+		name    string
+		run     func(env Env) error
+		process func(Task) error
+		want    []string
+	}{
+		{"correct", strictSequential, nil, nil},
+		{"error returned", strictSequential, func(Task) error { return failing }, nil},
+		{"swallowed", strictSwallow, func(task Task) error {
+			if task.ID == 3 {
+				return failing
+			}
+			return nil
+		}, []string{"1件のエラー"}},
+		{"dropped", func(env Env) error {
+			for {
+				task, ok := env.Source.Next()
+				if !ok {
+					return nil
+				}
+				if task.ID%2 == 0 {
+					env.Stats.Dropped.Add(1)
+					continue
+				}
+				env.Process(task)
+			}
+		}, nil, []string{"5個のタスクを処理せずに破棄"}},
+		{"unread", func(env Env) error {
+			for range 4 {
+				task, _ := env.Source.Next()
+				env.Process(task)
+			}
+			return nil
+		}, nil, []string{"10個のタスクのうち4個しか読み出しません", "6個のタスクを処理も破棄もせずに"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := batchEnv(10, sprintfData)
+			env.Stats = &RunStats{}
+			if tt.process != nil {
+				env.Process = tt.process
+			}
+			env, c := withStrictCheck(env)
+			env = countErrors(env)
+			err := tt.run(env)
+			got := c.violations(env, err)
+			if len(got) != len(tt.want) {
+				t.Fatalf("violations = %q, want %d", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("violation %d = %q, want it to contain %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+// 厳格モードで、エラーを握りつぶす戦略にだけ違反を記録し、実行を失敗させることを確認する
+func TestRunStrict(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Strict = true
+	cfg.Repetitions = 1
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}}
+
+	good, err := r.runRepeated(Strategy{Name: "good", Tasks: 100, Run: strictSequential})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := good.Metrics[metricStrictViolations]; v != 0 {
+		t.Errorf("violations of a correct strategy = %v, notes %q", v, good.Notes)
+	}
+
+	bad, err := r.runRepeated(Strategy{Name: "bad", Tasks: 100, Run: strictSwallow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := bad.Metrics[metricStrictViolations]; v != 1 {
+		t.Fatalf("violations of a swallowing strategy = %v, want 1", v)
+	}
+	if len(bad.Notes) == 0 || !strings.Contains(bad.Notes[len(bad.Notes)-1], "タスク50で発生させたエラーを返しませんでした") {
+		t.Errorf("notes = %q", bad.Notes)
+	}
+
+	if err := strictError(nil); err != nil {
+		t.Errorf("strictError(nil) = %v", err)
+	}
+	err = strictError([]string{"default/bad"})
+	if err == nil || !strings.Contains(err.Error(), "default/bad") {
+		t.Errorf("strictError = %v", err)
+	}
+}
+
+// 既定のシナリオでは、処理関数のエラーをログに出力して続ける現行の実装（chan-unlimited、chan-limited、direct-limited）
+// だけが厳格モードの検証に失敗することを確認する。ハーネスの誤りではなく、これらの実装がエラーを返さないため
+func TestStrictDefaultScenario(t *testing.T) {
+	sc, err := findScenario(defaultScenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.Strict = true
+	cfg.Repetitions = 1
+	cfg.Tasks = 200
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}, scenario: sc}
+	var failed []string
+	for _, s := range cfg.strategies(sc) {
+		res, err := r.runRepeated(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s.Name, err)
+		}
+		if res.Metrics[metricStrictViolations] > 0 {
+			failed = append(failed, s.Name)
+		}
+	}
+	want := []string{"chan-unlimited", "chan-limited", "direct-limited"}
+	if !slices.Equal(failed, want) {
+		t.Errorf("strategies failing strict mode = %v, want %v", failed, want)
+	}
+}
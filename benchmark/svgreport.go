@@ -0,0 +1,43 @@
+package benchmark
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// HTMLレポートと同じグラフ（処理時間、並列度ごとのスループット、レイテンシの分布）を、
+// 実行の最後にディレクトリへ1つずつSVGファイルとして書き出す。スライドや文書に貼り付けられる
+type svgReporter struct {
+	dir     string
+	results []Result
+}
+
+func newSVGReporter(target string, _ io.Writer) (Reporter, error) {
+	if target == "" {
+		return nil, errors.New("output directory is required (e.g. svg=charts)")
+	}
+	return &svgReporter{dir: target}, nil
+}
+
+func (s *svgReporter) Report(r Result) error {
+	s.results = append(s.results, r)
+	return nil
+}
+
+func (s *svgReporter) Close() error {
+	charts := resultCharts(s.results)
+	if len(charts) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	for _, c := range charts {
+		if err := os.WriteFile(filepath.Join(s.dir, c.File), []byte(c.chart.standalone(c.Title)), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package benchmark
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// グラフごとにタイトル付きの単独のSVGファイルを書き出すことを確認する
+func TestSVGReporter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "charts")
+	rep, err := newSVGReporter(dir, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []Result{testResult("chan-unlimited", 2e9), testResult("direct-unlimited", 1e9)} {
+		if err := rep.Report(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.ReadFile(filepath.Join(dir, defaultScenario+"-duration.svg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := string(out)
+	for _, want := range []string{`<?xml version="1.0"`, `xmlns="http://www.w3.org/2000/svg"`, defaultScenario + " / 処理時間", "direct-unlimited"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("missing %q in:\n%s", want, svg)
+		}
+	}
+	if _, err := newSVGReporter("", io.Discard); err == nil {
+		t.Error("expected an error without an output directory")
+	}
+}
@@ -0,0 +1,108 @@
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// タスク数を増やしたときの処理時間の伸びを超線形とみなすスケーリング指数。
+// 処理時間がタスク数のこの指数乗より速く伸びた場合に、GCやスケジューラーの負荷による劣化を疑う
+const superlinearExponent = 1.2
+
+// スイープの既定のタスク数（対数スケール）
+var defaultSweepTasks = []int{1000, 10000, 100000, 1000000}
+
+// タスク数のスイープの設定
+type SweepConfig struct {
+	// タスク数のスイープを実行するか
+	Enabled bool
+	// 各戦略を実行するタスク数（昇順）
+	Tasks []int
+}
+
+func (c SweepConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if len(c.Tasks) < 2 {
+		return fmt.Errorf("sweep requires at least 2 task counts, got %d", len(c.Tasks))
+	}
+	for i, n := range c.Tasks {
+		if n < 1 {
+			return fmt.Errorf("sweep task count must be at least 1, got %d", n)
+		}
+		if i > 0 && n <= c.Tasks[i-1] {
+			return fmt.Errorf("sweep task counts must be in ascending order, got %d after %d", n, c.Tasks[i-1])
+		}
+	}
+	return nil
+}
+
+// 各戦略をスイープのタスク数ごとに繰り返し実行し、タスク数に対する処理時間の伸びを求める
+func (r *runner) runSweep(list []Strategy) error {
+	r.out.sweepStart(r.cfg.Sweep)
+	for i, s := range list {
+		if i > 0 {
+			r.cd.wait()
+		}
+		r.out.strategyStart(i, s)
+		var prev Result
+		for j, n := range r.cfg.Sweep.Tasks {
+			if j > 0 {
+				r.cd.wait()
+			}
+			if r.ctx.Err() != nil {
+				return ErrInterrupted
+			}
+			sized := s
+			sized.Tasks = n
+			// 前のタスク数の処理時間からタスク数に比例すると見積もり、シナリオに割り当てた時間に
+			// 収まらない場合は残りのタスク数を実行せずに記録する
+			if j > 0 && !r.budget.fits(sweepEstimate(prev, n, r.cfg.Repetitions)) {
+				for _, rest := range r.cfg.Sweep.Tasks[j:] {
+					sized.Tasks = rest
+					skipped := r.skippedResult(sized, fmt.Sprintf("タスク数%dは実行時間の上限（%v）に収まらないため省略しました", rest, r.cfg.MaxDuration))
+					skipped.Params["tasks"] = itoa(rest)
+					skipped.Mode = modeSweep
+					if err := r.report(skipped); err != nil {
+						return err
+					}
+				}
+				break
+			}
+			res, err := r.runRepeated(sized)
+			if err != nil {
+				return err
+			}
+			res.Mode = modeSweep
+			if j > 0 {
+				applyScaling(&res, prev)
+			}
+			if err := r.report(res); err != nil {
+				return err
+			}
+			prev = res
+		}
+	}
+	return nil
+}
+
+// 前のタスク数の処理時間から、n個のタスクを繰り返し実行する時間をタスク数に比例するとして見積もる
+func sweepEstimate(prev Result, n, reps int) time.Duration {
+	perTask := prev.Metrics[metricWall] / prev.Metrics[metricTasks]
+	return time.Duration(perTask * float64(n) * float64(reps))
+}
+
+// 前のタスク数の結果からのスケーリング指数（処理時間の比の対数 / タスク数の比の対数）を求め、
+// 超線形に劣化したかを記録する。指数1は線形、1未満は並列化による償却を表す
+func applyScaling(res *Result, prev Result) {
+	tasks := res.Metrics[metricTasks] / prev.Metrics[metricTasks]
+	wall := res.Metrics[metricWall] / prev.Metrics[metricWall]
+	if tasks <= 1 || wall <= 0 {
+		return
+	}
+	exp := math.Log(wall) / math.Log(tasks)
+	res.Metrics[metricScalingExponent] = exp
+	res.Metrics[metricSuperlinear] = boolMetric(exp > superlinearExponent)
+}
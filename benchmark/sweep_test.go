@@ -0,0 +1,99 @@
+package benchmark
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// 受け取った結果を保持する出力先
+type collectReporter struct {
+	results []Result
+}
+
+func (c *collectReporter) Report(r Result) error {
+	c.results = append(c.results, r)
+	return nil
+}
+
+func (c *collectReporter) Close() error { return nil }
+
+func TestApplyScaling(t *testing.T) {
+	cases := []struct {
+		wall        float64
+		wantExp     float64
+		superlinear bool
+	}{
+		{1000, 1, false},
+		{100, 0, false},
+		{2000, math.Log10(20), true},
+	}
+	for _, c := range cases {
+		prev := Result{Metrics: map[string]float64{metricTasks: 1000, metricWall: 100}}
+		res := Result{Metrics: map[string]float64{metricTasks: 10000, metricWall: c.wall}}
+		applyScaling(&res, prev)
+		if got := res.Metrics[metricScalingExponent]; math.Abs(got-c.wantExp) > 1e-9 {
+			t.Errorf("wall %v: exponent = %v, want %v", c.wall, got, c.wantExp)
+		}
+		if got := res.flag(metricSuperlinear); got != c.superlinear {
+			t.Errorf("wall %v: superlinear = %v, want %v", c.wall, got, c.superlinear)
+		}
+	}
+}
+
+func TestSweepConfigValidate(t *testing.T) {
+	for _, tasks := range [][]int{{1000}, {1000, 100}, {0, 10}, {10, 10}} {
+		if err := (SweepConfig{Enabled: true, Tasks: tasks}).validate(); err == nil {
+			t.Errorf("%v: want error", tasks)
+		}
+	}
+	if err := (SweepConfig{Enabled: true, Tasks: defaultSweepTasks}).validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+// 各戦略がスイープのタスク数ごとに実行され、2つ目以降にスケーリング指数が記録されることを確認する
+func TestRunSweep(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Sweep = SweepConfig{Enabled: true, Tasks: []int{10, 100, 1000}}
+	rep := &collectReporter{}
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}, reporters: []Reporter{rep}}
+
+	var sizes []int
+	s := Strategy{Name: "count", Run: func(env Env) error {
+		n := 0
+		for {
+			if _, ok := env.Source.Next(); !ok {
+				break
+			}
+			n++
+		}
+		sizes = append(sizes, n)
+		return nil
+	}}
+	if err := r.runSweep([]Strategy{s}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sizes) != 3 || sizes[0] != 10 || sizes[1] != 100 || sizes[2] != 1000 {
+		t.Errorf("ran with %v tasks, want [10 100 1000]", sizes)
+	}
+	if len(rep.results) != 3 {
+		t.Fatalf("got %d results, want 3", len(rep.results))
+	}
+	for i, res := range rep.results {
+		if res.Mode != modeSweep {
+			t.Errorf("result %d: mode = %q, want %q", i, res.Mode, modeSweep)
+		}
+		_, ok := res.Metrics[metricScalingExponent]
+		if ok != (i > 0) {
+			t.Errorf("result %d: has scaling exponent = %v, want %v", i, ok, i > 0)
+		}
+		if got, want := res.Metrics[metricNsPerTask], res.Metrics[metricWall]/res.Metrics[metricTasks]; got != want {
+			t.Errorf("result %d: ns per task = %v, want %v", i, got, want)
+		}
+		if res.Metrics[metricThroughput] <= 0 {
+			t.Errorf("result %d: throughput was not recorded", i)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package benchmark
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// タスクのデータ（Task.Data）の生成方法
+type TaskData string
+
+const (
+	// タスクごとにfmt.Sprintfで生成する（従来の動作）
+	DataSprintf TaskData = "sprintf"
+	// データを持たない
+	DataNone TaskData = "none"
+	// 実行前に生成した文字列を使い回し、供給時には割り当てを行わない
+	DataPrealloc TaskData = "prealloc"
+	// タスクごとに指定サイズのランダムなバイト列を生成する
+	DataBytes TaskData = "bytes"
+)
+
+var taskDataKinds = []TaskData{DataSprintf, DataNone, DataPrealloc, DataBytes}
+
+// DataPreallocで事前に生成する文字列の数。タスクIDの剰余で使い回す
+const preallocData = numTasks
+
+func (d TaskData) validate() error {
+	names := make([]string, 0, len(taskDataKinds))
+	for _, known := range taskDataKinds {
+		if d == known {
+			return nil
+		}
+		names = append(names, string(known))
+	}
+	return fmt.Errorf("unknown task data %q (available: %s)", d, strings.Join(names, ", "))
+}
+
+// 結果のパラメータに記録する表記（bytesの場合はサイズを含める）
+func (d TaskData) label(size int) string {
+	if d == DataBytes {
+		return fmt.Sprintf("%s(%d)", d, size)
+	}
+	return string(d)
+}
+
+// タスクIDからタスクのデータを生成する関数。
+// 供給元のNextからのみ呼ばれるため、並行して呼ばれることはない
+type dataGen func(id int) string
+
+// 従来どおりタスクごとにfmt.Sprintfでデータを生成する
+func sprintfData(id int) string {
+	return fmt.Sprintf("Task data %d", id)
+}
+
+// 生成方法に応じたデータの生成関数を作成する。
+// DataPreallocの文字列はここで生成するため、計測の開始前に呼び出す
+func newDataGen(kind TaskData, size int) dataGen {
+	switch kind {
+	case DataNone:
+		return func(int) string { return "" }
+	case DataPrealloc:
+		pool := make([]string, preallocData)
+		for i := range pool {
+			pool[i] = sprintfData(i)
+		}
+		return func(id int) string { return pool[id%len(pool)] }
+	case DataBytes:
+		// 乱数の生成が計測を乱さないよう、暗号用でない高速な生成器を使う
+		rng := rand.New(rand.NewPCG(1, 2))
+		buf := make([]byte, size)
+		return func(int) string {
+			for i := 0; i < len(buf); i += 8 {
+				v := rng.Uint64()
+				for j := i; j < min(i+8, len(buf)); j++ {
+					buf[j] = byte(v)
+					v >>= 8
+				}
+			}
+			// 文字列への変換で、タスクごとにsizeバイトの割り当てが1回発生する
+			return string(buf)
+		}
+	}
+	return sprintfData
+}
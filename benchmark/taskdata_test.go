@@ -0,0 +1,77 @@
+package benchmark
+
+import (
+	"testing"
+)
+
+// 生成方法ごとに、供給されるタスクのデータを確認する
+func TestBatchEnvTaskData(t *testing.T) {
+	cases := []struct {
+		kind TaskData
+		want func(t *testing.T, id int, data string)
+	}{
+		{DataSprintf, func(t *testing.T, id int, data string) {
+			if data != sprintfData(id) {
+				t.Errorf("task %d: data = %q, want %q", id, data, sprintfData(id))
+			}
+		}},
+		{DataNone, func(t *testing.T, id int, data string) {
+			if data != "" {
+				t.Errorf("task %d: data = %q, want empty", id, data)
+			}
+		}},
+		{DataPrealloc, func(t *testing.T, id int, data string) {
+			if data != sprintfData(id) {
+				t.Errorf("task %d: data = %q, want %q", id, data, sprintfData(id))
+			}
+		}},
+		{DataBytes, func(t *testing.T, id int, data string) {
+			if len(data) != 13 {
+				t.Errorf("task %d: len(data) = %d, want 13", id, len(data))
+			}
+		}},
+	}
+	for _, c := range cases {
+		t.Run(string(c.kind), func(t *testing.T) {
+			env := batchEnv(100, newDataGen(c.kind, 13))
+			var prev string
+			for {
+				task, ok := env.Source.Next()
+				if !ok {
+					break
+				}
+				c.want(t, task.ID, task.Data)
+				if c.kind == DataBytes && task.ID > 0 && task.Data == prev {
+					t.Errorf("task %d: data is the same as the previous task", task.ID)
+				}
+				prev = task.Data
+			}
+		})
+	}
+}
+
+// 事前に生成する方法では、供給時に割り当てが発生しないことを確認する
+func TestPreallocDataAllocs(t *testing.T) {
+	gen := newDataGen(DataPrealloc, 0)
+	id := 0
+	allocs := testing.AllocsPerRun(1000, func() {
+		_ = gen(id)
+		id++
+	})
+	if allocs != 0 {
+		t.Errorf("allocs per task = %v, want 0", allocs)
+	}
+}
+
+func TestTaskDataValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TaskData = "json"
+	if err := cfg.validate(); err == nil {
+		t.Error("unknown task data: want error")
+	}
+	cfg.TaskData = DataBytes
+	cfg.TaskDataSize = 0
+	if err := cfg.validate(); err == nil {
+		t.Error("bytes with size 0: want error")
+	}
+}
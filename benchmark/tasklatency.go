@@ -0,0 +1,61 @@
+package benchmark
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// 供給元から読み出されてから処理を終えるまでの時間（チャネルやセマフォでの待ちと処理）をタスクごとに記録する。
+// 計測中はタスクのIDごとの配列に読み出しと完了の時刻を書くだけにしてロックを取らず、集計は計測の外で行う
+type taskLatencies struct {
+	base time.Time
+	// 読み出しと完了の時刻（baseからの経過時間、0は未記録）
+	read, done []atomic.Int64
+}
+
+// 読み出しと完了の時刻を記録するよう実行環境をラップする。タスク数が分かる供給元でのみ使う
+func trackTaskLatency(env Env) (Env, *taskLatencies) {
+	l := &taskLatencies{
+		base: time.Now(),
+		read: make([]atomic.Int64, env.Tasks),
+		done: make([]atomic.Int64, env.Tasks),
+	}
+	env.Source = &taskLatencySource{Source: env.Source, l: l}
+	process := env.Process
+	env.Process = func(task Task) error {
+		err := process(task)
+		l.record(l.done, task.ID)
+		return err
+	}
+	return env, l
+}
+
+// 経過時間を記録する。0と区別できるよう1ns以上にする
+func (l *taskLatencies) record(at []atomic.Int64, id int) {
+	if id >= 0 && id < len(at) {
+		at[id].Store(max(int64(time.Since(l.base)), 1))
+	}
+}
+
+type taskLatencySource struct {
+	Source
+	l *taskLatencies
+}
+
+func (s *taskLatencySource) Next() (Task, bool) {
+	task, ok := s.Source.Next()
+	if ok {
+		s.l.record(s.l.read, task.ID)
+	}
+	return task, ok
+}
+
+// 処理を終えたタスクのレイテンシをスケッチに加える。破棄したタスクや重複排除したタスクは含めない
+func (l *taskLatencies) addTo(s *latencySketch) {
+	for i := range l.done {
+		done, read := l.done[i].Load(), l.read[i].Load()
+		if done != 0 && read != 0 {
+			s.add(time.Duration(done - read))
+		}
+	}
+}
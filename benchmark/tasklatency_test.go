@@ -0,0 +1,61 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// 読み出してから処理を終えるまでの待ちを含めて記録し、処理しなかったタスクは含めないことを確認する
+func TestTrackTaskLatency(t *testing.T) {
+	env := batchEnv(10, sprintfData)
+	env.Process = func(Task) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}
+	env, l := trackTaskLatency(env)
+
+	// 全てのタスクを先に読み出してから順に処理するため、後のタスクほど待ちが長くなる。最後のタスクは処理しない
+	var queued []Task
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+		queued = append(queued, task)
+	}
+	for _, task := range queued[:9] {
+		env.Process(task)
+	}
+
+	var s latencySketch
+	l.addTo(&s)
+	if s.count != 9 {
+		t.Fatalf("recorded %d tasks, want 9", s.count)
+	}
+	if p := s.percentile(50); p < 4*time.Millisecond {
+		t.Errorf("p50 = %v, want at least 4ms of queueing and processing", p)
+	}
+	if p := s.percentile(0); p < time.Millisecond {
+		t.Errorf("min = %v, want at least the processing time", p)
+	}
+}
+
+// タスクごとのレイテンシの分位数とスケッチを結果に記録することを確認する
+func TestRunTaskLatency(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TaskLatency = true
+	cfg.Repetitions = 2
+	r := &runner{ctx: context.Background(), cfg: cfg, cd: &cooldown{}}
+	res, err := r.runRepeated(Strategy{Name: "probe", Tasks: 100, Run: strictSequential})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.Sketches[sketchTaskLatency]; s == nil || s.count != 200 {
+		t.Fatalf("task latency sketch = %+v, want 200 tasks", s)
+	}
+	p50, p95, p99 := res.Metrics[metricTaskLatencyP50], res.Metrics[metricTaskLatencyP95], res.Metrics[metricTaskLatencyP99]
+	if p50 <= 0 || p50 > p95 || p95 > p99 {
+		t.Errorf("percentiles = %v, %v, %v", p50, p95, p99)
+	}
+}
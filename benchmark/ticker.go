@@ -0,0 +1,59 @@
+package benchmark
+
+import (
+	"time"
+)
+
+// ティッカーのシナリオでタスクを生成する間隔と、1回の実行で生成するタスク数
+const (
+	tickInterval = 100 * time.Microsecond
+	tickerTasks  = 10000
+)
+
+// time.Tickerの発火ごとに1つずつタスクを供給する。
+// 発火時刻をタスクの予定時刻とし、受け取りが遅れて発火が間引かれた場合も次の発火時刻を使う
+type tickerSource struct {
+	src      Source
+	interval time.Duration
+	ticker   *time.Ticker
+}
+
+func newTickerSource(src Source, interval time.Duration) *tickerSource {
+	return &tickerSource{src: src, interval: interval}
+}
+
+func (s *tickerSource) Next() (Task, bool) {
+	if s.ticker == nil {
+		// 最初のタスクを要求されたときに動かし始める
+		s.ticker = time.NewTicker(s.interval)
+	}
+	tick := <-s.ticker.C
+	task, ok := s.src.Next()
+	if !ok {
+		s.ticker.Stop()
+		return Task{}, false
+	}
+	task.Scheduled = tick
+	return task, true
+}
+
+// ティッカーのシナリオの戦略一覧（cronやハートビートのような定期実行を模擬する）。
+// dispatchシナリオの各戦略に、一定間隔で生成されるタスクを処理させ、
+// 発火時刻から処理開始までの遅れ（ジッター）を計測する
+func tickerStrategies(p Params) []Strategy {
+	list := strategies(p)
+	for i, s := range list {
+		run := s.Run
+		list[i].Name = "ticker-" + s.Name
+		list[i].Pace = tickInterval
+		list[i].Tasks = tickerTasks
+		stages := append([]Stage(nil), s.Topology.Stages...)
+		stages[0].Note = "time.Tickerの発火ごとに読み出す"
+		list[i].Topology.Stages = stages
+		list[i].Run = func(env Env) error {
+			env.Source = newTickerSource(env.Source, tickInterval)
+			return run(withJitter(env))
+		}
+	}
+	return list
+}
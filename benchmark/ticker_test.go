@@ -0,0 +1,34 @@
+package benchmark
+
+import (
+	"testing"
+)
+
+// 全てのタスクに発火時刻が設定され、処理開始までの遅れが記録されることを確認する
+func TestTickerStrategies(t *testing.T) {
+	const n = 50
+
+	for _, s := range tickerStrategies(Params{Workers: 2, Shards: 2, ShardLimit: 2}) {
+		t.Run(s.Name, func(t *testing.T) {
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			process := env.Process
+			env.Process = func(task Task) error {
+				if task.Scheduled.IsZero() {
+					t.Errorf("task %d has no scheduled time", task.ID)
+				}
+				return process(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			j := env.Stats.jitterSummary()
+			if j.Count != n {
+				t.Fatalf("recorded %d jitters, want %d", j.Count, n)
+			}
+			if j.Min < 0 {
+				t.Errorf("negative jitter %v", j.Min)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package benchmark
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// タイムアウトのシナリオで各タスクに設定するタイムアウト（通常の処理時間より十分に長く、正常時は発火しない）
+	taskTimeout = time.Second
+	// タイムアウトを管理するタイマーホイールの1スロットの時間幅とスロット数（1周がタイムアウトより長くなるようにする）
+	timeoutWheelTick  = 10 * time.Millisecond
+	timeoutWheelSlots = 128
+)
+
+// タスクのタイムアウトの仕組み
+type timeoutKind struct {
+	name  string
+	title string
+	wrap  func(env Env) (Env, func(), error)
+}
+
+var timeoutKinds = []timeoutKind{
+	{name: "after", title: "タスクごとのtime.After（監視goroutineでselect）", wrap: withAfterTimeout},
+	{name: "afterfunc", title: "タスクごとのtime.AfterFunc（完了時にStop）", wrap: withAfterFuncTimeout},
+	{name: "wheel", title: fmt.Sprintf("共有のtime.Tickerで進めるタイマーホイール（%v × %dスロット）", timeoutWheelTick, timeoutWheelSlots), wrap: withWheelTimeout},
+}
+
+// タイムアウトのシナリオの戦略一覧。
+// dispatchシナリオの各戦略で、各タスクにタイムアウトを設定する仕組み（タスクごとのランタイムのタイマー vs
+// 共有のティッカーで進めるタイマーホイール）を比べ、10万個のタイマーの追加と停止のコストが
+// ディスパッチの仕組みごとにどう効くかを計測する
+func timeoutStrategies(p Params) []Strategy {
+	var list []Strategy
+	for _, kind := range timeoutKinds {
+		for _, s := range strategies(p) {
+			run := s.Run
+			s.Name = "timeout-" + kind.name + "-" + s.Name
+			s.Title = s.Title + " + " + kind.title
+			s.Run = func(env Env) error {
+				env, stop, err := kind.wrap(env)
+				if err != nil {
+					return err
+				}
+				defer stop()
+				return run(env)
+			}
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// 各タスクの処理中に、time.Afterとselectで待つ監視goroutineを置く。
+// 処理がタイムアウトまでに終わらなければ監視goroutineがタイムアウトとして数える
+func withAfterTimeout(env Env) (Env, func(), error) {
+	process := env.Process
+	env.Process = func(task Task) error {
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-time.After(taskTimeout):
+				env.timedOut()
+			case <-done:
+			}
+		}()
+		defer close(done)
+		return process(task)
+	}
+	return env, func() {}, nil
+}
+
+// 各タスクの処理の前にtime.AfterFuncでタイムアウトを予約し、処理を終えたら取り消す
+func withAfterFuncTimeout(env Env) (Env, func(), error) {
+	process := env.Process
+	env.Process = func(task Task) error {
+		t := time.AfterFunc(taskTimeout, env.timedOut)
+		defer t.Stop()
+		return process(task)
+	}
+	return env, func() {}, nil
+}
+
+// タスクの処理状態（タイムアウトのタイマーホイール用）
+const (
+	timeoutPending int32 = iota
+	timeoutFinished
+	timeoutExpired
+)
+
+// 各タスクの処理の前に期限をタイマーホイールに追加し、1つのgoroutineが共有のtime.Tickerでホイールを進めて
+// 期限を過ぎても終わっていないタスクをタイムアウトとして数える。処理を終えたタスクはホイールから取り除かず、
+// 期限のスロットで読み飛ばす（取り消しはタスクの状態を書き換えるだけ）。タスクの状態はタスクIDで引くため、
+// 供給元のタスク数が分かっている必要がある
+func withWheelTimeout(env Env) (Env, func(), error) {
+	if env.Tasks <= 0 {
+		return env, nil, fmt.Errorf("timeout wheel requires a known task count")
+	}
+	states := make([]atomic.Int32, env.Tasks)
+	wheel := newTimerWheel(time.Now(), timeoutWheelTick, timeoutWheelSlots)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(timeoutWheelTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				expired, _ := wheel.advance(now)
+				for _, task := range expired {
+					if states[task.ID].CompareAndSwap(timeoutPending, timeoutExpired) {
+						env.timedOut()
+					}
+				}
+			}
+		}
+	}()
+
+	process := env.Process
+	env.Process = func(task Task) error {
+		wheel.add(Task{ID: task.ID, Scheduled: time.Now().Add(taskTimeout)})
+		defer states[task.ID].CompareAndSwap(timeoutPending, timeoutFinished)
+		return process(task)
+	}
+	stop := func() {
+		close(done)
+		<-stopped
+	}
+	return env, stop, nil
+}
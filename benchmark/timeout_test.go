@@ -0,0 +1,93 @@
+package benchmark
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 全てのタスクを処理し、タイムアウトまでに終わったタスクをタイムアウトとして数えないことを確認する
+func TestTimeoutStrategies(t *testing.T) {
+	const n = 2000
+
+	for _, s := range timeoutStrategies(Params{Workers: 100, Shards: 4, ShardLimit: 16}) {
+		t.Run(s.Name, func(t *testing.T) {
+			var completed atomic.Int64
+			env := BatchEnv(n)
+			env.Stats = &RunStats{}
+			env.Process = func(task Task) error {
+				defer completed.Add(1)
+				return processTask(task)
+			}
+			if err := s.Run(env); err != nil {
+				t.Fatal(err)
+			}
+			if got := completed.Load(); got != n {
+				t.Errorf("processed %d tasks, want %d", got, n)
+			}
+			if got := env.Stats.TimedOut.Load(); got != 0 {
+				t.Errorf("%d tasks timed out, want 0", got)
+			}
+		})
+	}
+}
+
+// タイムアウトを過ぎても終わらないタスクを、どの仕組みでも1度だけ数えることを確認する
+func TestTimeoutExpires(t *testing.T) {
+	for _, kind := range timeoutKinds {
+		t.Run(kind.name, func(t *testing.T) {
+			t.Parallel()
+			env := BatchEnv(2)
+			env.Stats = &RunStats{}
+			env.Process = func(task Task) error {
+				if task.ID == 1 {
+					time.Sleep(taskTimeout + 100*time.Millisecond)
+				}
+				return nil
+			}
+			env, stop, err := kind.wrap(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for {
+				task, ok := env.Source.Next()
+				if !ok {
+					break
+				}
+				if err := env.Process(task); err != nil {
+					t.Fatal(err)
+				}
+			}
+			stop()
+			if got := env.Stats.TimedOut.Load(); got != 1 {
+				t.Errorf("%d tasks timed out, want 1", got)
+			}
+		})
+	}
+}
+
+func TestTimeoutWheelRequiresTaskCount(t *testing.T) {
+	env := BatchEnv(10)
+	env.Tasks = 0
+	if _, _, err := withWheelTimeout(env); err == nil {
+		t.Error("want error without a known task count")
+	}
+}
+
+// 処理のエラーを戦略の実装どおりに扱い、タイムアウトの仕組みが握りつぶさないことを確認する
+func TestTimeoutPropagatesErrors(t *testing.T) {
+	for _, s := range timeoutStrategies(Params{Workers: 100, Shards: 4, ShardLimit: 16}) {
+		if s.Func != "DirectGoroutineWithUnlimitedParallelism" {
+			continue
+		}
+		t.Run(s.Name, func(t *testing.T) {
+			var processed atomic.Int64
+			env := BatchEnv(2000)
+			env.Process = injectFailure(processTask, 3, ErrorFatal, &processed)
+			if err := s.Run(env); !errors.Is(err, errInjectedFailure) {
+				t.Errorf("got %v, want injected failure", err)
+			}
+		})
+	}
+}
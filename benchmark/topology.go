@@ -0,0 +1,132 @@
+package benchmark
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// 戦略の構成（goroutine、チャネル、同時実行数の制限）。
+// describeサブコマンドで、結果とそれぞれの設計を対応づけるために表示する
+type Topology struct {
+	// 供給元のタスクが処理されるまでに通る段。先頭は供給元を読み出す段
+	Stages []Stage `json:"stages"`
+	// 同時実行数の制限（なければ空）
+	Limiter string `json:"limiter,omitempty"`
+	// 全タスクの完了を待つ仕組み
+	Completion string `json:"completion"`
+}
+
+// 構成の1段（同じ役割を持つgoroutineの組）
+type Stage struct {
+	Role StageRole `json:"role"`
+	// goroutine数。0はタスクごとに起動する
+	Goroutines int `json:"goroutines"`
+	// 前の段からタスクを受け取る方法（チャネルやgoroutineの起動）。先頭の段では空
+	From string `json:"from,omitempty"`
+	// 補足
+	Note string `json:"note,omitempty"`
+}
+
+// 段の役割
+type StageRole string
+
+const (
+	// 供給元からタスクを読み出す
+	RoleProducer StageRole = "producer"
+	// タスクを受け取り、処理するgoroutineに振り分ける
+	RoleDispatcher StageRole = "dispatcher"
+	// タスクを処理する
+	RoleWorker StageRole = "worker"
+)
+
+// 表示に使う役割の名前
+func (r StageRole) label() string {
+	switch r {
+	case RoleProducer:
+		return "供給元の読み出し"
+	case RoleDispatcher:
+		return "ディスパッチャー"
+	case RoleWorker:
+		return "ワーカー"
+	}
+	return string(r)
+}
+
+// 供給元を呼び出し元のgoroutineで読み出す段
+var producerStage = Stage{Role: RoleProducer, Goroutines: 1}
+
+// タスクを受け渡すバッファ付きチャネル
+func chanFrom(elem string, buffer int) string {
+	return fmt.Sprintf("chan %s（バッファ%d）", elem, buffer)
+}
+
+// goroutine数の表示
+func formatGoroutines(n int) string {
+	if n == 0 {
+		return "タスクごと"
+	}
+	return strconv.Itoa(n)
+}
+
+// 構成の表示形式
+const (
+	FormatText    = "text"
+	FormatMermaid = "mermaid"
+	FormatDOT     = "dot"
+)
+
+// 登録されている戦略の構成を表示する。specはシナリオの指定（カンマ区切り、またはall）、
+// formatは表示形式（FormatText、FormatMermaid、FormatDOT）
+func Describe(spec, format string) error {
+	list, err := resolveScenarios(spec)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatText:
+	case FormatMermaid, FormatDOT:
+		return printDiagrams(list, format)
+	default:
+		return fmt.Errorf("unknown format %q (available: %s, %s, %s)", format, FormatText, FormatMermaid, FormatDOT)
+	}
+	p := defaultParams()
+	for i, sc := range list {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("=== シナリオ: %s（%s）===\n", sc.Name, sc.Title)
+		for _, s := range sc.Strategies(p) {
+			fmt.Println()
+			describeStrategy(s)
+		}
+	}
+	return nil
+}
+
+// 1つの戦略の構成を表示する
+func describeStrategy(s Strategy) {
+	fmt.Printf("[%s] %s\n", s.Name, s.Title)
+	fmt.Printf("  関数: %s\n", s.Func)
+	t := s.Topology
+	if len(t.Stages) == 0 {
+		fmt.Println("  （構成情報なし）")
+		return
+	}
+	fmt.Println("  構成:")
+	for i, st := range t.Stages {
+		line := fmt.Sprintf("    %d. %s: goroutine %s", i+1, st.Role.label(), formatGoroutines(st.Goroutines))
+		if st.From != "" {
+			line += " ← " + st.From
+		}
+		if st.Note != "" {
+			line += "（" + st.Note + "）"
+		}
+		fmt.Println(line)
+	}
+	limiter := t.Limiter
+	if limiter == "" {
+		limiter = "なし"
+	}
+	fmt.Printf("  同時実行数の制限: %s\n", limiter)
+	fmt.Printf("  完了待ち: %s\n", t.Completion)
+}
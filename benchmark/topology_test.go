@@ -0,0 +1,71 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+)
+
+// 全ての戦略に、供給元の読み出しから始まる構成と完了待ちの仕組みが記述されていることを確認する
+func TestStrategyTopology(t *testing.T) {
+	for _, sc := range scenarios {
+		for _, s := range sc.Strategies(defaultParams()) {
+			topo := s.Topology
+			if len(topo.Stages) == 0 {
+				t.Errorf("%s/%s: no topology", sc.Name, s.Name)
+				continue
+			}
+			if topo.Stages[0].Role != RoleProducer {
+				t.Errorf("%s/%s: first stage is %s, want %s", sc.Name, s.Name, topo.Stages[0].Role, RoleProducer)
+			}
+			if last := topo.Stages[len(topo.Stages)-1]; last.Role != RoleWorker {
+				t.Errorf("%s/%s: last stage is %s, want %s", sc.Name, s.Name, last.Role, RoleWorker)
+			}
+			for i, st := range topo.Stages[1:] {
+				if st.From == "" {
+					t.Errorf("%s/%s: stage %d has no handoff", sc.Name, s.Name, i+2)
+				}
+			}
+			if topo.Completion == "" {
+				t.Errorf("%s/%s: no completion", sc.Name, s.Name)
+			}
+		}
+	}
+}
+
+// 構成図に全ての段と受け渡し、制限、完了待ちが含まれることを確認する
+func TestTopologyDiagrams(t *testing.T) {
+	topo := Topology{
+		Stages: []Stage{
+			producerStage,
+			{Role: RoleWorker, Goroutines: 4, From: chanFrom("Task", 100), Note: `"quoted"`},
+		},
+		Limiter:    "semaphore.Weighted(4)",
+		Completion: "sync.WaitGroup",
+	}
+
+	m := topo.mermaid()
+	for _, want := range []string{
+		"flowchart LR",
+		`s0 -->|"chan Task（バッファ100）"| s1`,
+		"#quot;quoted#quot;",
+		"semaphore.Weighted(4)",
+		"sync.WaitGroup",
+	} {
+		if !strings.Contains(m, want) {
+			t.Errorf("mermaid diagram does not contain %q:\n%s", want, m)
+		}
+	}
+
+	d := topo.dot("dispatch/test")
+	for _, want := range []string{
+		`digraph "dispatch/test" {`,
+		`s0 -> s1 [label="chan Task（バッファ100）"];`,
+		`\"quoted\"`,
+		"limiter -> s1",
+		"s1 -> done",
+	} {
+		if !strings.Contains(d, want) {
+			t.Errorf("dot diagram does not contain %q:\n%s", want, d)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// 単調な遅延傾向とみなすKendallのτの閾値
+	slowdownTauThreshold = 0.8
+	// 最初と最後の実行を比較したときに遅延とみなす比率
+	slowdownRatioThreshold = 1.05
+	// 自動延長するクールダウンの下限と上限
+	minExtendedCooldown = time.Second
+	maxExtendedCooldown = 30 * time.Second
+)
+
+// 繰り返し実行の処理時間が単調に遅くなっているかを判定する。
+// サーマルスロットリングやバックグラウンド負荷の混入を疑う目安として使う
+func detectSlowdown(durs []time.Duration) bool {
+	n := len(durs)
+	if n < 3 {
+		return false
+	}
+
+	// Mann-Kendallの傾向検定と同じく、全ペアの大小関係の符号を合計する
+	s := 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			switch {
+			case durs[j] > durs[i]:
+				s++
+			case durs[j] < durs[i]:
+				s--
+			}
+		}
+	}
+	tau := float64(s) / float64(n*(n-1)/2)
+	ratio := float64(durs[n-1]) / float64(durs[0])
+	return tau >= slowdownTauThreshold && ratio >= slowdownRatioThreshold
+}
+
+// 実行間のクールダウンを管理する
+type cooldown struct {
+	d      time.Duration
+	extend bool
+	// キャンセルされたら待機を打ち切る（nilの場合は打ち切らない）
+	ctx context.Context
+	// 遅延傾向の警告の出力先（nilの場合は出力しない）。結果の出力と混ざらないよう標準エラー出力を渡す
+	w io.Writer
+}
+
+// クールダウン時間だけ待つ
+func (c *cooldown) wait() {
+	if c.d <= 0 {
+		return
+	}
+	if c.ctx == nil {
+		time.Sleep(c.d)
+		return
+	}
+	t := time.NewTimer(c.d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-c.ctx.Done():
+	}
+}
+
+// 遅延傾向が検出されたときに警告し、必要に応じてクールダウンを延長する
+func (c *cooldown) onSlowdown(name string) {
+	c.printf("警告: %s の処理時間が繰り返しごとに単調に増加しています（サーマルスロットリングやバックグラウンド負荷の可能性）\n", name)
+	if !c.extend {
+		return
+	}
+	next := c.d * 2
+	if next < minExtendedCooldown {
+		next = minExtendedCooldown
+	}
+	if next > maxExtendedCooldown {
+		next = maxExtendedCooldown
+	}
+	c.d = next
+	c.printf("クールダウンを %v に延長します\n", c.d)
+}
+
+func (c *cooldown) printf(format string, args ...any) {
+	if c.w != nil {
+		fmt.Fprintf(c.w, format, args...)
+	}
+}
+
+// 遅延傾向が疑われる結果に付ける注記
+func slowdownNote(suspected bool) string {
+	if suspected {
+		return "（※スロットリングの疑い）"
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectSlowdown(t *testing.T) {
+	ms := time.Millisecond
+	cases := []struct {
+		name string
+		durs []time.Duration
+		want bool
+	}{
+		{"too few", []time.Duration{10 * ms, 20 * ms}, false},
+		{"flat", []time.Duration{10 * ms, 10 * ms, 10 * ms, 10 * ms}, false},
+		{"monotonic", []time.Duration{10 * ms, 11 * ms, 12 * ms, 14 * ms}, true},
+		{"noisy", []time.Duration{10 * ms, 12 * ms, 9 * ms, 11 * ms, 10 * ms}, false},
+		{"tiny increase", []time.Duration{100 * ms, 101 * ms, 102 * ms}, false},
+		{"speeding up", []time.Duration{14 * ms, 12 * ms, 11 * ms, 10 * ms}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := detectSlowdown(c.durs); got != c.want {
+				t.Errorf("detectSlowdown(%v) = %v, want %v", c.durs, got, c.want)
+			}
+		})
+	}
+}
+
+// 遅延傾向の警告とクールダウンの延長を、渡した出力先にだけ書くことを確認する
+func TestOnSlowdown(t *testing.T) {
+	var w strings.Builder
+	c := &cooldown{d: 100 * time.Millisecond, extend: true, w: &w}
+	c.onSlowdown("chan-limited")
+	if c.d != minExtendedCooldown {
+		t.Errorf("cooldown = %v, want %v", c.d, minExtendedCooldown)
+	}
+	out := w.String()
+	if !strings.Contains(out, "警告: chan-limited") || !strings.Contains(out, "クールダウンを 1s に延長します") {
+		t.Errorf("output = %q", out)
+	}
+
+	// 出力先がない場合は書かずに延長だけする
+	c = &cooldown{extend: true}
+	c.onSlowdown("chan-limited")
+	if c.d != minExtendedCooldown {
+		t.Errorf("cooldown = %v, want %v", c.d, minExtendedCooldown)
+	}
+}
@@ -0,0 +1,301 @@
+package benchmark
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 結果のファイルとプロファイルなどの成果物のアップロード先の設定
+type UploadConfig struct {
+	// アップロード先（s3://バケット/接頭辞 または gs://バケット/接頭辞）。空の場合はアップロードしない
+	URL string
+}
+
+// アップロード先のバケットと接頭辞
+type uploadTarget struct {
+	scheme string
+	bucket string
+	prefix string
+}
+
+func parseUploadURL(s string) (uploadTarget, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return uploadTarget{}, err
+	}
+	if u.Scheme != "s3" && u.Scheme != "gs" {
+		return uploadTarget{}, fmt.Errorf("upload URL must be s3://bucket/prefix or gs://bucket/prefix, got %q", s)
+	}
+	if u.Host == "" {
+		return uploadTarget{}, fmt.Errorf("upload URL %q has no bucket", s)
+	}
+	return uploadTarget{scheme: u.Scheme, bucket: u.Host, prefix: strings.Trim(u.Path, "/")}, nil
+}
+
+// アップロード先の設定を確認する
+func (c UploadConfig) validate() error {
+	if c.URL == "" {
+		return nil
+	}
+	_, err := parseUploadURL(c.URL)
+	return err
+}
+
+// オブジェクトストレージの接続先と認証情報。
+// S3のAPIで署名（AWS Signature Version 4）して送り、GCSにはHMACキーを使ったS3互換のAPIで送る
+type objectStore struct {
+	// バケットを含まない接続先（末尾の/なし）。空の場合はS3のバケットごとのエンドポイントを使う
+	endpoint  string
+	region    string
+	accessKey string
+	secretKey string
+	token     string
+	client    *http.Client
+}
+
+// 環境変数から接続先と認証情報を読み取る。GCSのHMACキーもAWS_ACCESS_KEY_IDとAWS_SECRET_ACCESS_KEYで渡す
+func objectStoreFromEnv(scheme string) (*objectStore, error) {
+	s := &objectStore{
+		endpoint:  strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL"), "/"),
+		region:    cmp.Or(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1"),
+		accessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		token:     os.Getenv("AWS_SESSION_TOKEN"),
+		client:    &http.Client{Timeout: time.Minute},
+	}
+	if scheme == "gs" {
+		s.region = "auto"
+		if s.endpoint == "" {
+			s.endpoint = "https://storage.googleapis.com"
+		}
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, errors.New("upload requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY (HMAC keys for GCS)")
+	}
+	return s, nil
+}
+
+// オブジェクトのURLを返す。接続先を指定した場合はパス形式、S3の既定ではバケットごとのホストを使う
+func (s *objectStore) objectURL(bucket, key string) string {
+	escaped := escapeKey(key)
+	if s.endpoint != "" {
+		return s.endpoint + "/" + bucket + "/" + escaped
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, s.region, escaped)
+}
+
+// キーを署名の正規化と同じ規則でURIエンコードする。英数字と-_.~/以外は全てエンコードする
+func escapeKey(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// オブジェクトを1つ書き込む
+func (s *objectStore) put(bucket, key string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(bucket, key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	s.sign(req, body, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("upload %s failed: %s", key, resp.Status)
+	}
+	return nil
+}
+
+// AWS Signature Version 4でリクエストに署名する
+func (s *objectStore) sign(req *http.Request, body []byte, now time.Time) {
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.token != "" {
+		req.Header.Set("X-Amz-Security-Token", s.token)
+	}
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(values[0])
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{day, s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// 結果のファイルと、各結果が参照するプロファイルなどの成果物を、実行の最後にまとめてアップロードする。
+// ファイルを書き出す出力先より後に閉じるよう、出力先の最後に加える
+type uploadReporter struct {
+	target uploadTarget
+	store  *objectStore
+	// アップロードする接頭辞の下の、実行ごとのディレクトリ（時刻-ホスト名）
+	run   string
+	files []string
+}
+
+func newUploadReporter(c UploadConfig, reports []string) (Reporter, error) {
+	target, err := parseUploadURL(c.URL)
+	if err != nil {
+		return nil, err
+	}
+	store, err := objectStoreFromEnv(target.scheme)
+	if err != nil {
+		return nil, err
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	u := &uploadReporter{
+		target: target,
+		store:  store,
+		run:    time.Now().UTC().Format("20060102T150405Z") + "-" + host,
+	}
+	for _, spec := range reports {
+		kind, file, _ := parseReportSpec(spec)
+		if reporterKinds[kind].writesFile && file != "" && file != "-" {
+			u.add(file)
+		}
+	}
+	return u, nil
+}
+
+// アップロードするファイルを加える（同じファイルは1回だけ）
+func (u *uploadReporter) add(file string) {
+	if !slices.Contains(u.files, file) {
+		u.files = append(u.files, file)
+	}
+}
+
+func (u *uploadReporter) Report(r Result) error {
+	names := make([]string, 0, len(r.Artifacts))
+	for name := range r.Artifacts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		u.add(r.Artifacts[name])
+	}
+	return nil
+}
+
+// ファイルのオブジェクトのキー。相対パスはディレクトリの構成を保ち、それ以外はファイル名だけを使う
+func (u *uploadReporter) key(file string) string {
+	name := filepath.ToSlash(filepath.Clean(file))
+	if filepath.IsAbs(file) || name == ".." || strings.HasPrefix(name, "../") {
+		name = filepath.Base(file)
+	}
+	return path.Join(u.target.prefix, u.run, name)
+}
+
+// この実行の成果物を置く場所
+func (u *uploadReporter) location() string {
+	return fmt.Sprintf("%s://%s/%s/", u.target.scheme, u.target.bucket, path.Join(u.target.prefix, u.run))
+}
+
+// アップロードするファイルの一覧。ディレクトリに書き出す出力先（svg）は、閉じた後のディレクトリの中のファイルに展開する
+func (u *uploadReporter) expand() ([]string, error) {
+	var files []string
+	var errs []error
+	for _, file := range u.files {
+		err := filepath.WalkDir(file, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				files = append(files, path)
+			}
+			return err
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return files, errors.Join(errs...)
+}
+
+func (u *uploadReporter) Close() error {
+	if len(u.files) == 0 {
+		return nil
+	}
+	files, err := u.expand()
+	errs := []error{err}
+	uploaded := 0
+	for _, file := range files {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := u.store.put(u.target.bucket, u.key(file), body); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		uploaded++
+	}
+	if uploaded > 0 {
+		fmt.Fprintf(os.Stderr, "成果物をアップロードしました: %s（%d件）\n", u.location(), uploaded)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,132 @@
+package benchmark
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// 結果のファイルと成果物を、署名したPUTで「接頭辞/時刻-ホスト名/ファイル名」にアップロードすることを確認する
+func TestUploadReporter(t *testing.T) {
+	var mu sync.Mutex
+	uploaded := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(b)
+		if r.Method != http.MethodPut {
+			t.Errorf("method = %s, want PUT", r.Method)
+		}
+		if got := r.Header.Get("X-Amz-Content-Sha256"); got != hex.EncodeToString(sum[:]) {
+			t.Errorf("content hash = %q", got)
+		}
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/us-west-2/s3/aws4_request") {
+			t.Errorf("Authorization = %q", auth)
+		}
+		mu.Lock()
+		uploaded[r.URL.Path] = string(b)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "us-west-2")
+
+	dir := t.TempDir()
+	results := filepath.Join(dir, "results.json")
+	profile := filepath.Join(dir, "dispatch-a.pprof")
+	os.WriteFile(results, []byte("[]"), 0o644)
+	os.WriteFile(profile, []byte("profile"), 0o644)
+	// ディレクトリに書き出す出力先は、ディレクトリの中のファイルをアップロードする
+	charts := filepath.Join(dir, "charts")
+	os.Mkdir(charts, 0o755)
+	os.WriteFile(filepath.Join(charts, "dispatch-duration.svg"), []byte("<svg/>"), 0o644)
+
+	rep, err := newUploadReporter(UploadConfig{URL: "s3://bucket/nightly"}, []string{"console", "json=" + results, "csv=-", "svg=" + charts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testResult("a", 100)
+	r.Artifacts = map[string]string{"cpu_profile": profile}
+	rep.Report(r)
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	run := rep.(*uploadReporter).run
+	want := map[string]string{
+		"/bucket/nightly/" + run + "/results.json":          "[]",
+		"/bucket/nightly/" + run + "/dispatch-a.pprof":      "profile",
+		"/bucket/nightly/" + run + "/dispatch-duration.svg": "<svg/>",
+	}
+	if len(uploaded) != len(want) {
+		t.Errorf("uploaded %v, want %v", uploaded, want)
+	}
+	for path, body := range want {
+		if uploaded[path] != body {
+			t.Errorf("%s = %q, want %q", path, uploaded[path], body)
+		}
+	}
+}
+
+func TestUploadKeys(t *testing.T) {
+	u := &uploadReporter{target: uploadTarget{prefix: "p"}, run: "run"}
+	for file, want := range map[string]string{
+		"profiles/a.pprof": "p/run/profiles/a.pprof",
+		"../a.pprof":       "p/run/a.pprof",
+		"/tmp/a.pprof":     "p/run/a.pprof",
+	} {
+		if got := u.key(file); got != want {
+			t.Errorf("key(%q) = %q, want %q", file, got, want)
+		}
+	}
+	if got, want := escapeKey("p/run/a b+c.json"), "p/run/a%20b%2Bc.json"; got != want {
+		t.Errorf("escapeKey = %q, want %q", got, want)
+	}
+}
+
+func TestParseUploadURL(t *testing.T) {
+	target, err := parseUploadURL("gs://bucket/a/b/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != (uploadTarget{scheme: "gs", bucket: "bucket", prefix: "a/b"}) {
+		t.Errorf("got %+v", target)
+	}
+	for _, s := range []string{"https://bucket/a", "s3:///a"} {
+		if _, err := parseUploadURL(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+// 出力先にファイルやディレクトリを書き出す種類は、全てアップロードの対象として登録されていることを確認する
+func TestReporterKindsWritesFile(t *testing.T) {
+	dir := t.TempDir()
+	r := testResult("a", 100)
+	r.Metrics[metricTasks] = 10
+	r.Samples = []float64{90, 100, 110}
+	for _, kind := range ReporterKinds() {
+		target := filepath.Join(dir, kind)
+		rep, err := reporterKinds[kind].new(target, io.Discard)
+		if err != nil {
+			// URLを出力先とする種類や、ビルドタグなしのsqliteなど
+			continue
+		}
+		rep.Report(r)
+		if err := rep.Close(); err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if _, err := os.Stat(target); (err == nil) != reporterKinds[kind].writesFile {
+			t.Errorf("%s: wrote %s = %v, but writesFile = %v", kind, target, err == nil, reporterKinds[kind].writesFile)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package benchmark
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 要約の各節に載せる最大の行数
+const webhookMaxLines = 10
+
+// 実行の完了を知らせるWebhookの設定
+type WebhookConfig struct {
+	// 実行の最後に要約をPOSTするURL（Slackの受信Webhookと同じ{"text": "..."}の形式）。空の場合は送らない
+	URL string
+	// 処理時間の差を比べる前回の結果ファイル（jsonの出力先に書き出したもの）。空の場合は比べない
+	Baseline string
+	// 要約に載せる成果物の場所。空でアップロード先を指定した場合は、アップロード先を載せる
+	Link string
+}
+
+// Webhookの設定を確認する
+func (c WebhookConfig) validate() error {
+	if c.URL == "" {
+		if c.Baseline != "" || c.Link != "" {
+			return errors.New("webhook baseline and link require a webhook URL")
+		}
+		return nil
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook URL must be http or https, got %q", c.URL)
+	}
+	return nil
+}
+
+// 実行の最後に、戦略の組ごとの最速の戦略、前回の結果との処理時間の差、成果物の場所をまとめた
+// 短い要約をWebhookに送る。夜間のソークやスイープの完了を知るために使う
+type webhookReporter struct {
+	url      string
+	baseline []Result
+	link     string
+	client   *http.Client
+	results  []Result
+}
+
+func newWebhookReporter(c WebhookConfig, link string) (Reporter, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	w := &webhookReporter{
+		url:    c.URL,
+		link:   link,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	if c.Link != "" {
+		w.link = c.Link
+	}
+	if c.Baseline != "" {
+		baseline, err := loadResults(c.Baseline)
+		if err != nil {
+			return nil, err
+		}
+		w.baseline = baseline
+	}
+	return w, nil
+}
+
+func (w *webhookReporter) Report(r Result) error {
+	w.results = append(w.results, r)
+	return nil
+}
+
+func (w *webhookReporter) Close() error {
+	if len(w.results) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(map[string]string{"text": webhookSummary(w.results, w.baseline, w.link)})
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s failed: %s", w.url, resp.Status)
+	}
+	return nil
+}
+
+// 処理時間を比べられる結果（中止しておらず、処理時間を計測したもの）
+func hasWall(r Result) bool {
+	_, ok := r.Metrics[metricWall]
+	return r.AbortReason == "" && ok
+}
+
+// 戦略の組の表示名
+func contestLabel(r Result) string {
+	switch r.Mode {
+	case modeBatch:
+		return r.Scenario
+	case modeSweep:
+		return fmt.Sprintf("%s（スイープ、%.0fタスク）", r.Scenario, r.Metrics[metricTasks])
+	case modeRamp:
+		return fmt.Sprintf("%s（負荷ランプ、負荷 %g）", r.Scenario, r.Metrics[metricLoad])
+	default:
+		return fmt.Sprintf("%s（%s）", r.Scenario, r.Mode)
+	}
+}
+
+// 実行の要約を作成する
+func webhookSummary(results, baseline []Result, link string) string {
+	var b strings.Builder
+	first := results[0]
+	fmt.Fprintf(&b, "ベンチマークが完了しました: %d件の結果（ホスト %s、%s、コード %s）\n",
+		len(results), first.Environment.Hostname, first.Environment.GoVersion, first.Provenance.label())
+
+	// 同じ条件で比べた戦略の組ごとに、処理時間の最も短い戦略と次点との差
+	var order []string
+	contests := map[string][]Result{}
+	for _, r := range results {
+		if !hasWall(r) {
+			continue
+		}
+		k := strings.Join(resultKey(r, ""), "\x00")
+		if _, ok := contests[k]; !ok {
+			order = append(order, k)
+		}
+		contests[k] = append(contests[k], r)
+	}
+	var winners []string
+	for _, k := range order {
+		group := contests[k]
+		if len(group) < 2 {
+			continue
+		}
+		slices.SortStableFunc(group, func(a, b Result) int {
+			return cmp.Compare(a.Metrics[metricWall], b.Metrics[metricWall])
+		})
+		best, next := group[0], group[1]
+		faster := (1 - best.Metrics[metricWall]/next.Metrics[metricWall]) * 100
+		winners = append(winners, fmt.Sprintf("• %s: %s %v（次点の%sより %.1f%% 速い）",
+			contestLabel(best), best.Strategy, best.duration(metricWall).Round(time.Microsecond), next.Strategy, faster))
+	}
+	writeSection(&b, "最速の戦略", winners)
+
+	// 前回の結果との処理時間の差（変化の大きい順）
+	if len(baseline) > 0 {
+		before := map[string]Result{}
+		for _, r := range baseline {
+			if hasWall(r) {
+				before[strings.Join(resultKey(r, r.Strategy), "\x00")] = r
+			}
+		}
+		type delta struct {
+			line   string
+			change float64
+		}
+		var deltas []delta
+		for _, r := range results {
+			if !hasWall(r) {
+				continue
+			}
+			prev, ok := before[strings.Join(resultKey(r, r.Strategy), "\x00")]
+			if !ok || prev.Metrics[metricWall] <= 0 {
+				continue
+			}
+			change := (r.Metrics[metricWall]/prev.Metrics[metricWall] - 1) * 100
+			deltas = append(deltas, delta{
+				line: fmt.Sprintf("• %s/%s: %v → %v（%+.1f%%）", contestLabel(r), r.Strategy,
+					prev.duration(metricWall).Round(time.Microsecond), r.duration(metricWall).Round(time.Microsecond), change),
+				change: change,
+			})
+		}
+		slices.SortStableFunc(deltas, func(a, b delta) int {
+			return cmp.Compare(math.Abs(b.change), math.Abs(a.change))
+		})
+		lines := make([]string, len(deltas))
+		for i, d := range deltas {
+			lines[i] = d.line
+		}
+		if len(lines) == 0 {
+			lines = []string{"• 前回の結果に同じ条件の戦略がありません"}
+		}
+		writeSection(&b, "前回との処理時間の差（中央値、変化の大きい順）", lines)
+	}
+
+	if link != "" {
+		fmt.Fprintf(&b, "成果物: %s\n", link)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// 要約の節を書き出す。行が多い場合は先頭のwebhookMaxLines行だけを載せる
+func writeSection(b *strings.Builder, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%s:\n", title)
+	for _, line := range lines[:min(len(lines), webhookMaxLines)] {
+		b.WriteString(line + "\n")
+	}
+	if rest := len(lines) - webhookMaxLines; rest > 0 {
+		fmt.Fprintf(b, "• ほか%d件\n", rest)
+	}
+}
@@ -0,0 +1,60 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 最速の戦略、前回との差、成果物の場所を要約に載せることを確認する
+func TestWebhookSummary(t *testing.T) {
+	results := []Result{testResult("a", 1.5e6), testResult("b", 3e6)}
+	baseline := []Result{testResult("a", 1.2e6), testResult("c", 1e6)}
+	got := webhookSummary(results, baseline, "s3://bucket/run/")
+	for _, want := range []string{
+		"2件の結果",
+		"• dispatch: a 1.5ms（次点のbより 50.0% 速い）",
+		"• dispatch/a: 1.2ms → 1.5ms（+25.0%）",
+		"成果物: s3://bucket/run/",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary does not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "/c:") {
+		t.Errorf("summary contains a strategy missing from this run:\n%s", got)
+	}
+}
+
+// 実行の最後に要約を{"text": ...}の形式でPOSTすることを確認する
+func TestWebhookReporter(t *testing.T) {
+	var text string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		text = body["text"]
+	}))
+	defer srv.Close()
+
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	b, _ := json.Marshal([]Result{testResult("a", 500)})
+	os.WriteFile(baseline, b, 0o644)
+
+	rep, err := newWebhookReporter(WebhookConfig{URL: srv.URL, Baseline: baseline}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.Report(testResult("a", 1000))
+	if err := rep.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "+100.0%") {
+		t.Errorf("posted %q", text)
+	}
+}
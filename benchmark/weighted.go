@@ -0,0 +1,95 @@
+package benchmark
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// 重み付きsemaphoreシナリオの戦略一覧。
+// 同じ予算（最も重いタスクをワーカー数だけ同時に処理できる量）を、
+// 全タスクを最も重いタスクとみなして配分する方法と、タスクの重さに応じて配分する方法を比較する
+func weightedStrategies(p Params) []Strategy {
+	numWorkers := p.Workers
+	capacity := int64(numWorkers) * maxTaskWeight
+	return []Strategy{
+		{
+			Name:  "weighted-uniform",
+			Title: fmt.Sprintf("一律の重み（全タスクを重さ%dとして取得、予算%d = %d同時実行）", maxTaskWeight, capacity, numWorkers),
+			Func:  "weightedSemaphore",
+			Limit: numWorkers,
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文"},
+				},
+				Limiter:    fmt.Sprintf("semaphore.Weighted(%d)（全タスクを重さ%dとして取得）", capacity, maxTaskWeight),
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return weightedSemaphore(env, capacity, func(Task) int64 { return maxTaskWeight })
+			},
+		},
+		{
+			Name:  "weighted-by-size",
+			Title: fmt.Sprintf("タスクの重さに応じた重み（軽い1、少し重い5、重い20、予算%d）", capacity),
+			Func:  "weightedSemaphore",
+			Limit: int(capacity),
+			Topology: Topology{
+				Stages: []Stage{
+					producerStage,
+					{Role: RoleWorker, From: "go文"},
+				},
+				Limiter:    fmt.Sprintf("semaphore.Weighted(%d)（タスクの重さ1、5、20で取得）", capacity),
+				Completion: "sync.WaitGroup",
+			},
+			Run: func(env Env) error {
+				return weightedSemaphore(env, capacity, taskWeight)
+			},
+		},
+	}
+}
+
+// 最も重いタスクの重み
+var maxTaskWeight = taskWeight(Task{ID: 0})
+
+// タスクの重み（最も軽いタスクの処理時間を1とした処理時間の比）
+func taskWeight(task Task) int64 {
+	return int64(taskDuration(task.ID) / taskDuration(1))
+}
+
+// タスクごとにgoroutineを起動し、重み付きsemaphoreでタスクの重みの合計を予算内に制限する
+func weightedSemaphore(env Env, capacity int64, weight func(Task) int64) error {
+	ctx := env.context()
+	sem := semaphore.NewWeighted(capacity)
+	var wg sync.WaitGroup
+
+	for {
+		task, ok := env.Source.Next()
+		if !ok {
+			break
+		}
+
+		// 重いタスクは軽いタスクが処理を終えて予算が空くまで待つ
+		w := weight(task)
+		if err := sem.Acquire(ctx, w); err != nil {
+			wg.Wait()
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer sem.Release(w)
+			defer wg.Done()
+
+			if err := env.Process(task); err != nil {
+				log.Printf("Error processing task %d: %v", task.ID, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
@@ -0,0 +1,66 @@
+package benchmark
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 重みがタスクの処理時間に比例し、重いタスクほど大きな重みになることを確認する
+func TestTaskWeight(t *testing.T) {
+	for _, tt := range []struct {
+		id   int
+		want int64
+	}{{1, 1}, {10, 5}, {100, 20}, {0, 20}} {
+		task := Task{ID: tt.id}
+		if got := taskWeight(task); got != tt.want {
+			t.Errorf("taskWeight(%d) = %d, want %d", tt.id, got, tt.want)
+		}
+		if d := time.Duration(taskWeight(task)) * taskDuration(1); d != taskDuration(tt.id) {
+			t.Errorf("task %d: weight × lightest duration = %v, want %v", tt.id, d, taskDuration(tt.id))
+		}
+	}
+	if maxTaskWeight != 20 {
+		t.Errorf("maxTaskWeight = %d, want 20", maxTaskWeight)
+	}
+}
+
+// 処理中のタスクの重みの合計が、どの時点でもsemaphoreの予算を超えないことを確認する
+func TestWeightedSemaphoreBudget(t *testing.T) {
+	const capacity = 2 * 20
+	for _, tt := range []struct {
+		name   string
+		weight func(Task) int64
+	}{
+		{"uniform", func(Task) int64 { return maxTaskWeight }},
+		{"by-size", taskWeight},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			env := batchEnv(1000, sprintfData)
+			var inflight, peak, processed atomic.Int64
+			env.Process = func(task Task) error {
+				w := tt.weight(task)
+				n := inflight.Add(w)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(taskDuration(task.ID))
+				inflight.Add(-w)
+				processed.Add(1)
+				return nil
+			}
+			if err := weightedSemaphore(env, capacity, tt.weight); err != nil {
+				t.Fatal(err)
+			}
+			if processed.Load() != 1000 {
+				t.Errorf("processed %d tasks, want 1000", processed.Load())
+			}
+			if p := peak.Load(); p > capacity || p == 0 {
+				t.Errorf("peak weight in flight = %d, want between 1 and %d", p, capacity)
+			}
+		})
+	}
+}
@@ -1,15 +1,297 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/go-to-k/go-speed-chan-vs-goroutine/benchmark"
 )
 
 func main() {
-	if err := benchmark.Run(); err != nil {
+	args := os.Args[1:]
+
+	var err error
+	switch {
+	case len(args) > 0 && args[0] == "profdiff":
+		err = profDiff(args[1:])
+	case len(args) > 0 && args[0] == "escape":
+		err = benchmark.RunEscapeAnalysis()
+	case len(args) > 0 && args[0] == "describe":
+		err = describe(args[1:])
+	case len(args) > 0 && args[0] == "merge":
+		err = merge(args[1:])
+	case len(args) > 0 && args[0] == "trend":
+		err = trend(args[1:])
+	case len(args) > 1 && args[0] == benchmark.QuotaChildCommand:
+		err = quotaChild(args[1])
+	default:
+		err = run(args)
+	}
+	if errors.Is(err, benchmark.ErrInterrupted) {
+		os.Exit(130)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// ベンチマークを実行する
+func run(args []string) error {
+	// プリセットを先に適用し、個別のフラグで指定した項目はプリセットより優先する
+	cfg := benchmark.DefaultConfig()
+	if name := flagArg(args, "preset"); name != "" {
+		if err := benchmark.ApplyPreset(&cfg, name); err != nil {
+			return err
+		}
+	}
+	if path := flagArg(args, "config"); path != "" {
+		if err := benchmark.LoadConfigFile(&cfg, path); err != nil {
+			return err
+		}
+	}
+	fs := flag.NewFlagSet("go-speed-chan-vs-goroutine", flag.ExitOnError)
+	fs.String("preset", cfg.Preset, "名前付きの設定のひな形を適用する（"+strings.Join(benchmark.PresetNames(), ", ")+"）。個別のフラグで上書きできる")
+	fs.String("config", cfg.ConfigFile, "実験の定義（シナリオ、実行する戦略、タスク数、ワーカー数、処理時間の分布など）を書いたYAMLファイル。-presetの後に適用し、個別のフラグで上書きできる")
+	registerFlags(fs, &cfg)
+	strategies := fs.String("strategies", strings.Join(cfg.Strategies, ","), "実行する戦略をカンマ区切りで指定（省略時はシナリオの全ての戦略）")
+
+	report := fs.String("report", strings.Join(cfg.Reports, ","), "結果の出力先をカンマ区切りで指定（"+strings.Join(benchmark.ReporterKinds(), ", ")+"。例: console,json=results.json）")
+	output := fs.String("output", "", "戦略ごとに1行（戦略、ワーカー数、タスク数、処理時間、スループット）の要約CSVを書き出すファイル（-reportにcsv-summaryを加える）")
+	fs.StringVar(&cfg.Format, "format", cfg.Format, "標準出力の形式（text: 人が読む形式、json: 全ての結果のJSON配列だけを書き、コンソールなどそれ以外の表示は標準エラー出力に書く）")
+	hooks := fs.String("hooks", strings.Join(cfg.Hooks, ","), "全ての戦略に差し込むフックをカンマ区切りで指定（"+strings.Join(benchmark.HookNames(), ", ")+"）")
+	interleave := fs.String("interleave", strings.Join(cfg.Interleave, ","), "交互実行（ABAB…）で比較する2つの戦略をカンマ区切りで指定（例: chan-unlimited,direct-unlimited）")
+	fs.BoolVar(&cfg.Sweep.Enabled, "sweep", cfg.Sweep.Enabled, "タスク数を対数スケールで増やしながら各戦略を実行し、処理時間の伸び（超線形の劣化）を確認する")
+	cpuQuotas := fs.String("cpu-quotas", "", "CPUクォータ（CPU数）をカンマ区切りで指定し、cgroupでクォータを設定した子プロセスでクォータごとに全体を実行する（Linuxのみ、例: 0.5,1,2）")
+	fs.BoolVar(&cfg.QuotaGOMAXPROCS, "quota-gomaxprocs", cfg.QuotaGOMAXPROCS, "CPUクォータごとに、GOMAXPROCSをCPU数にした場合とクォータに合わせた場合（automaxprocsと同じ）を比べる")
+	sweepTasks := fs.String("sweep-tasks", joinInts(cfg.Sweep.Tasks), "スイープのタスク数をカンマ区切りで昇順に指定")
+	fs.BoolVar(&cfg.Ramp.Enabled, "ramp", cfg.Ramp.Enabled, "投入レートを段階的に上げてレイテンシとスループットの推移を計測する")
+	fs.StringVar(&cfg.Ramp.Loop, "loop", cfg.Ramp.Loop, "負荷の生成方式（open: 一定のスケジュールで投入、closed: 完了を待って次を投入）")
+	fs.Float64Var(&cfg.Ramp.StartRate, "ramp-start", cfg.Ramp.StartRate, "開ループの負荷ランプの開始レート（タスク/秒）")
+	fs.IntVar(&cfg.Ramp.StartClients, "ramp-start-clients", cfg.Ramp.StartClients, "閉ループの負荷ランプの開始クライアント数")
+	fs.Float64Var(&cfg.Ramp.Factor, "ramp-factor", cfg.Ramp.Factor, "負荷ランプのステップごとの負荷の倍率")
+	fs.IntVar(&cfg.Ramp.Steps, "ramp-steps", cfg.Ramp.Steps, "負荷ランプの最大ステップ数")
+	fs.DurationVar(&cfg.Ramp.StepDuration, "ramp-step-duration", cfg.Ramp.StepDuration, "負荷ランプの各ステップの継続時間")
+	fs.BoolVar(&cfg.Chaos.Enabled, "chaos", cfg.Chaos.Enabled, "実行中にランダムなGoschedの呼び出し、GCの実行、CPUを占有するgoroutineの起動で外乱を起こし、各戦略の頑健さを比べる")
+	fs.DurationVar(&cfg.Chaos.Interval, "chaos-interval", cfg.Chaos.Interval, "GCの実行やCPUを占有するgoroutineの起動を起こす平均間隔")
+	fs.Float64Var(&cfg.Chaos.YieldProbability, "chaos-yield", cfg.Chaos.YieldProbability, "各タスクの処理の前にruntime.Goschedを呼ぶ確率")
+	fs.DurationVar(&cfg.Chaos.HogDuration, "chaos-hog", cfg.Chaos.HogDuration, "CPUを占有するgoroutineが回り続ける時間")
+	fs.StringVar((*string)(&cfg.AcquirePolicy), "acquire-policy", string(cfg.AcquirePolicy), "semaphoreの取得に失敗したときの方針（count: 破棄して数える、retry: やり直す、abort: 中止する）")
+	fs.IntVar(&cfg.FailAt, "fail-at", cfg.FailAt, "指定したIDのタスクでエラーを発生させ、各戦略がエラーを返すかを確認する")
+	fs.BoolVar(&cfg.Strict, "strict", cfg.Strict, "厳格モード。各実行で全てのタスクを処理したか、処理関数のエラーを返したかを確かめ（-fail-atがなければ別の1回で途中のタスクにエラーを発生させる）、タスクを破棄したりエラーを握りつぶしたりした戦略があれば終了コード1で終わる")
+	fs.StringVar((*string)(&cfg.FailKind), "fail-kind", string(cfg.FailKind), "-fail-atで発生させるエラーの分類（transient, fatal, cancelled, timeout）")
+	fs.StringVar(&cfg.LogSink, "log-sink", cfg.LogSink, "ログの出力先（stderr: 標準エラー出力に直接書く、ring: 計測中はメモリ上のリングバッファに書き、計測の外で書き出す）")
+	fs.IntVar(&cfg.LogRingSize, "log-ring-size", cfg.LogRingSize, "-log-sink=ringで保持するログの行数（超えた場合は古い行から破棄する）")
+	fs.BoolVar(&cfg.DebugLog, "debug-log", cfg.DebugLog, "各タスクの処理の終了をログに出力する（計測を歪めないよう-log-sink=ringと組み合わせる）")
+	fs.DurationVar(&cfg.MaxDuration, "max-duration", cfg.MaxDuration, "実行全体の時間の上限。残りの時間をシナリオに均等に割り振り、スイープは収まらないタスク数を省略し、上限に達した後のシナリオは実行せずに記録する（0は上限なし）")
+	fs.StringVar(&cfg.Checkpoint, "checkpoint", cfg.Checkpoint, "シナリオが完了するたびに進捗を保存するファイル。既にあれば完了済みのシナリオを飛ばして再開する")
+	maxHeapMB := fs.Uint64("max-heap-mb", 0, "ヒープ使用量の上限（MB）。超えたらそのシナリオの実行を中止する（0は制限なし）")
+	fs.IntVar(&cfg.Limits.MaxGoroutines, "max-goroutines", cfg.Limits.MaxGoroutines, "goroutine数の上限。超えたらそのシナリオの実行を中止する（0は制限なし）")
+	fs.Float64Var(&cfg.AllocBudget, "alloc-budget", cfg.AllocBudget, "タスクあたりの割り当て回数の予算。各戦略が予算以内に収まるかを判定する（例: 0でゼロアロケーション、負の値は判定しない）")
+	fs.StringVar(&cfg.Publish.URL, "publish-url", cfg.Publish.URL, "実行の最後に全ての結果のJSON配列をPOSTするURL（例: https://collector.example.com/results）")
+	fs.Func("publish-header", "-publish-urlへの送信に付けるヘッダー（「名前: 値」、複数回指定できる）。値の$VARは環境変数で置き換える（例: 'Authorization: Bearer $BENCH_TOKEN'）", func(s string) error {
+		cfg.Publish.Headers = append(cfg.Publish.Headers, s)
+		return nil
+	})
+	fs.StringVar(&cfg.Upload.URL, "upload-url", cfg.Upload.URL, "実行の最後に結果のファイルとプロファイルをアップロードする先（s3://バケット/接頭辞 または gs://バケット/接頭辞）。接頭辞の下の「時刻-ホスト名」のディレクトリに置く。認証情報はAWS_ACCESS_KEY_IDとAWS_SECRET_ACCESS_KEY（GCSではHMACキー）から読む")
+	fs.StringVar(&cfg.Webhook.URL, "webhook-url", cfg.Webhook.URL, "実行の最後に、最速の戦略、前回との差、成果物の場所の要約をPOSTするWebhookのURL（Slackの受信Webhookと同じ形式）")
+	fs.StringVar(&cfg.Webhook.Baseline, "webhook-baseline", cfg.Webhook.Baseline, "Webhookの要約で処理時間の差を比べる前回の結果ファイル（jsonの出力先に書き出したもの）")
+	fs.StringVar(&cfg.Webhook.Link, "webhook-link", cfg.Webhook.Link, "Webhookの要約に載せる成果物の場所（省略時は-upload-urlのアップロード先）")
+	fs.BoolVar(&cfg.DryRun, "dry-run", cfg.DryRun, "実行せずに設定を検証し、実行計画と所要時間の見積もりを表示する")
+	fs.BoolVar(&cfg.Flamegraph, "flamegraph", cfg.Flamegraph, "CPUプロファイルからフレームグラフのSVGを生成する（-profile-dirが必要）")
+	fs.StringVar(&cfg.PprofAddr, "pprof-addr", cfg.PprofAddr, "実行中にpprofのHTTPサーバーを起動するアドレス（例: localhost:6060）。長い実行の途中でプロファイルやgoroutineのダンプを取得できる")
+	fs.StringVar(&cfg.ListenAddr, "listen", cfg.ListenAddr, "実行中の戦略のカウンタ（供給、完了、エラー、破棄、処理中、待ち）とgoroutine数をPrometheusの形式で公開するアドレス（例: :9090）。/metricsをPrometheusで収集できる")
+	fs.BoolVar(&cfg.LiveCounters, "expvar", cfg.LiveCounters, "実行中の戦略のカウンタ（供給、処理中、完了、エラー、破棄）をexpvarで公開する（-pprof-addrのサーバーの/debug/varsで取得できる）")
+	fs.BoolVar(&cfg.ContentionProfile, "contention-profile", cfg.ContentionProfile, "戦略ごとにブロックとミューテックスの競合のプロファイルを書き出す（-profile-dirが必要）")
+	fs.Parse(args)
+
+	if *interleave != "" {
+		cfg.Interleave = strings.Split(*interleave, ",")
+	}
+	tasks, err := parseInts(*sweepTasks)
+	if err != nil {
+		return err
+	}
+	cfg.Sweep.Tasks = tasks
+	if *cpuQuotas != "" {
+		if cfg.CPUQuotas, err = parseFloats(*cpuQuotas); err != nil {
+			return err
+		}
+	}
+	if *hooks != "" {
+		cfg.Hooks = strings.Split(*hooks, ",")
+	}
+	if *strategies != "" {
+		cfg.Strategies = strings.Split(*strategies, ",")
+	}
+	cfg.Reports = strings.Split(*report, ",")
+	if *output != "" {
+		cfg.Reports = append(cfg.Reports, "csv-summary="+*output)
+	}
+	cfg.Limits.MaxHeapBytes = *maxHeapMB << 20
+
+	ctx, stop := interruptContext()
+	defer stop()
+	return benchmark.RunContext(ctx, cfg)
+}
+
+// CPUクォータを設定したcgroupの中で、親プロセスから渡された設定で実行する
+func quotaChild(cfgPath string) error {
+	ctx, stop := interruptContext()
+	defer stop()
+	return benchmark.RunQuotaChild(ctx, cfgPath)
+}
+
+// 中断時は実行中の戦略をキャンセルし、完了した結果を出力してから終了する。
+// 2回目のシグナルではすぐに終了できるよう、最初のシグナルの後は通常の動作に戻す
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	return ctx, stop
+}
+
+// 2つの戦略のCPUプロファイルの差分を表示する
+func profDiff(args []string) error {
+	cfg := benchmark.DefaultConfig()
+	fs := flag.NewFlagSet("profdiff", flag.ExitOnError)
+	registerFlags(fs, &cfg)
+
+	a := fs.String("a", "chan-unlimited", "比較元（A）の戦略")
+	b := fs.String("b", "direct-unlimited", "比較先（B）の戦略")
+	fs.Parse(args)
+
+	return benchmark.RunProfileDiff(cfg, *a, *b)
+}
+
+// 各戦略の構成（goroutine、チャネル、同時実行数の制限）を表示する
+func describe(args []string) error {
+	fs := flag.NewFlagSet("describe", flag.ExitOnError)
+	scenario := fs.String("scenario", "all", "構成を表示するシナリオ（"+strings.Join(benchmark.ScenarioNames(), ", ")+"）。カンマ区切りで複数指定でき、allで全て")
+	format := fs.String("format", benchmark.FormatText, "表示形式（text: 構成の一覧、mermaid: Mermaidの図、dot: Graphvizの図）")
+	fs.Parse(args)
+
+	return benchmark.Describe(*scenario, *format)
+}
+
+// 複数の結果ファイルの同じ戦略の結果を合算して出力する
+func merge(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	report := fs.String("report", "console", "合算した結果の出力先をカンマ区切りで指定（"+strings.Join(benchmark.ReporterKinds(), ", ")+"）")
+	allowMixed := fs.Bool("allow-mixed-versions", false, "異なるコミットのベンチマークで計測した結果も合算する")
+	by := fs.String("by", "", "値が異なる結果を別々に合算するパラメータをカンマ区切りで指定（例: workers,task_data）")
+	fs.Parse(args)
+
+	var keys []string
+	if *by != "" {
+		keys = strings.Split(*by, ",")
+	}
+	return benchmark.RunMerge(fs.Args(), strings.Split(*report, ","), keys, *allowMixed)
+}
+
+// 蓄積した結果から、各戦略のメトリクスのGoのバージョンとコミットごとの推移を表示する
+func trend(args []string) error {
+	fs := flag.NewFlagSet("trend", flag.ExitOnError)
+	metric := fs.String("metric", "wall_ns", "推移を表示するメトリクス（小さいほど良いものとして最速を判定する）")
+	scenario := fs.String("scenario", "", "表示するシナリオをカンマ区切りで指定（省略時は全て）")
+	fs.Parse(args)
+
+	var scenarios []string
+	if *scenario != "" {
+		scenarios = strings.Split(*scenario, ",")
+	}
+	return benchmark.RunTrend(fs.Args(), *metric, scenarios)
+}
+
+// サブコマンド間で共通のフラグを登録する
+func registerFlags(fs *flag.FlagSet, cfg *benchmark.Config) {
+	fs.StringVar(&cfg.Scenario, "scenario", cfg.Scenario, "実行するシナリオ（"+strings.Join(benchmark.ScenarioNames(), ", ")+"）。カンマ区切りで複数指定でき、allで全て")
+	fs.IntVar(&cfg.Repetitions, "reps", cfg.Repetitions, "各戦略の繰り返し回数")
+	fs.IntVar(&cfg.Tasks, "tasks", cfg.Tasks, "1回の実行で処理するタスク数（0は戦略ごとの既定値。100万を超える指定もできる）")
+	fs.IntVar(&cfg.Workers, "workers", cfg.Workers, "並列度を制限する戦略の同時実行数（0はCPU数）")
+	fs.IntVar(&cfg.Dispatchers, "dispatchers", cfg.Dispatchers, "dispatchシナリオのチャネルを使う戦略で、同じタスクのチャネルから競合して受信するディスパッチャーの数（0は既定値の1）")
+	fs.Func("checkpoint-units", "cancelシナリオで比べる、タスクがctxを確認する間隔（100単位の作業のうち何単位ごとか）をカンマ区切りで指定（既定値は1,10,100）", func(s string) error {
+		units, err := parseInts(s)
+		if err != nil {
+			return err
+		}
+		cfg.CheckpointUnits = units
+		return nil
+	})
+	fs.IntVar(&cfg.Buffer, "buffer", cfg.Buffer, "dispatchシナリオのチャネルを使う戦略のタスクのチャネルのバッファの大きさ（0は既定値の100）")
+	fs.DurationVar(&cfg.Cooldown, "cooldown", cfg.Cooldown, "各実行の間に挟むクールダウン時間（例: 2s）")
+	fs.BoolVar(&cfg.ExtendCooldown, "auto-cooldown", cfg.ExtendCooldown, "処理時間の単調な増加（スロットリングの疑い）を検出したらクールダウンを自動延長する")
+	fs.StringVar((*string)(&cfg.TaskData), "task-data", string(cfg.TaskData), "タスクのデータの生成方法（sprintf: タスクごとにfmt.Sprintf、none: データなし、prealloc: 事前に生成した文字列を使い回す、bytes: タスクごとにランダムなバイト列）")
+	fs.IntVar(&cfg.TaskDataSize, "task-data-size", cfg.TaskDataSize, "-task-data=bytesで生成するバイト数")
+	fs.BoolVar(&cfg.InflightBytes, "inflight-bytes", cfg.InflightBytes, "供給元から読み出されてから処理を終えるまでのタスク（チャネルに積まれたものと処理中のもの）が保持するバイト数のピークを戦略ごとに計測する（-task-data=bytesと組み合わせて大きなデータの滞留を比べる）")
+	fs.BoolVar(&cfg.IdleMemory, "idle-memory", cfg.IdleMemory, "全てのタスクを処理し終えてからプールの終了を始めるまでの待機中のメモリ使用量を、プールを残す戦略（context-tree、afterfunc）で計測する（計測の前にGCとメモリの返却を行うため、処理時間が長くなる）")
+	fs.BoolVar(&cfg.GCOff, "gc-off", cfg.GCOff, "各戦略をGCありで実行した後に、GCを止めて（GOGC=off）もう一度実行し、GCの影響を除いたディスパッチのコストを並べて表示する")
+	fs.StringVar(&cfg.OTel, "otel", cfg.OTel, "各戦略を計装なしで実行した後に、OpenTelemetryのスパン（戦略、各回、タスクの受け渡しと処理）を送る計装をしてもう一度実行し、計装のコストを並べて表示する。送信先はfile=ファイル、otlp（環境変数かlocalhost:4318）、otlp=エンドポイント（OTLP/HTTP）")
+	fs.IntVar(&cfg.OTelSample, "otel-sample", cfg.OTelSample, "-otelでタスクのスパンを送る間隔（IDがこの倍数のタスクだけ送る）")
+	fs.Uint64Var(&cfg.GCOffLimitMB, "gc-off-limit-mb", cfg.GCOffLimitMB, "-gc-offでGCを止めて実行する間のヒープの上限（MB）。これに近づいた場合だけGCが動く（GOMEMLIMITと同じ）")
+	fs.BoolVar(&cfg.GoroutineProfile, "goroutine-profile", cfg.GoroutineProfile, "計測とは別に各戦略をもう1回実行し、goroutine数のピーク時と完了後のgoroutineを生成元（go文の位置）ごとに数える（ダンプに時間がかかるため、計測には含めない）")
+	fs.BoolVar(&cfg.TaskLatency, "task-latency", cfg.TaskLatency, "供給元から読み出されてから処理を終えるまで（チャネルやセマフォでの待ちと処理）の時間をタスクごとに記録し、戦略ごとにp50、p95、p99を求める（タスクごとに時刻を読むため、少し遅くなる）")
+	fs.BoolVar(&cfg.DispatchTime, "dispatch-time", cfg.DispatchTime, "チャネルの戦略で、ディスパッチャーのgoroutineがタスクの受け渡しに使った時間と処理時間に占める割合を計測する（受信とセマフォの待ちを除く。タスクごとに時刻を読むため、少し遅くなる）")
+	fs.StringVar(&cfg.ProfileDir, "profile-dir", cfg.ProfileDir, "各アプローチのCPUプロファイルを書き出すディレクトリ")
+}
+
+// 引数から-presetや-configの値を取り出す。フラグの既定値をプリセットや設定ファイルの設定にするため、解析の前に読む
+func flagArg(args []string, flagName string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != flagName {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// カンマ区切りの小数の列を解析する
+func parseFloats(s string) ([]float64, error) {
+	var fs []float64
+	for _, f := range strings.Split(s, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q in %q", f, s)
+		}
+		fs = append(fs, v)
+	}
+	return fs, nil
+}
+
+// 整数の列をカンマ区切りの文字列にする
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+// カンマ区切りの整数の列を読み取る
+func parseInts(s string) ([]int, error) {
+	var ns []int
+	for _, f := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q in %q", f, s)
+		}
+		ns = append(ns, n)
+	}
+	return ns, nil
+}
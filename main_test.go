@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fnを実行し、標準出力と標準エラー出力に書かれた内容を返す
+func captureOutput(t *testing.T, fn func() error) (stdout, stderr string) {
+	t.Helper()
+	capture := func(f **os.File) (func() string, error) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		orig := *f
+		*f = w
+		var buf bytes.Buffer
+		done := make(chan struct{})
+		go func() {
+			io.Copy(&buf, r)
+			close(done)
+		}()
+		return func() string {
+			*f = orig
+			w.Close()
+			<-done
+			r.Close()
+			return buf.String()
+		}, nil
+	}
+	restoreOut, err := capture(&os.Stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoreErr, err := capture(&os.Stderr)
+	if err != nil {
+		restoreOut()
+		t.Fatal(err)
+	}
+	runErr := fn()
+	stdout, stderr = restoreOut(), restoreErr()
+	if runErr != nil {
+		t.Fatalf("%v\nstderr:\n%s", runErr, stderr)
+	}
+	return stdout, stderr
+}
+
+// -format jsonでは、-reportの指定にかかわらず標準出力に全ての結果のJSON配列を1つだけ書き、
+// コンソールの表示は標準エラー出力に書くことを確認する
+func TestFormatJSONStdout(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "results.json")
+	args := []string{"-format", "json", "-tasks", "100", "-reps", "1", "-strategies", "chan-unlimited,direct-unlimited"}
+	tests := []struct {
+		report string
+		// コンソールの表示が標準エラー出力に出るか
+		console bool
+	}{
+		{report: "json=" + file},
+		{report: "console,json", console: true},
+		{report: "json,json=-,csv"},
+		{report: "console,markdown-table", console: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.report, func(t *testing.T) {
+			stdout, stderr := captureOutput(t, func() error {
+				return run(append(args, "-report", tt.report))
+			})
+			var results []map[string]any
+			if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+				t.Fatalf("stdout is not a single JSON array: %v\n%s", err, stdout)
+			}
+			if len(results) != 2 {
+				t.Errorf("got %d results, want 2", len(results))
+			}
+			if got := strings.Contains(stderr, "処理タスク数"); got != tt.console {
+				t.Errorf("console output on stderr = %v, want %v\n%s", got, tt.console, stderr)
+			}
+		})
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []map[string]any
+	if err := json.Unmarshal(b, &results); err != nil || len(results) != 2 {
+		t.Errorf("%s: %d results, err %v", file, len(results), err)
+	}
+
+	stdout, _ := captureOutput(t, func() error {
+		return run([]string{"-format", "json", "-dry-run"})
+	})
+	if strings.TrimSpace(stdout) != "[]" {
+		t.Errorf("dry run stdout = %q, want an empty array", stdout)
+	}
+}
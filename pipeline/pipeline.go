@@ -0,0 +1,142 @@
+// Package pipeline は、ステージをチャネルでつないだ多段のパイプラインを型付きで組み立てて実行する。
+//
+// 各ステージは指定数のgoroutineで入力を処理し、結果を次のステージに渡す。
+// いずれかのステージや出力先がエラーを返すと全体をキャンセルし、最初のエラーを返す
+//
+//	p := pipeline.Connect(
+//		pipeline.New(pipeline.Stage[string, int]{Name: "parse", Workers: 1, Fn: parse}),
+//		pipeline.Stage[int, int]{Name: "square", Workers: 4, Fn: square},
+//	)
+//	err := pipeline.RunPipeline(ctx, p, slices.Values(inputs), func(n int) error { ... })
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+)
+
+// パイプラインの1つのステージ。Workers個のgoroutineが入力を並行に受け取ってFnを適用し、
+// 結果をバッファBufferのチャネルで次のステージに渡す
+type Stage[In, Out any] struct {
+	// エラーや構成の表示に使う名前
+	Name string
+	// 入力を処理するgoroutineの数（0以下の場合は1）
+	Workers int
+	// 次のステージに渡すチャネルのバッファ
+	Buffer int
+	// 入力ごとの処理。エラーを返すとパイプライン全体をキャンセルする
+	Fn func(ctx context.Context, in In) (Out, error)
+}
+
+func (s Stage[In, Out]) workers() int {
+	return max(1, s.Workers)
+}
+
+// 入力の型Inから出力の型Outまでのステージの連なり
+type Pipeline[In, Out any] struct {
+	names []string
+	// 入力のチャネルからステージのgoroutineを起動し、最後のステージの出力のチャネルを返す。
+	// 起動したgoroutineはwgで数え、エラーはfailに渡す
+	start func(ctx context.Context, in <-chan In, wg *sync.WaitGroup, fail func(error)) <-chan Out
+}
+
+// ステージの名前を先頭から順に返す
+func (p Pipeline[In, Out]) Stages() []string {
+	return append([]string(nil), p.names...)
+}
+
+// 1つのステージからなるパイプラインを作成する
+func New[In, Out any](s Stage[In, Out]) Pipeline[In, Out] {
+	return Pipeline[In, Out]{
+		names: []string{s.Name},
+		start: func(ctx context.Context, in <-chan In, wg *sync.WaitGroup, fail func(error)) <-chan Out {
+			return runStage(ctx, s, in, wg, fail)
+		},
+	}
+}
+
+// パイプラインの後ろにステージをつなぐ
+func Connect[In, Mid, Out any](p Pipeline[In, Mid], s Stage[Mid, Out]) Pipeline[In, Out] {
+	return Pipeline[In, Out]{
+		names: append(p.Stages(), s.Name),
+		start: func(ctx context.Context, in <-chan In, wg *sync.WaitGroup, fail func(error)) <-chan Out {
+			return runStage(ctx, s, p.start(ctx, in, wg, fail), wg, fail)
+		},
+	}
+}
+
+// ステージのgoroutineを起動する。全てのgoroutineが終了したら出力のチャネルを閉じる
+func runStage[In, Out any](ctx context.Context, s Stage[In, Out], in <-chan In, wg *sync.WaitGroup, fail func(error)) <-chan Out {
+	out := make(chan Out, s.Buffer)
+	var stage sync.WaitGroup
+	for i := 0; i < s.workers(); i++ {
+		stage.Add(1)
+		go func() {
+			defer stage.Done()
+			for v := range in {
+				if ctx.Err() != nil {
+					return
+				}
+				res, err := s.Fn(ctx, v)
+				if err != nil {
+					fail(fmt.Errorf("stage %s: %w", s.Name, err))
+					return
+				}
+				select {
+				case out <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stage.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// sourceの値をパイプラインに流し、最後のステージの出力をsinkに渡す。
+// sinkは呼び出し元のgoroutineで1つずつ呼ばれる。全ての出力を渡し終えるか、いずれかのステージやsinkがエラーを返すか、
+// ctxがキャンセルされるまで待つ。起動した全てのgoroutineが終了してから戻り、最初のエラーを返す
+func RunPipeline[In, Out any](ctx context.Context, p Pipeline[In, Out], source iter.Seq[In], sink func(Out) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	fail := func(err error) { cancel(err) }
+
+	var wg sync.WaitGroup
+	in := make(chan In)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(in)
+		for v := range source {
+			select {
+			case in <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	out := p.start(ctx, in, &wg, fail)
+
+	for v := range out {
+		if ctx.Err() != nil {
+			// キャンセル後は出力を読み捨て、ステージのgoroutineを終了させる
+			continue
+		}
+		if err := sink(v); err != nil {
+			fail(fmt.Errorf("sink: %w", err))
+		}
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	return nil
+}
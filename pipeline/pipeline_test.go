@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func parse(_ context.Context, s string) (int, error) {
+	return strconv.Atoi(s)
+}
+
+func square(_ context.Context, n int) (int, error) {
+	return n * n, nil
+}
+
+// 全ての入力が各ステージを通って出力先に渡ることを確認する
+func TestRunPipeline(t *testing.T) {
+	p := Connect(
+		New(Stage[string, int]{Name: "parse", Workers: 2, Buffer: 4, Fn: parse}),
+		Stage[int, int]{Name: "square", Workers: 4, Fn: square},
+	)
+	if got, want := p.Stages(), []string{"parse", "square"}; !slices.Equal(got, want) {
+		t.Errorf("stages = %q, want %q", got, want)
+	}
+
+	var inputs []string
+	want := 0
+	for i := 0; i < 1000; i++ {
+		inputs = append(inputs, strconv.Itoa(i))
+		want += i * i
+	}
+	sum := 0
+	err := RunPipeline(context.Background(), p, slices.Values(inputs), func(n int) error {
+		sum += n
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != want {
+		t.Errorf("sum = %d, want %d", sum, want)
+	}
+}
+
+// ステージと出力先のエラーで全体を止め、最初のエラーを返すことを確認する
+func TestRunPipelineError(t *testing.T) {
+	p := Connect(
+		New(Stage[string, int]{Name: "parse", Workers: 2, Fn: parse}),
+		Stage[int, int]{Name: "square", Workers: 2, Fn: square},
+	)
+	inputs := slices.Repeat([]string{"1"}, 1000)
+	inputs[10] = "x"
+	var sunk atomic.Int64
+	err := RunPipeline(context.Background(), p, slices.Values(inputs), func(int) error {
+		sunk.Add(1)
+		return nil
+	})
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		t.Errorf("got %v, want the parse error", err)
+	}
+	if sunk.Load() == int64(len(inputs)-1) {
+		t.Error("pipeline was not cancelled after the error")
+	}
+
+	errSink := errors.New("sink failed")
+	err = RunPipeline(context.Background(), p, slices.Values(slices.Repeat([]string{"1"}, 1000)), func(int) error {
+		return errSink
+	})
+	if !errors.Is(err, errSink) {
+		t.Errorf("got %v, want the sink error", err)
+	}
+}
+
+// 呼び出し元のコンテキストのキャンセルで止まることを確認する
+func TestRunPipelineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(Stage[int, int]{Name: "square", Fn: square})
+	forever := func(yield func(int) bool) {
+		for i := 0; yield(i); i++ {
+		}
+	}
+	err := RunPipeline(ctx, p, forever, func(n int) error {
+		if n > 100 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}